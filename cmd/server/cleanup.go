@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/repository/mongo"
+	"user-management-api/internal/services"
+
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// runCleanup implements "server cleanup": it purges expired data once,
+// prints what it removed from each category and how long that took, and
+// returns the exit code, non-zero when any category failed
+func runCleanup(cfg *config.Config, db *mongodriver.Database, args []string) int {
+	flags := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 10*time.Minute, "give up after this long")
+	flags.Parse(args)
+
+	cleanup := services.NewCleanupService(cfg.Cleanup,
+		mongo.NewOneTimeTokenRepository(db),
+		mongo.NewSessionRepository(db),
+		mongo.NewAuthEventRepository(db),
+		mongo.NewBulkOperationRepository(db),
+		mongo.NewAuditLogRepository(db),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	results := cleanup.Run(ctx)
+	return printCleanupResults(os.Stdout, results)
+}
+
+func printCleanupResults(out io.Writer, results []services.CleanupResult) int {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tDELETED\tDURATION\tSTATUS")
+	var total int64
+	var elapsed time.Duration
+	code := 0
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = "error: " + result.Err.Error()
+			code = 1
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", result.Name, result.Deleted, result.Duration.Round(time.Millisecond), status)
+		total += result.Deleted
+		elapsed += result.Duration
+	}
+	fmt.Fprintf(tw, "total\t%d\t%s\t\n", total, elapsed.Round(time.Millisecond))
+	tw.Flush()
+	return code
+}
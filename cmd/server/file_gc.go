@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/repository/mongo"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/storage"
+
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// runFileGC implements "server gc-files": it removes the stored files no
+// file record or avatar refers to, printing each one, or only lists them
+// with -dry-run, and returns the exit code, non-zero when the run failed
+func runFileGC(cfg *config.Config, db *mongodriver.Database, args []string) int {
+	flags := flag.NewFlagSet("gc-files", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "list orphaned files without removing them")
+	minAge := flags.Duration("min-age", cfg.Uploads.GCMinAge, "keep files stored more recently than this")
+	timeout := flags.Duration("timeout", time.Hour, "give up after this long")
+	flags.Parse(args)
+	if *minAge < time.Hour {
+		fmt.Fprintln(os.Stderr, "-min-age must be at least 1h, as uploads are stored before they are recorded")
+		return 2
+	}
+
+	gc := services.NewFileGCService(
+		mongo.NewFileRepository(db),
+		mongo.NewUserRepository(db),
+		newUploadStorage(cfg),
+		*minAge,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	start := time.Now()
+	result, err := gc.Run(ctx, *dryRun, func(key string, obj *storage.Object) {
+		fmt.Printf("%s\t%d\t%s\n", key, obj.Size, obj.Modified.Format(time.RFC3339))
+	})
+	verb, removed := "removed", result.Deleted
+	if *dryRun {
+		verb, removed = "would remove", result.Orphaned
+	}
+	fmt.Printf("scanned %d stored files, %s %d of %d orphans (%d bytes) in %s\n",
+		result.Scanned, verb, removed, result.Orphaned, result.Bytes, time.Since(start).Round(time.Millisecond))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
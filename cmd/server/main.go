@@ -2,21 +2,37 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 	"user-management-api/internal/config"
 	"user-management-api/internal/handlers"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/modules"
+	"user-management-api/internal/modules/builtin"
+	"user-management-api/internal/repository/cache"
 	"user-management-api/internal/repository/mongo"
 	"user-management-api/internal/routes"
 	"user-management-api/internal/services"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/cdn"
+	"user-management-api/pkg/clamav"
 	"user-management-api/pkg/database"
+	"user-management-api/pkg/httpserver"
+	"user-management-api/pkg/imaging"
+	"user-management-api/pkg/jobs"
+	"user-management-api/pkg/mailer"
+	"user-management-api/pkg/oauth"
+	"user-management-api/pkg/policy"
+	"user-management-api/pkg/saml"
+	"user-management-api/pkg/storage"
+	"user-management-api/pkg/utils"
 
-	_ "user-management-api/docs" // This line is needed for swagger
+	"github.com/gin-gonic/gin"
 
+	_ "user-management-api/docs" // This line is needed for swagger
 )
 
 // @title Go Gin Layered Architecture API
@@ -27,6 +43,20 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+// @description "Bearer " followed by an access token from sign-in, a personal access token (pat_...) or an OAuth2 token. Routes that name a permission in their description admit only users whose role grants it, and the token must also carry the scope listed under OAuth2. Built-in roles: superadmin (everything, and managing admins), admin (everything except managing superadmins), support (users:read and users:pii: look users up without changing or deleting them), auditor (audit:read only) and user (their own profile, files and projects). support and auditor inherit user; admin inherits user and superadmin inherits admin.
+//
+// @securityDefinitions.oauth2.accessCode OAuth2
+// @authorizationUrl http://localhost:8080/oauth/authorize
+// @tokenUrl http://localhost:8080/api/v1/auth/token
+// @scope.profile:read Read the user's own profile (every role)
+// @scope.profile:write Change the user's own profile, avatar and tokens (every role)
+// @scope.files:read Read the user's files (every role)
+// @scope.files:write Upload, change and delete the user's files (every role)
+// @scope.projects:read Read the user's projects (every role)
+// @scope.projects:write Create, change and delete the user's projects (every role)
+// @scope.users:read Look up other users (support, admin, superadmin)
+// @scope.users:write Create and change other users (admin, superadmin)
+// @scope.audit:read Read audit logs, auth events, user history and bulk operations (auditor, admin, superadmin)
 
 func main() {
 	cfg, err := config.LoadConfig()
@@ -34,55 +64,378 @@ func main() {
 		log.Fatal("Failed to load configuration", err)
 	}
 
-	mongoDb, err := database.NewMongoDB(cfg.Database.URI, cfg.Database.Name, cfg.Database.Timeout)
+	if cfg.JWT.EncryptionKey != nil {
+		if err := auth.SetEncryptionKey(cfg.JWT.EncryptionKey); err != nil {
+			log.Fatal("Invalid JWT encryption key", err)
+		}
+	}
+
+	if len(cfg.JWT.SigningKeys) > 0 {
+		if err := auth.SetHMACSigningKeys(cfg.JWT.SigningKeys, cfg.JWT.ActiveKID); err != nil {
+			log.Fatal("Invalid JWT signing keys", err)
+		}
+	}
+
+	if cfg.JWT.Algorithm != "HS256" {
+		if err := auth.SetAsymmetricSigningKey(cfg.JWT.KeyID, cfg.JWT.Algorithm, cfg.JWT.PrivateKeyPEM); err != nil {
+			log.Fatal("Invalid JWT private key", err)
+		}
+	}
+
+	hashParams := utils.PasswordHashParams{
+		Algorithm:     cfg.Password.HashAlgorithm,
+		BcryptCost:    cfg.Password.BcryptCost,
+		Argon2Memory:  cfg.Password.Argon2MemoryKiB,
+		Argon2Time:    cfg.Password.Argon2Time,
+		Argon2Threads: cfg.Password.Argon2Threads,
+	}
+	if cfg.Password.CalibrateTarget > 0 {
+		if hashParams, err = utils.CalibratePasswordHashParams(hashParams, cfg.Password.CalibrateTarget); err != nil {
+			log.Fatal("Password hash calibration failed", err)
+		}
+		log.Printf("Password hashing calibrated for %s: %s", cfg.Password.CalibrateTarget, hashParams)
+	} else {
+		log.Printf("Password hashing: %s", hashParams)
+	}
+	if err := utils.SetPasswordHashParams(hashParams); err != nil {
+		log.Fatal("Invalid password hashing parameters", err)
+	}
+
+	// "server cleanup" purges expired data once and exits, e.g. from cron
+	if len(os.Args) > 1 && os.Args[1] == "cleanup" {
+		mongoDb, err := database.NewMongoDB(cfg.Database.URI, cfg.Database.Name, cfg.Database.Timeout, cfg.Sync.TombstoneRetention)
+		if err != nil {
+			log.Fatal("failed to connect to mongodb")
+		}
+		code := runCleanup(cfg, mongoDb.Database, os.Args[2:])
+		mongoDb.Close(context.Background())
+		os.Exit(code)
+	}
+
+	// "server gc-files" removes orphaned uploaded files once and exits
+	if len(os.Args) > 1 && os.Args[1] == "gc-files" {
+		mongoDb, err := database.NewMongoDB(cfg.Database.URI, cfg.Database.Name, cfg.Database.Timeout, cfg.Sync.TombstoneRetention)
+		if err != nil {
+			log.Fatal("failed to connect to mongodb")
+		}
+		code := runFileGC(cfg, mongoDb.Database, os.Args[2:])
+		mongoDb.Close(context.Background())
+		os.Exit(code)
+	}
+
+	if cfg.Database.ResilientStart {
+		if err := serveResilient(cfg); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("server exited")
+		return
+	}
+
+	mongoDb, err := database.NewMongoDB(cfg.Database.URI, cfg.Database.Name, cfg.Database.Timeout, cfg.Sync.TombstoneRetention)
 	if err != nil {
 		log.Fatal("failed to connect to mongodb")
 	}
 	defer mongoDb.Close(context.Background())
+
+	router, shutdownHooks := setupApp(cfg, mongoDb)
+
+	// start server until SIGINT/SIGTERM, then stop workers and drain jobs
+	err = httpserver.Run(":"+cfg.Server.Port, router, 5*time.Second, shutdownHooks...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("server exited")
+
+}
+
+// newUploadStorage returns the backend uploaded files are kept in, which
+// the files module serves when they are kept locally
+func newUploadStorage(cfg *config.Config) storage.Storage {
+	switch cfg.Uploads.Storage {
+	case "s3":
+		s3, err := storage.NewS3(storage.S3Config(cfg.Uploads.S3))
+		if err != nil {
+			log.Fatal("Invalid S3 storage configuration", err)
+		}
+		return s3
+	case "gcs":
+		gcs, err := storage.NewGCS(context.Background(), storage.GCSConfig(cfg.Uploads.GCS))
+		if err != nil {
+			log.Fatal("Invalid GCS storage configuration", err)
+		}
+		return gcs
+	}
+	return storage.NewLocal(cfg.Uploads.Root, cfg.Server.PublicURL+"/api/v1/uploads")
+}
+
+// setupApp wires the repositories, services and handlers on top of the
+// connected database, starts the background workers and returns the
+// router with the hooks that stop the workers and drain the job queue
+func setupApp(cfg *config.Config, mongoDb *database.MongoDB) (*gin.Engine, []httpserver.ShutdownHook) {
+	var err error
 	// initialize repositories
-	userRepo := mongo.NewUserRepository(mongoDb.Database)
+	// every user write goes through the cache so it can drop stale entries
+	userRepo := cache.NewUserRepository(mongo.NewUserRepository(mongoDb.Database), cfg.Session.LoginCacheTTL)
+	grantRepo := mongo.NewGrantRepository(mongoDb.Database)
+	tombstoneRepo := mongo.NewTombstoneRepository(mongoDb.Database)
+	clientRepo := mongo.NewClientRepository(mongoDb.Database)
+	codeRepo := mongo.NewAuthorizationCodeRepository(mongoDb.Database)
+	historyRepo := mongo.NewUserHistoryRepository(mongoDb.Database)
+	auditRepo := mongo.NewAuditLogRepository(mongoDb.Database)
+	ipBanRepo := mongo.NewIPBanRepository(mongoDb.Database)
+	authEventRepo := mongo.NewAuthEventRepository(mongoDb.Database)
+	sessionRepo := mongo.NewSessionRepository(mongoDb.Database)
+	oneTimeTokenRepo := mongo.NewOneTimeTokenRepository(mongoDb.Database)
+	samlAssertionRepo := mongo.NewSAMLAssertionRepository(mongoDb.Database)
+	trustedDeviceRepo := mongo.NewTrustedDeviceRepository(mongoDb.Database)
+	tokenRepo := mongo.NewPersonalAccessTokenRepository(mongoDb.Database)
+	projectRepo := mongo.NewProjectRepository(mongoDb.Database)
+	bulkOperationRepo := mongo.NewBulkOperationRepository(mongoDb.Database)
+	transactor := mongo.NewTransactor(mongoDb.Database)
+	identityRepo := mongo.NewIdentityRepository(mongoDb.Database)
+	roleRepo := mongo.NewRoleRepository(mongoDb.Database)
+	policyRepo := mongo.NewPolicyRepository(mongoDb.Database)
+	orgRepo := mongo.NewOrganizationRepository(mongoDb.Database)
+	membershipRepo := mongo.NewMembershipRepository(mongoDb.Database)
+
+	// background jobs
+	jobQueue := jobs.NewQueue(cfg.Jobs.Workers, cfg.Jobs.QueueSize)
+
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if cfg.Mail.Driver == "smtp" {
+		mail = &mailer.SMTPMailer{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			From:     cfg.Mail.From,
+		}
+	}
+
+	store := newUploadStorage(cfg)
+	middleware.SetUploadStorage(store)
+	var scanner *clamav.Client
+	if cfg.Uploads.ClamdAddress != "" {
+		scanner, err = clamav.New(cfg.Uploads.ClamdAddress, cfg.Uploads.ClamdTimeout)
+		if err != nil {
+			log.Fatal("Invalid CLAMD_ADDRESS", err)
+		}
+		middleware.SetUploadScanner(scanner)
+	}
+	var fileCDN *cdn.CDN
+	if cfg.Uploads.CDN.BaseURL != "" {
+		var signer cdn.Signer
+		switch cfg.Uploads.CDN.Signing {
+		case "cloudfront":
+			pemKey, err := os.ReadFile(cfg.Uploads.CDN.PrivateKeyFile)
+			if err != nil {
+				log.Fatal("Failed to read CDN_PRIVATE_KEY_FILE", err)
+			}
+			signer, err = cdn.NewCloudFront(cfg.Uploads.CDN.KeyPairID, pemKey)
+			if err != nil {
+				log.Fatal("Invalid CDN_PRIVATE_KEY_FILE", err)
+			}
+		case "cloudflare":
+			signer = cdn.NewCloudflare(cfg.Uploads.CDN.Secret)
+		}
+		fileCDN = cdn.New(cfg.Uploads.CDN.BaseURL, signer, cfg.Uploads.CDN.URLTTL)
+	}
+	if cfg.Uploads.HasVariants() {
+		transcoder := imaging.NewTranscoder(cfg.Uploads.CwebpPath, cfg.Uploads.AvifencPath)
+		for name, profile := range cfg.Uploads.Profiles {
+			for _, format := range profile.Variants {
+				if !transcoder.Available(format) {
+					log.Printf("upload profile %s: no %s encoder found, its %s variants are skipped", name, format, format)
+				}
+			}
+		}
+		middleware.SetUploadTranscoder(transcoder)
+	}
 
 	// initialize services
-	authService := services.NewAuthService(userRepo, cfg.JWT.Secret, cfg.JWT.ExpiresIn.String())
-	userService := services.NewUserService(userRepo)
+	roleService := services.NewRoleService(roleRepo, userRepo)
+	rolesCtx, cancelRoles := context.WithTimeout(context.Background(), cfg.Database.Timeout)
+	err = roleService.Load(rolesCtx)
+	cancelRoles()
+	if err != nil {
+		log.Fatal("failed to load roles", err)
+	}
+	policyEnforcer := policy.NewEnforcer()
+	policyService := services.NewPolicyService(policyRepo, policyEnforcer)
+	policiesCtx, cancelPolicies := context.WithTimeout(context.Background(), cfg.Database.Timeout)
+	err = policyService.Load(policiesCtx)
+	cancelPolicies()
+	if err != nil {
+		log.Fatal("failed to load policies", err)
+	}
+	middleware.SetPolicyEnforcer(policyEnforcer)
+	notificationService, err := services.NewNotificationService(mail, jobQueue, cfg.Server.PublicURL)
+	if err != nil {
+		log.Fatal("Failed to load email templates", err)
+	}
+	historyService := services.NewHistoryService(historyRepo, auditRepo, userRepo)
+	authEventService := services.NewAuthEventService(authEventRepo)
+	oneTimeTokens := utils.NewOneTimeTokens(oneTimeTokenRepo, []byte(cfg.JWT.Secret))
+	trustedDeviceService := services.NewTrustedDeviceService(trustedDeviceRepo)
+	activityService := services.NewActivityService(userRepo, cfg.Session.ActivityInterval)
+	middleware.SetActivityRecorder(activityService)
+	sessionService := services.NewSessionService(sessionRepo, notificationService, trustedDeviceService, activityService, oneTimeTokens, cfg.Session.TTL, cfg.JWT.RefreshTTL, cfg.Session.MaxPerUser, cfg.Session.LimitPolicy)
+	middleware.SetSessionChecker(sessionService)
+	tokenService := services.NewPersonalAccessTokenService(tokenRepo, userRepo)
+	middleware.SetPersonalAccessTokenAuthenticator(tokenService)
+	registrationThrottle := services.NewRegistrationThrottle(services.RegistrationThrottlePolicy{
+		DomainLimit:   cfg.Register.DomainLimit,
+		IPBlockLimit:  cfg.Register.IPBlockLimit,
+		Window:        cfg.Register.Window,
+		ExemptDomains: cfg.Register.ExemptDomains,
+	})
+	avatarService := services.NewAvatarService(userRepo, historyService, store, cfg.Uploads.AvatarSize)
+	services.RegisterUserHook(services.AfterUpdate, avatarService.RemoveReplaced)
+	services.RegisterUserHook(services.AfterDelete, avatarService.RemoveReplaced)
+	services.RegisterUserHook(services.AfterAnonymize, avatarService.RemoveReplaced)
+	authService := services.NewAuthService(userRepo, userRepo, notificationService, sessionService, historyService, authEventService, oneTimeTokens, registrationThrottle, avatarService, cfg.JWT.Secret, cfg.JWT.AccessTTL, cfg.Password.MaxAge)
+	userService := services.NewUserService(userRepo, tombstoneRepo, membershipRepo, bulkOperationRepo, transactor, historyService, models.NewProfileChecklist(cfg.Profile.CompletenessFields))
+	clientService := services.NewClientService(clientRepo, codeRepo, userRepo, cfg.JWT.Secret)
+	grantService := services.NewGrantService(grantRepo, userRepo)
+	projectService := services.NewProjectService(projectRepo, userRepo)
+	orgService := services.NewOrganizationService(orgRepo, membershipRepo, userRepo)
+	services.RegisterUserHook(services.AfterDelete, orgService.RemoveUserMemberships)
+	services.RegisterUserHook(services.AfterAnonymize, orgService.RemoveUserMemberships)
+	erasureService := services.NewErasureService(userRepo, sessionRepo, tokenRepo, trustedDeviceRepo, authEventRepo, historyService, cfg.Erasure.GracePeriod)
+	userStatusService := services.NewUserStatusService(userRepo, sessionRepo, tokenRepo, historyService)
+	services.RegisterUserHook(services.AfterUpdate, userStatusService.SignOutDeactivated)
+
+	var oauthProviders []*oauth.Provider
+	for _, p := range cfg.OAuth.Providers {
+		provider, err := oauth.NewProvider(p.Name, p.ClientID, p.ClientSecret, oauth.Endpoints{
+			AuthURL:     p.AuthURL,
+			TokenURL:    p.TokenURL,
+			UserInfoURL: p.UserInfoURL,
+			Scopes:      p.Scopes,
+		})
+		if err != nil {
+			log.Fatal("Invalid OAuth provider", err)
+		}
+		oauthProviders = append(oauthProviders, provider)
+	}
+	identityService := services.NewIdentityService(identityRepo, userRepo, sessionService, authEventService, oneTimeTokens, oauthProviders, cfg.Server.PublicURL, cfg.JWT.Secret, cfg.JWT.AccessTTL)
+	services.RegisterUserHook(services.AfterDelete, identityService.RemoveUserIdentities)
+	services.RegisterUserHook(services.AfterAnonymize, identityService.RemoveUserIdentities)
+	bruteForceService := services.NewBruteForceService(ipBanRepo, services.BruteForcePolicy{
+		MaxFailures: cfg.BruteForce.MaxFailures,
+		Window:      cfg.BruteForce.Window,
+		BaseBan:     cfg.BruteForce.BaseBan,
+		MaxBan:      cfg.BruteForce.MaxBan,
+		Retention:   cfg.BruteForce.Retention,
+	})
 
 	// initialize handler
 
-	healthHandler := handlers.NewHealthHandler()
 	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService)
-	fileHandler := handlers.NewFileHandler()
+	userHandler := handlers.NewUserHandler(userService, historyService, avatarService)
+	grantHandler := handlers.NewGrantHandler(grantService)
+	clientHandler := handlers.NewClientHandler(clientService)
+	pageHandler := handlers.NewPageHandler(authService, clientService)
+	authEventHandler := handlers.NewAuthEventHandler(authEventService)
+	deviceHandler := handlers.NewDeviceHandler(trustedDeviceService)
+	tokenHandler := handlers.NewPersonalAccessTokenHandler(tokenService)
+	projectHandler := handlers.NewProjectHandler(projectService)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	identityHandler := handlers.NewIdentityHandler(identityService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	policyHandler := handlers.NewPolicyHandler(policyService)
 
-	// setup router
-	router := routes.SetupRoutes(cfg, healthHandler, authHandler, userHandler, fileHandler)
-
-	// start server
-	srv := &http.Server{
-		Addr:    ":" + cfg.Server.Port,
-		Handler: router,
+	// optional modules
+	summary := cfg.Summary()
+	mods := []modules.Module{
+		builtin.NewFilesModule(cfg, mongoDb.Database, store, scanner, fileCDN, grantService),
+		builtin.NewExportsModule(cfg, mongoDb.Database, userRepo),
+		builtin.NewSyncModule(cfg, userRepo, tombstoneRepo),
 	}
-
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Failed to start server", err)
+	if cfg.Modules.IsEnabled("reports") {
+		if reports, err := builtin.NewReportsModule(cfg, mongoDb.Database, userRepo, tombstoneRepo, jobQueue); err != nil {
+			log.Printf("PDF reports disabled: %v", err)
+		} else {
+			mods = append(mods, reports)
 		}
-	}()
+	}
+	if cfg.Telemetry.Enabled {
+		mods = append(mods, builtin.NewTelemetryModule(cfg.Telemetry, mongoDb.Database, &summary))
+	}
+	mods = modules.Enabled(cfg.Modules, mods...)
 
-	log.Printf("Server started on port %s", cfg.Server.Port)
+	summary.Version = version
+	summary.RateLimits = routes.RateLimitProfiles()
+	summary.Modules = make([]string, 0, len(mods))
+	for _, mod := range mods {
+		summary.Modules = append(summary.Modules, mod.Name())
+	}
+	logSummary(summary)
+	healthHandler := handlers.NewHealthHandler(summary)
 
-	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), cfg.Database.Timeout)
+	err = modules.Migrate(migrateCtx, mongoDb.Database, mods)
+	cancelMigrate()
+	if err != nil {
+		log.Fatal("failed to migrate modules", err)
+	}
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	modules.StartWorkers(workerCtx, mods)
+	go roleService.RefreshEvery(workerCtx, time.Minute)
+	go policyService.RefreshEvery(workerCtx, time.Minute)
+	go erasureService.AnonymizeEvery(workerCtx, cfg.Erasure.CheckInterval)
 
-	log.Println("Shutting down server...")
+	var samlHandler *handlers.SAMLHandler
+	if cfg.SAML.Enabled {
+		idpCert, err := saml.ParseCertificate(cfg.SAML.IDPCertPEM)
+		if err != nil {
+			log.Fatal("Invalid SAML IdP certificate", err)
+		}
+		sp := &saml.ServiceProvider{
+			EntityID:    cfg.SAML.EntityID,
+			ACSURL:      cfg.SAML.BaseURL + "/api/v1/auth/saml/acs",
+			SLOURL:      cfg.SAML.BaseURL + "/api/v1/auth/saml/slo",
+			IDPEntityID: cfg.SAML.IDPEntityID,
+			IDPSSOURL:   cfg.SAML.IDPSSOURL,
+			IDPSLOURL:   cfg.SAML.IDPSLOURL,
+			IDPCert:     idpCert,
+		}
+		samlHandler = handlers.NewSAMLHandler(services.NewSAMLService(sp, userRepo, samlAssertionRepo, oneTimeTokens, sessionService, historyService, authEventService, cfg.JWT.Secret, cfg.SAML.DefaultRole, cfg.JWT.AccessTTL))
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// setup router
+	router := routes.SetupRoutes(cfg, routes.Handlers{
+		Health:    healthHandler,
+		Auth:      authHandler,
+		User:      userHandler,
+		Grant:     grantHandler,
+		SAML:      samlHandler,
+		Client:    clientHandler,
+		Page:      pageHandler,
+		AuthEvent: authEventHandler,
+		Device:    deviceHandler,
+		Token:     tokenHandler,
+		Project:   projectHandler,
+		Org:       orgHandler,
+		Identity:  identityHandler,
+		Role:      roleHandler,
+		Policy:    policyHandler,
+		Route:     handlers.NewRouteHandler(routes.Matrix),
+		Erasure:   handlers.NewErasureHandler(erasureService),
+		Status:    handlers.NewUserStatusHandler(userStatusService),
+	}, grantService, bruteForceService, mods)
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("server forced to shutdown ", err)
+	hooks := []httpserver.ShutdownHook{
+		func(ctx context.Context) error {
+			stopWorkers()
+			return nil
+		},
+		func(ctx context.Context) error {
+			if err := jobQueue.Shutdown(ctx); err != nil {
+				return fmt.Errorf("background jobs cancelled: %w", err)
+			}
+			return nil
+		},
 	}
-	log.Println("server exited")
-
+	return router, hooks
 }
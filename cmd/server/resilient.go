@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/routes"
+	"user-management-api/pkg/database"
+	"user-management-api/pkg/httpserver"
+)
+
+// serveResilient serves before MongoDB is reachable. Until it is, /health
+// reports the service degraded and every other route answers 503, while
+// the connection is retried with backoff; once it succeeds the full
+// application is set up and takes over without a restart.
+func serveResilient(cfg *config.Config) error {
+	handler := httpserver.NewSwitch(routes.SetupDegraded(cfg))
+	connectCtx, stopConnecting := context.WithCancel(context.Background())
+
+	var (
+		mu       sync.Mutex
+		stopping bool
+		mongoDb  *database.MongoDB
+		hooks    []httpserver.ShutdownHook
+	)
+	go func() {
+		db, err := database.ConnectWithRetry(connectCtx, cfg.Database.URI, cfg.Database.Name, cfg.Database.Timeout,
+			cfg.Sync.TombstoneRetention, cfg.Database.RetryMaxInterval,
+			func(attempt int, err error, wait time.Duration) {
+				log.Printf("mongodb unavailable (attempt %d): %v; retrying in %s", attempt, err, wait)
+			})
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if stopping {
+			// connected while shutting down
+			db.Close(context.Background())
+			return
+		}
+		mongoDb = db
+		router, appHooks := setupApp(cfg, db)
+		hooks = appHooks
+		handler.Set(router)
+		log.Println("mongodb available, serving all routes")
+	}()
+
+	return httpserver.Run(":"+cfg.Server.Port, handler, 5*time.Second,
+		func(ctx context.Context) error {
+			stopConnecting()
+			mu.Lock()
+			defer mu.Unlock()
+			stopping = true
+			for _, hook := range hooks {
+				if err := hook(ctx); err != nil {
+					log.Printf("shutdown: %v", err)
+				}
+			}
+			if mongoDb != nil {
+				return mongoDb.Close(ctx)
+			}
+			return nil
+		},
+	)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"user-management-api/internal/config"
+)
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// logSummary logs a one-line banner followed by the configuration summary
+// as JSON, so operators can check a deployment from its logs alone
+func logSummary(summary config.Summary) {
+	log.Printf("user-management-api %s starting: env=%s port=%s database=%s/%s storage=%s modules=%v",
+		summary.Version, summary.Environment, summary.Port, summary.Database.URI, summary.Database.Name,
+		summary.Storage.Backend, summary.Modules)
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("startup summary: %v", err)
+		return
+	}
+	log.Printf("startup summary: %s", encoded)
+}
@@ -1,59 +1,1175 @@
 package config
 
 import (
+	"encoding/base64"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	SAML       SAMLConfig
+	OAuth      OAuthConfig
+	Sync       SyncConfig
+	Jobs       JobsConfig
+	Report     ReportConfig
+	Mail       MailConfig
+	Session    SessionConfig
+	BruteForce BruteForceConfig
+	Cleanup    CleanupConfig
+	Uploads    UploadConfig
+	Password   PasswordConfig
+	Register   RegistrationConfig
+	Bots       BotDetectionConfig
+	Modules    ModulesConfig
+	Erasure    ErasureConfig
+	Telemetry  TelemetryConfig
+	Profile    ProfileConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
+	// PublicURL is where browsers reach this server, used in emailed links
+	PublicURL string
+	// LogLayerTimings logs how each request's latency splits between
+	// middleware, handler, service and repository
+	LogLayerTimings bool
 }
 
+// DatabaseConfig says where MongoDB is. With ResilientStart the server
+// starts without it, reporting itself degraded and retrying the connection
+// with a backoff of up to RetryMaxInterval, instead of exiting.
 type DatabaseConfig struct {
 	URI     string
 	Name    string
 	Timeout time.Duration
+
+	ResilientStart   bool
+	RetryMaxInterval time.Duration
 }
 
 type JWTConfig struct {
-	Secret    string
-	ExpiresIn time.Duration
+	Secret string
+	// AccessTTL is the lifetime of access tokens; tokens bound to a session
+	// never outlive it
+	AccessTTL time.Duration
+	// RefreshTTL is the lifetime of refresh tokens; zero disables them
+	RefreshTTL time.Duration
+	// EncryptionKey is a 32 byte AES key; when set tokens are issued as JWE
+	EncryptionKey []byte
+	// SigningKeys maps key ids to HMAC secrets for rotation; ActiveKID signs
+	SigningKeys map[string]string
+	ActiveKID   string
+	// Algorithm is HS256 (shared secret), RS256 or EdDSA; asymmetric
+	// algorithms sign with PrivateKeyPEM published under KeyID
+	Algorithm     string
+	PrivateKeyPEM []byte
+	KeyID         string
+}
+
+// SAMLConfig configures the optional SAML SSO service provider
+type SAMLConfig struct {
+	Enabled     bool
+	EntityID    string
+	BaseURL     string // public URL of this API, used to build ACS/SLO URLs
+	IDPEntityID string
+	IDPSSOURL   string
+	IDPSLOURL   string
+	IDPCertPEM  []byte
+	DefaultRole string // role given to auto-provisioned users
+}
+
+// OAuthConfig lists the external identity providers users can link their
+// accounts to and sign in with
+type OAuthConfig struct {
+	Providers []OAuthProviderConfig
+}
+
+// OAuthProviderConfig configures one provider. The URLs may be left empty
+// for providers with a preset (google, github).
+type OAuthProviderConfig struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	Scopes       []string
+}
+
+// SyncConfig controls delta sync; tokens older than TombstoneRetention can
+// no longer be served because the tombstones they depend on are gone
+type SyncConfig struct {
+	TombstoneRetention time.Duration
+}
+
+// JobsConfig sizes the in-process background job queue
+type JobsConfig struct {
+	Workers   int
+	QueueSize int
+}
+
+// ReportConfig controls PDF report generation; reports are disabled when
+// the wkhtmltopdf binary cannot be found
+type ReportConfig struct {
+	Path        string
+	Wkhtmltopdf string
+	BrandName   string
+	BrandColor  string
+}
+
+// MailConfig selects how email is delivered: "log" prints messages for
+// development, "smtp" sends them through a relay
+type MailConfig struct {
+	Driver       string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+}
+
+// SessionConfig limits simultaneous sign-in sessions per user. MaxPerUser
+// of zero means unlimited; LimitPolicy is "reject" (refuse the new sign-in)
+// or "evict_oldest" (end the oldest session to make room). A user's last
+// seen time is written at most once per ActivityInterval. Sign-in lookups
+// by email are cached for LoginCacheTTL, zero disabling the cache; other
+// instances' changes to a user can take that long to be seen at sign-in.
+type SessionConfig struct {
+	TTL              time.Duration
+	MaxPerUser       int
+	LimitPolicy      string
+	ActivityInterval time.Duration
+	LoginCacheTTL    time.Duration
+}
+
+// BruteForceConfig bans an IP address for BaseBan after MaxFailures failed
+// sign-ins within Window; each further ban doubles, up to MaxBan. Ban
+// history is forgotten after Retention without failures.
+type BruteForceConfig struct {
+	MaxFailures int
+	Window      time.Duration
+	BaseBan     time.Duration
+	MaxBan      time.Duration
+	Retention   time.Duration
+}
+
+// ErasureConfig sets how long users who asked to be deleted can still be
+// restored by an admin, and how often the server looks for users whose
+// GracePeriod is over to anonymize them
+type ErasureConfig struct {
+	GracePeriod   time.Duration
+	CheckInterval time.Duration
+}
+
+// TelemetryConfig controls the opt-in telemetry module, which posts
+// anonymous usage statistics to Endpoint every Interval. It is off unless
+// Enabled.
+type TelemetryConfig struct {
+	Enabled  bool
+	Endpoint string
+	Interval time.Duration
+}
+
+// CleanupConfig says how long expired data is kept before the cleanup
+// command purges it. Temporary uploads are files left in TempUploadDir by
+// interrupted uploads.
+type CleanupConfig struct {
+	AuditRetention      time.Duration
+	SessionRetention    time.Duration
+	TempUploadDir       string
+	TempUploadRetention time.Duration
+}
+
+// UploadConfig holds the upload profiles routes refer to by name. Files are
+// kept by the Storage backend, "local", "s3" or "gcs". With local every
+// profile saves under Root, which is also where downloads are served from;
+// on any backend a profile's Path relative to Root is the key prefix of its
+// files.
+// Resumable uploads are assembled, and images copied for the variant
+// encoders, in TempDir, the cleanup command's UPLOAD_TEMP_DIR.
+// ZIP and gzip uploads are refused when they hold more than
+// ArchiveMaxEntries entries or expand beyond ArchiveMaxBytes or
+// ArchiveMaxRatio times their own size.
+// Avatars are cropped to a square and scaled to AvatarSize pixels wide.
+// Presigned direct uploads to s3 or gcs must start within PresignTTL, and
+// resumable uploads must finish within ResumableTTL.
+// With a ClamdAddress every upload is scanned for viruses by clamd, which
+// must answer within ClamdTimeout.
+// Profiles with Variants have their images transcoded with the cwebp and
+// avifenc binaries at CwebpPath and AvifencPath.
+// With a CDN base URL, file URLs point at the CDN and are signed.
+// Every GCInterval, unless zero, stored files older than GCMinAge that no
+// file record or avatar refers to are removed.
+type UploadConfig struct {
+	Storage  string
+	S3       S3Config
+	GCS      GCSConfig
+	CDN      CDNConfig
+	Root     string
+	TempDir  string
+	Profiles map[string]UploadProfile
+
+	ArchiveMaxEntries int
+	ArchiveMaxBytes   int64
+	ArchiveMaxRatio   int64
+
+	AvatarSize int
+
+	PresignTTL   time.Duration
+	ResumableTTL time.Duration
+
+	ClamdAddress string
+	ClamdTimeout time.Duration
+
+	CwebpPath   string
+	AvifencPath string
+
+	GCInterval time.Duration
+	GCMinAge   time.Duration
+}
+
+// HasVariants reports whether any profile asks for image variants
+func (c UploadConfig) HasVariants() bool {
+	for _, profile := range c.Profiles {
+		if len(profile.Variants) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyPrefix is the storage key prefix the files of profile are saved under
+func (c UploadConfig) KeyPrefix(profile UploadProfile) string {
+	rel, err := filepath.Rel(c.Root, profile.Path)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// S3Config says which S3-compatible bucket the s3 storage backend uses.
+// Without an AccessKey, credentials come from the AWS environment, shared
+// credentials file or instance role. Every key is put under Prefix, and
+// files larger than PartSize are sent as multipart uploads.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool
+	Prefix    string
+	PublicURL string
+	PartSize  uint64
+}
+
+// GCSConfig says which Google Cloud Storage bucket the gcs storage backend
+// uses. CredentialsFile is a service account's JSON key; without one the
+// application default credentials are used. Every key is put under Prefix.
+type GCSConfig struct {
+	Bucket          string
+	CredentialsFile string
+	Prefix          string
+	PublicURL       string
+}
+
+// CDNConfig says which CDN serves uploaded files, at BaseURL, and how its
+// URLs are signed: "cloudfront" with the key pair KeyPairID and the
+// private key in PrivateKeyFile, or "cloudflare" with the HMAC Secret of a
+// WAF rule. Signed URLs are valid for URLTTL.
+type CDNConfig struct {
+	BaseURL        string
+	Signing        string
+	KeyPairID      string
+	PrivateKeyFile string
+	Secret         string
+	URLTTL         time.Duration
+}
+
+// UploadProfile says what one kind of upload accepts. AllowedTypes are
+// compared with the type detected from the file's contents, without
+// parameters, under any of its aliases. Lists written when types were
+// sniffed by net/http keep working: text/plain also allows CSV and JSON
+// files and text/xml SVG images.
+// EXIF, XMP and text metadata is stripped from JPEG, PNG and WebP images
+// unless KeepMetadata is set. JPEG and PNG images are also saved in each
+// of the Variants formats, webp or avif.
+type UploadProfile struct {
+	MaxFileSize  int64
+	AllowedTypes []string
+	AllowedExts  []string
+	Path         string
+	FieldName    string
+	Required     bool
+	MaxFiles     int
+	KeepMetadata bool
+	Variants     []string
+}
+
+// Built-in upload profiles; UPLOAD_PROFILES adds more
+const (
+	UploadProfileAny      = "any"
+	UploadProfileImage    = "image"
+	UploadProfileDocument = "document"
+	UploadProfileImages   = "images"
+	UploadProfileAvatar   = "avatar"
+)
+
+// PasswordConfig sets the password expiry policy and how passwords are
+// hashed. A MaxAge of zero means passwords never expire. HashAlgorithm is
+// bcrypt or argon2id; with a CalibrateTarget the work factor is raised at
+// startup until hashing takes about that long on the host.
+type PasswordConfig struct {
+	MaxAge time.Duration
+
+	HashAlgorithm   string
+	BcryptCost      int
+	Argon2MemoryKiB uint32
+	Argon2Time      uint32
+	Argon2Threads   uint8
+	CalibrateTarget time.Duration
+}
+
+// RegistrationConfig throttles sign-ups per email domain and per IP block
+// within Window; a limit of zero disables that check
+type RegistrationConfig struct {
+	DomainLimit   int
+	IPBlockLimit  int
+	Window        time.Duration
+	ExemptDomains []string
+}
+
+// ProfileConfig lists the fields that count towards a profile's
+// completeness score: first_name, last_name, avatar, email_verified, locale
+// and tags, or metadata.<key> for a key of the user's metadata
+type ProfileConfig struct {
+	CompletenessFields []string
+}
+
+// profileFields are the fields ProfileConfig accepts besides metadata keys,
+// see models.profileChecks
+var profileFields = []string{"first_name", "last_name", "avatar", "email_verified", "locale", "tags"}
+
+// BotDetectionConfig scores requests to the register and login forms.
+// HoneypotField names a form field hidden from people, TimestampField one
+// holding the Unix time the form was rendered; submissions sooner than
+// MinFillTime look automated.
+type BotDetectionConfig struct {
+	Enabled        bool
+	HoneypotField  string
+	TimestampField string
+	MinFillTime    time.Duration
+	PenaltyScore   int
+	BlockScore     int
+}
+
+// ModulesConfig lists optional modules (files, exports, reports, sync,
+// telemetry) to leave out; every other module is enabled
+type ModulesConfig struct {
+	Disabled []string
+}
+
+// IsEnabled reports whether the named module should be loaded
+func (c ModulesConfig) IsEnabled(name string) bool {
+	for _, disabled := range c.Disabled {
+		if disabled == name {
+			return false
+		}
+	}
+	return true
 }
 
 func LoadConfig() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 	}
 
-	expiresIn, _ := time.ParseDuration(getEnv("JWT_EXPIRES_IN", "4h"))
+	// JWT_EXPIRES_IN is the former name of JWT_ACCESS_TTL
+	accessTTL, err := time.ParseDuration(getEnv("JWT_ACCESS_TTL", getEnv("JWT_EXPIRES_IN", "4h")))
+	if err != nil || accessTTL <= 0 {
+		return nil, fmt.Errorf("JWT_ACCESS_TTL must be a positive duration")
+	}
+	refreshTTL, err := time.ParseDuration(getEnv("JWT_REFRESH_TTL", "0"))
+	if err != nil || refreshTTL < 0 {
+		return nil, fmt.Errorf("JWT_REFRESH_TTL must be a non-negative duration")
+	}
+	tombstoneRetention, err := time.ParseDuration(getEnv("SYNC_TOMBSTONE_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SYNC_TOMBSTONE_RETENTION: %w", err)
+	}
+
+	jobWorkers, err := strconv.Atoi(getEnv("JOB_WORKERS", "2"))
+	if err != nil || jobWorkers < 1 {
+		return nil, fmt.Errorf("JOB_WORKERS must be a positive integer")
+	}
+	jobQueueSize, err := strconv.Atoi(getEnv("JOB_QUEUE_SIZE", "100"))
+	if err != nil || jobQueueSize < 1 {
+		return nil, fmt.Errorf("JOB_QUEUE_SIZE must be a positive integer")
+	}
+
+	sessionTTL, err := time.ParseDuration(getEnv("SESSION_TTL", "24h"))
+	if err != nil || sessionTTL <= 0 {
+		return nil, fmt.Errorf("SESSION_TTL must be a positive duration")
+	}
+	maxSessions, err := strconv.Atoi(getEnv("MAX_SESSIONS_PER_USER", "0"))
+	if err != nil || maxSessions < 0 {
+		return nil, fmt.Errorf("MAX_SESSIONS_PER_USER must be a non-negative integer")
+	}
+	sessionLimitPolicy := getEnv("SESSION_LIMIT_POLICY", "evict_oldest")
+	if sessionLimitPolicy != "reject" && sessionLimitPolicy != "evict_oldest" {
+		return nil, fmt.Errorf("SESSION_LIMIT_POLICY must be reject or evict_oldest")
+	}
+	activityInterval, err := time.ParseDuration(getEnv("ACTIVITY_TOUCH_INTERVAL", "5m"))
+	if err != nil || activityInterval <= 0 {
+		return nil, fmt.Errorf("ACTIVITY_TOUCH_INTERVAL must be a positive duration")
+	}
+	loginCacheTTL, err := time.ParseDuration(getEnv("LOGIN_CACHE_TTL", "5s"))
+	if err != nil || loginCacheTTL < 0 {
+		return nil, fmt.Errorf("LOGIN_CACHE_TTL must be a non-negative duration")
+	}
+
+	bruteForce, err := loadBruteForceConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	retryMaxInterval, err := time.ParseDuration(getEnv("MONGODB_RETRY_MAX_INTERVAL", "30s"))
+	if err != nil || retryMaxInterval <= 0 {
+		return nil, fmt.Errorf("MONGODB_RETRY_MAX_INTERVAL must be a positive duration")
+	}
+
+	erasure, err := loadErasureConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cleanup, err := loadCleanupConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	telemetry, err := loadTelemetryConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := loadProfileConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	uploads, err := loadUploadConfig()
+	if err != nil {
+		return nil, err
+	}
+	uploads.TempDir = cleanup.TempUploadDir
+
+	password, err := loadPasswordConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	registration, err := loadRegistrationConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	bots, err := loadBotDetectionConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var encryptionKey []byte
+	if encoded := getEnv("JWT_ENCRYPTION_KEY", ""); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("JWT_ENCRYPTION_KEY must be a base64 encoded 32 byte key")
+		}
+		encryptionKey = key
+	}
+
+	signingKeys, err := parseKeyList(getEnv("JWT_SIGNING_KEYS", ""))
+	if err != nil {
+		return nil, err
+	}
+	activeKID := getEnv("JWT_ACTIVE_KID", "")
+	if len(signingKeys) > 0 {
+		if _, ok := signingKeys[activeKID]; !ok {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID must name one of the keys in JWT_SIGNING_KEYS")
+		}
+	}
+
+	algorithm := getEnv("JWT_ALGORITHM", "HS256")
+	var privateKeyPEM []byte
+	switch algorithm {
+	case "HS256":
+	case "RS256", "EdDSA":
+		privateKeyPEM = []byte(getEnv("JWT_PRIVATE_KEY", ""))
+		if path := getEnv("JWT_PRIVATE_KEY_FILE", ""); path != "" {
+			privateKeyPEM, err = os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read JWT_PRIVATE_KEY_FILE: %w", err)
+			}
+		}
+		if len(privateKeyPEM) == 0 {
+			return nil, fmt.Errorf("JWT_ALGORITHM %s requires JWT_PRIVATE_KEY or JWT_PRIVATE_KEY_FILE", algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", algorithm)
+	}
+
+	mailConfig := MailConfig{
+		Driver:       getEnv("MAIL_DRIVER", "log"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		From:         getEnv("MAIL_FROM", "no-reply@localhost"),
+	}
+	switch mailConfig.Driver {
+	case "log":
+	case "smtp":
+		if mailConfig.SMTPHost == "" {
+			return nil, fmt.Errorf("MAIL_DRIVER smtp requires SMTP_HOST")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported MAIL_DRIVER %q", mailConfig.Driver)
+	}
+
+	samlConfig := SAMLConfig{
+		Enabled:     getEnv("SAML_ENABLED", "false") == "true",
+		BaseURL:     strings.TrimRight(getEnv("SAML_BASE_URL", "http://localhost:8080"), "/"),
+		IDPEntityID: getEnv("SAML_IDP_ENTITY_ID", ""),
+		IDPSSOURL:   getEnv("SAML_IDP_SSO_URL", ""),
+		IDPSLOURL:   getEnv("SAML_IDP_SLO_URL", ""),
+		DefaultRole: getEnv("SAML_DEFAULT_ROLE", "user"),
+	}
+	samlConfig.EntityID = getEnv("SAML_ENTITY_ID", samlConfig.BaseURL+"/api/v1/auth/saml/metadata")
+	if samlConfig.Enabled {
+		path := getEnv("SAML_IDP_CERT_FILE", "")
+		if path == "" || samlConfig.IDPEntityID == "" || samlConfig.IDPSSOURL == "" {
+			return nil, fmt.Errorf("SAML_ENABLED requires SAML_IDP_ENTITY_ID, SAML_IDP_SSO_URL and SAML_IDP_CERT_FILE")
+		}
+		samlConfig.IDPCertPEM, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SAML_IDP_CERT_FILE: %w", err)
+		}
+	}
+
+	var oauthConfig OAuthConfig
+	for _, name := range parseList(getEnv("OAUTH_PROVIDERS", "")) {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		provider := OAuthProviderConfig{
+			Name:         strings.ToLower(name),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			AuthURL:      getEnv(prefix+"AUTH_URL", ""),
+			TokenURL:     getEnv(prefix+"TOKEN_URL", ""),
+			UserInfoURL:  getEnv(prefix+"USERINFO_URL", ""),
+			Scopes:       parseList(getEnv(prefix+"SCOPES", "")),
+		}
+		if provider.ClientID == "" || provider.ClientSecret == "" {
+			return nil, fmt.Errorf("OAuth provider %s requires %sCLIENT_ID and %sCLIENT_SECRET", name, prefix, prefix)
+		}
+		oauthConfig.Providers = append(oauthConfig.Providers, provider)
+	}
+
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:            getEnv("PORT", "8080"),
+			Env:             getEnv("ENV", "development"),
+			PublicURL:       strings.TrimRight(getEnv("PUBLIC_URL", "http://localhost:8080"), "/"),
+			LogLayerTimings: getEnv("LOG_LAYER_TIMINGS", "false") == "true",
 		},
 		Database: DatabaseConfig{
-			URI:     getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-			Name:    getEnv("DATABASE_NAME", "go_starter_db"),
-			Timeout: 10 * time.Second,
+			URI:              getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+			Name:             getEnv("DATABASE_NAME", "go_starter_db"),
+			Timeout:          10 * time.Second,
+			ResilientStart:   getEnv("MONGODB_RESILIENT_START", "false") == "true",
+			RetryMaxInterval: retryMaxInterval,
 		},
 		JWT: JWTConfig{
-			Secret:    getEnv("JWT_SECRET", "default_secret_key"),
-			ExpiresIn: expiresIn,
+			Secret:        getEnv("JWT_SECRET", "default_secret_key"),
+			AccessTTL:     accessTTL,
+			RefreshTTL:    refreshTTL,
+			EncryptionKey: encryptionKey,
+			SigningKeys:   signingKeys,
+			ActiveKID:     activeKID,
+			Algorithm:     algorithm,
+			PrivateKeyPEM: privateKeyPEM,
+			KeyID:         getEnv("JWT_KEY_ID", "default"),
+		},
+		SAML:  samlConfig,
+		OAuth: oauthConfig,
+		Sync: SyncConfig{
+			TombstoneRetention: tombstoneRetention,
 		},
+		Jobs: JobsConfig{
+			Workers:   jobWorkers,
+			QueueSize: jobQueueSize,
+		},
+		Report: ReportConfig{
+			Path:        getEnv("REPORT_PATH", "./reports"),
+			Wkhtmltopdf: getEnv("WKHTMLTOPDF_PATH", "wkhtmltopdf"),
+			BrandName:   getEnv("REPORT_BRAND_NAME", "User Management API"),
+			BrandColor:  getEnv("REPORT_BRAND_COLOR", "#1f6feb"),
+		},
+		Mail: mailConfig,
+		Session: SessionConfig{
+			TTL:              sessionTTL,
+			MaxPerUser:       maxSessions,
+			LimitPolicy:      sessionLimitPolicy,
+			ActivityInterval: activityInterval,
+			LoginCacheTTL:    loginCacheTTL,
+		},
+		BruteForce: bruteForce,
+		Cleanup:    cleanup,
+		Uploads:    uploads,
+		Password:   password,
+		Register:   registration,
+		Bots:       bots,
+		Modules: ModulesConfig{
+			Disabled: parseList(getEnv("DISABLED_MODULES", "")),
+		},
+		Erasure:   erasure,
+		Telemetry: telemetry,
+		Profile:   profile,
 	}, nil
 }
 
+func loadBruteForceConfig() (BruteForceConfig, error) {
+	var cfg BruteForceConfig
+	maxFailures, err := strconv.Atoi(getEnv("BRUTE_FORCE_MAX_FAILURES", "10"))
+	if err != nil || maxFailures < 1 {
+		return cfg, fmt.Errorf("BRUTE_FORCE_MAX_FAILURES must be a positive integer")
+	}
+	cfg.MaxFailures = maxFailures
+
+	durations := []struct {
+		key, fallback string
+		dst           *time.Duration
+	}{
+		{"BRUTE_FORCE_WINDOW", "15m", &cfg.Window},
+		{"BRUTE_FORCE_BAN", "5m", &cfg.BaseBan},
+		{"BRUTE_FORCE_MAX_BAN", "24h", &cfg.MaxBan},
+		{"BRUTE_FORCE_RETENTION", "168h", &cfg.Retention},
+	}
+	for _, d := range durations {
+		value, err := time.ParseDuration(getEnv(d.key, d.fallback))
+		if err != nil || value <= 0 {
+			return cfg, fmt.Errorf("%s must be a positive duration", d.key)
+		}
+		*d.dst = value
+	}
+	if cfg.MaxBan < cfg.BaseBan {
+		return cfg, fmt.Errorf("BRUTE_FORCE_MAX_BAN must not be shorter than BRUTE_FORCE_BAN")
+	}
+	return cfg, nil
+}
+
+func loadErasureConfig() (ErasureConfig, error) {
+	var cfg ErasureConfig
+	durations := []struct {
+		key, fallback string
+		dst           *time.Duration
+	}{
+		{"ERASURE_GRACE_PERIOD", "720h", &cfg.GracePeriod},
+		{"ERASURE_CHECK_INTERVAL", "1h", &cfg.CheckInterval},
+	}
+	for _, d := range durations {
+		value, err := time.ParseDuration(getEnv(d.key, d.fallback))
+		if err != nil || value <= 0 {
+			return cfg, fmt.Errorf("%s must be a positive duration", d.key)
+		}
+		*d.dst = value
+	}
+	return cfg, nil
+}
+
+func loadTelemetryConfig() (TelemetryConfig, error) {
+	cfg := TelemetryConfig{
+		Enabled:  getEnv("TELEMETRY_ENABLED", "false") == "true",
+		Endpoint: getEnv("TELEMETRY_ENDPOINT", ""),
+	}
+	interval, err := time.ParseDuration(getEnv("TELEMETRY_INTERVAL", "24h"))
+	if err != nil || interval < time.Hour {
+		return cfg, fmt.Errorf("TELEMETRY_INTERVAL must be a duration of at least 1h")
+	}
+	cfg.Interval = interval
+	if cfg.Enabled && cfg.Endpoint == "" {
+		return cfg, fmt.Errorf("TELEMETRY_ENABLED requires TELEMETRY_ENDPOINT")
+	}
+	return cfg, nil
+}
+
+func loadCleanupConfig() (CleanupConfig, error) {
+	cfg := CleanupConfig{
+		TempUploadDir: getEnv("UPLOAD_TEMP_DIR", "./tmp/uploads"),
+	}
+	durations := []struct {
+		key, fallback string
+		dst           *time.Duration
+	}{
+		{"AUDIT_RETENTION", "2160h", &cfg.AuditRetention},
+		{"SESSION_RETENTION", "2160h", &cfg.SessionRetention},
+		{"UPLOAD_TEMP_RETENTION", "24h", &cfg.TempUploadRetention},
+	}
+	for _, d := range durations {
+		value, err := time.ParseDuration(getEnv(d.key, d.fallback))
+		if err != nil || value <= 0 {
+			return cfg, fmt.Errorf("%s must be a positive duration", d.key)
+		}
+		*d.dst = value
+	}
+	return cfg, nil
+}
+
+// defaultUploadProfiles returns the built-in profiles saving under root
+func defaultUploadProfiles(root string) map[string]UploadProfile {
+	imageTypes := []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+	return map[string]UploadProfile{
+		UploadProfileAny: {
+			MaxFileSize:  10 << 20, // 10MB
+			AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "application/pdf"},
+			AllowedExts:  []string{".jpg", ".jpeg", ".png", ".gif", ".pdf"},
+			Path:         root,
+			FieldName:    "file",
+			Required:     true,
+			MaxFiles:     1,
+		},
+		UploadProfileImage: {
+			MaxFileSize:  5 << 20, // 5MB
+			AllowedTypes: imageTypes,
+			AllowedExts:  imageExts,
+			Path:         filepath.Join(root, "images"),
+			FieldName:    "image",
+			Required:     true,
+			MaxFiles:     1,
+		},
+		UploadProfileImages: {
+			MaxFileSize:  5 << 20, // 5MB
+			AllowedTypes: imageTypes,
+			AllowedExts:  imageExts,
+			Path:         filepath.Join(root, "images"),
+			FieldName:    "images",
+			Required:     true,
+			MaxFiles:     5,
+		},
+		// Avatars are optional on registration; the upload is replaced by
+		// a resized copy saved under avatars/
+		UploadProfileAvatar: {
+			MaxFileSize:  5 << 20, // 5MB
+			AllowedTypes: imageTypes,
+			AllowedExts:  imageExts,
+			Path:         filepath.Join(root, "images"),
+			FieldName:    "avatar",
+			Required:     false,
+			MaxFiles:     1,
+		},
+		UploadProfileDocument: {
+			MaxFileSize:  20 << 20, // 20MB
+			AllowedTypes: []string{"application/pdf", "application/msword", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+			AllowedExts:  []string{".pdf", ".doc", ".docx"},
+			Path:         filepath.Join(root, "documents"),
+			FieldName:    "document",
+			Required:     true,
+			MaxFiles:     1,
+		},
+	}
+}
+
+// loadUploadConfig starts from the built-in profiles, adds those named in
+// UPLOAD_PROFILES and applies the UPLOAD_<NAME>_* overrides to all of them
+func loadUploadConfig() (UploadConfig, error) {
+	cfg := UploadConfig{
+		// UPLOAD_STORAGE is the former name of STORAGE_DRIVER
+		Storage: getEnv("STORAGE_DRIVER", getEnv("UPLOAD_STORAGE", "local")),
+		Root:    filepath.Clean(getEnv("UPLOAD_ROOT", "./uploads")),
+	}
+	switch cfg.Storage {
+	case "local":
+	case "s3":
+		s3, err := loadS3Config()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.S3 = s3
+	case "gcs":
+		gcs, err := loadGCSConfig()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.GCS = gcs
+	default:
+		return cfg, fmt.Errorf("STORAGE_DRIVER must be local, s3 or gcs")
+	}
+	cfg.Profiles = defaultUploadProfiles(cfg.Root)
+	cdn, err := loadCDNConfig()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.CDN = cdn
+
+	archiveMaxEntries, err := strconv.Atoi(getEnv("UPLOAD_ARCHIVE_MAX_ENTRIES", "1000"))
+	if err != nil || archiveMaxEntries < 1 {
+		return cfg, fmt.Errorf("UPLOAD_ARCHIVE_MAX_ENTRIES must be a positive integer")
+	}
+	archiveMaxBytes, err := strconv.ParseInt(getEnv("UPLOAD_ARCHIVE_MAX_BYTES", "536870912"), 10, 64)
+	if err != nil || archiveMaxBytes < 1 {
+		return cfg, fmt.Errorf("UPLOAD_ARCHIVE_MAX_BYTES must be a positive integer")
+	}
+	archiveMaxRatio, err := strconv.ParseInt(getEnv("UPLOAD_ARCHIVE_MAX_RATIO", "100"), 10, 64)
+	if err != nil || archiveMaxRatio < 1 {
+		return cfg, fmt.Errorf("UPLOAD_ARCHIVE_MAX_RATIO must be a positive integer")
+	}
+	cfg.ArchiveMaxEntries, cfg.ArchiveMaxBytes, cfg.ArchiveMaxRatio = archiveMaxEntries, archiveMaxBytes, archiveMaxRatio
+	avatarSize, err := strconv.Atoi(getEnv("AVATAR_SIZE", "256"))
+	if err != nil || avatarSize < 16 || avatarSize > 2048 {
+		return cfg, fmt.Errorf("AVATAR_SIZE must be an integer between 16 and 2048")
+	}
+	cfg.AvatarSize = avatarSize
+	presignTTL, err := time.ParseDuration(getEnv("UPLOAD_PRESIGN_TTL", "15m"))
+	if err != nil || presignTTL < time.Minute || presignTTL > 7*24*time.Hour {
+		return cfg, fmt.Errorf("UPLOAD_PRESIGN_TTL must be a duration between 1m and 168h")
+	}
+	cfg.PresignTTL = presignTTL
+	resumableTTL, err := time.ParseDuration(getEnv("UPLOAD_RESUMABLE_TTL", "24h"))
+	if err != nil || resumableTTL < time.Minute {
+		return cfg, fmt.Errorf("UPLOAD_RESUMABLE_TTL must be a duration of at least 1m")
+	}
+	cfg.ResumableTTL = resumableTTL
+	cfg.ClamdAddress = getEnv("CLAMD_ADDRESS", "")
+	clamdTimeout, err := time.ParseDuration(getEnv("CLAMD_TIMEOUT", "30s"))
+	if err != nil || clamdTimeout <= 0 {
+		return cfg, fmt.Errorf("CLAMD_TIMEOUT must be a positive duration")
+	}
+	cfg.ClamdTimeout = clamdTimeout
+	cfg.CwebpPath = getEnv("CWEBP_PATH", "cwebp")
+	cfg.AvifencPath = getEnv("AVIFENC_PATH", "avifenc")
+	gcInterval, err := time.ParseDuration(getEnv("FILE_GC_INTERVAL", "24h"))
+	if err != nil || (gcInterval != 0 && gcInterval < time.Hour) {
+		return cfg, fmt.Errorf("FILE_GC_INTERVAL must be 0 or a duration of at least 1h")
+	}
+	cfg.GCInterval = gcInterval
+	gcMinAge, err := time.ParseDuration(getEnv("FILE_GC_MIN_AGE", "24h"))
+	if err != nil || gcMinAge < time.Hour {
+		return cfg, fmt.Errorf("FILE_GC_MIN_AGE must be a duration of at least 1h")
+	}
+	cfg.GCMinAge = gcMinAge
+
+	for _, name := range parseList(getEnv("UPLOAD_PROFILES", "")) {
+		name = strings.ToLower(name)
+		if strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789_") != "" {
+			return cfg, fmt.Errorf("upload profile name %q may only contain letters, digits and underscores", name)
+		}
+		if _, ok := cfg.Profiles[name]; ok {
+			continue
+		}
+		cfg.Profiles[name] = UploadProfile{
+			MaxFileSize: 10 << 20, // 10MB
+			Path:        filepath.Join(cfg.Root, name),
+			FieldName:   "file",
+			Required:    true,
+			MaxFiles:    1,
+		}
+	}
+
+	for name, profile := range cfg.Profiles {
+		prefix := "UPLOAD_" + strings.ToUpper(name) + "_"
+		if value := getEnv(prefix+"TYPES", ""); value != "" {
+			profile.AllowedTypes = parseList(value)
+		}
+		if value := getEnv(prefix+"EXTENSIONS", ""); value != "" {
+			profile.AllowedExts = parseList(strings.ToLower(value))
+		}
+		if value := getEnv(prefix+"MAX_BYTES", ""); value != "" {
+			maxFileSize, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return cfg, fmt.Errorf("%sMAX_BYTES must be an integer", prefix)
+			}
+			profile.MaxFileSize = maxFileSize
+		}
+		if value := getEnv(prefix+"MAX_FILES", ""); value != "" {
+			maxFiles, err := strconv.Atoi(value)
+			if err != nil {
+				return cfg, fmt.Errorf("%sMAX_FILES must be an integer", prefix)
+			}
+			profile.MaxFiles = maxFiles
+		}
+		if value := getEnv(prefix+"PATH", ""); value != "" {
+			profile.Path = filepath.Clean(value)
+		}
+		profile.FieldName = getEnv(prefix+"FIELD", profile.FieldName)
+		if value := getEnv(prefix+"REQUIRED", ""); value != "" {
+			profile.Required = value == "true"
+		}
+		if value := getEnv(prefix+"KEEP_METADATA", ""); value != "" {
+			profile.KeepMetadata = value == "true"
+		}
+		if value := getEnv(prefix+"VARIANTS", ""); value != "" {
+			profile.Variants = parseList(strings.ToLower(value))
+		}
+		// avatars are replaced by a resized copy, so variants would be lost
+		if name == UploadProfileAvatar && len(profile.Variants) > 0 {
+			return cfg, fmt.Errorf("%sVARIANTS is not supported", prefix)
+		}
+		if err := profile.validate(cfg.Root); err != nil {
+			return cfg, fmt.Errorf("upload profile %s: %w", name, err)
+		}
+		cfg.Profiles[name] = profile
+	}
+	return cfg, nil
+}
+
+func loadS3Config() (S3Config, error) {
+	cfg := S3Config{
+		Endpoint:  getEnv("S3_ENDPOINT", "s3.amazonaws.com"),
+		Region:    getEnv("S3_REGION", ""),
+		Bucket:    getEnv("S3_BUCKET", ""),
+		AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		SecretKey: getEnv("S3_SECRET_KEY", ""),
+		UseSSL:    getEnv("S3_USE_SSL", "true") == "true",
+		PathStyle: getEnv("S3_PATH_STYLE", "false") == "true",
+		Prefix:    strings.Trim(getEnv("S3_PREFIX", ""), "/"),
+		PublicURL: strings.TrimRight(getEnv("S3_PUBLIC_URL", ""), "/"),
+	}
+	if cfg.Bucket == "" {
+		return cfg, fmt.Errorf("S3_BUCKET is required when STORAGE_DRIVER is s3")
+	}
+	if (cfg.AccessKey == "") != (cfg.SecretKey == "") {
+		return cfg, fmt.Errorf("S3_ACCESS_KEY and S3_SECRET_KEY must be set together")
+	}
+	partSize, err := strconv.ParseUint(getEnv("S3_PART_SIZE", "16777216"), 10, 64)
+	if err != nil || partSize < 5<<20 {
+		return cfg, fmt.Errorf("S3_PART_SIZE must be at least 5242880 bytes, the S3 minimum")
+	}
+	cfg.PartSize = partSize
+	return cfg, nil
+}
+
+func loadGCSConfig() (GCSConfig, error) {
+	cfg := GCSConfig{
+		Bucket:          getEnv("GCS_BUCKET", ""),
+		CredentialsFile: getEnv("GCS_CREDENTIALS_FILE", ""),
+		Prefix:          strings.Trim(getEnv("GCS_PREFIX", ""), "/"),
+		PublicURL:       strings.TrimRight(getEnv("GCS_PUBLIC_URL", ""), "/"),
+	}
+	if cfg.Bucket == "" {
+		return cfg, fmt.Errorf("GCS_BUCKET is required when STORAGE_DRIVER is gcs")
+	}
+	return cfg, nil
+}
+
+func loadCDNConfig() (CDNConfig, error) {
+	cfg := CDNConfig{
+		BaseURL:        strings.TrimRight(getEnv("CDN_BASE_URL", ""), "/"),
+		Signing:        getEnv("CDN_SIGNING", "cloudfront"),
+		KeyPairID:      getEnv("CDN_KEY_PAIR_ID", ""),
+		PrivateKeyFile: getEnv("CDN_PRIVATE_KEY_FILE", ""),
+		Secret:         getEnv("CDN_SIGNING_SECRET", ""),
+	}
+	if cfg.BaseURL == "" {
+		return cfg, nil
+	}
+	switch cfg.Signing {
+	case "cloudfront":
+		if cfg.KeyPairID == "" || cfg.PrivateKeyFile == "" {
+			return cfg, fmt.Errorf("CDN_KEY_PAIR_ID and CDN_PRIVATE_KEY_FILE are required when CDN_SIGNING is cloudfront")
+		}
+	case "cloudflare":
+		if cfg.Secret == "" {
+			return cfg, fmt.Errorf("CDN_SIGNING_SECRET is required when CDN_SIGNING is cloudflare")
+		}
+	default:
+		return cfg, fmt.Errorf("CDN_SIGNING must be cloudfront or cloudflare")
+	}
+	urlTTL, err := time.ParseDuration(getEnv("CDN_URL_TTL", "1h"))
+	if err != nil || urlTTL < time.Minute {
+		return cfg, fmt.Errorf("CDN_URL_TTL must be a duration of at least 1m")
+	}
+	cfg.URLTTL = urlTTL
+	return cfg, nil
+}
+
+// validate rejects profiles that could never accept a file or that save
+// outside root
+func (p UploadProfile) validate(root string) error {
+	if p.MaxFileSize <= 0 {
+		return fmt.Errorf("max file size must be positive")
+	}
+	if p.MaxFiles < 1 {
+		return fmt.Errorf("max files must be at least 1")
+	}
+	if p.FieldName == "" {
+		return fmt.Errorf("field name is required")
+	}
+	if len(p.AllowedTypes) == 0 || len(p.AllowedExts) == 0 {
+		return fmt.Errorf("allowed types and extensions are required")
+	}
+	for _, contentType := range p.AllowedTypes {
+		kind, subtype, found := strings.Cut(contentType, "/")
+		if !found || kind == "" || subtype == "" || strings.ContainsAny(contentType, "; ") {
+			return fmt.Errorf("invalid MIME type %q, expected type/subtype", contentType)
+		}
+	}
+	for _, ext := range p.AllowedExts {
+		if len(ext) < 2 || !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, "/\\ ") {
+			return fmt.Errorf("invalid extension %q, expected e.g. .csv", ext)
+		}
+	}
+	for _, format := range p.Variants {
+		if format != "webp" && format != "avif" {
+			return fmt.Errorf("invalid variant %q, expected webp or avif", format)
+		}
+	}
+	rel, err := filepath.Rel(root, p.Path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %s is outside UPLOAD_ROOT %s", p.Path, root)
+	}
+	return nil
+}
+
+func loadPasswordConfig() (PasswordConfig, error) {
+	cfg := PasswordConfig{HashAlgorithm: getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt")}
+	if cfg.HashAlgorithm != "bcrypt" && cfg.HashAlgorithm != "argon2id" {
+		return cfg, fmt.Errorf("PASSWORD_HASH_ALGORITHM must be bcrypt or argon2id")
+	}
+	maxAgeDays, err := strconv.Atoi(getEnv("PASSWORD_MAX_AGE_DAYS", "0"))
+	if err != nil || maxAgeDays < 0 {
+		return cfg, fmt.Errorf("PASSWORD_MAX_AGE_DAYS must be a non-negative integer")
+	}
+	cfg.MaxAge = time.Duration(maxAgeDays) * 24 * time.Hour
+
+	cfg.BcryptCost, err = strconv.Atoi(getEnv("PASSWORD_BCRYPT_COST", "10"))
+	if err != nil || cfg.BcryptCost < 10 || cfg.BcryptCost > 31 {
+		return cfg, fmt.Errorf("PASSWORD_BCRYPT_COST must be between 10 and 31")
+	}
+	memory, err := strconv.ParseUint(getEnv("PASSWORD_ARGON2_MEMORY_KIB", "65536"), 10, 32)
+	if err != nil || memory < 8192 {
+		return cfg, fmt.Errorf("PASSWORD_ARGON2_MEMORY_KIB must be at least 8192")
+	}
+	passes, err := strconv.ParseUint(getEnv("PASSWORD_ARGON2_TIME", "1"), 10, 32)
+	if err != nil || passes < 1 {
+		return cfg, fmt.Errorf("PASSWORD_ARGON2_TIME must be a positive integer")
+	}
+	threads, err := strconv.ParseUint(getEnv("PASSWORD_ARGON2_THREADS", "2"), 10, 8)
+	if err != nil || threads < 1 {
+		return cfg, fmt.Errorf("PASSWORD_ARGON2_THREADS must be between 1 and 255")
+	}
+	cfg.Argon2MemoryKiB, cfg.Argon2Time, cfg.Argon2Threads = uint32(memory), uint32(passes), uint8(threads)
+
+	cfg.CalibrateTarget, err = time.ParseDuration(getEnv("PASSWORD_HASH_CALIBRATE_TARGET", "0"))
+	if err != nil || cfg.CalibrateTarget < 0 {
+		return cfg, fmt.Errorf("PASSWORD_HASH_CALIBRATE_TARGET must be a non-negative duration")
+	}
+	return cfg, nil
+}
+
+func loadRegistrationConfig() (RegistrationConfig, error) {
+	cfg := RegistrationConfig{
+		ExemptDomains: parseList(getEnv("REGISTRATION_EXEMPT_DOMAINS", "")),
+	}
+	domainLimit, err := strconv.Atoi(getEnv("REGISTRATION_DOMAIN_LIMIT", "50"))
+	if err != nil || domainLimit < 0 {
+		return cfg, fmt.Errorf("REGISTRATION_DOMAIN_LIMIT must be a non-negative integer")
+	}
+	ipBlockLimit, err := strconv.Atoi(getEnv("REGISTRATION_IP_BLOCK_LIMIT", "20"))
+	if err != nil || ipBlockLimit < 0 {
+		return cfg, fmt.Errorf("REGISTRATION_IP_BLOCK_LIMIT must be a non-negative integer")
+	}
+	window, err := time.ParseDuration(getEnv("REGISTRATION_THROTTLE_WINDOW", "1h"))
+	if err != nil || window <= 0 {
+		return cfg, fmt.Errorf("REGISTRATION_THROTTLE_WINDOW must be a positive duration")
+	}
+	cfg.DomainLimit, cfg.IPBlockLimit, cfg.Window = domainLimit, ipBlockLimit, window
+	return cfg, nil
+}
+
+func loadProfileConfig() (ProfileConfig, error) {
+	cfg := ProfileConfig{
+		CompletenessFields: parseList(getEnv("PROFILE_COMPLETENESS_FIELDS", "first_name,last_name,avatar,email_verified")),
+	}
+	seen := make(map[string]bool, len(cfg.CompletenessFields))
+	for _, field := range cfg.CompletenessFields {
+		key, isMetadata := strings.CutPrefix(field, "metadata.")
+		if isMetadata && (key == "" || strings.ContainsAny(key, ".$")) {
+			return cfg, fmt.Errorf("PROFILE_COMPLETENESS_FIELDS: invalid metadata key %q", key)
+		}
+		if !isMetadata && !slices.Contains(profileFields, field) {
+			return cfg, fmt.Errorf("PROFILE_COMPLETENESS_FIELDS: unknown field %q, expected one of %v or metadata.<key>", field, profileFields)
+		}
+		if seen[field] {
+			return cfg, fmt.Errorf("PROFILE_COMPLETENESS_FIELDS: %q is listed twice", field)
+		}
+		seen[field] = true
+	}
+	return cfg, nil
+}
+
+func loadBotDetectionConfig() (BotDetectionConfig, error) {
+	cfg := BotDetectionConfig{
+		Enabled:        getEnv("BOT_DETECTION_ENABLED", "false") == "true",
+		HoneypotField:  getEnv("BOT_HONEYPOT_FIELD", "website"),
+		TimestampField: getEnv("BOT_TIMESTAMP_FIELD", "form_rendered_at"),
+	}
+	minFillTime, err := time.ParseDuration(getEnv("BOT_MIN_FILL_TIME", "2s"))
+	if err != nil || minFillTime < 0 {
+		return cfg, fmt.Errorf("BOT_MIN_FILL_TIME must be a non-negative duration")
+	}
+	penaltyScore, err := strconv.Atoi(getEnv("BOT_PENALTY_SCORE", "50"))
+	if err != nil || penaltyScore < 1 {
+		return cfg, fmt.Errorf("BOT_PENALTY_SCORE must be a positive integer")
+	}
+	blockScore, err := strconv.Atoi(getEnv("BOT_BLOCK_SCORE", "100"))
+	if err != nil || blockScore < 1 {
+		return cfg, fmt.Errorf("BOT_BLOCK_SCORE must be a positive integer")
+	}
+	cfg.MinFillTime, cfg.PenaltyScore, cfg.BlockScore = minFillTime, penaltyScore, blockScore
+	return cfg, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// parseKeyList parses "kid1:secret1,kid2:secret2" into a map
+func parseKeyList(value string) (map[string]string, error) {
+	keys := make(map[string]string)
+	if value == "" {
+		return keys, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		kid, secret, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || kid == "" || secret == "" {
+			return nil, fmt.Errorf("invalid key entry %q, expected kid:secret", pair)
+		}
+		keys[kid] = secret
+	}
+	return keys, nil
+}
+
+// parseList parses "a, b,c" into its non-empty items
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
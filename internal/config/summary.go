@@ -0,0 +1,111 @@
+package config
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Summary is the configuration an operator needs to check a deployment,
+// with credentials left out. It is logged at startup and served by
+// /version.
+type Summary struct {
+	Version     string          `json:"version"`
+	Environment string          `json:"environment"`
+	Port        string          `json:"port"`
+	PublicURL   string          `json:"public_url"`
+	Modules     []string        `json:"modules"`
+	Database    DatabaseSummary `json:"database"`
+	Storage     StorageSummary  `json:"storage"`
+	// RateLimits describes each rate limit profile routes can use
+	RateLimits  map[string]string `json:"rate_limits"`
+	JWT         string            `json:"jwt_algorithm"`
+	Mail        string            `json:"mail_driver"`
+	SAMLEnabled bool              `json:"saml_enabled"`
+}
+
+type DatabaseSummary struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+type StorageSummary struct {
+	Backend  string   `json:"backend"`
+	Root     string   `json:"root"`
+	Profiles []string `json:"upload_profiles"`
+	// Scanner is the clamd address uploads are scanned by, if any
+	Scanner string `json:"virus_scanner,omitempty"`
+	// CDN is where file URLs point, if not at the storage backend
+	CDN string `json:"cdn,omitempty"`
+}
+
+// Summary returns the parts of the summary the configuration alone
+// determines; the caller fills in the version, modules and rate limits
+func (c *Config) Summary() Summary {
+	profiles := make([]string, 0, len(c.Uploads.Profiles))
+	for name := range c.Uploads.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	root := c.Uploads.Root
+	switch c.Uploads.Storage {
+	case "s3":
+		root = strings.TrimSuffix("s3://"+c.Uploads.S3.Bucket+"/"+c.Uploads.S3.Prefix, "/")
+	case "gcs":
+		root = strings.TrimSuffix("gs://"+c.Uploads.GCS.Bucket+"/"+c.Uploads.GCS.Prefix, "/")
+	}
+	return Summary{
+		Environment: c.Server.Env,
+		Port:        c.Server.Port,
+		PublicURL:   c.Server.PublicURL,
+		Database: DatabaseSummary{
+			URI:  RedactURI(c.Database.URI),
+			Name: c.Database.Name,
+		},
+		Storage: StorageSummary{
+			Backend:  c.Uploads.Storage,
+			Root:     root,
+			Profiles: profiles,
+			Scanner:  c.Uploads.ClamdAddress,
+			CDN:      c.Uploads.CDN.BaseURL,
+		},
+		JWT:         c.JWT.Algorithm,
+		Mail:        c.Mail.Driver,
+		SAMLEnabled: c.SAML.Enabled,
+	}
+}
+
+// redacted replaces secrets in redacted URIs
+const redacted = "REDACTED"
+
+// RedactURI hides the password of a connection string and the values of
+// query parameters that look like credentials. A URI that cannot be parsed
+// is hidden entirely, since its secrets cannot be told apart.
+func RedactURI(uri string) string {
+	if uri == "" {
+		return ""
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return redacted
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			lower := strings.ToLower(key)
+			for _, secret := range []string{"password", "secret", "token", "key", "authmechanismproperties"} {
+				if strings.Contains(lower, secret) {
+					query.Set(key, redacted)
+					break
+				}
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -3,12 +3,14 @@ package handlers
 import (
 	"net/http"
 	// "path/filepath"
+	"user-management-api/internal/middleware"
 	"user-management-api/internal/models"
 	"user-management-api/internal/services"
+	"user-management-api/pkg/auth"
 	"user-management-api/pkg/errors"
-	"user-management-api/pkg/utils"
+	"user-management-api/pkg/router"
 
-	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AuthHandler struct {
@@ -23,48 +25,31 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 
 // Register godoc
 // @Summary      Register a new user
-// @Description  Create a new user account
+// @Description  Create a new user account. An optional avatar image is cropped to a square, resized and stored; its URL is saved on the user.
 // @Tags         auth
-// @Accept       json
+// @Accept       multipart/form-data
 // @Produce      json
-// @Param        user  body      models.CreateUserRequest  true  "User Registration Info"
+// @Param        user    formData  models.RegisterRequest  true   "User Registration Info"
+// @Param        avatar  formData  file                    false  "Avatar image (JPEG, PNG, GIF or WebP)"
 // @Success      201   {object}  models.APIResponse{data=models.AuthResponse} "User created successfully"
-// @Failure      400   {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      400   {object}  models.APIResponse{error=map[string]string} "Validation failed, invalid request or unusable avatar"
 // @Failure      409   {object}  models.APIResponse "User already exists"
 // @Failure      500   {object}  models.APIResponse "Internal server error"
 // @Router       /auth/register [post]
-
-// ShouldBindJSON changed to ShouldBind because of multipart/form-data
-func (h *AuthHandler) Register(c *gin.Context) {
-	var req models.CreateUserRequest
-	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+func (h *AuthHandler) Register(c router.Context) {
+	req, appErr := Bind[models.RegisterRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
 			Success: false,
-			Message: "Invalid request data",
-			Error:   err.Error(),
+			Message: appErr.Message,
+			Error:   appErr.Details,
 		})
 		return
 	}
 
-	if err := utils.ValidateStruct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
-			Success: false,
-			Message: "Validation failed",
-			Error:   utils.FormatValidationError(err, models.CreateUserRequest{}),
-		})
-		return
-	}
-
-    // Get uploaded file paths from context (set by SingleImageUpload middleware)
-    uploadedFiles, exists := c.Get("uploadedFiles")
-    var imgPathStr string
-    if exists {
-        if files, ok := uploadedFiles.([]string); ok && len(files) > 0 {
-            imgPathStr = files[0] // use the first uploaded file as avatar path
-        }
-    }
-
-	authResponse, err := h.authService.Register(c.Request.Context(), &req, imgPathStr)
+	// The avatar, if any, was saved by the upload middleware; the service
+	// resizes it and removes the upload
+	authResponse, err := h.authService.Register(c.Request().Context(), &req, uploadedFile(c), loginContext(c))
 	if err != nil {
 		if appError, ok := err.(*errors.AppError); ok {
 			c.JSON(appError.Code, models.APIResponse{
@@ -98,31 +83,459 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Success      200          {object}  models.APIResponse{data=models.AuthResponse} "Login successful"
 // @Failure      400          {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
 // @Failure      401          {object}  models.APIResponse "Invalid credentials or inactive user"
+// @Failure      403          {object}  models.APIResponse "Password expired, change it via /auth/change-expired-password"
 // @Failure      500          {object}  models.APIResponse "Internal server error"
 // @Router       /auth/login [post]
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req models.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+func (h *AuthHandler) Login(c router.Context) {
+	req, appErr := Bind[models.LoginRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	// Login user
+	authResponse, err := h.authService.Login(c.Request().Context(), &req, loginContext(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Login successful",
+		Data:    authResponse,
+	})
+}
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Trade a refresh token for a new access token and refresh token in the same session. Each refresh token works once; available when JWT_REFRESH_TTL is set.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.RefreshTokenRequest  true  "Refresh token"
+// @Success      200      {object}  models.APIResponse{data=models.AuthResponse} "Tokens refreshed"
+// @Failure      400      {object}  models.APIResponse "Validation failed, or invalid or expired token"
+// @Failure      401      {object}  models.APIResponse "Inactive user"
+// @Failure      500      {object}  models.APIResponse "Internal server error"
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(c router.Context) {
+	req, appErr := Bind[models.RefreshTokenRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	authResponse, err := h.authService.Refresh(c.Request().Context(), req.RefreshToken, loginContext(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Tokens refreshed",
+		Data:    authResponse,
+	})
+}
+
+// Logout godoc
+// @Summary      Log out
+// @Description  End the session the access token belongs to, so the token stops working before it expires
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse "Logged out successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	var sessionID *primitive.ObjectID
+	if claims, ok := middleware.GetTokenClaims(c.Request().Context()); ok {
+		sessionID = claims.SessionID
+	}
+
+	err = h.authService.Logout(c.Request().Context(), userID, sessionID, loginContext(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// loginContext describes the client making a sign-in request
+func loginContext(c router.Context) models.LoginContext {
+	return models.LoginContext{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request().UserAgent(),
+		DeviceID:  c.Request().Header.Get("X-Device-ID"),
+	}
+}
+
+// IssueActionToken godoc
+// @Summary      Issue a scoped action token
+// @Description  Mint a short-lived token that only authorizes one action on one resource (max 15 minutes)
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ActionTokenRequest  true  "Action and resource to scope the token to"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.ActionTokenResponse} "Action token issued"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/action-token [post]
+func (h *AuthHandler) IssueActionToken(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.ActionTokenRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	token, err := h.authService.IssueActionToken(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Action token issued",
+		Data:    token,
+	})
+}
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys for verifying tokens signed with RS256 or EdDSA
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  auth.JWKSet "Public signing keys"
+// @Router       /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c router.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.PublicJWKS())
+}
+
+// Impersonate godoc
+// @Summary      Impersonate a user
+// @Description  Issue a 15 minute token acting as the target user, carrying an impersonated_by claim (Admin only)
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      201  {object}  models.APIResponse{data=models.ImpersonationResponse} "Impersonation token issued"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID"
+// @Failure      403  {object}  models.APIResponse "This user cannot be impersonated"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/impersonate [post]
+func (h *AuthHandler) Impersonate(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	targetID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Invalid request body",
-			Error:   err.Error(),
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	result, err := h.authService.Impersonate(c.Request().Context(), adminID, targetID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
 		})
 		return
 	}
 
-	// Validate request
-	if err := utils.ValidateStruct(&req); err != nil {
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Impersonation token issued",
+		Data:    result,
+	})
+}
+
+// CheckAvailability godoc
+// @Summary      Check username and email availability
+// @Description  Report whether a username and/or email are still free, so sign-up forms can validate before submitting. At least one is required. A free value can still be taken before registration completes.
+// @Tags         auth
+// @Produce      json
+// @Param        username  query     string  false  "Username to check"
+// @Param        email     query     string  false  "Email to check"
+// @Success      200  {object}  models.APIResponse{data=models.AvailabilityResponse} "Availability checked"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Neither given, or validation failed"
+// @Failure      429  {object}  models.APIResponse "Rate limit exceeded"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/availability [get]
+func (h *AuthHandler) CheckAvailability(c router.Context) {
+	query, appErr := Bind[models.AvailabilityQuery](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	result, err := h.authService.CheckAvailability(c.Request().Context(), &query)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Availability checked",
+		Data:    result,
+	})
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset email
+// @Description  Email a single-use reset link to the address if it belongs to an active account. The response is the same whether or not it does.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ForgotPasswordRequest  true  "Account email"
+// @Success      200  {object}  models.APIResponse "Reset email sent if the account exists"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c router.Context) {
+	req, appErr := Bind[models.ForgotPasswordRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	err := h.authService.ForgotPassword(c.Request().Context(), req.Email)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "If the account exists, a reset email has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password using the token from a reset email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ResetPasswordRequest  true  "Reset token and new password"
+// @Success      200  {object}  models.APIResponse "Password reset successfully"
+// @Failure      400  {object}  models.APIResponse "Validation failed, or invalid or expired token"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c router.Context) {
+	req, appErr := Bind[models.ResetPasswordRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	err := h.authService.ResetPassword(c.Request().Context(), &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Password reset successfully",
+	})
+}
+
+// AdminResetPassword godoc
+// @Summary      Reset a user's password
+// @Description  Reset an active user's password, either by emailing them a reset link valid for an hour or by setting a temporary password. The temporary password is returned once; sign-in refuses it with PASSWORD_CHANGE_REQUIRED until the user replaces it through /auth/change-expired-password, and the user's sessions are ended. Requires the users:write permission.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                            true  "User ID"
+// @Param        request  body      models.AdminResetPasswordRequest  true  "Reset method"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.AdminResetPasswordResponse} "Password reset"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Invalid user ID or validation failed"
+// @Failure      403  {object}  models.APIResponse "Missing permission or the user's role outranks yours"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      409  {object}  models.APIResponse "User is not active"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/reset-password [post]
+func (h *AuthHandler) AdminResetPassword(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Validation failed",
-			Error:   utils.FormatValidationError(err, models.LoginRequest{}),
+			Message: "Invalid user ID",
 		})
 		return
 	}
 
-	// Login user
-	authResponse, err := h.authService.Login(c.Request.Context(), &req)
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.AdminResetPasswordRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	result, err := h.authService.AdminResetPassword(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID, &req)
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
 			c.JSON(appErr.Code, models.APIResponse{
@@ -139,9 +552,103 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	c.Header("Cache-Control", "no-store")
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "Login successful",
+		Message: "Password reset",
+		Data:    result,
+	})
+}
+
+// ChangeExpiredPassword godoc
+// @Summary      Change an expired password
+// @Description  Replace a password that login rejected with PASSWORD_EXPIRED or PASSWORD_CHANGE_REQUIRED, authenticating with the current password, and sign in
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ChangeExpiredPasswordRequest  true  "Credentials and new password"
+// @Success      200  {object}  models.APIResponse{data=models.AuthResponse} "Password changed"
+// @Failure      400  {object}  models.APIResponse "Validation failed"
+// @Failure      401  {object}  models.APIResponse "Invalid credentials or inactive user"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/change-expired-password [post]
+func (h *AuthHandler) ChangeExpiredPassword(c router.Context) {
+	req, appErr := Bind[models.ChangeExpiredPasswordRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	authResponse, err := h.authService.ChangeExpiredPassword(c.Request().Context(), &req, loginContext(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Password changed successfully",
 		Data:    authResponse,
 	})
 }
+
+// VerifyEmail godoc
+// @Summary      Verify email address
+// @Description  Confirm an email address using the token from a verification email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.VerifyEmailRequest  true  "Verification token"
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Email verified successfully"
+// @Failure      400  {object}  models.APIResponse "Validation failed, or invalid or used token"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c router.Context) {
+	req, appErr := Bind[models.VerifyEmailRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	user, err := h.authService.VerifyEmail(c.Request().Context(), req.Token)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Email verified successfully",
+		Data:    user.ToResponse(),
+	})
+}
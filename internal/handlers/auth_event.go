@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+)
+
+type AuthEventHandler struct {
+	authEventService *services.AuthEventService
+}
+
+func NewAuthEventHandler(authEventService *services.AuthEventService) *AuthEventHandler {
+	return &AuthEventHandler{
+		authEventService: authEventService,
+	}
+}
+
+// ListAuthEvents godoc
+// @Summary      Query the auth event log
+// @Description  List logins, logouts, failed attempts, password changes, token refreshes and impersonations, newest first, filtered by effective user, acting user, event type and date range. Requires the audit:read permission.
+// @Tags         auth-events
+// @Produce      json
+// @Param        user_id  query     string  false  "Effective user ID, the user acted as"
+// @Param        actor_id query     string  false  "Acting user ID, e.g. the impersonating admin"
+// @Param        type     query     string  false  "Event type" Enums(login, login_failed, logout, password_change, token_refresh, impersonation)
+// @Param        from     query     string  false  "RFC3339 start of the range (inclusive)"
+// @Param        to       query     string  false  "RFC3339 end of the range (exclusive)"
+// @Param        page     query     int     false  "Page number"  default(1)
+// @Param        limit    query     int     false  "Items per page" default(20)
+// @Security     BearerAuth
+// @Security     OAuth2[audit:read]
+// @Success      200  {object}  models.PaginatedResponse{data=[]models.AuthEvent} "Auth events retrieved successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Invalid query parameters"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth-events [get]
+func (h *AuthEventHandler) ListAuthEvents(c router.Context) {
+	query, appErr := Bind[models.AuthEventQuery](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	result, err := h.authEventService.Query(c.Request().Context(), &query)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
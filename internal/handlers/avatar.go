@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// uploadedFile returns the storage key of the first file the upload
+// middleware saved for this request, or "" when there is none
+func uploadedFile(c router.Context) string {
+	if files := middleware.GetUploadedFiles(c.Request().Context()); len(files) > 0 {
+		return files[0].Key
+	}
+	return ""
+}
+
+// UploadProfileAvatar godoc
+// @Summary      Upload my avatar
+// @Description  Replace the current user's avatar. The image is cropped to a square, resized and stored; its URL is saved on the user and the previous avatar is removed.
+// @Tags         users
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        avatar  formData  file  true  "Avatar image (JPEG, PNG, GIF or WebP)"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Avatar updated successfully"
+// @Failure      400  {object}  models.APIResponse "Missing or unusable image"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      413  {object}  models.APIResponse "Image is too large"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/avatar [put]
+func (h *UserHandler) UploadProfileAvatar(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	h.setAvatar(c, userID, userID, middleware.GetUserRole(c.Request().Context()), false)
+}
+
+// DeleteProfileAvatar godoc
+// @Summary      Remove my avatar
+// @Description  Clear the current user's avatar and remove the stored image
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Avatar removed successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/avatar [delete]
+func (h *UserHandler) DeleteProfileAvatar(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	user, err := h.avatarService.Clear(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Avatar removed successfully",
+		Data:    user,
+	})
+}
+
+// UploadUserAvatar godoc
+// @Summary      Upload a user's avatar
+// @Description  Replace a user's avatar. The image is cropped to a square, resized and stored; its URL is saved on the user and the previous avatar is removed. Requires the users:write permission.
+// @Tags         users
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id      path      string  true  "User ID"
+// @Param        avatar  formData  file    true  "Avatar image (JPEG, PNG, GIF or WebP)"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Avatar updated successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID, missing or unusable image"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      413  {object}  models.APIResponse "Image is too large"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/avatar [put]
+func (h *UserHandler) UploadUserAvatar(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	h.setAvatar(c, actorID, userID, middleware.GetUserRole(c.Request().Context()), true)
+}
+
+// setAvatar stores the uploaded image as the avatar of userID, redacting
+// the response when the caller is not the user
+func (h *UserHandler) setAvatar(c router.Context, actorID, userID primitive.ObjectID, actorRole string, redact bool) {
+	key := uploadedFile(c)
+	if key == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "File field 'avatar' is required",
+			Error:   "FILE_REQUIRED",
+		})
+		return
+	}
+
+	user, err := h.avatarService.Set(c.Request().Context(), actorID, actorRole, userID, key)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	if redact {
+		redactFields(c, user)
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Avatar updated successfully",
+		Data:    user,
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+	"user-management-api/pkg/timing"
+	"user-management-api/pkg/utils"
+)
+
+// Bind decodes the request into a T, trims surrounding whitespace from its
+// string fields and validates it. GET requests bind the query string; other
+// requests bind the body by content type, defaulting to JSON; an empty
+// body binds as the zero value and is left to validation to reject. The
+// returned error is ErrInvalidQuery, ErrInvalidRequest or ErrValidationFailed
+// with the decoding error or the failing fields as details, ready to be sent
+// as the response.
+//
+// Fields tagged sanitize:"-", such as passwords, are left exactly as sent.
+// Reading and checking the request is charged to the handler layer.
+func Bind[T any](c router.Context) (T, *errors.AppError) {
+	defer timing.Track(c.Request().Context(), timing.LayerHandler)()
+	var req T
+	if err := router.Decode(c.Request(), &req); err != nil && err != io.EOF {
+		if c.Request().Method == http.MethodGet {
+			return req, errors.ErrInvalidQuery.WithDetails(err.Error())
+		}
+		return req, errors.ErrInvalidRequest.WithDetails(err.Error())
+	}
+	sanitize(reflect.ValueOf(&req).Elem())
+	if err := utils.ValidateStruct(&req); err != nil {
+		return req, errors.ErrValidationFailed.WithDetails(utils.FormatValidationError(err, &req))
+	}
+	return req, nil
+}
+
+// defaultQuery returns the query parameter name, or value when it is not
+// given at all
+func defaultQuery(c router.Context, name, value string) string {
+	if values, ok := c.Request().URL.Query()[name]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return value
+}
+
+// sanitize trims the strings in v, descending into nested structs
+func sanitize(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(strings.TrimSpace(v.String()))
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			sanitize(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			sanitize(v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() || t.Field(i).Tag.Get("sanitize") == "-" {
+				continue
+			}
+			sanitize(v.Field(i))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-management-api/internal/middleware"
+	"user-management-api/pkg/router"
+	"user-management-api/pkg/timing"
+)
+
+// slowBody is a request body that takes delay to arrive
+type slowBody struct {
+	io.Reader
+	delay time.Duration
+}
+
+func (b *slowBody) Read(p []byte) (int, error) {
+	time.Sleep(b.delay)
+	b.delay = 0
+	return b.Reader.Read(p)
+}
+
+func TestBindChargesDecodingToHandler(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	ctx, budget := timing.WithBudget(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/", &slowBody{Reader: strings.NewReader(`{"name": "test"}`), delay: delay})
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(ctx)
+
+	handler := middleware.TimedHandler(func(c router.Context) {
+		if _, err := Bind[struct {
+			Name string `json:"name" validate:"required"`
+		}](c); err != nil {
+			t.Errorf("Bind: %v", err)
+		}
+	})
+	router.HTTP(handler, router.PathValue).ServeHTTP(httptest.NewRecorder(), req)
+
+	_, spent := budget.Finish()
+	if spent[timing.LayerHandler] < delay {
+		t.Errorf("handler layer spent %s, want at least %s", spent[timing.LayerHandler], delay)
+	}
+	if spent[timing.LayerService] >= delay {
+		t.Errorf("service layer spent %s decoding, want less than %s", spent[timing.LayerService], delay)
+	}
+}
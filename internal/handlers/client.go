@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ClientHandler struct {
+	clientService *services.ClientService
+}
+
+func NewClientHandler(clientService *services.ClientService) *ClientHandler {
+	return &ClientHandler{
+		clientService: clientService,
+	}
+}
+
+// Token godoc
+// @Summary      OAuth2 token endpoint
+// @Description  Issue a machine token with the client_credentials grant, or exchange an authorization code (with its PKCE verifier) for a user token. Client credentials may be sent as form fields or HTTP Basic auth. Responses follow RFC 6749 rather than the APIResponse envelope so standard OAuth2 clients work unchanged.
+// @Tags         auth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "client_credentials or authorization_code"
+// @Param        client_id      formData  string  false  "Client ID"
+// @Param        client_secret  formData  string  false  "Client secret"
+// @Param        scope          formData  string  false  "Space separated scopes"
+// @Param        code           formData  string  false  "Authorization code"
+// @Param        redirect_uri   formData  string  false  "Redirect URI used to obtain the code"
+// @Param        code_verifier  formData  string  false  "PKCE code verifier"
+// @Success      200  {object}  models.TokenResponse "Token issued"
+// @Failure      400  {object}  models.OAuthError "invalid_request, unsupported_grant_type, invalid_grant or invalid_scope"
+// @Failure      401  {object}  models.OAuthError "invalid_client"
+// @Router       /auth/token [post]
+func (h *ClientHandler) Token(c router.Context) {
+	var req models.TokenRequest
+	if err := router.Decode(c.Request(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.OAuthError{
+			Error:            "invalid_request",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+	if clientID, clientSecret, ok := c.Request().BasicAuth(); ok {
+		req.ClientID = clientID
+		req.ClientSecret = clientSecret
+	}
+
+	token, err := h.clientService.IssueToken(c.Request().Context(), &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code < http.StatusInternalServerError {
+			c.JSON(appErr.Code, models.OAuthError{
+				Error:            appErr.Type,
+				ErrorDescription: appErr.Message,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.OAuthError{
+			Error: "server_error",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, token)
+}
+
+// RegisterClient godoc
+// @Summary      Register a machine client
+// @Description  Create a client for the client_credentials grant; the secret is only returned once (Admin only)
+// @Tags         clients
+// @Accept       json
+// @Produce      json
+// @Param        client  body      models.CreateClientRequest  true  "Client name and allowed scopes"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.CreateClientResponse} "Client registered successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /clients [post]
+func (h *ClientHandler) RegisterClient(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.CreateClientRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	result, err := h.clientService.Register(c.Request().Context(), adminID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Client registered successfully",
+		Data:    result,
+	})
+}
+
+// ListClients godoc
+// @Summary      List machine clients
+// @Description  List registered OAuth2 clients (Admin only)
+// @Tags         clients
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]models.OAuthClient} "Clients retrieved successfully"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /clients [get]
+func (h *ClientHandler) ListClients(c router.Context) {
+	clients, err := h.clientService.List(c.Request().Context())
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Clients retrieved successfully",
+		Data:    clients,
+	})
+}
+
+// DeactivateClient godoc
+// @Summary      Deactivate a machine client
+// @Description  Stop a client from obtaining new tokens (Admin only)
+// @Tags         clients
+// @Produce      json
+// @Param        id   path      string  true  "Client record ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse "Client deactivated successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid client ID"
+// @Failure      404  {object}  models.APIResponse "Client not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /clients/{id} [delete]
+func (h *ClientHandler) DeactivateClient(c router.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid client ID",
+		})
+		return
+	}
+
+	err = h.clientService.Deactivate(c.Request().Context(), id)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Client deactivated successfully",
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DeviceHandler struct {
+	deviceService *services.TrustedDeviceService
+}
+
+func NewDeviceHandler(deviceService *services.TrustedDeviceService) *DeviceHandler {
+	return &DeviceHandler{
+		deviceService: deviceService,
+	}
+}
+
+// ListDevices godoc
+// @Summary      List my trusted devices
+// @Description  List the devices the current user has marked as trusted and that have not expired
+// @Tags         devices
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[profile:read]
+// @Success      200  {object}  models.APIResponse{data=[]models.TrustedDevice} "Devices retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me/devices [get]
+func (h *DeviceHandler) ListDevices(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	devices, err := h.deviceService.List(c.Request().Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Devices retrieved successfully",
+		Data:    devices,
+	})
+}
+
+// TrustDevice godoc
+// @Summary      Trust this device
+// @Description  Mark the device making the request as trusted for 30 days. Devices are told apart by user agent and the optional X-Device-ID header; trusting a device again renews it.
+// @Tags         devices
+// @Accept       json
+// @Produce      json
+// @Param        X-Device-ID  header    string                      false  "Stable identifier of the client device"
+// @Param        request      body      models.TrustDeviceRequest  false  "Optional device name"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.TrustedDevice} "Device trusted"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me/devices [post]
+func (h *DeviceHandler) TrustDevice(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	// the body is optional
+	req, appErr := Bind[models.TrustDeviceRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	device, err := h.deviceService.Trust(c.Request().Context(), userID, loginContext(c), req.Name)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Device trusted",
+		Data:    device,
+	})
+}
+
+// RevokeDevice godoc
+// @Summary      Stop trusting a device
+// @Description  Remove one of the current user's trusted devices
+// @Tags         devices
+// @Produce      json
+// @Param        id   path      string  true  "Device ID"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse "Device removed"
+// @Failure      400  {object}  models.APIResponse "Invalid device ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Device not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me/devices/{id} [delete]
+func (h *DeviceHandler) RevokeDevice(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	deviceID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid device ID",
+		})
+		return
+	}
+
+	err = h.deviceService.Revoke(c.Request().Context(), userID, deviceID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Device removed",
+	})
+}
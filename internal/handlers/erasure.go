@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ErasureHandler struct {
+	erasureService *services.ErasureService
+}
+
+func NewErasureHandler(erasureService *services.ErasureService) *ErasureHandler {
+	return &ErasureHandler{
+		erasureService: erasureService,
+	}
+}
+
+// RequestDeletion godoc
+// @Summary      Delete my account
+// @Description  Ask for the current user's account to be erased. The account is deactivated and signed out everywhere at once, and anonymized when the grace period ends: personal data is scrubbed from the user, its history and its sign-in events, while records that only count it are kept. Until then an admin can cancel the deletion. Asking again returns the pending request.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      202  {object}  models.APIResponse{data=models.DeletionRequest} "Deletion scheduled"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me [delete]
+func (h *ErasureHandler) RequestDeletion(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	deletion, err := h.erasureService.Request(c.Request().Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Deletion scheduled",
+		Data:    deletion,
+	})
+}
+
+// CancelDeletion godoc
+// @Summary      Cancel a user's deletion
+// @Description  Withdraw a user's pending deletion while its grace period runs, restoring the account as it was before the request. The user has to sign in again. Requires the users:delete permission.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Deletion cancelled"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      409  {object}  models.APIResponse "No deletion pending, or its grace period is over"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/deletion [delete]
+func (h *ErasureHandler) CancelDeletion(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	user, err := h.erasureService.Cancel(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, user)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Deletion cancelled",
+		Data:    user,
+	})
+}
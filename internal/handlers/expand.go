@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"strings"
+	"user-management-api/pkg/router"
+)
+
+// expandParam returns the relations named in ?expand=, which may be given
+// comma separated or repeated (?expand=owner&expand=team)
+func expandParam(c router.Context) []string {
+	var names []string
+	for _, value := range c.Request().URL.Query()["expand"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/export"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportUsers godoc
+// @Summary      Export users as CSV or JSON
+// @Description  Download every user matching the filters as CSV or as a JSON array, streamed rather than paginated so it suits large datasets. Columns and date format come from a saved template, explicit parameters, or both; explicit parameters win. Requires the users:export permission.
+// @Tags         exports
+// @Produce      text/csv
+// @Produce      json
+// @Param        format       query     string  false  "Output format, csv by default"  Enums(csv, json)
+// @Param        role         query     string  false  "Only users with this role"
+// @Param        status       query     string  false  "Only users with this status"  Enums(pending, active, suspended, banned, deactivated)
+// @Param        q            query     string  false  "Only users matching this full-text search"
+// @Param        tag          query     []string  false  "Only users holding every one of these tags"  collectionFormat(multi)
+// @Param        template     query     string  false  "Export template ID"
+// @Param        columns      query     string  false  "Comma separated column keys, in output order"
+// @Param        date_format  query     string  false  "Date format"  Enums(rfc3339, date, datetime, unix)
+// @Security     BearerAuth
+// @Success      200  {file}    file "CSV or JSON export"
+// @Failure      400  {object}  models.APIResponse "Unknown column or date format"
+// @Failure      404  {object}  models.APIResponse "Template not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/export [get]
+func (h *ExportHandler) ExportUsers(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	query, appErr := Bind[models.ExportUsersQuery](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	userExport, err := h.exportService.ResolveUserExport(c.Request().Context(), adminID, &query)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if userExport.Format == export.FormatJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+	filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102-150405"), userExport.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// the status line is already sent, so a failure here can only be logged
+	if err := h.exportService.WriteUsers(c.Request().Context(), c.Writer(), userExport); err != nil {
+		log.Printf("user export failed: %v", err)
+	}
+}
+
+// ListExportColumns godoc
+// @Summary      List export columns
+// @Description  List the column keys accepted by the user export and its templates. Requires the users:export permission.
+// @Tags         exports
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.ExportColumnsResponse} "Columns retrieved successfully"
+// @Router       /users/export/columns [get]
+func (h *ExportHandler) ListExportColumns(c router.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Columns retrieved successfully",
+		Data:    h.exportService.Columns(),
+	})
+}
+
+// CreateTemplate godoc
+// @Summary      Create an export template
+// @Description  Save a column selection, order and date format for later exports. Requires the users:export permission.
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Param        template  body      models.CreateExportTemplateRequest  true  "Template definition"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.ExportTemplate} "Template created successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or unknown column"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/export/templates [post]
+func (h *ExportHandler) CreateTemplate(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.CreateExportTemplateRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	template, err := h.exportService.CreateTemplate(c.Request().Context(), adminID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Template created successfully",
+		Data:    template,
+	})
+}
+
+// ListTemplates godoc
+// @Summary      List export templates
+// @Description  List the export templates owned by the current admin. Requires the users:export permission.
+// @Tags         exports
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]models.ExportTemplate} "Templates retrieved successfully"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/export/templates [get]
+func (h *ExportHandler) ListTemplates(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	templates, err := h.exportService.ListTemplates(c.Request().Context(), adminID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Templates retrieved successfully",
+		Data:    templates,
+	})
+}
+
+// DeleteTemplate godoc
+// @Summary      Delete an export template
+// @Description  Delete one of the current admin's export templates. Requires the users:export permission.
+// @Tags         exports
+// @Produce      json
+// @Param        id   path      string  true  "Template ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse "Template deleted successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid template ID"
+// @Failure      404  {object}  models.APIResponse "Template not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/export/templates/{id} [delete]
+func (h *ExportHandler) DeleteTemplate(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid template ID",
+		})
+		return
+	}
+
+	err = h.exportService.DeleteTemplate(c.Request().Context(), adminID, id)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Template deleted successfully",
+	})
+}
@@ -1,99 +1,109 @@
 package handlers
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"mime/multipart"
+	"io"
+	"log"
+	"mime"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"strconv"
+	"strings"
 	"time"
+	"user-management-api/internal/config"
 	"user-management-api/internal/middleware"
 	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+	"user-management-api/pkg/storage"
 
-	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type FileHandler struct{}
+type FileHandler struct {
+	store            storage.Storage
+	fileService      *services.FileService
+	resumableService *services.ResumableUploadService
+	uploads          config.UploadConfig
+}
 
-func NewFileHandler() *FileHandler {
-	return &FileHandler{}
+// NewFileHandler serves downloads from store, where upload profiles save.
+// Resumable uploads are checked against the profiles in uploads.
+func NewFileHandler(store storage.Storage, fileService *services.FileService, resumableService *services.ResumableUploadService, uploads config.UploadConfig) *FileHandler {
+	return &FileHandler{store: store, fileService: fileService, resumableService: resumableService, uploads: uploads}
 }
 
 // UploadFile godoc
 // @Summary      Upload a file
-// @Description  Upload a single file with validation
+// @Description  Upload a single file with validation. Its metadata is saved and returned.
 // @Tags         files
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        file  formData  file  true  "File to upload"
+// @Param        checksum    formData  string  false  "Hex SHA-256 of the file, sent before it"
+// @Param        visibility  formData  string  false  "Who can fetch the file, public by default"  Enums(public, private)
+// @Param        file        formData  file    true   "File to upload"
 // @Security     BearerAuth
 // @Success      200  {object}  models.APIResponse{data=map[string]interface{}} "File uploaded successfully"
 // @Failure      400  {object}  models.APIResponse "Invalid file or validation failed"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      422  {object}  models.APIResponse "File is infected"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Failure      503  {object}  models.APIResponse "File could not be scanned for viruses"
 // @Router       /files/upload [post]
-func (h *FileHandler) UploadFile(c *gin.Context) {
-	// Get uploaded files from context (set by middleware)
-	uploadedFiles, exists := c.Get("uploadedFiles")
-	if !exists {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+func (h *FileHandler) UploadFile(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
-			Message: "No files found",
-			Error:   "NO_FILES",
+			Message: "Unauthorized",
 		})
 		return
 	}
 
-	files := uploadedFiles.([]*multipart.FileHeader)
-	config, _ := c.Get("uploadConfig")
-	uploadConfig := config.(middleware.FileUploadConfig)
-
-	// Create upload directory if it doesn't exist
-	if err := os.MkdirAll(uploadConfig.UploadPath, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	// Get uploaded files from context (set by middleware)
+	uploaded := middleware.GetUploadedFiles(c.Request().Context())
+	if len(uploaded) == 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Failed to create upload directory",
-			Error:   "DIRECTORY_CREATION_FAILED",
+			Message: "No files found",
+			Error:   "NO_FILES",
 		})
 		return
 	}
 
-	var uploadedFileInfos []map[string]interface{}
-
-	// Process each file
-	for _, fileHeader := range files {
-		// Generate unique filename
-		filename := generateUniqueFilename(fileHeader.Filename)
-		filepath := filepath.Join(uploadConfig.UploadPath, filename)
-
-		// Save file
-		if err := c.SaveUploadedFile(fileHeader, filepath); err != nil {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
+	files := make([]*models.File, 0, len(uploaded))
+	for _, file := range uploaded {
+		record := file.File()
+		record.Visibility = c.Request().PostFormValue("visibility")
+		files = append(files, record)
+	}
+	if err := h.fileService.Record(c.Request().Context(), userID, files); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
 				Success: false,
-				Message: "Failed to save file",
-				Error:   "FILE_SAVE_FAILED",
+				Message: appErr.Message,
+				Error:   appErr.Type,
 			})
 			return
 		}
-
-		// Create file info
-		fileInfo := map[string]interface{}{
-			"original_name": fileHeader.Filename,
-			"filename":      filename,
-			"size":          fileHeader.Size,
-			"path":          filepath,
-			"uploaded_at":   time.Now(),
-		}
-
-		uploadedFileInfos = append(uploadedFileInfos, fileInfo)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "File(s) uploaded successfully",
 		Data: map[string]interface{}{
-			"files": uploadedFileInfos,
-			"count": len(uploadedFileInfos),
+			"files": files,
+			"count": len(files),
 		},
 	})
 }
@@ -104,13 +114,15 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 // @Tags         files
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        image  formData  file  true  "Image file to upload"
+// @Param        checksum    formData  string  false  "Hex SHA-256 of the image, sent before it"
+// @Param        visibility  formData  string  false  "Who can fetch the image, public by default"  Enums(public, private)
+// @Param        image       formData  file    true   "Image file to upload"
 // @Security     BearerAuth
 // @Success      200  {object}  models.APIResponse{data=map[string]interface{}} "Image uploaded successfully"
 // @Failure      400  {object}  models.APIResponse "Invalid image or validation failed"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
 // @Router       /files/upload/image [post]
-func (h *FileHandler) UploadImage(c *gin.Context) {
+func (h *FileHandler) UploadImage(c router.Context) {
 	h.UploadFile(c) // Reuse the same logic
 }
 
@@ -120,21 +132,1115 @@ func (h *FileHandler) UploadImage(c *gin.Context) {
 // @Tags         files
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        document  formData  file  true  "Document file to upload"
+// @Param        checksum    formData  string  false  "Hex SHA-256 of the document, sent before it"
+// @Param        visibility  formData  string  false  "Who can fetch the document, public by default"  Enums(public, private)
+// @Param        document    formData  file    true   "Document file to upload"
 // @Security     BearerAuth
 // @Success      200  {object}  models.APIResponse{data=map[string]interface{}} "Document uploaded successfully"
 // @Failure      400  {object}  models.APIResponse "Invalid document or validation failed"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
 // @Router       /files/upload/document [post]
-func (h *FileHandler) UploadDocument(c *gin.Context) {
+func (h *FileHandler) UploadDocument(c router.Context) {
 	h.UploadFile(c) // Reuse the same logic
 }
 
-// generateUniqueFilename generates a unique filename to prevent conflicts
-func generateUniqueFilename(originalFilename string) string {
-	ext := filepath.Ext(originalFilename)
-	name := originalFilename[:len(originalFilename)-len(ext)]
-	timestamp := time.Now().Unix()
-	id := primitive.NewObjectID().Hex()
-	return fmt.Sprintf("%s_%d_%s%s", name, timestamp, id, ext)
-}
\ No newline at end of file
+// ListFiles godoc
+// @Summary      List my files
+// @Description  Get a paginated list of the files the current user uploaded, newest first
+// @Tags         files
+// @Produce      json
+// @Param        page   query     int  false  "Page number"  default(1)
+// @Param        limit  query     int  false  "Items per page" default(10)
+// @Security     BearerAuth
+// @Success      200  {object}  models.PaginatedResponse{data=[]models.File} "Files retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files [get]
+func (h *FileHandler) ListFiles(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "10"))
+
+	result, err := h.fileService.List(c.Request().Context(), userID, page, limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetFile godoc
+// @Summary      Get a file's metadata
+// @Description  Get the metadata of one of the current user's files. Admins can get any file.
+// @Tags         files
+// @Produce      json
+// @Param        id   path      string  true  "File ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.File} "File retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid file ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/{id} [get]
+func (h *FileHandler) GetFile(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	fileID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid file ID",
+		})
+		return
+	}
+
+	file, err := h.fileService.Get(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), fileID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "File retrieved successfully",
+		Data:    file,
+	})
+}
+
+// UpdateFile godoc
+// @Summary      Change a file's visibility
+// @Description  Make one of the current user's files public, so anyone with its URL can fetch it, or private, so it is only served through share links and the owner's download tokens. Admins can update any file.
+// @Tags         files
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "File ID"
+// @Param        request  body      models.UpdateFileRequest  true  "New visibility"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.File} "File updated successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid file ID or validation failed"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/{id} [patch]
+func (h *FileHandler) UpdateFile(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	fileID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid file ID",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.UpdateFileRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	file, err := h.fileService.SetVisibility(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), fileID, req.Visibility)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "File updated successfully",
+		Data:    file,
+	})
+}
+
+// ShareFile godoc
+// @Summary      Create a share link
+// @Description  Get a signed link anyone can download one of the current user's files from, public or private, until it expires (after a day by default, at most 7 days). Links cannot be revoked except by deleting the file. Admins can share any file.
+// @Tags         files
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true   "File ID"
+// @Param        request  body      models.ShareFileRequest  false  "How long the link lasts"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.FileShareLink} "Share link created"
+// @Failure      400  {object}  models.APIResponse "Invalid file ID or validation failed"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/{id}/share [post]
+func (h *FileHandler) ShareFile(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	fileID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid file ID",
+		})
+		return
+	}
+
+	var req models.ShareFileRequest
+	if c.Request().ContentLength != 0 {
+		var appErr *errors.AppError
+		if req, appErr = Bind[models.ShareFileRequest](c); appErr != nil {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Details,
+			})
+			return
+		}
+	}
+
+	link, err := h.fileService.Share(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), fileID, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Share link created",
+		Data:    link,
+	})
+}
+
+// DeleteFile godoc
+// @Summary      Delete a file
+// @Description  Delete one of the current user's files from storage along with its metadata. Admins can delete any file.
+// @Tags         files
+// @Produce      json
+// @Param        id   path      string  true  "File ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse "File deleted successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid file ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/{id} [delete]
+func (h *FileHandler) DeleteFile(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	fileID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid file ID",
+		})
+		return
+	}
+
+	err = h.fileService.Delete(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), fileID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "File deleted successfully",
+	})
+}
+
+// ArchiveFiles godoc
+// @Summary      Download files as a ZIP
+// @Description  Download up to 100 of the current user's files in one ZIP archive, streamed as it is built. Entries are named after the files' original names, numbered when they repeat. Admins can include any file.
+// @Tags         files
+// @Accept       json
+// @Produce      application/zip
+// @Param        request  body      models.ArchiveFilesRequest  true  "IDs of the files to include"
+// @Security     BearerAuth
+// @Success      200  {file}    file "ZIP archive"
+// @Failure      400  {object}  models.APIResponse "Validation failed"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/archive [post]
+func (h *FileHandler) ArchiveFiles(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.ArchiveFilesRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	files, err := h.fileService.ResolveArchive(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), req.IDs)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("files-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// the status line is already sent, so a failure here can only be logged
+	if err := h.fileService.WriteArchive(c.Request().Context(), c.Writer(), files); err != nil {
+		log.Printf("file archive failed: %v", err)
+	}
+}
+
+// PresignUpload godoc
+// @Summary      Start a direct upload
+// @Description  Check a file against an upload profile (any by default) and return a URL to PUT it to, so it goes straight to S3 or GCS storage instead of through the API. Send the returned headers with the PUT, then confirm the upload with the returned token.
+// @Tags         files
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.PresignUploadRequest  true  "File to upload"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.PresignUploadResponse} "Upload URL created"
+// @Failure      400  {object}  models.APIResponse "Validation failed, unknown profile or file not accepted"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Failure      501  {object}  models.APIResponse "Storage does not take direct uploads"
+// @Router       /files/presign [post]
+func (h *FileHandler) PresignUpload(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.PresignUploadRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	presigned, err := h.fileService.Presign(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Upload URL created",
+		Data:    presigned,
+	})
+}
+
+// ConfirmUpload godoc
+// @Summary      Finish a direct upload
+// @Description  Check a file PUT to a presigned URL against its upload profile and mark its metadata ready. The type is detected from the file's first bytes and the file is scanned for viruses when a scanner is configured; a file the profile does not accept, or an infected one, is deleted. Images are stripped of their metadata unless the profile keeps it.
+// @Tags         files
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ConfirmUploadRequest  true  "Key, profile and token from POST /files/presign"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.File} "File uploaded successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid token or file not accepted"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Nothing was uploaded"
+// @Failure      422  {object}  models.APIResponse "File is infected"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Failure      501  {object}  models.APIResponse "Storage does not take direct uploads"
+// @Failure      503  {object}  models.APIResponse "File could not be scanned for viruses"
+// @Router       /files/presign/confirm [post]
+func (h *FileHandler) ConfirmUpload(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.ConfirmUploadRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	file, err := h.fileService.Confirm(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "File uploaded successfully",
+		Data:    file,
+	})
+}
+
+// tusVersion is the version of the tus protocol resumable uploads speak
+const tusVersion = "1.0.0"
+
+// progressKeepalive spaces the comments sent on idle progress streams, so
+// proxies do not close them
+const progressKeepalive = 15 * time.Second
+
+// CreateResumableUpload godoc
+// @Summary      Start a resumable upload
+// @Description  Start a tus upload for a large file sent in chunks. Upload-Length is the file's size and Upload-Metadata carries its base64-encoded filename and, optionally, the upload profile (any by default; avatar is not allowed), the hex SHA-256 checksum the file must have and its visibility (public by default). PATCH the chunks to the returned Location.
+// @Tags         files
+// @Produce      json
+// @Param        Upload-Length    header    int     true   "File size in bytes"
+// @Param        Upload-Metadata  header    string  true   "Comma-separated key and base64 value pairs: filename, and optionally profile, checksum and visibility"
+// @Param        Tus-Resumable    header    string  false  "tus protocol version, 1.0.0"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.ResumableUpload} "Upload created"
+// @Failure      400  {object}  models.APIResponse "Invalid headers, unknown profile or file not accepted"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      413  {object}  models.APIResponse "File is larger than the profile allows"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/uploads [post]
+func (h *FileHandler) CreateResumableUpload(c router.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	length, err := strconv.ParseInt(c.Request().Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Upload-Length must be a positive integer",
+			Error:   "INVALID_UPLOAD_LENGTH",
+		})
+		return
+	}
+	metadata := parseUploadMetadata(c.Request().Header.Get("Upload-Metadata"))
+	filename := metadata["filename"]
+	if filename == "" {
+		// the name Uppy sends
+		filename = metadata["name"]
+	}
+	if filename == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Upload-Metadata must include the filename",
+			Error:   "INVALID_UPLOAD_METADATA",
+		})
+		return
+	}
+
+	upload, err := h.resumableService.Create(c.Request().Context(), userID, metadata["profile"], filename, metadata["checksum"], metadata["visibility"], length)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request().URL.Path, "/")+"/"+upload.ID.Hex())
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Upload created",
+		Data:    upload,
+	})
+}
+
+// ResumableUploadOffset godoc
+// @Summary      Check a resumable upload
+// @Description  Return how many bytes of a tus upload have arrived in the Upload-Offset header, so an interrupted upload can resume from there
+// @Tags         files
+// @Param        id   path      string  true  "Upload ID"
+// @Security     BearerAuth
+// @Success      200  "Upload-Offset and Upload-Length headers"
+// @Failure      401  "Unauthorized"
+// @Failure      404  "Upload not found or expired"
+// @Router       /files/uploads/{id} [head]
+func (h *FileHandler) ResumableUploadOffset(c router.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Cache-Control", "no-store")
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+	uploadID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	upload, err := h.resumableService.Get(c.Request().Context(), userID, uploadID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.Status(appErr.Code)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// GetResumableUpload godoc
+// @Summary      Get a resumable upload
+// @Description  Get the progress of one of the current user's tus uploads, and the ID of the file it was saved as once finished
+// @Tags         files
+// @Produce      json
+// @Param        id   path      string  true  "Upload ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.ResumableUpload} "Upload retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid upload ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Upload not found or expired"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/uploads/{id} [get]
+func (h *FileHandler) GetResumableUpload(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	uploadID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid upload ID",
+		})
+		return
+	}
+
+	upload, err := h.resumableService.Get(c.Request().Context(), userID, uploadID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Upload retrieved successfully",
+		Data:    upload,
+	})
+}
+
+// ResumableUploadEvents godoc
+// @Summary      Follow a resumable upload's progress
+// @Description  Stream the progress of one of the current user's tus uploads as server-sent "progress" events: chunks arriving, then each check and saving step of the finished file (saving and scanning as it is streamed to storage, then checking, stripping, transcoding and recording), and finally completed with the file's ID or failed with the reason. The first event tells where the upload stands; the stream ends after completed or failed. Only events from the server receiving the chunks are seen.
+// @Tags         files
+// @Produce      text/event-stream
+// @Param        id   path      string  true  "Upload ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.UploadProgressEvent "Stream of progress events"
+// @Failure      400  {object}  models.APIResponse "Invalid upload ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Upload not found or expired"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/uploads/{id}/events [get]
+func (h *FileHandler) ResumableUploadEvents(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	uploadID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid upload ID",
+		})
+		return
+	}
+
+	ctx := c.Request().Context()
+	events, stop, err := h.resumableService.Follow(ctx, userID, uploadID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+	defer stop()
+
+	c.Header("Cache-Control", "no-cache")
+	// keep nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Content-Type", "text/event-stream")
+	c.Status(http.StatusOK)
+	flusher := http.NewResponseController(c.Writer())
+	flusher.Flush()
+	keepalive := time.NewTicker(progressKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("Failed to encode upload progress: %v", err)
+				return
+			}
+			fmt.Fprintf(c.Writer(), "event: progress\ndata: %s\n\n", data)
+			flusher.Flush()
+			if event.Stage == models.UploadStageCompleted || event.Stage == models.UploadStageFailed {
+				return
+			}
+		case <-keepalive.C:
+			io.WriteString(c.Writer(), ": keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// PatchResumableUpload godoc
+// @Summary      Send a chunk of a resumable upload
+// @Description  Append the request body to a tus upload at Upload-Offset, which must be the number of bytes received so far. A chunk cut short is kept up to where it broke off. The chunk that completes the file runs the checks every upload gets and returns the saved file's metadata; a file the profile does not accept is discarded along with the upload.
+// @Tags         files
+// @Accept       application/offset+octet-stream
+// @Produce      json
+// @Param        id             path      string  true   "Upload ID"
+// @Param        Upload-Offset  header    int     true   "Bytes received so far"
+// @Param        Tus-Resumable  header    string  false  "tus protocol version, 1.0.0"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.File} "File uploaded successfully"
+// @Success      204  "Chunk received; Upload-Offset is the new offset"
+// @Failure      400  {object}  models.APIResponse "Invalid offset, or file not accepted"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Upload not found or expired"
+// @Failure      409  {object}  models.APIResponse "Upload-Offset does not match"
+// @Failure      415  {object}  models.APIResponse "Wrong Content-Type"
+// @Failure      422  {object}  models.APIResponse "File is infected"
+// @Failure      423  {object}  models.APIResponse "Another request is writing to this upload"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Failure      503  {object}  models.APIResponse "File could not be scanned for viruses"
+// @Router       /files/uploads/{id} [patch]
+func (h *FileHandler) PatchResumableUpload(c router.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	uploadID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid upload ID",
+		})
+		return
+	}
+	if router.ContentType(c.Request()) != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, models.APIResponse{
+			Success: false,
+			Message: "Content-Type must be application/offset+octet-stream",
+			Error:   "UNSUPPORTED_MEDIA_TYPE",
+		})
+		return
+	}
+	offset, err := strconv.ParseInt(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Upload-Offset must be a non-negative integer",
+			Error:   "INVALID_UPLOAD_OFFSET",
+		})
+		return
+	}
+
+	ctx := c.Request().Context()
+	upload, file, err := h.resumableService.Append(ctx, userID, uploadID, offset, c.Request().Body, func(upload *models.ResumableUpload, staged *os.File) (*models.File, error) {
+		return h.finishResumableUpload(ctx, userID, upload, staged)
+	})
+	if upload != nil {
+		setUploadHeaders(c, upload)
+	}
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		// a file that fails its checks will not pass them when sent again
+		if middleware.IsUploadRejected(err) {
+			if err := h.resumableService.Delete(context.WithoutCancel(ctx), userID, uploadID); err != nil {
+				log.Printf("Failed to remove rejected upload %s: %v", uploadID.Hex(), err)
+			}
+		}
+		c.JSON(middleware.UploadErrorResponse(err))
+		return
+	}
+
+	if file == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "File uploaded successfully",
+		Data:    file,
+	})
+}
+
+// DeleteResumableUpload godoc
+// @Summary      Cancel a resumable upload
+// @Description  Abandon one of the current user's tus uploads and discard what has arrived of it. The file of a finished upload is kept.
+// @Tags         files
+// @Param        id   path      string  true  "Upload ID"
+// @Security     BearerAuth
+// @Success      204  "Upload cancelled"
+// @Failure      400  {object}  models.APIResponse "Invalid upload ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Upload not found or expired"
+// @Failure      423  {object}  models.APIResponse "Another request is writing to this upload"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /files/uploads/{id} [delete]
+func (h *FileHandler) DeleteResumableUpload(c router.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	uploadID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid upload ID",
+		})
+		return
+	}
+
+	if err := h.resumableService.Delete(c.Request().Context(), userID, uploadID); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// finishResumableUpload checks and saves a fully received resumable upload
+// as FileUploadMiddleware does a multipart one, and records its metadata.
+// Each stage it reaches, and a failure, is reported to the upload's
+// followers.
+func (h *FileHandler) finishResumableUpload(ctx context.Context, userID primitive.ObjectID, upload *models.ResumableUpload, staged *os.File) (file *models.File, err error) {
+	defer func() {
+		if err == nil {
+			return
+		}
+		message := middleware.UploadErrorMessage(err)
+		if appErr, ok := err.(*errors.AppError); ok {
+			message = appErr.Message
+		}
+		h.resumableService.Report(upload, models.UploadStageFailed, message)
+	}()
+	ctx = middleware.WithUploadProgress(ctx, func(stage string) {
+		h.resumableService.Report(upload, stage, "")
+	})
+
+	profile, ok := h.uploads.Profiles[upload.Profile]
+	if !ok {
+		return nil, errors.ErrUnknownUploadProfile
+	}
+	uploaded, err := middleware.SaveStagedUpload(ctx, staged, upload.Filename, upload.Checksum, middleware.NewFileUploadConfig(h.uploads, profile))
+	if err != nil {
+		return nil, err
+	}
+	file = uploaded.File()
+	file.Visibility = upload.Visibility
+	h.resumableService.Report(upload, models.UploadStageRecording, "")
+	if err := h.fileService.Record(ctx, userID, []*models.File{file}); err != nil {
+		// Record may have pointed file at another copy, so remove what was
+		// saved for this upload
+		middleware.DeleteUploadedFiles(context.WithoutCancel(ctx), []middleware.UploadedFile{*uploaded})
+		return nil, err
+	}
+	return file, nil
+}
+
+// setUploadHeaders reports a resumable upload's progress in tus headers
+func setUploadHeaders(c router.Context, upload *models.ResumableUpload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// parseUploadMetadata decodes a tus Upload-Metadata header, a list of
+// comma-separated keys each followed by its base64-encoded value. Pairs
+// that do not decode are left out.
+func parseUploadMetadata(header string) map[string]string {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(value)
+	}
+	return metadata
+}
+
+// DownloadFile godoc
+// @Summary      Download a file with an action token
+// @Description  Serve an uploaded file to holders of a file:download action token minted for its path. Private files are only served to their owner.
+// @Tags         files
+// @Produce      octet-stream
+// @Param        path   path      string  true  "File path relative to the uploads directory"
+// @Param        token  query     string  true  "Action token"
+// @Success      200  {file}    file "File contents"
+// @Failure      401  {object}  models.APIResponse "Invalid or expired action token"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Router       /files/download/{path} [get]
+func (h *FileHandler) DownloadFile(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+	// look the file up by its canonical key, however the path was spelt
+	key, keyErr := storage.CleanKey(strings.TrimPrefix(c.Param("path"), "/"))
+	var allowed bool
+	if keyErr == nil {
+		allowed, err = h.fileService.CanDownload(c.Request().Context(), userID, key)
+	}
+	if !h.checkServable(c, allowed, err) {
+		return
+	}
+	h.serve(c, key, path.Base(key), true)
+}
+
+// ServeUpload godoc
+// @Summary      Fetch an uploaded file
+// @Description  Serve an uploaded file from the local storage backend, where file URLs point. Private files are not served here.
+// @Tags         files
+// @Produce      octet-stream
+// @Param        key  path      string  true  "File key"
+// @Success      200  {file}    file "File contents"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Router       /uploads/{key} [get]
+func (h *FileHandler) ServeUpload(c router.Context) {
+	key, keyErr := storage.CleanKey(strings.TrimPrefix(c.Param("key"), "/"))
+	var allowed bool
+	var err error
+	if keyErr == nil {
+		allowed, err = h.fileService.CanServe(c.Request().Context(), key)
+	}
+	if !h.checkServable(c, allowed, err) {
+		return
+	}
+	h.serve(c, key, path.Base(key), false)
+}
+
+// SharedFile godoc
+// @Summary      Download a shared file
+// @Description  Serve a file, public or private, to holders of a share link from POST /files/{id}/share, under its original name
+// @Tags         files
+// @Produce      octet-stream
+// @Param        id     path      string  true  "File ID"
+// @Param        token  query     string  true  "Share token"
+// @Success      200  {file}    file "File contents"
+// @Failure      401  {object}  models.APIResponse "Invalid or expired share link"
+// @Failure      404  {object}  models.APIResponse "File not found"
+// @Router       /files/shared/{id} [get]
+func (h *FileHandler) SharedFile(c router.Context) {
+	fileID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "File not found",
+			Error:   "FILE_NOT_FOUND",
+		})
+		return
+	}
+
+	file, err := h.fileService.GetShared(c.Request().Context(), fileID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+	h.serve(c, file.Key, file.OriginalName, true)
+}
+
+// checkServable responds 404 to a file that may not be served, or 500 when
+// that could not be checked, and reports whether to go on
+func (h *FileHandler) checkServable(c router.Context, allowed bool, err error) bool {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "File not found",
+			Error:   "FILE_NOT_FOUND",
+		})
+		return false
+	}
+	return true
+}
+
+// serve writes the file under key, as a download named name when
+// attachment is set. Range requests are honoured when the storage can
+// seek.
+func (h *FileHandler) serve(c router.Context, key, name string, attachment bool) {
+	file, err := h.store.Open(c.Request().Context(), key)
+	if err != nil {
+		if err == storage.ErrNotFound || err == storage.ErrInvalidKey {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Message: "File not found",
+				Error:   "FILE_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to read file",
+			Error:   "FILE_READ_FAILED",
+		})
+		return
+	}
+	defer file.Close()
+
+	if attachment {
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer(), c.Request(), name, time.Time{}, seeker)
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer(), file)
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GrantHandler struct {
+	grantService *services.GrantService
+}
+
+func NewGrantHandler(grantService *services.GrantService) *GrantHandler {
+	return &GrantHandler{
+		grantService: grantService,
+	}
+}
+
+// ListGrants godoc
+// @Summary      List access grants
+// @Description  List the grants the current user has given to others and received from others
+// @Tags         grants
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.GrantListResponse} "Grants retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/grants [get]
+func (h *GrantHandler) ListGrants(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	grants, err := h.grantService.List(c.Request().Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Grants retrieved successfully",
+		Data:    grants,
+	})
+}
+
+// CreateGrant godoc
+// @Summary      Grant access to another user
+// @Description  Give another user limited access to the current user's resources
+// @Tags         grants
+// @Accept       json
+// @Produce      json
+// @Param        grant  body      models.CreateGrantRequest  true  "Grantee and scopes"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.Grant} "Access granted"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/grants [post]
+func (h *GrantHandler) CreateGrant(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.CreateGrantRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	grant, err := h.grantService.Grant(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Access granted",
+		Data:    grant,
+	})
+}
+
+// RequestGrant godoc
+// @Summary      Request access from another user
+// @Description  Ask another user for limited access to their resources; the grant stays pending until they approve it
+// @Tags         grants
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.RequestGrantRequest  true  "Owner and scopes"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.Grant} "Access requested"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/grants/requests [post]
+func (h *GrantHandler) RequestGrant(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.RequestGrantRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	grant, err := h.grantService.Request(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Access requested",
+		Data:    grant,
+	})
+}
+
+// ApproveGrant godoc
+// @Summary      Approve an access request
+// @Description  Consent to a pending access request made to the current user
+// @Tags         grants
+// @Produce      json
+// @Param        id   path      string  true  "Grant ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.Grant} "Access request approved"
+// @Failure      400  {object}  models.APIResponse "Invalid grant ID or grant is not pending"
+// @Failure      404  {object}  models.APIResponse "Grant not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/grants/{id}/approve [post]
+func (h *GrantHandler) ApproveGrant(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	grantID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid grant ID",
+		})
+		return
+	}
+
+	grant, err := h.grantService.Approve(c.Request().Context(), userID, grantID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Access request approved",
+		Data:    grant,
+	})
+}
+
+// RevokeGrant godoc
+// @Summary      Revoke a grant
+// @Description  Revoke a grant the current user gave, or give up one they received
+// @Tags         grants
+// @Produce      json
+// @Param        id   path      string  true  "Grant ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse "Grant revoked"
+// @Failure      400  {object}  models.APIResponse "Invalid grant ID"
+// @Failure      404  {object}  models.APIResponse "Grant not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/grants/{id} [delete]
+func (h *GrantHandler) RevokeGrant(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	grantID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid grant ID",
+		})
+		return
+	}
+
+	err = h.grantService.Revoke(c.Request().Context(), userID, grantID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Grant revoked",
+	})
+}
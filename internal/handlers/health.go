@@ -3,24 +3,61 @@ package handlers
 import (
 	"net/http"
 	"time"
+	"user-management-api/internal/config"
 	"user-management-api/internal/models"
-
-	"github.com/gin-gonic/gin"
+	"user-management-api/pkg/router"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	summary config.Summary
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+// NewHealthHandler serves summary, the configuration the server started
+// with, from /version
+func NewHealthHandler(summary config.Summary) *HealthHandler {
+	return &HealthHandler{summary: summary}
 }
 
-func (h *HealthHandler) HealthCheck(ctx *gin.Context) {
+func (h *HealthHandler) HealthCheck(ctx router.Context) {
 	ctx.JSON((http.StatusOK), models.APIResponse{
 		Success: true,
 		Message: "Service is running",
-		Data: gin.H{
+		Data: map[string]any{
 			"status":    "OK",
 			"timestamp": time.Now().UTC().Format(time.RFC3339),
 		},
 	})
 }
+
+// Version reports the running build and its configuration summary
+func (h *HealthHandler) Version(ctx router.Context) {
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Version retrieved successfully",
+		Data:    h.summary,
+	})
+}
+
+// DegradedHealthCheck answers /health while the server runs without its
+// database, so load balancers keep it out of rotation
+func DegradedHealthCheck(ctx router.Context) {
+	ctx.JSON(http.StatusServiceUnavailable, models.APIResponse{
+		Success: false,
+		Message: "Service is degraded: the database is unavailable",
+		Data: map[string]any{
+			"status":    "DEGRADED",
+			"database":  "unavailable",
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+// ServiceUnavailable answers every other route while the server runs
+// without its database
+func ServiceUnavailable(ctx router.Context) {
+	ctx.JSON(http.StatusServiceUnavailable, models.APIResponse{
+		Success: false,
+		Message: "Service is starting, please try again later",
+		Error:   "SERVICE_UNAVAILABLE",
+	})
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+)
+
+type IdentityHandler struct {
+	identityService *services.IdentityService
+}
+
+func NewIdentityHandler(identityService *services.IdentityService) *IdentityHandler {
+	return &IdentityHandler{
+		identityService: identityService,
+	}
+}
+
+// ListIdentities godoc
+// @Summary      List my linked identities
+// @Description  List the external accounts linked to the current user and the providers available for linking
+// @Tags         identities
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[profile:read]
+// @Success      200  {object}  models.APIResponse{data=models.IdentityListResponse} "Identities retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me/identities [get]
+func (h *IdentityHandler) ListIdentities(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	identities, err := h.identityService.List(c.Request().Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Identities retrieved successfully",
+		Data:    identities,
+	})
+}
+
+// LinkIdentity godoc
+// @Summary      Start linking an identity
+// @Description  Return the provider URL where the current user approves linking their external account. The provider then redirects to the link callback.
+// @Tags         identities
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name, e.g. google"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.IdentityLinkResponse} "Continue at the provider"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Identity provider not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me/identities/{provider} [post]
+func (h *IdentityHandler) LinkIdentity(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	link, err := h.identityService.StartLink(c.Request().Context(), userID, c.Param("provider"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Continue at the provider",
+		Data:    link,
+	})
+}
+
+// LinkCallback godoc
+// @Summary      Finish linking an identity
+// @Description  Redirect target of the provider after the user approved linking; links the external account to the user who started the link
+// @Tags         identities
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name"
+// @Param        code      query     string  true  "Authorization code"
+// @Param        state     query     string  true  "State issued when linking started"
+// @Success      200  {object}  models.APIResponse{data=models.Identity} "Identity linked"
+// @Failure      400  {object}  models.APIResponse "Missing or invalid code or state"
+// @Failure      401  {object}  models.APIResponse "Single sign-on failed"
+// @Failure      404  {object}  models.APIResponse "Identity provider not found"
+// @Failure      409  {object}  models.APIResponse "Account already linked, or its email belongs to another user"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me/identities/{provider}/callback [get]
+func (h *IdentityHandler) LinkCallback(c router.Context) {
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "code and state are required",
+			Error:   c.Query("error"),
+		})
+		return
+	}
+
+	identity, err := h.identityService.CompleteLink(c.Request().Context(), c.Param("provider"), code, state)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Identity linked",
+		Data:    identity,
+	})
+}
+
+// UnlinkIdentity godoc
+// @Summary      Unlink an identity
+// @Description  Remove the current user's linked account at a provider. The last identity of an account without a password cannot be removed.
+// @Tags         identities
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse "Identity unlinked"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Identity not found"
+// @Failure      409  {object}  models.APIResponse "Cannot unlink the only way to sign in"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/me/identities/{provider} [delete]
+func (h *IdentityHandler) UnlinkIdentity(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	err = h.identityService.Unlink(c.Request().Context(), userID, c.Param("provider"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Identity unlinked",
+	})
+}
+
+// Login godoc
+// @Summary      Sign in with a linked identity
+// @Description  Redirect the browser to the provider to sign in with an account linked earlier
+// @Tags         identities
+// @Param        provider  path  string  true  "Provider name"
+// @Success      302
+// @Failure      404  {object}  models.APIResponse "Identity provider not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/oauth/{provider}/login [get]
+func (h *IdentityHandler) Login(c router.Context) {
+	redirectURL, err := h.identityService.StartLogin(c.Request().Context(), c.Param("provider"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// LoginCallback godoc
+// @Summary      Finish signing in with a linked identity
+// @Description  Redirect target of the provider; signs in the user the external account is linked to and returns a token
+// @Tags         identities
+// @Produce      json
+// @Param        provider  path      string  true  "Provider name"
+// @Param        code      query     string  true  "Authorization code"
+// @Param        state     query     string  true  "State issued when sign-in started"
+// @Success      200  {object}  models.APIResponse{data=models.AuthResponse} "Login successful"
+// @Failure      400  {object}  models.APIResponse "Missing or invalid code or state"
+// @Failure      401  {object}  models.APIResponse "No user is linked to this account"
+// @Failure      404  {object}  models.APIResponse "Identity provider not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/oauth/{provider}/callback [get]
+func (h *IdentityHandler) LoginCallback(c router.Context) {
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "code and state are required",
+			Error:   c.Query("error"),
+		})
+		return
+	}
+
+	authResponse, err := h.identityService.CompleteLogin(c.Request().Context(), c.Param("provider"), code, state, loginContext(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Login successful",
+		Data:    authResponse,
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+)
+
+// InviteUser godoc
+// @Summary      Invite a user
+// @Description  Create an inactive user without a password and email them a link to choose one. Inviting an address whose invitation is still pending resends the link. Requires the users:write permission.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        user  body      models.InviteUserRequest  true  "Invited user's details"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      201  {object}  models.APIResponse{data=models.UserResponse} "Invitation sent successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the role outranks yours"
+// @Failure      409  {object}  models.APIResponse "User already exists"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/invite [post]
+func (h *AuthHandler) InviteUser(c router.Context) {
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.InviteUserRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	user, err := h.authService.Invite(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	response := user.ToResponse()
+	redactFields(c, response)
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Invitation sent successfully",
+		Data:    response,
+	})
+}
+
+// AcceptInvitation godoc
+// @Summary      Accept an invitation
+// @Description  Set the first password of an invited user using the token from the invitation email. This activates the account and marks its email verified.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.AcceptInvitationRequest  true  "Invitation token and password"
+// @Success      200  {object}  models.APIResponse "Invitation accepted"
+// @Failure      400  {object}  models.APIResponse "Validation failed, or invalid or expired token"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/accept-invite [post]
+func (h *AuthHandler) AcceptInvitation(c router.Context) {
+	req, appErr := Bind[models.AcceptInvitationRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	if _, err := h.authService.AcceptInvitation(c.Request().Context(), &req); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Invitation accepted; you can now log in",
+	})
+}
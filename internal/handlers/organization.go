@@ -0,0 +1,750 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrganizationHandler struct {
+	orgService *services.OrganizationService
+}
+
+func NewOrganizationHandler(orgService *services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: orgService,
+	}
+}
+
+// ListOrganizations godoc
+// @Summary      List my organizations
+// @Description  List the organizations the current user is an active member of, with their role in each
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[profile:read]
+// @Success      200  {object}  models.APIResponse{data=[]models.Organization} "Organizations retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations [get]
+func (h *OrganizationHandler) ListOrganizations(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgs, err := h.orgService.ListMine(c.Request().Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Organizations retrieved successfully",
+		Data:    orgs,
+	})
+}
+
+// CreateOrganization godoc
+// @Summary      Create an organization
+// @Description  Create an organization with the current user as its owner
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        organization  body      models.CreateOrganizationRequest  true  "Organization details"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      201  {object}  models.APIResponse{data=models.Organization} "Organization created successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.CreateOrganizationRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	org, err := h.orgService.Create(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Organization created successfully",
+		Data:    org,
+	})
+}
+
+// ListInvitations godoc
+// @Summary      List my invitations
+// @Description  List the current user's pending invitations to organizations
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[profile:read]
+// @Success      200  {object}  models.APIResponse{data=[]models.Membership} "Invitations retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/invitations [get]
+func (h *OrganizationHandler) ListInvitations(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	invitations, err := h.orgService.ListInvitations(c.Request().Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Invitations retrieved successfully",
+		Data:    invitations,
+	})
+}
+
+// GetOrganization godoc
+// @Summary      Get an organization
+// @Description  Get an organization the current user belongs to
+// @Tags         organizations
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:read]
+// @Success      200  {object}  models.APIResponse{data=models.Organization} "Organization retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid organization ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Organization not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id} [get]
+func (h *OrganizationHandler) GetOrganization(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	org, err := h.orgService.Get(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), orgID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Organization retrieved successfully",
+		Data:    org,
+	})
+}
+
+// UpdateOrganization godoc
+// @Summary      Update an organization
+// @Description  Change an organization's name or description. Requires the admin or owner role in the organization.
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Param        organization  body      models.UpdateOrganizationRequest  true  "Fields to update"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.Organization} "Organization updated successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Your role in this organization does not allow this"
+// @Failure      404  {object}  models.APIResponse "Organization not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id} [put]
+func (h *OrganizationHandler) UpdateOrganization(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.UpdateOrganizationRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	org, err := h.orgService.Update(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), orgID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Organization updated successfully",
+		Data:    org,
+	})
+}
+
+// DeleteOrganization godoc
+// @Summary      Delete an organization
+// @Description  Delete an organization and all of its memberships. Requires the owner role in the organization.
+// @Tags         organizations
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse "Organization deleted successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid organization ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Your role in this organization does not allow this"
+// @Failure      404  {object}  models.APIResponse "Organization not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	err = h.orgService.Delete(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), orgID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Organization deleted successfully",
+	})
+}
+
+// ListMembers godoc
+// @Summary      List members
+// @Description  List an organization's members and pending invitations, oldest first
+// @Tags         organizations
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Param        page    query     int     false  "Page number"  default(1)
+// @Param        limit   query     int     false  "Items per page" default(20)
+// @Param        expand  query     string  false  "Relations to populate: user"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:read]
+// @Success      200  {object}  models.PaginatedResponse{data=[]models.Membership} "Members retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid organization ID or unknown relation in expand"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Organization not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id}/members [get]
+func (h *OrganizationHandler) ListMembers(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "20"))
+
+	result, err := h.orgService.ListMembers(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), orgID, page, limit, expandParam(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, result)
+	c.JSON(http.StatusOK, result)
+}
+
+// InviteMember godoc
+// @Summary      Invite a member
+// @Description  Invite an existing user by email with an organization role. Requires the admin or owner role, and only owners can invite owners. The user joins by accepting the invitation.
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Param        invitation  body      models.InviteMemberRequest  true  "Invitee email and role"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      201  {object}  models.APIResponse{data=models.Membership} "Invitation sent successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Your role in this organization does not allow this"
+// @Failure      404  {object}  models.APIResponse "Organization or user not found"
+// @Failure      409  {object}  models.APIResponse "User is already a member or invited"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id}/invitations [post]
+func (h *OrganizationHandler) InviteMember(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.InviteMemberRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	membership, err := h.orgService.Invite(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), orgID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Invitation sent successfully",
+		Data:    membership,
+	})
+}
+
+// JoinOrganization godoc
+// @Summary      Join an organization
+// @Description  Accept the current user's invitation to an organization
+// @Tags         organizations
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.Membership} "Joined organization successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid organization ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "No pending invitation"
+// @Failure      409  {object}  models.APIResponse "Already a member"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id}/join [post]
+func (h *OrganizationHandler) JoinOrganization(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	membership, err := h.orgService.Join(c.Request().Context(), userID, orgID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Joined organization successfully",
+		Data:    membership,
+	})
+}
+
+// LeaveOrganization godoc
+// @Summary      Leave an organization
+// @Description  Leave an organization, or decline an invitation to it. The last owner cannot leave.
+// @Tags         organizations
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse "Left organization successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid organization ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Organization not found"
+// @Failure      409  {object}  models.APIResponse "Last owner of the organization"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id}/leave [post]
+func (h *OrganizationHandler) LeaveOrganization(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	err = h.orgService.Leave(c.Request().Context(), userID, orgID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Left organization successfully",
+	})
+}
+
+// UpdateMember godoc
+// @Summary      Change a member's role
+// @Description  Change a member's organization role. Requires the admin or owner role, and only owners can appoint or demote owners.
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Param        user_id  path      string  true  "Member user ID"
+// @Param        membership  body      models.UpdateMembershipRequest  true  "New role"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.Membership} "Member updated successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Your role in this organization does not allow this"
+// @Failure      404  {object}  models.APIResponse "Organization or member not found"
+// @Failure      409  {object}  models.APIResponse "Last owner of the organization"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id}/members/{user_id} [put]
+func (h *OrganizationHandler) UpdateMember(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	memberID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.UpdateMembershipRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	membership, err := h.orgService.UpdateMember(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), orgID, memberID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Member updated successfully",
+		Data:    membership,
+	})
+}
+
+// RemoveMember godoc
+// @Summary      Remove a member
+// @Description  Remove a member or withdraw an invitation. Requires the admin or owner role, and only owners can remove owners.
+// @Tags         organizations
+// @Produce      json
+// @Param        id  path      string  true  "Organization ID"
+// @Param        user_id  path      string  true  "Member user ID"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse "Member removed successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid organization or user ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Your role in this organization does not allow this"
+// @Failure      404  {object}  models.APIResponse "Organization or member not found"
+// @Failure      409  {object}  models.APIResponse "Last owner of the organization"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /organizations/{id}/members/{user_id} [delete]
+func (h *OrganizationHandler) RemoveMember(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	memberID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	err = h.orgService.RemoveMember(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), orgID, memberID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Member removed successfully",
+	})
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+	"user-management-api/pkg/utils"
+)
+
+//go:embed templates/pages/*.html
+var pageTemplateFS embed.FS
+
+// pageTemplates holds one template set per page, each sharing the layout
+var pageTemplates = map[string]*template.Template{
+	"message":        mustParsePage("message"),
+	"reset_password": mustParsePage("reset_password"),
+	"consent":        mustParsePage("consent"),
+	"accept_invite":  mustParsePage("accept_invite"),
+}
+
+func mustParsePage(name string) *template.Template {
+	return template.Must(template.ParseFS(pageTemplateFS, "templates/pages/layout.html", "templates/pages/"+name+".html"))
+}
+
+type pageData struct {
+	Title   string
+	Message string
+	Success bool
+	Error   string
+	Token   string
+	Email   string
+	Client  *models.OAuthClient
+	Scopes  []string
+	Request *models.AuthorizeRequest
+}
+
+// PageHandler serves the small set of server-rendered pages that email
+// links and OAuth clients send browsers to, so those flows work without a
+// separate frontend
+type PageHandler struct {
+	authService   *services.AuthService
+	clientService *services.ClientService
+}
+
+func NewPageHandler(authService *services.AuthService, clientService *services.ClientService) *PageHandler {
+	return &PageHandler{
+		authService:   authService,
+		clientService: clientService,
+	}
+}
+
+// VerifyEmail confirms the address from an emailed verification link
+func (h *PageHandler) VerifyEmail(c router.Context) {
+	token := c.Query("token")
+	if err := utils.ValidateStruct(&models.VerifyEmailRequest{Token: token}); err != nil {
+		h.render(c, http.StatusBadRequest, "message", pageData{
+			Title:   "Email verification failed",
+			Message: "This verification link is invalid.",
+		})
+		return
+	}
+
+	if _, err := h.authService.VerifyEmail(c.Request().Context(), token); err != nil {
+		h.render(c, statusOf(err), "message", pageData{
+			Title:   "Email verification failed",
+			Message: "This verification link is invalid or has already been used.",
+		})
+		return
+	}
+
+	h.render(c, http.StatusOK, "message", pageData{
+		Title:   "Email verified",
+		Message: "Thanks, your email address is confirmed. You can close this page.",
+		Success: true,
+	})
+}
+
+// ResetPasswordForm shows the form behind an emailed reset link
+func (h *PageHandler) ResetPasswordForm(c router.Context) {
+	h.render(c, http.StatusOK, "reset_password", pageData{
+		Title: "Choose a new password",
+		Token: c.Query("token"),
+	})
+}
+
+// ResetPassword handles the reset form submission
+func (h *PageHandler) ResetPassword(c router.Context) {
+	req := models.ResetPasswordRequest{
+		Token:    c.Request().PostFormValue("token"),
+		Password: c.Request().PostFormValue("password"),
+	}
+	data := pageData{Title: "Choose a new password", Token: req.Token}
+
+	if req.Password != c.Request().PostFormValue("confirm_password") {
+		data.Error = "The passwords do not match."
+		h.render(c, http.StatusBadRequest, "reset_password", data)
+		return
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		data.Error = "Passwords must be at least 6 characters and the link must be complete."
+		h.render(c, http.StatusBadRequest, "reset_password", data)
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request().Context(), &req); err != nil {
+		h.render(c, statusOf(err), "message", pageData{
+			Title:   "Password not changed",
+			Message: "This reset link is invalid, expired or has already been used. Request a new one and try again.",
+		})
+		return
+	}
+
+	h.render(c, http.StatusOK, "message", pageData{
+		Title:   "Password changed",
+		Message: "Your password has been changed. You can now sign in with it.",
+		Success: true,
+	})
+}
+
+// AcceptInvitationForm shows the form behind an emailed invitation link
+func (h *PageHandler) AcceptInvitationForm(c router.Context) {
+	h.render(c, http.StatusOK, "accept_invite", pageData{
+		Title: "Set up your account",
+		Token: c.Query("token"),
+	})
+}
+
+// AcceptInvitation handles the invitation form submission
+func (h *PageHandler) AcceptInvitation(c router.Context) {
+	req := models.AcceptInvitationRequest{
+		Token:    c.Request().PostFormValue("token"),
+		Password: c.Request().PostFormValue("password"),
+	}
+	data := pageData{Title: "Set up your account", Token: req.Token}
+
+	if req.Password != c.Request().PostFormValue("confirm_password") {
+		data.Error = "The passwords do not match."
+		h.render(c, http.StatusBadRequest, "accept_invite", data)
+		return
+	}
+	if err := utils.ValidateStruct(&req); err != nil {
+		data.Error = "Passwords must be at least 6 characters and the link must be complete."
+		h.render(c, http.StatusBadRequest, "accept_invite", data)
+		return
+	}
+
+	if _, err := h.authService.AcceptInvitation(c.Request().Context(), &req); err != nil {
+		h.render(c, statusOf(err), "message", pageData{
+			Title:   "Invitation not accepted",
+			Message: "This invitation link is invalid, expired or has already been used. Ask for a new invitation and try again.",
+		})
+		return
+	}
+
+	h.render(c, http.StatusOK, "message", pageData{
+		Title:   "Account ready",
+		Message: "Your account is set up. You can now sign in with your new password.",
+		Success: true,
+	})
+}
+
+// Consent shows the OAuth authorization page
+func (h *PageHandler) Consent(c router.Context) {
+	var req models.AuthorizeRequest
+	_ = router.DecodeQuery(c.Request(), &req)
+
+	client, scopes, err := h.clientService.ValidateAuthorization(c.Request().Context(), &req)
+	if err != nil {
+		h.renderAuthorizeError(c, err)
+		return
+	}
+
+	h.render(c, http.StatusOK, "consent", pageData{
+		Title:   "Authorize " + client.Name,
+		Client:  client,
+		Scopes:  scopes,
+		Request: &req,
+	})
+}
+
+// Authorize handles the consent form. Users sign in on the form itself, as
+// the API keeps no browser session.
+func (h *PageHandler) Authorize(c router.Context) {
+	var req models.AuthorizeRequest
+	_ = router.Decode(c.Request(), &req)
+
+	client, scopes, err := h.clientService.ValidateAuthorization(c.Request().Context(), &req)
+	if err != nil {
+		h.renderAuthorizeError(c, err)
+		return
+	}
+
+	if c.Request().PostFormValue("decision") != "allow" {
+		c.Redirect(http.StatusFound, h.clientService.DenyURL(&req))
+		return
+	}
+
+	data := pageData{
+		Title:   "Authorize " + client.Name,
+		Client:  client,
+		Scopes:  scopes,
+		Request: &req,
+		Email:   c.Request().PostFormValue("email"),
+	}
+	user, err := h.authService.Authenticate(c.Request().Context(), c.Request().PostFormValue("email"), c.Request().PostFormValue("password"), loginContext(c))
+	if err != nil {
+		data.Error = "Incorrect email or password."
+		h.render(c, statusOf(err), "consent", data)
+		return
+	}
+
+	redirectURL, err := h.clientService.Authorize(c.Request().Context(), &req, user)
+	if err != nil {
+		h.renderAuthorizeError(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+func (h *PageHandler) renderAuthorizeError(c router.Context, err error) {
+	message := "Something went wrong, please try again later."
+	if appErr, ok := err.(*errors.AppError); ok && appErr.Code < http.StatusInternalServerError {
+		message = appErr.Message
+	}
+	h.render(c, statusOf(err), "message", pageData{
+		Title:   "Authorization failed",
+		Message: message,
+	})
+}
+
+func (h *PageHandler) render(c router.Context, status int, page string, data pageData) {
+	// pages carry tokens in their URLs and must never be framed or cached
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := pageTemplates[page].ExecuteTemplate(c.Writer(), page+".html", data); err != nil {
+		log.Printf("Failed to render %s page: %v", page, err)
+	}
+}
+
+// statusOf maps a service error to its HTTP status
+func statusOf(err error) int {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appErr.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PersonalAccessTokenHandler struct {
+	tokenService *services.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(tokenService *services.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+// ListTokens godoc
+// @Summary      List my access tokens
+// @Description  List the current user's unexpired personal access tokens. The tokens themselves are never returned again; use the hint to tell them apart.
+// @Tags         tokens
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[profile:read]
+// @Success      200  {object}  models.APIResponse{data=[]models.PersonalAccessToken} "Tokens retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/tokens [get]
+func (h *PersonalAccessTokenHandler) ListTokens(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	tokens, err := h.tokenService.List(c.Request().Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Tokens retrieved successfully",
+		Data:    tokens,
+	})
+}
+
+// CreateToken godoc
+// @Summary      Create an access token
+// @Description  Create a personal access token for scripts, sent as "Authorization: Bearer pat_...". Its scopes must be among those of the token making the request, and it expires after expires_in_days (default 30, at most 365). The token is shown only in this response.
+// @Tags         tokens
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.CreatePersonalAccessTokenRequest  true  "Token name, scopes and lifetime"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      201  {object}  models.APIResponse{data=models.CreatedPersonalAccessToken} "Token created"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or scope not allowed"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      409  {object}  models.APIResponse "Too many tokens"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/tokens [post]
+func (h *PersonalAccessTokenHandler) CreateToken(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.CreatePersonalAccessTokenRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	token, err := h.tokenService.Create(c.Request().Context(), userID, middleware.GetTokenScopes(c.Request().Context()), &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Token created",
+		Data:    token,
+	})
+}
+
+// RevokeToken godoc
+// @Summary      Revoke an access token
+// @Description  Delete one of the current user's personal access tokens; it stops working immediately
+// @Tags         tokens
+// @Produce      json
+// @Param        id   path      string  true  "Token ID"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse "Token revoked"
+// @Failure      400  {object}  models.APIResponse "Invalid token ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Token not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) RevokeToken(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	tokenID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid token ID",
+		})
+		return
+	}
+
+	err = h.tokenService.Revoke(c.Request().Context(), userID, tokenID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Token revoked",
+	})
+}
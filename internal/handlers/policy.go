@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PolicyHandler struct {
+	policyService *services.PolicyService
+}
+
+func NewPolicyHandler(policyService *services.PolicyService) *PolicyHandler {
+	return &PolicyHandler{
+		policyService: policyService,
+	}
+}
+
+// ListPolicies godoc
+// @Summary      List access policies
+// @Description  List the rules that allow or deny roles access to routes
+// @Tags         policies
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]models.Policy} "Policies retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /policies [get]
+func (h *PolicyHandler) ListPolicies(c router.Context) {
+	policies, err := h.policyService.List(c.Request().Context())
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Policies retrieved successfully",
+		Data:    policies,
+	})
+}
+
+// CreatePolicy godoc
+// @Summary      Create an access policy
+// @Description  Allow or deny a role (or "*" for every role) an HTTP method on a path pattern such as /api/v1/users/:id or /api/v1/projects/*. Once any rule covers a route and method, only the roles it allows get through; deny rules win over allow rules. The rule applies to every instance within a minute. Admins are never restricted by policies.
+// @Tags         policies
+// @Accept       json
+// @Produce      json
+// @Param        policy  body      models.CreatePolicyRequest  true  "Policy rule"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.Policy} "Policy created successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or unknown role"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Failure      409  {object}  models.APIResponse "Policy already exists"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /policies [post]
+func (h *PolicyHandler) CreatePolicy(c router.Context) {
+	req, appErr := Bind[models.CreatePolicyRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	policy, err := h.policyService.Create(c.Request().Context(), &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Policy created successfully",
+		Data:    policy,
+	})
+}
+
+// DeletePolicy godoc
+// @Summary      Delete an access policy
+// @Description  Remove a policy rule
+// @Tags         policies
+// @Produce      json
+// @Param        id  path      string  true  "Policy ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse "Policy deleted successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid policy ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Failure      404  {object}  models.APIResponse "Policy not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /policies/{id} [delete]
+func (h *PolicyHandler) DeletePolicy(c router.Context) {
+	policyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid policy ID",
+		})
+		return
+	}
+
+	err = h.policyService.Delete(c.Request().Context(), policyID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Policy deleted successfully",
+	})
+}
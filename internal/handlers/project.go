@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProjectHandler serves the projects resource. It is the reference for
+// adding a new user-owned resource: handler, service, repository and
+// routes each in their own file.
+type ProjectHandler struct {
+	projectService *services.ProjectService
+}
+
+func NewProjectHandler(projectService *services.ProjectService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+	}
+}
+
+// ListProjects godoc
+// @Summary      List projects
+// @Description  Get a paginated list of the current user's projects. Admins see every user's projects.
+// @Tags         projects
+// @Produce      json
+// @Param        page   query     int     false  "Page number"  default(1)
+// @Param        limit  query     int     false  "Items per page" default(10)
+// @Param        expand query     string  false  "Relations to populate, e.g. owner"
+// @Security     BearerAuth
+// @Security     OAuth2[projects:read]
+// @Success      200  {object}  models.PaginatedResponse{data=[]models.Project} "Projects retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Unknown relation in expand"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /projects [get]
+func (h *ProjectHandler) ListProjects(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "10"))
+
+	result, err := h.projectService.List(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), page, limit, expandParam(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, result)
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateProject godoc
+// @Summary      Create a project
+// @Description  Create a project owned by the current user
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        project  body      models.CreateProjectRequest  true  "Project details"
+// @Security     BearerAuth
+// @Security     OAuth2[projects:write]
+// @Success      201  {object}  models.APIResponse{data=models.Project} "Project created successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /projects [post]
+func (h *ProjectHandler) CreateProject(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.CreateProjectRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	project, err := h.projectService.Create(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Project created successfully",
+		Data:    project,
+	})
+}
+
+// GetProject godoc
+// @Summary      Get a project
+// @Description  Get one of the current user's projects. Admins can get any project.
+// @Tags         projects
+// @Produce      json
+// @Param        id      path      string  true   "Project ID"
+// @Param        expand  query     string  false  "Relations to populate, e.g. owner"
+// @Security     BearerAuth
+// @Security     OAuth2[projects:read]
+// @Success      200  {object}  models.APIResponse{data=models.Project} "Project retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid project ID or unknown relation in expand"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Project not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /projects/{id} [get]
+func (h *ProjectHandler) GetProject(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	projectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid project ID",
+		})
+		return
+	}
+
+	project, err := h.projectService.Get(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), projectID, expandParam(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, project)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Project retrieved successfully",
+		Data:    project,
+	})
+}
+
+// UpdateProject godoc
+// @Summary      Update a project
+// @Description  Update one of the current user's projects. Admins can update any project.
+// @Tags         projects
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true  "Project ID"
+// @Param        project  body      models.UpdateProjectRequest  true  "Fields to update"
+// @Security     BearerAuth
+// @Security     OAuth2[projects:write]
+// @Success      200  {object}  models.APIResponse{data=models.Project} "Project updated successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Project not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /projects/{id} [put]
+func (h *ProjectHandler) UpdateProject(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	projectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid project ID",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.UpdateProjectRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	project, err := h.projectService.Update(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), projectID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Project updated successfully",
+		Data:    project,
+	})
+}
+
+// DeleteProject godoc
+// @Summary      Delete a project
+// @Description  Delete one of the current user's projects. Admins can delete any project.
+// @Tags         projects
+// @Produce      json
+// @Param        id   path      string  true  "Project ID"
+// @Security     BearerAuth
+// @Security     OAuth2[projects:write]
+// @Success      200  {object}  models.APIResponse "Project deleted successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid project ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      404  {object}  models.APIResponse "Project not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	projectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid project ID",
+		})
+		return
+	}
+
+	err = h.projectService.Delete(c.Request().Context(), userID, middleware.GetUserRole(c.Request().Context()), projectID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Project deleted successfully",
+	})
+}
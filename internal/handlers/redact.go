@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/fieldauth"
+	"user-management-api/pkg/router"
+)
+
+// redactFields clears the fields of v the requester's role may not see,
+// as declared by authz struct tags. Call it on every response that can
+// carry other users' records.
+func redactFields(c router.Context, v any) {
+	viewerID, _ := middleware.GetUserId(c.Request().Context())
+	fieldauth.Redact(v, fieldauth.Viewer{
+		ID:          viewerID,
+		Permissions: models.ScopesForRole(middleware.GetUserRole(c.Request().Context())),
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// CreateReport godoc
+// @Summary      Request a PDF report
+// @Description  Queue generation of a branded PDF report. user_profile needs user_id, monthly_activity needs month (YYYY-MM). Poll the returned report until it is completed (Admin only)
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        report  body      models.CreateReportRequest  true  "Report type and subject"
+// @Security     BearerAuth
+// @Success      202  {object}  models.APIResponse{data=models.Report} "Report queued"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      503  {object}  models.APIResponse "Job queue is full"
+// @Router       /reports [post]
+func (h *ReportHandler) CreateReport(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.CreateReportRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	report, err := h.reportService.Request(c.Request().Context(), adminID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Report queued",
+		Data:    report,
+	})
+}
+
+// ListReports godoc
+// @Summary      List reports
+// @Description  List the most recent reports requested by the current admin (Admin only)
+// @Tags         reports
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]models.Report} "Reports retrieved successfully"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /reports [get]
+func (h *ReportHandler) ListReports(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	reports, err := h.reportService.List(c.Request().Context(), adminID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Reports retrieved successfully",
+		Data:    reports,
+	})
+}
+
+// GetReport godoc
+// @Summary      Get report status
+// @Description  Get the status of a requested report (Admin only)
+// @Tags         reports
+// @Produce      json
+// @Param        id   path      string  true  "Report ID"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.Report} "Report retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid report ID"
+// @Failure      404  {object}  models.APIResponse "Report not found"
+// @Router       /reports/{id} [get]
+func (h *ReportHandler) GetReport(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid report ID",
+		})
+		return
+	}
+
+	report, err := h.reportService.Get(c.Request().Context(), adminID, id)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Report retrieved successfully",
+		Data:    report,
+	})
+}
+
+// DownloadReport godoc
+// @Summary      Download a report
+// @Description  Download a completed report as PDF (Admin only)
+// @Tags         reports
+// @Produce      application/pdf
+// @Param        id   path      string  true  "Report ID"
+// @Security     BearerAuth
+// @Success      200  {file}    file "PDF report"
+// @Failure      400  {object}  models.APIResponse "Invalid report ID"
+// @Failure      404  {object}  models.APIResponse "Report not found"
+// @Failure      409  {object}  models.APIResponse "Report is not ready yet"
+// @Router       /reports/{id}/download [get]
+func (h *ReportHandler) DownloadReport(c router.Context) {
+	adminID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid report ID",
+		})
+		return
+	}
+
+	report, err := h.reportService.GetFile(c.Request().Context(), adminID, id)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s.pdf", report.Type, report.ID.Hex())
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	http.ServeFile(c.Writer(), c.Request(), report.FilePath)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+// ListRoles godoc
+// @Summary      List roles
+// @Description  List every role with its permissions
+// @Tags         roles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]models.Role} "Roles retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /roles [get]
+func (h *RoleHandler) ListRoles(c router.Context) {
+	roles, err := h.roleService.List(c.Request().Context())
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Roles retrieved successfully",
+		Data:    roles,
+	})
+}
+
+// ListPermissions godoc
+// @Summary      List permissions
+// @Description  List the permissions roles can grant
+// @Tags         roles
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]string} "Permissions retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Router       /permissions [get]
+func (h *RoleHandler) ListPermissions(c router.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Permissions retrieved successfully",
+		Data:    models.Permissions,
+	})
+}
+
+// GetRole godoc
+// @Summary      Get a role
+// @Description  Get a role and its permissions by name
+// @Tags         roles
+// @Produce      json
+// @Param        name  path      string  true  "Role name"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.Role} "Role retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Failure      404  {object}  models.APIResponse "Role not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /roles/{name} [get]
+func (h *RoleHandler) GetRole(c router.Context) {
+	role, err := h.roleService.Get(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role retrieved successfully",
+		Data:    role,
+	})
+}
+
+// CreateRole godoc
+// @Summary      Create a role
+// @Description  Define a new role from a set of permissions and the roles it inherits, optionally with its own session limit
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        role  body      models.CreateRoleRequest  true  "Role name and permissions"
+// @Security     BearerAuth
+// @Success      201  {object}  models.APIResponse{data=models.Role} "Role created successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed, unknown permission or role, or inheritance cycle"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Failure      409  {object}  models.APIResponse "Role already exists"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /roles [post]
+func (h *RoleHandler) CreateRole(c router.Context) {
+	req, appErr := Bind[models.CreateRoleRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	role, err := h.roleService.Create(c.Request().Context(), &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Role created successfully",
+		Data:    role,
+	})
+}
+
+// UpdateRole godoc
+// @Summary      Update a role
+// @Description  Change a role's description, replace its permissions or the roles it inherits, or set its session limit; an empty session_limit removes it. Only the session limit of the admin roles can be changed.
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string                    true  "Role name"
+// @Param        role  body      models.UpdateRoleRequest  true  "Fields to update"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.Role} "Role updated successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed, unknown permission or role, or inheritance cycle"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions or protected role"
+// @Failure      404  {object}  models.APIResponse "Role not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /roles/{name} [put]
+func (h *RoleHandler) UpdateRole(c router.Context) {
+	req, appErr := Bind[models.UpdateRoleRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	role, err := h.roleService.Update(c.Request().Context(), c.Param("name"), &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role updated successfully",
+		Data:    role,
+	})
+}
+
+// DeleteRole godoc
+// @Summary      Delete a role
+// @Description  Delete a role no user holds. Built-in roles cannot be deleted.
+// @Tags         roles
+// @Produce      json
+// @Param        name  path      string  true  "Role name"
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse "Role deleted successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions or protected role"
+// @Failure      404  {object}  models.APIResponse "Role not found"
+// @Failure      409  {object}  models.APIResponse "Role is still assigned to users or inherited by other roles"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /roles/{name} [delete]
+func (h *RoleHandler) DeleteRole(c router.Context) {
+	err := h.roleService.Delete(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role deleted successfully",
+	})
+}
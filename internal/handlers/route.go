@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/router"
+)
+
+type RouteHandler struct {
+	matrix func() []models.RouteInfo
+}
+
+// NewRouteHandler serves the route matrix returned by matrix, which is
+// called on each request so routes registered later are included
+func NewRouteHandler(matrix func() []models.RouteInfo) *RouteHandler {
+	return &RouteHandler{
+		matrix: matrix,
+	}
+}
+
+// ListRoutes godoc
+// @Summary      List routes and their access rules
+// @Description  Export the route matrix: every registered route with the authentication, role permission, token scope, rate limit, upload profile and extra middleware it requires
+// @Tags         policies
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]models.RouteInfo} "Routes retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Router       /routes [get]
+func (h *RouteHandler) ListRoutes(c router.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Routes retrieved successfully",
+		Data:    h.matrix(),
+	})
+}
+
+// ListPermissions godoc
+// @Summary      List permissions with their roles and routes
+// @Description  Export the permissions matrix: for every permission, the roles granting it (inherited ones included) and the routes requiring it as role permission or token scope
+// @Tags         policies
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=[]models.PermissionInfo} "Permissions retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Insufficient permissions"
+// @Router       /routes/permissions [get]
+func (h *RouteHandler) ListPermissions(c router.Context) {
+	routes := h.matrix()
+	permissions := make([]models.PermissionInfo, 0, len(models.Permissions))
+	for _, permission := range models.Permissions {
+		info := models.PermissionInfo{
+			Permission: permission,
+			Roles:      models.RolesWithPermission(permission),
+			Routes:     []string{},
+		}
+		for _, route := range routes {
+			if route.Permission == permission || route.Scope == permission {
+				info.Routes = append(info.Routes, route.Method+" "+route.Path)
+			}
+		}
+		permissions = append(permissions, info)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Permissions retrieved successfully",
+		Data:    permissions,
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+)
+
+type SAMLHandler struct {
+	samlService *services.SAMLService
+}
+
+func NewSAMLHandler(samlService *services.SAMLService) *SAMLHandler {
+	return &SAMLHandler{
+		samlService: samlService,
+	}
+}
+
+// Metadata godoc
+// @Summary      SAML service provider metadata
+// @Description  SP metadata XML to register this API with a SAML identity provider
+// @Tags         saml
+// @Produce      xml
+// @Success      200  {string}  string "SP metadata"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/saml/metadata [get]
+func (h *SAMLHandler) Metadata(c router.Context) {
+	metadata, err := h.samlService.Metadata()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+	c.Header("Content-Type", "application/samlmetadata+xml")
+	c.Status(http.StatusOK)
+	c.Writer().Write(metadata)
+}
+
+// Login godoc
+// @Summary      Start SAML single sign-on
+// @Description  Redirect the browser to the identity provider
+// @Tags         saml
+// @Param        relay_state  query  string  false  "Opaque value returned to the ACS"
+// @Success      302
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/saml/login [get]
+func (h *SAMLHandler) Login(c router.Context) {
+	redirectURL, err := h.samlService.LoginURL(c.Request().Context(), c.Query("relay_state"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// AssertionConsumer godoc
+// @Summary      SAML assertion consumer service
+// @Description  Receive the identity provider's signed response, provision the user on first login and return a token
+// @Tags         saml
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        SAMLResponse  formData  string  true  "Base64 encoded SAML response"
+// @Success      200  {object}  models.APIResponse{data=models.AuthResponse} "Login successful"
+// @Failure      400  {object}  models.APIResponse "Missing SAMLResponse"
+// @Failure      401  {object}  models.APIResponse "Single sign-on failed"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/saml/acs [post]
+func (h *SAMLHandler) AssertionConsumer(c router.Context) {
+	samlResponse := c.Request().PostFormValue("SAMLResponse")
+	if samlResponse == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "SAMLResponse is required",
+		})
+		return
+	}
+
+	authResponse, err := h.samlService.Login(c.Request().Context(), samlResponse, loginContext(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Login successful",
+		Data:    authResponse,
+	})
+}
+
+// SingleLogout godoc
+// @Summary      SAML single logout
+// @Description  Handle an identity provider initiated LogoutRequest: end the user's sessions and redirect back with a LogoutResponse
+// @Tags         saml
+// @Param        SAMLRequest  query  string  true   "Deflated, base64 encoded LogoutRequest"
+// @Param        RelayState   query  string  false  "Relay state"
+// @Param        SigAlg       query  string  true   "Signature algorithm (RSA-SHA256 or RSA-SHA1)"
+// @Param        Signature    query  string  true   "Base64 signature of the query by the identity provider"
+// @Success      302
+// @Failure      401  {object}  models.APIResponse "Single sign-on failed"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /auth/saml/slo [get]
+func (h *SAMLHandler) SingleLogout(c router.Context) {
+	redirectURL, err := h.samlService.Logout(c.Request().Context(), c.Request().URL.RawQuery, loginContext(c))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+	c.Redirect(http.StatusFound, redirectURL)
+}
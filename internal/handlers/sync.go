@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+)
+
+type SyncHandler struct {
+	syncService *services.SyncService
+}
+
+func NewSyncHandler(syncService *services.SyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// Sync godoc
+// @Summary      Delta sync of users
+// @Description  Return users created, updated and deleted since a sync token; omit the token for a full sync (Admin only)
+// @Tags         sync
+// @Produce      json
+// @Param        token  query     string  false  "Sync token from the previous response"
+// @Param        limit  query     int     false  "Maximum changes per page"  default(100)
+// @Security     BearerAuth
+// @Success      200  {object}  models.APIResponse{data=models.SyncResponse} "Changes retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid sync token"
+// @Failure      410  {object}  models.APIResponse "Sync token expired, a full resync is required"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /sync [get]
+func (h *SyncHandler) Sync(c router.Context) {
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "100"))
+
+	result, err := h.syncService.Sync(c.Request().Context(), c.Query("token"), limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Changes retrieved successfully",
+		Data:    result,
+	})
+}
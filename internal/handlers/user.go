@@ -3,38 +3,45 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"user-management-api/internal/middleware"
 	"user-management-api/internal/models"
 	"user-management-api/internal/services"
 	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
 	"user-management-api/pkg/utils"
 
-	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserHandler struct {
-	userService *services.UserService
+	userService    *services.UserService
+	historyService *services.HistoryService
+	avatarService  *services.AvatarService
 }
 
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, historyService *services.HistoryService, avatarService *services.AvatarService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:    userService,
+		historyService: historyService,
+		avatarService:  avatarService,
 	}
 }
 
 // GetProfile godoc
 // @Summary      Get user profile
-// @Description  Get the profile of the currently authenticated user
+// @Description  Get the profile of the currently authenticated user. The current profile includes a completeness score with hints for the onboarding checklist fields still missing; profiles read with as_of do not.
 // @Tags         users
 // @Produce      json
 // @Security     BearerAuth
+// @Security     OAuth2[profile:read]
 // @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Profile retrieved successfully"
 // @Failure      401  {object}  models.APIResponse "Unauthorized"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
 // @Router       /users/profile [get]
-func (h *UserHandler) GetProfile(c *gin.Context) {
-	userID, err := middleware.GetUserId(c)
+func (h *UserHandler) GetProfile(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
@@ -43,7 +50,20 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetByID(c.Request.Context(), userID)
+	var user *models.UserResponse
+	if asOf := c.Query("as_of"); asOf != "" {
+		at, parseErr := time.Parse(time.RFC3339Nano, asOf)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid as_of timestamp, expected RFC3339",
+			})
+			return
+		}
+		user, err = h.historyService.UserAsOf(c.Request().Context(), userID, at)
+	} else {
+		user, err = h.userService.GetProfile(c.Request().Context(), userID)
+	}
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
 			c.JSON(appErr.Code, models.APIResponse{
@@ -67,19 +87,79 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	})
 }
 
+// UpdatePreferences godoc
+// @Summary      Update my preferences
+// @Description  Update the current user's own preferences, such as opting out of new sign-in alerts
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.UpdatePreferencesRequest  true  "Preferences"
+// @Security     BearerAuth
+// @Security     OAuth2[profile:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Preferences updated successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/profile/preferences [put]
+func (h *UserHandler) UpdatePreferences(c router.Context) {
+	userID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.UpdatePreferencesRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	user, err := h.userService.UpdatePreferences(c.Request().Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Preferences updated successfully",
+		Data:    user,
+	})
+}
+
 // GetUser godoc
 // @Summary      Get a user by ID
-// @Description  Get a single user by their ID (Admin only)
+// @Description  Get a single user by their ID, or as they were at a past moment with as_of, rebuilt from the change history. Requires the users:read permission.
 // @Tags         users
 // @Produce      json
-// @Param        id   path      string  true  "User ID"
+// @Param        id     path      string  true   "User ID"
+// @Param        as_of  query     string  false  "RFC3339 timestamp to rebuild the user at"
 // @Security     BearerAuth
+// @Security     OAuth2[users:read]
 // @Success      200  {object}  models.APIResponse{data=models.UserResponse} "User retrieved successfully"
-// @Failure      400  {object}  models.APIResponse "Invalid user ID"
-// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID or as_of timestamp"
+// @Failure      404  {object}  models.APIResponse "User not found, or did not exist yet at as_of"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
 // @Router       /users/{id} [get]
-func (h *UserHandler) GetUser(c *gin.Context) {
+func (h *UserHandler) GetUser(c router.Context) {
 	idParam := c.Param("id")
 	userID, err := primitive.ObjectIDFromHex(idParam)
 	if err != nil {
@@ -90,7 +170,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetByID(c.Request.Context(), userID)
+	user, err := h.userService.GetByID(c.Request().Context(), userID)
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
 			c.JSON(appErr.Code, models.APIResponse{
@@ -107,6 +187,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
+	redactFields(c, user)
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "User retrieved successfully",
@@ -116,39 +197,40 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 
 // CreateUser godoc
 // @Summary      Create a new user
-// @Description  Create a new user (Admin only)
+// @Description  Create a new user. Requires the users:write permission.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        user  body      models.CreateUserRequest  true  "New User Info"
 // @Security     BearerAuth
+// @Security     OAuth2[users:write]
 // @Success      201  {object}  models.APIResponse{data=models.UserResponse} "User created successfully"
 // @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
 // @Failure      409  {object}  models.APIResponse "User already exists"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
 // @Router       /users [post]
-func (h *UserHandler) CreateUser(c *gin.Context) {
-	var req models.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+func (h *UserHandler) CreateUser(c router.Context) {
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
-			Message: "Invalid request body",
-			Error:   err.Error(),
+			Message: "Unauthorized",
 		})
 		return
 	}
 
-	// Validate request
-	if err := utils.ValidateStruct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+	req, appErr := Bind[models.CreateUserRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
 			Success: false,
-			Message: "Validation failed",
-			Error:   utils.FormatValidationError(err, models.CreateUserRequest{}),
+			Message: appErr.Message,
+			Error:   appErr.Details,
 		})
 		return
 	}
 
-	user, err := h.userService.Create(c.Request.Context(), &req)
+	user, err := h.userService.Create(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), &req)
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
 			c.JSON(appErr.Code, models.APIResponse{
@@ -165,6 +247,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	redactFields(c, user)
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "User created successfully",
@@ -174,19 +257,21 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary      Update a user
-// @Description  Update an existing user's details by ID (Admin only)
+// @Description  Update an existing user's details by ID. Empty fields are left unchanged; use PATCH to clear them. Requires the users:write permission.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        id    path      string                   true  "User ID"
 // @Param        user  body      models.UpdateUserRequest  true  "User Update Info"
 // @Security     BearerAuth
+// @Security     OAuth2[users:write]
 // @Success      200  {object}  models.APIResponse{data=models.UserResponse} "User updated successfully"
 // @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
 // @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
 // @Router       /users/{id} [put]
-func (h *UserHandler) UpdateUser(c *gin.Context) {
+func (h *UserHandler) UpdateUser(c router.Context) {
 	idParam := c.Param("id")
 	userID, err := primitive.ObjectIDFromHex(idParam)
 	if err != nil {
@@ -197,27 +282,96 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	var req models.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
 			Success: false,
-			Message: "Invalid request body",
-			Error:   err.Error(),
+			Message: "Unauthorized",
 		})
 		return
 	}
 
-	// Validate request
-	if err := utils.ValidateStruct(&req); err != nil {
+	req, appErr := Bind[models.UpdateUserRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	user, err := h.userService.Update(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, user)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "User updated successfully",
+		Data:    user,
+	})
+}
+
+// PatchUser godoc
+// @Summary      Partially update a user
+// @Description  Change only the fields present in the body. An empty string clears first_name, last_name, avatar or locale; username, email and role cannot be cleared. Requires the users:write permission.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                  true  "User ID"
+// @Param        user  body      models.PatchUserRequest  true  "Fields to change"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "User updated successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id} [patch]
+func (h *UserHandler) PatchUser(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Message: "Validation failed",
-			Error:   utils.FormatValidationError(err, models.UpdateUserRequest{}),
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.PatchUserRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
 		})
 		return
 	}
 
-	user, err := h.userService.Update(c.Request.Context(), userID, &req)
+	user, err := h.userService.Patch(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID, &req)
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
 			c.JSON(appErr.Code, models.APIResponse{
@@ -234,6 +388,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	redactFields(c, user)
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "User updated successfully",
@@ -241,21 +396,24 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	})
 }
 
-// DeleteUser godoc
-// @Summary      Delete a user
-// @Description  Delete a user by their ID (Admin only)
+// UpdateUserMetadata godoc
+// @Summary      Update a user's metadata
+// @Description  Attach application data to a user. The body is a JSON merge patch (RFC 7396) applied to the user's metadata: null removes a key, objects are merged and other values replace the current ones. Metadata is limited to 16 KiB, 64 top-level keys and 5 levels of nesting. Requires the users:write permission.
 // @Tags         users
+// @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "User ID"
+// @Param        id     path      string          true  "User ID"
+// @Param        patch  body      map[string]any  true  "Merge patch"
 // @Security     BearerAuth
-// @Success      200  {object}  models.APIResponse "User deleted successfully"
-// @Failure      400  {object}  models.APIResponse "Invalid user ID"
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Metadata updated successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID, body or metadata"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
 // @Failure      404  {object}  models.APIResponse "User not found"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
-// @Router       /users/{id} [delete]
-func (h *UserHandler) DeleteUser(c *gin.Context) {
-	idParam := c.Param("id")
-	userID, err := primitive.ObjectIDFromHex(idParam)
+// @Router       /users/{id}/metadata [patch]
+func (h *UserHandler) UpdateUserMetadata(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
@@ -264,7 +422,25 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	err = h.userService.Delete(c.Request.Context(), userID)
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	var patch map[string]any
+	if err := c.BindJSON(&patch); err != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Request body must be a JSON object",
+		})
+		return
+	}
+
+	user, err := h.userService.UpdateMetadata(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID, patch)
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
 			c.JSON(appErr.Code, models.APIResponse{
@@ -281,29 +457,61 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	redactFields(c, user)
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "User deleted successfully",
+		Message: "Metadata updated successfully",
+		Data:    user,
 	})
 }
 
-// ListUsers godoc
-// @Summary      List users
-// @Description  Get a paginated list of all users (Admin only)
+// AddUserTags godoc
+// @Summary      Tag a user
+// @Description  Attach tags to a user for segmentation. Tags are lowercased; each is at most 40 letters, digits and _ : . -, starting with a letter or digit, and a user holds at most 32. Tags the user already has are ignored. Requires the users:write permission.
 // @Tags         users
+// @Accept       json
 // @Produce      json
-// @Param        page   query     int  false  "Page number"  default(1)
-// @Param        limit  query     int  false  "Items per page" default(10)
+// @Param        id       path      string                  true  "User ID"
+// @Param        request  body      models.UserTagsRequest  true  "Tags to add"
 // @Security     BearerAuth
-// @Success      200  {object}  models.PaginatedUserResponse "Users retrieved successfully"
-// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Tags added successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Invalid user ID, validation failed or invalid tags"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      409  {object}  models.APIResponse "The user would have too many tags"
 // @Failure      500  {object}  models.APIResponse "Internal server error"
-// @Router       /users [get]
-func (h *UserHandler) ListUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+// @Router       /users/{id}/tags [post]
+func (h *UserHandler) AddUserTags(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.UserTagsRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
 
-	result, err := h.userService.List(c.Request.Context(), page, limit)
+	user, err := h.userService.AddTags(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID, req.Tags)
 	if err != nil {
 		if appErr, ok := err.(*errors.AppError); ok {
 			c.JSON(appErr.Code, models.APIResponse{
@@ -320,5 +528,660 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	redactFields(c, user)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Tags added successfully",
+		Data:    user,
+	})
+}
+
+// RemoveUserTag godoc
+// @Summary      Untag a user
+// @Description  Detach a tag from a user; removing a tag the user does not hold changes nothing. Requires the users:write permission.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Param        tag  path      string  true  "Tag to remove"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Tag removed successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID or tag"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/tags/{tag} [delete]
+func (h *UserHandler) RemoveUserTag(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	user, err := h.userService.RemoveTag(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID, c.Param("tag"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, user)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Tag removed successfully",
+		Data:    user,
+	})
+}
+
+// DeleteUser godoc
+// @Summary      Delete a user
+// @Description  Delete a user by their ID. Requires the users:delete permission.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse "User deleted successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id} [delete]
+func (h *UserHandler) DeleteUser(c router.Context) {
+	idParam := c.Param("id")
+	userID, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	err = h.userService.Delete(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "User deleted successfully",
+	})
+}
+
+// ListUsers godoc
+// @Summary      List users
+// @Description  Get a paginated list of all users, or of the members of one organization, optionally only those holding the given tags. Requires the users:read permission.
+// @Tags         users
+// @Produce      json
+// @Param        page    query     int     false  "Page number"  default(1)
+// @Param        limit   query     int     false  "Items per page" default(10)
+// @Param        org_id  query     string    false  "Only active members of this organization"
+// @Param        tag     query     []string  false  "Only users holding every one of these tags"  collectionFormat(multi)
+// @Security     BearerAuth
+// @Security     OAuth2[users:read]
+// @Success      200  {object}  models.PaginatedUserResponse "Users retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid organization ID or tag"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users [get]
+func (h *UserHandler) ListUsers(c router.Context) {
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "10"))
+
+	var orgID *primitive.ObjectID
+	if param := c.Query("org_id"); param != "" {
+		id, err := primitive.ObjectIDFromHex(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid organization ID",
+			})
+			return
+		}
+		orgID = &id
+	}
+
+	result, err := h.userService.List(c.Request().Context(), orgID, c.Request().URL.Query()["tag"], page, limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, result)
+	c.JSON(http.StatusOK, result)
+}
+
+// SearchUsers godoc
+// @Summary      Search users
+// @Description  Full-text search over usernames, emails and first and last names, most relevant first. Requires the users:read permission.
+// @Tags         users
+// @Produce      json
+// @Param        q      query     string  true   "Search terms"
+// @Param        page   query     int     false  "Page number"  default(1)
+// @Param        limit  query     int     false  "Items per page" default(10)
+// @Security     BearerAuth
+// @Security     OAuth2[users:read]
+// @Success      200  {object}  models.PaginatedUserResponse "Users retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid input"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/search [get]
+func (h *UserHandler) SearchUsers(c router.Context) {
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "10"))
+
+	result, err := h.userService.Search(c.Request().Context(), c.Query("q"), page, limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, result)
+	c.JSON(http.StatusOK, result)
+}
+
+// BulkDeleteUsers godoc
+// @Summary      Delete many users
+// @Description  Delete up to 100 users at once, in one transaction where the database supports it. IDs that do not exist are reported as missing; the operation is recorded with the acting user. Requires the users:delete permission.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.BulkUserRequest  true  "User IDs"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.BulkOperation} "Users deleted successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/bulk-delete [post]
+func (h *UserHandler) BulkDeleteUsers(c router.Context) {
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.BulkUserRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	operation, err := h.userService.BulkDelete(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), req.IDs)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Users deleted successfully",
+		Data:    operation,
+	})
+}
+
+// BulkDeactivateUsers godoc
+// @Summary      Deactivate many users
+// @Description  Deactivate up to 100 users at once, in one transaction where the database supports it. Users already inactive are left out and IDs that do not exist are reported as missing; the operation is recorded with the acting user. Requires the users:write permission.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.BulkUserRequest  true  "User IDs"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.BulkOperation} "Users deactivated successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      403  {object}  models.APIResponse "Missing permission, or the user's role outranks yours"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/bulk-deactivate [post]
+func (h *UserHandler) BulkDeactivateUsers(c router.Context) {
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.BulkUserRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	operation, err := h.userService.BulkDeactivate(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), req.IDs)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Users deactivated successfully",
+		Data:    operation,
+	})
+}
+
+// ListBulkOperations godoc
+// @Summary      List bulk operations
+// @Description  Audit log of bulk deletes and deactivations: who ran them, when, and the users they changed, newest first. Requires the audit:read permission.
+// @Tags         users
+// @Produce      json
+// @Param        page   query     int  false  "Page number"  default(1)
+// @Param        limit  query     int  false  "Items per page" default(10)
+// @Security     BearerAuth
+// @Security     OAuth2[audit:read]
+// @Success      200  {object}  models.PaginatedResponse{data=[]models.BulkOperation} "Bulk operations retrieved successfully"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/bulk-operations [get]
+func (h *UserHandler) ListBulkOperations(c router.Context) {
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "10"))
+
+	result, err := h.userService.ListBulkOperations(c.Request().Context(), page, limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUserHistory godoc
+// @Summary      Get a user's change history
+// @Description  List every recorded change to a user, newest first. Values of sensitive fields such as password and email are redacted. Requires the audit:read permission.
+// @Tags         users
+// @Produce      json
+// @Param        id     path      string  true   "User ID"
+// @Param        page   query     int     false  "Page number"  default(1)
+// @Param        limit  query     int     false  "Items per page" default(20)
+// @Security     BearerAuth
+// @Security     OAuth2[audit:read]
+// @Success      200  {object}  models.PaginatedResponse{data=[]models.UserChange} "History retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/history [get]
+func (h *UserHandler) GetUserHistory(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "20"))
+
+	result, err := h.historyService.List(c.Request().Context(), userID, page, limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUserAudit godoc
+// @Summary      Get a user's audit log
+// @Description  List every create, update and delete of a user with who made it, when, and the field-level diff, newest first. Values of sensitive fields such as password and email are redacted. Entries outlive the user and are purged after AUDIT_RETENTION. Requires the audit:read permission.
+// @Tags         users
+// @Produce      json
+// @Param        id     path      string  true   "User ID"
+// @Param        page   query     int     false  "Page number"  default(1)
+// @Param        limit  query     int     false  "Items per page" default(20)
+// @Security     BearerAuth
+// @Security     OAuth2[audit:read]
+// @Success      200  {object}  models.PaginatedResponse{data=[]models.AuditLog} "Audit log retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid user ID"
+// @Failure      401  {object}  models.APIResponse "Unauthorized"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/audit [get]
+func (h *UserHandler) GetUserAudit(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(defaultQuery(c, "page", "1"))
+	limit, _ := strconv.Atoi(defaultQuery(c, "limit", "20"))
+
+	result, err := h.historyService.Audit(c.Request().Context(), userID, page, limit)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BatchGetUsers godoc
+// @Summary      Get many users by ID
+// @Description  Fetch up to 100 users in one round trip, via ?ids=a,b,c on GET or a JSON body on POST. Requires the users:read permission.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        ids      query     string                   false  "Comma separated user IDs (GET)"
+// @Param        request  body      models.BatchUserRequest  false  "User IDs (POST)"
+// @Security     BearerAuth
+// @Security     OAuth2[users:read]
+// @Success      200  {object}  models.APIResponse{data=models.BatchUserResponse} "Users retrieved successfully"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Validation failed or invalid request"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/batch [get]
+// @Router       /users/batch [post]
+func (h *UserHandler) BatchGetUsers(c router.Context) {
+	var req models.BatchUserRequest
+	if c.Request().Method == http.MethodGet {
+		if ids := c.Query("ids"); ids != "" {
+			req.IDs = strings.Split(ids, ",")
+		}
+	} else if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Validate request
+	if err := utils.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   utils.FormatValidationError(err, models.BatchUserRequest{}),
+		})
+		return
+	}
+
+	result, err := h.userService.GetBatch(c.Request().Context(), req.IDs)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, result)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Users retrieved successfully",
+		Data:    result,
+	})
+}
+
+// AggregateUsers godoc
+// @Summary      Count users by dimension
+// @Description  Count users grouped by role, status or created_month, cached for one minute. Requires the users:read permission.
+// @Tags         users
+// @Produce      json
+// @Param        group_by  query     string  true  "Dimension to group by"  Enums(role, status, created_month)
+// @Security     BearerAuth
+// @Security     OAuth2[users:read]
+// @Success      200  {object}  models.APIResponse{data=models.UserAggregateResponse} "Aggregation computed successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid group_by"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/aggregate [get]
+func (h *UserHandler) AggregateUsers(c router.Context) {
+	result, err := h.userService.Aggregate(c.Request().Context(), c.Query("group_by"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Aggregation computed successfully",
+		Data:    result,
+	})
+}
+
+// GetUserStats godoc
+// @Summary      User statistics
+// @Description  Total and active users, users per role and signups per UTC day over the last 30 days, for admin dashboards. Cached for one minute. Requires the users:read permission.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     OAuth2[users:read]
+// @Success      200  {object}  models.APIResponse{data=models.UserStats} "Statistics computed successfully"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/stats [get]
+func (h *UserHandler) GetUserStats(c router.Context) {
+	stats, err := h.userService.Stats(c.Request().Context())
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Statistics computed successfully",
+		Data:    stats,
+	})
+}
+
+// WatchUserChanges godoc
+// @Summary      Long-poll for user changes
+// @Description  Wait up to timeout seconds (max 30) for users updated after since, then return their IDs. Requires the users:read permission.
+// @Tags         users
+// @Produce      json
+// @Param        since    query     string  true   "RFC3339 timestamp, usually the previous cursor"
+// @Param        timeout  query     int     false  "Seconds to wait for changes"  default(30)
+// @Security     BearerAuth
+// @Security     OAuth2[users:read]
+// @Success      200  {object}  models.APIResponse{data=models.UserChangesResponse} "Changes retrieved successfully"
+// @Failure      400  {object}  models.APIResponse "Invalid since timestamp"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/changes [get]
+func (h *UserHandler) WatchUserChanges(c router.Context) {
+	since, err := time.Parse(time.RFC3339Nano, c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid since timestamp, expected RFC3339",
+		})
+		return
+	}
+	timeout, _ := strconv.Atoi(defaultQuery(c, "timeout", "30"))
+
+	result, err := h.userService.WaitForChanges(c.Request().Context(), since, time.Duration(timeout)*time.Second)
+	if err != nil {
+		if c.Request().Context().Err() != nil {
+			// client disconnected while waiting
+			return
+		}
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Changes retrieved successfully",
+		Data:    result,
+	})
 }
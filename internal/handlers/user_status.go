@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/router"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserStatusHandler struct {
+	statusService *services.UserStatusService
+}
+
+func NewUserStatusHandler(statusService *services.UserStatusService) *UserStatusHandler {
+	return &UserStatusHandler{
+		statusService: statusService,
+	}
+}
+
+// ChangeUserStatus godoc
+// @Summary      Change a user's status
+// @Description  Move a user to another status with an optional reason, kept on the user and in its history. Allowed changes: pending to active, banned or deactivated; active to suspended, banned or deactivated; suspended to active, banned or deactivated; banned or deactivated back to active. Users who stop being active are signed out everywhere. Requires the users:write permission.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                          true  "User ID"
+// @Param        status  body      models.ChangeUserStatusRequest  true  "New status and reason"
+// @Security     BearerAuth
+// @Security     OAuth2[users:write]
+// @Success      200  {object}  models.APIResponse{data=models.UserResponse} "Status changed"
+// @Failure      400  {object}  models.APIResponse{error=map[string]string} "Invalid user ID or validation failed"
+// @Failure      403  {object}  models.APIResponse "Missing permission, the user's role outranks yours, or the user is you"
+// @Failure      404  {object}  models.APIResponse "User not found"
+// @Failure      409  {object}  models.APIResponse "Transition not allowed, or a deletion is pending"
+// @Failure      500  {object}  models.APIResponse "Internal server error"
+// @Router       /users/{id}/status [put]
+func (h *UserStatusHandler) ChangeUserStatus(c router.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	actorID, err := middleware.GetUserId(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	req, appErr := Bind[models.ChangeUserStatusRequest](c)
+	if appErr != nil {
+		c.JSON(appErr.Code, models.APIResponse{
+			Success: false,
+			Message: appErr.Message,
+			Error:   appErr.Details,
+		})
+		return
+	}
+
+	user, err := h.statusService.Change(c.Request().Context(), actorID, middleware.GetUserRole(c.Request().Context()), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			c.JSON(appErr.Code, models.APIResponse{
+				Success: false,
+				Message: appErr.Message,
+				Error:   appErr.Type,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Internal server error",
+		})
+		return
+	}
+
+	redactFields(c, user)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Status changed",
+		Data:    user,
+	})
+}
@@ -1,17 +1,86 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"user-management-api/internal/config"
 	"user-management-api/internal/models"
-	"user-management-api/pkg/utils"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/policy"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// SessionChecker reports whether the session a token was issued for is
+// still active
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID primitive.ObjectID) (bool, error)
+}
+
+// sessionChecker validates the sid claim of tokens; nil skips the check
+var sessionChecker SessionChecker
+
+// SetSessionChecker makes AuthMidddleware refuse tokens whose session has
+// been revoked or has expired
+func SetSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
+
+// PersonalAccessTokenAuthenticator resolves a personal access token to the
+// claims of the user who created it
+type PersonalAccessTokenAuthenticator interface {
+	AuthenticatePersonalAccessToken(ctx context.Context, token string) (*auth.JWTClaims, error)
+}
+
+// patAuthenticator validates personal access tokens; nil refuses them
+var patAuthenticator PersonalAccessTokenAuthenticator
+
+// SetPersonalAccessTokenAuthenticator makes AuthMidddleware accept
+// personal access tokens, recognized by their "pat_" prefix, alongside JWTs
+func SetPersonalAccessTokenAuthenticator(authenticator PersonalAccessTokenAuthenticator) {
+	patAuthenticator = authenticator
+}
+
+// validateBearer checks a bearer token, which is either a JWT or a
+// personal access token
+func validateBearer(ctx context.Context, token string, cfg *config.Config) (*auth.JWTClaims, error) {
+	if strings.HasPrefix(token, models.PersonalAccessTokenPrefix) {
+		if patAuthenticator == nil {
+			return nil, auth.ErrWrongTokenUse
+		}
+		return patAuthenticator.AuthenticatePersonalAccessToken(ctx, token)
+	}
+	return auth.ValidateToken(token, cfg.JWT.Secret)
+}
+
+// ActivityRecorder notes that a user made an authenticated request
+type ActivityRecorder interface {
+	RecordActivity(ctx context.Context, userID primitive.ObjectID)
+}
+
+// activityRecorder tracks when users were last seen; nil skips it
+var activityRecorder ActivityRecorder
+
+// SetActivityRecorder makes AuthMidddleware report every request it
+// authenticates, except those of impersonating admins
+func SetActivityRecorder(recorder ActivityRecorder) {
+	activityRecorder = recorder
+}
+
+// policyEnforcer restricts routes by role at runtime; nil skips the check
+var policyEnforcer *policy.Enforcer
+
+// SetPolicyEnforcer makes AuthMidddleware refuse requests the enforcer's
+// policies deny. Admins are exempt so a bad policy can always be removed.
+func SetPolicyEnforcer(enforcer *policy.Enforcer) {
+	policyEnforcer = enforcer
+}
+
 func AuthMidddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -34,7 +103,7 @@ func AuthMidddleware(cfg *config.Config) gin.HandlerFunc {
 		}
 		// extract token
 		token := strings.TrimPrefix(authHeader, "Bearer ")
-		claims, err := utils.ValidateToken(token, cfg.JWT.Secret)
+		claims, err := validateBearer(c.Request.Context(), token, cfg)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
 				Success: false,
@@ -43,17 +112,68 @@ func AuthMidddleware(cfg *config.Config) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
-		c.Next()
+		if claims.SessionID != nil && sessionChecker != nil {
+			active, err := sessionChecker.IsSessionActive(c.Request.Context(), *claims.SessionID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Message: "Internal server error",
+				})
+				c.Abort()
+				return
+			}
+			if !active {
+				c.JSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "Session has ended",
+					Error:   "SESSION_ENDED",
+				})
+				c.Abort()
+				return
+			}
+		}
+		set(c, keyTokenClaims, claims)
+		set(c, keyUserID, claims.UserID)
+		set(c, keyUserEmail, claims.Email)
+		set(c, keyUserRole, claims.Role)
+		scopes := claims.Scopes
+		if scopes == nil {
+			// tokens issued before the scopes claim get their role's defaults
+			scopes = models.ScopesForRole(claims.Role)
+		}
+		set(c, keyTokenScopes, scopes)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), auth.PrincipalFromClaims(claims)))
+		if claims.ImpersonatedBy != nil {
+			set(c, keyImpersonatedBy, *claims.ImpersonatedBy)
+			log.Printf("impersonated request: admin=%s user=%s %s %s",
+				claims.ImpersonatedBy.Hex(), claims.UserID.Hex(), c.Request.Method, c.Request.URL.Path)
+		} else if activityRecorder != nil {
+			activityRecorder.RecordActivity(c.Request.Context(), claims.UserID)
+		}
+		if policyEnforcer != nil && !models.RoleIncludes(claims.Role, models.RoleAdmin) &&
+			policyEnforcer.Enforce(models.IncludedRoles(claims.Role), c.Request.URL.Path, c.Request.Method) == policy.Denied {
+			c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "Access denied by policy",
+				Error:   "POLICY_DENIED",
+			})
+			c.Abort()
+			return
+		}
+		if runPlugins(c, PostAuth) {
+			c.Next()
+		}
 	}
 }
 
+// RequireRole admits users holding one of roles, or a role inheriting
+// one of them.
+//
+// Deprecated: roles are defined at runtime; use RequirePermission.
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		userRole, exists := ctx.Get("user_role")
-		if !exists {
+		role := GetUserRole(ctx.Request.Context())
+		if role == "" {
 			ctx.JSON(http.StatusForbidden, models.APIResponse{
 				Success: false,
 				Message: "Unauthorized access",
@@ -61,9 +181,8 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 			ctx.Abort()
 			return
 		}
-		role := userRole.(string)
 		for _, requriedRoles := range roles {
-			if role == requriedRoles {
+			if models.RoleIncludes(role, requriedRoles) {
 				ctx.Next()
 				return
 			}
@@ -76,10 +195,172 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
-func GetUserId(ctx *gin.Context) (primitive.ObjectID, error) {
-	userId, exists := ctx.Get("user_id")
-	if !exists {
+// RequirePermission admits users whose role grants every one of
+// permissions. Unlike RequireScope it looks the role up on each request,
+// so changes to a role apply without new tokens. Client tokens carry no
+// role and are refused. Personal access tokens and tokens delegated to an
+// OAuth client must also hold each permission as a scope, so they cannot
+// do more than their owner narrowed them to.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		granted := models.ScopesForRole(GetUserRole(ctx.Request.Context()))
+		claims, _ := GetTokenClaims(ctx.Request.Context())
+		narrowed := claims != nil && (claims.TokenUse == auth.TokenUsePersonal || claims.ClientID != "")
+		tokenScopes := GetTokenScopes(ctx.Request.Context())
+		for _, permission := range permissions {
+			if !slices.Contains(granted, permission) || narrowed && !slices.Contains(tokenScopes, permission) {
+				ctx.JSON(http.StatusForbidden, models.APIResponse{
+					Success: false,
+					Message: "Insufficient permissions",
+				})
+				ctx.Abort()
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// RequireScope admits tokens holding every one of scopes. It works for both
+// user tokens and client_credentials tokens, which set "token_scopes" too.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenScopes := GetTokenScopes(ctx.Request.Context())
+		for _, scope := range scopes {
+			if !slices.Contains(tokenScopes, scope) {
+				ctx.JSON(http.StatusForbidden, models.APIResponse{
+					Success: false,
+					Message: "Insufficient scope",
+					Error:   "missing scope " + scope,
+				})
+				ctx.Abort()
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// GetUserId returns the user the request acts for, given the request
+// context
+func GetUserId(ctx context.Context) (primitive.ObjectID, error) {
+	userId, ok := ctx.Value(keyUserID).(primitive.ObjectID)
+	if !ok {
 		return primitive.NilObjectID, errors.New("user ID not found in context")
 	}
-	return userId.(primitive.ObjectID), nil
+	return userId, nil
+}
+
+// GetUserRole returns the role of the authenticated user, empty for
+// requests authenticated as a client
+func GetUserRole(ctx context.Context) string {
+	role, _ := ctx.Value(keyUserRole).(string)
+	return role
+}
+
+// GetTokenScopes returns the scopes granted to the token that authenticated
+// the request
+func GetTokenScopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(keyTokenScopes).([]string)
+	return scopes
+}
+
+// GetTokenClaims returns the claims of the access token that authenticated
+// the request
+func GetTokenClaims(ctx context.Context) (*auth.JWTClaims, bool) {
+	claims, ok := ctx.Value(keyTokenClaims).(*auth.JWTClaims)
+	return claims, ok
+}
+
+// GetCustomClaim decodes a custom claim added by a auth.ClaimsHook from the
+// request's access token
+func GetCustomClaim[T any](ctx context.Context, name string) (T, bool) {
+	claims, ok := GetTokenClaims(ctx)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return auth.CustomClaim[T](claims, name)
+}
+
+// RequireActionToken admits requests carrying a scoped action token (in the
+// "token" query parameter or the X-Action-Token header) minted for action.
+// When resourceParam is set the token's resource must equal that path
+// parameter, so a token for one file cannot be replayed for another.
+func RequireActionToken(cfg *config.Config, action, resourceParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			token = c.GetHeader("X-Action-Token")
+		}
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Action token is required",
+			})
+			c.Abort()
+			return
+		}
+
+		resource := ""
+		if resourceParam != "" {
+			resource = strings.TrimPrefix(c.Param(resourceParam), "/")
+		}
+
+		claims, err := auth.ValidateActionToken(token, cfg.JWT.Secret, action, resource)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Invalid or expired action token",
+			})
+			c.Abort()
+			return
+		}
+		set(c, keyUserID, claims.UserID)
+		set(c, keyActionResource, claims.Resource)
+		if runPlugins(c, PostAuth) {
+			c.Next()
+		}
+	}
+}
+
+// GetImpersonatorId returns the admin impersonating the current user, if any
+func GetImpersonatorId(ctx context.Context) (primitive.ObjectID, bool) {
+	adminId, ok := ctx.Value(keyImpersonatedBy).(primitive.ObjectID)
+	return adminId, ok
+}
+
+// ClientAuthMiddleware authenticates machine clients holding a token from
+// the client_credentials grant and exposes the client and its scopes
+func ClientAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Authorization header is required",
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := auth.ValidateClientToken(strings.TrimPrefix(authHeader, "Bearer "), cfg.JWT.Secret)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Invalid or expired token",
+			})
+			c.Abort()
+			return
+		}
+		set(c, keyClientID, claims.ClientID)
+		set(c, keyTokenScopes, claims.Scopes)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), auth.Principal{
+			ActorType: auth.ActorClient,
+			ClientID:  claims.ClientID,
+		}))
+		if runPlugins(c, PostAuth) {
+			c.Next()
+		}
+	}
 }
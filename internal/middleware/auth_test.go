@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-management-api/internal/config"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakePATs authenticates every personal access token as an admin's token
+// holding scopes
+type fakePATs struct {
+	scopes []string
+}
+
+func (f fakePATs) AuthenticatePersonalAccessToken(ctx context.Context, token string) (*auth.JWTClaims, error) {
+	return &auth.JWTClaims{
+		UserID:   primitive.NewObjectID(),
+		Role:     models.RoleAdmin,
+		TokenUse: auth.TokenUsePersonal,
+		Scopes:   f.scopes,
+	}, nil
+}
+
+func TestRequirePermissionNarrowedPersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer SetPersonalAccessTokenAuthenticator(nil)
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   int
+	}{
+		{"narrowed", []string{models.ScopeProfileRead}, http.StatusForbidden},
+		{"holding the permission", []string{models.ScopeProfileRead, models.PermissionRolesWrite}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPersonalAccessTokenAuthenticator(fakePATs{scopes: tt.scopes})
+			engine := gin.New()
+			engine.POST("/roles", AuthMidddleware(&config.Config{}), RequirePermission(models.PermissionRolesWrite), func(c *gin.Context) {
+				c.Status(http.StatusCreated)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/roles", nil)
+			req.Header.Set("Authorization", "Bearer "+models.PersonalAccessTokenPrefix+"test")
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("POST /roles = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"user-management-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BruteForceGuard tracks failed authentication attempts per IP address
+type BruteForceGuard interface {
+	BannedUntil(ctx context.Context, ip string) (time.Time, bool, error)
+	RecordFailure(ctx context.Context, ip string) error
+	RecordSuccess(ctx context.Context, ip string) error
+}
+
+// BruteForceProtection refuses requests from banned IP addresses and feeds
+// the outcome of the others back to guard: a 401 response counts as a
+// failed attempt and a 2xx response resets the count. Unlike rate limiting
+// it only punishes failures, and bans outlive a restart.
+func BruteForceProtection(guard BruteForceGuard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		until, banned, err := guard.BannedUntil(c.Request.Context(), ip)
+		if err != nil {
+			// fail open: an unreachable ban store must not lock everyone out
+			log.Printf("brute force check for %s: %v", ip, err)
+		}
+		if banned {
+			retryAfter := int(math.Ceil(time.Until(until).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, models.APIResponse{
+				Success: false,
+				Message: "Too many failed attempts. Please try again later.",
+				Error:   "IP_BANNED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		switch {
+		case status == http.StatusUnauthorized:
+			err = guard.RecordFailure(c.Request.Context(), ip)
+		case status >= 200 && status < 300:
+			err = guard.RecordSuccess(c.Request.Context(), ip)
+		default:
+			return
+		}
+		if err != nil {
+			log.Printf("brute force tracking for %s: %v", ip, err)
+		}
+	}
+}
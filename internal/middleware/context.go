@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contextKey names a value middleware stores for the handler. Values are
+// kept on the request context rather than the gin context, so handlers
+// written against router.Context, which only see the request, can read
+// them through the Get functions.
+type contextKey string
+
+const (
+	keyUserID         contextKey = "user_id"
+	keyUserEmail      contextKey = "user_email"
+	keyUserRole       contextKey = "user_role"
+	keyTokenClaims    contextKey = "token_claims"
+	keyTokenScopes    contextKey = "token_scopes"
+	keyImpersonatedBy contextKey = "impersonated_by"
+	keyActingUserID   contextKey = "acting_user_id"
+	keyActionResource contextKey = "action_resource"
+	keyClientID       contextKey = "client_id"
+	keyUploadedFiles  contextKey = "uploaded_files"
+)
+
+// set stores value under key on the request of c
+func set(c *gin.Context, key contextKey, value any) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), key, value))
+}
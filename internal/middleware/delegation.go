@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GrantChecker decides whether one user may act on another's resources
+type GrantChecker interface {
+	HasGrant(ctx context.Context, ownerID, granteeID primitive.ObjectID, scope string) (bool, error)
+}
+
+// OnBehalfOf lets an authenticated user act on another user's resources
+// when the X-On-Behalf-Of header names an owner who granted them scope.
+// The owner becomes the effective user_id for the rest of the chain and
+// the caller is kept as acting_user_id. Requests without the header are
+// passed through untouched. Must run after AuthMidddleware.
+func OnBehalfOf(checker GrantChecker, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ownerHeader := c.GetHeader("X-On-Behalf-Of")
+		if ownerHeader == "" {
+			c.Next()
+			return
+		}
+
+		ownerID, err := primitive.ObjectIDFromHex(ownerHeader)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid X-On-Behalf-Of header",
+			})
+			c.Abort()
+			return
+		}
+
+		actorID, err := GetUserId(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Unauthorized",
+			})
+			c.Abort()
+			return
+		}
+
+		allowed, err := checker.HasGrant(c.Request.Context(), ownerID, actorID, scope)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Internal server error",
+			})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "No grant for this resource",
+				Error:   "GRANT_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		set(c, keyActingUserID, actorID)
+		set(c, keyUserID, ownerID)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), auth.Principal{
+			EffectiveID: ownerID,
+			ActorID:     actorID,
+			ActorType:   auth.ActorDelegate,
+		}))
+		c.Next()
+	}
+}
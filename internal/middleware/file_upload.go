@@ -1,213 +1,790 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
+	"user-management-api/internal/config"
 	"user-management-api/internal/models"
+	"user-management-api/pkg/clamav"
+	"user-management-api/pkg/filesafe"
+	"user-management-api/pkg/imaging"
+	"user-management-api/pkg/storage"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"slices"
 )
 
-// generateUniqueFilename generates a unique filename to prevent conflicts
-func generateUniqueFilename(originalFilename string) string {
-	ext := filepath.Ext(originalFilename)
-	name := originalFilename[:len(originalFilename)-len(ext)]
-	timestamp := time.Now().Unix()
-	id := primitive.NewObjectID().Hex()
-	return fmt.Sprintf("%s_%d_%s%s", name, timestamp, id, ext)
-}
-
 // FileUploadConfig holds configuration for file upload middleware
 type FileUploadConfig struct {
-	MaxFileSize   int64    // Maximum file size in bytes
-	AllowedTypes  []string // Allowed MIME types
-	AllowedExts   []string // Allowed file extensions
-	UploadPath    string   // Upload directory path
-	FieldName     string   // Form field name for file
-	Required      bool     // Whether file is required
-	MaxFiles      int      // Maximum number of files (for multiple uploads)
+	MaxFileSize   int64                  // Maximum file size in bytes
+	AllowedTypes  []string               // Allowed MIME types
+	AllowedExts   []string               // Allowed file extensions
+	KeyPrefix     string                 // Storage key prefix files are saved under
+	TempDir       string                 // Directory images are copied to for the variant encoders
+	FieldName     string                 // Form field name for file
+	Required      bool                   // Whether file is required
+	MaxFiles      int                    // Maximum number of files (for multiple uploads)
+	StripMetadata bool                   // Remove EXIF, XMP and text metadata from images
+	Variants      []string               // Formats JPEG and PNG images are also saved in
+	Archive       filesafe.ArchiveLimits // Limits for ZIP and gzip files
 }
 
-// DefaultFileUploadConfig returns a default configuration
-func DefaultFileUploadConfig() FileUploadConfig {
+// NewFileUploadConfig returns the middleware configuration for an upload
+// profile from uploads
+func NewFileUploadConfig(uploads config.UploadConfig, profile config.UploadProfile) FileUploadConfig {
 	return FileUploadConfig{
-		MaxFileSize:  10 << 20, // 10MB
-		AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "application/pdf"},
-		AllowedExts:  []string{".jpg", ".jpeg", ".png", ".gif", ".pdf"},
-		UploadPath:   "./uploads",
-		FieldName:    "file",
-		Required:     true,
-		MaxFiles:     1,
+		MaxFileSize:   profile.MaxFileSize,
+		AllowedTypes:  profile.AllowedTypes,
+		AllowedExts:   profile.AllowedExts,
+		KeyPrefix:     uploads.KeyPrefix(profile),
+		TempDir:       uploads.TempDir,
+		FieldName:     profile.FieldName,
+		Required:      profile.Required,
+		MaxFiles:      profile.MaxFiles,
+		StripMetadata: !profile.KeepMetadata,
+		Variants:      profile.Variants,
+		Archive: filesafe.ArchiveLimits{
+			MaxEntries: uploads.ArchiveMaxEntries,
+			MaxBytes:   uploads.ArchiveMaxBytes,
+			MaxRatio:   uploads.ArchiveMaxRatio,
+		},
 	}
 }
 
-// ImageUploadConfig returns configuration for image uploads only
-func ImageUploadConfig() FileUploadConfig {
-	return FileUploadConfig{
-		MaxFileSize:  5 << 20, // 5MB
-		AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
-		AllowedExts:  []string{".jpg", ".jpeg", ".png", ".gif", ".webp"},
-		UploadPath:   "./uploads/images",
-		FieldName:    "image",
-		Required:     true,
-		MaxFiles:     1,
+// uploadStorage is where uploaded files are saved
+var uploadStorage storage.Storage
+
+// SetUploadStorage makes FileUploadMiddleware save files to store
+func SetUploadStorage(store storage.Storage) {
+	uploadStorage = store
+}
+
+// uploadScanner scans uploaded files for viruses when set
+var uploadScanner *clamav.Client
+
+// SetUploadScanner makes FileUploadMiddleware scan every file with scanner
+// before it is saved, refusing infected files
+func SetUploadScanner(scanner *clamav.Client) {
+	uploadScanner = scanner
+}
+
+// uploadTranscoder saves the variants upload profiles ask for when set
+var uploadTranscoder *imaging.Transcoder
+
+// SetUploadTranscoder makes FileUploadMiddleware save images in the
+// formats of config.Variants with transcoder, as well as the original
+func SetUploadTranscoder(transcoder *imaging.Transcoder) {
+	uploadTranscoder = transcoder
+}
+
+type uploadProgressKey struct{}
+
+// WithUploadProgress has the checks and saving of a file done with ctx
+// call report with each stage they reach, such as models.UploadStageScanning
+func WithUploadProgress(ctx context.Context, report func(stage string)) context.Context {
+	return context.WithValue(ctx, uploadProgressKey{}, report)
+}
+
+// reportStage passes stage to the function set with WithUploadProgress
+func reportStage(ctx context.Context, stage string) {
+	if report, ok := ctx.Value(uploadProgressKey{}).(func(stage string)); ok {
+		report(stage)
 	}
 }
 
-// DocumentUploadConfig returns configuration for document uploads
-func DocumentUploadConfig() FileUploadConfig {
-	return FileUploadConfig{
-		MaxFileSize:  20 << 20, // 20MB
-		AllowedTypes: []string{"application/pdf", "application/msword", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
-		AllowedExts:  []string{".pdf", ".doc", ".docx"},
-		UploadPath:   "./uploads/documents",
-		FieldName:    "document",
-		Required:     true,
-		MaxFiles:     1,
+// UploadedFile is a file FileUploadMiddleware saved for the handler
+type UploadedFile struct {
+	Key          string
+	OriginalName string
+	Size         int64
+	ContentType  string
+	Checksum     string               // hex SHA-256 of the saved contents
+	Scan         *models.FileScan     // nil when no scanner is set
+	Variants     []models.FileVariant // copies saved in other formats
+}
+
+// File returns the metadata to record for the saved file
+func (f UploadedFile) File() *models.File {
+	return &models.File{
+		OriginalName: f.OriginalName,
+		Key:          f.Key,
+		Size:         f.Size,
+		ContentType:  f.ContentType,
+		Checksum:     f.Checksum,
+		Scan:         f.Scan,
+		Variants:     f.Variants,
 	}
 }
 
+// GetUploadedFiles returns the files FileUploadMiddleware saved for this
+// request
+func GetUploadedFiles(ctx context.Context) []UploadedFile {
+	files, _ := ctx.Value(keyUploadedFiles).([]UploadedFile)
+	return files
+}
+
+// Limits on what a multipart upload may carry besides its files
+const (
+	// maxFormValueBytes caps the combined size of the non-file form fields
+	maxFormValueBytes = 1 << 20
+	// multipartOverhead allows for part headers and boundaries
+	multipartOverhead = 64 << 10
+	// checksumField holds the hex SHA-256 a client expects of the next file
+	checksumField = "checksum"
+)
+
+// uploadError is a failed upload and the response it gets
+type uploadError struct {
+	status  int
+	message string
+	code    string
+}
+
+func (e *uploadError) Error() string { return e.message }
+
+// FileUploadMiddleware streams the files in config.FieldName from the
+// multipart body straight to the upload storage under config.KeyPrefix,
+// then checks them there, so neither memory use nor disk use grows with
+// file size. A file is abandoned as soon as it passes config.MaxFileSize,
+// and the whole body is capped with http.MaxBytesReader. The other form
+// fields are made available to the handler as usual. Files are deleted
+// again when the handler fails the request.
 func FileUploadMiddleware(config FileUploadConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Parse multipart form
-		if err := c.Request.ParseMultipartForm(config.MaxFileSize); err != nil {
-			c.JSON(http.StatusBadRequest, models.APIResponse{
+		if uploadStorage == nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
-				Message: "Failed to parse multipart form",
-				Error:   "INVALID_FORM_DATA",
+				Message: "File storage is not configured",
+				Error:   "STORAGE_NOT_CONFIGURED",
 			})
 			c.Abort()
 			return
 		}
 
-		// Get file from form
-		form, err := c.MultipartForm()
+		saved, err := receiveUpload(c, config)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, models.APIResponse{
-				Success: false,
-				Message: "Failed to get multipart form",
-				Error:   "INVALID_FORM_DATA",
-			})
-			c.Abort()
+			AbortWithUploadError(c, err)
 			return
 		}
 
-		files := form.File[config.FieldName]
-		
-		// Check if file is required
-		if config.Required && len(files) == 0 {
-			c.JSON(http.StatusBadRequest, models.APIResponse{
-				Success: false,
-				Message: fmt.Sprintf("File field '%s' is required", config.FieldName),
-				Error:   "FILE_REQUIRED",
-			})
-			c.Abort()
-			return
+		set(c, keyUploadedFiles, saved)
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			DeleteUploadedFiles(context.WithoutCancel(c.Request.Context()), saved)
 		}
+	}
+}
 
-		// Check maximum number of files
-		if len(files) > config.MaxFiles {
-			c.JSON(http.StatusBadRequest, models.APIResponse{
-				Success: false,
-				Message: fmt.Sprintf("Maximum %d files allowed", config.MaxFiles),
-				Error:   "TOO_MANY_FILES",
-			})
-			c.Abort()
-			return
+// AbortWithUploadError responds to an upload that failed to be received
+// or saved as FileUploadMiddleware does
+func AbortWithUploadError(c *gin.Context, err error) {
+	c.JSON(UploadErrorResponse(err))
+	c.Abort()
+}
+
+// UploadErrorResponse is the status and body FileUploadMiddleware responds
+// to err with
+func UploadErrorResponse(err error) (int, models.APIResponse) {
+	uploadErr := asUploadError(err)
+	return uploadErr.status, models.APIResponse{
+		Success: false,
+		Message: uploadErr.message,
+		Error:   uploadErr.code,
+	}
+}
+
+// UploadErrorMessage is the message AbortWithUploadError responds to err
+// with
+func UploadErrorMessage(err error) string {
+	return asUploadError(err).message
+}
+
+// asUploadError returns err as an uploadError, making other errors a
+// failure to save the file
+func asUploadError(err error) *uploadError {
+	if uploadErr, ok := err.(*uploadError); ok {
+		return uploadErr
+	}
+	return &uploadError{http.StatusInternalServerError, "Failed to save file", "FILE_SAVE_FAILED"}
+}
+
+// IsUploadRejected reports whether err means the file itself was refused,
+// as opposed to failing to be checked or saved, in which case sending it
+// again may succeed
+func IsUploadRejected(err error) bool {
+	uploadErr, ok := err.(*uploadError)
+	return ok && uploadErr.status < http.StatusInternalServerError
+}
+
+// DeleteUploadedFiles removes saved files and their variants, ignoring
+// those already removed
+func DeleteUploadedFiles(ctx context.Context, files []UploadedFile) {
+	for _, file := range files {
+		keys := []string{file.Key}
+		for _, variant := range file.Variants {
+			keys = append(keys, variant.Key)
+		}
+		for _, key := range keys {
+			if err := uploadStorage.Delete(ctx, key); err != nil && err != storage.ErrNotFound {
+				log.Printf("Failed to delete upload %s: %v", key, err)
+			}
 		}
+	}
+}
 
-		// Validate files
-		for _, fileHeader := range files {
-			if err := validateFile(fileHeader, config); err != nil {
-				c.JSON(http.StatusBadRequest, models.APIResponse{
-					Success: false,
-					Message: err.Error(),
-					Error:   "FILE_VALIDATION_FAILED",
-				})
-				c.Abort()
-				return
+// receiveUpload reads the multipart body part by part and returns the
+// saved files. On failure no file is kept.
+func receiveUpload(c *gin.Context, config FileUploadConfig) (saved []UploadedFile, err error) {
+	limit := config.MaxFileSize*int64(config.MaxFiles) + maxFormValueBytes + multipartOverhead
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, &uploadError{http.StatusBadRequest, "Failed to parse multipart form", "INVALID_FORM_DATA"}
+	}
+	defer func() {
+		if err != nil {
+			DeleteUploadedFiles(context.WithoutCancel(c.Request.Context()), saved)
+		}
+	}()
+
+	values := url.Values{}
+	var valueBytes int64
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return saved, bodyError(err)
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValueBytes-valueBytes+1))
+			if err != nil {
+				return saved, bodyError(err)
+			}
+			if valueBytes += int64(len(value)); valueBytes > maxFormValueBytes {
+				return saved, &uploadError{http.StatusRequestEntityTooLarge, "Form fields are too large", "REQUEST_TOO_LARGE"}
 			}
+			values.Add(part.FormName(), string(value))
+			continue
+		}
+		// Files in other fields are skipped, as NextPart discards them
+		if part.FormName() != config.FieldName {
+			continue
+		}
+		if len(saved) == config.MaxFiles {
+			return saved, &uploadError{http.StatusBadRequest, fmt.Sprintf("Maximum %d files allowed", config.MaxFiles), "TOO_MANY_FILES"}
+		}
+		// The n-th checksum field sent so far belongs to the n-th file
+		var checksum string
+		if checksums := values[checksumField]; len(checksums) > len(saved) {
+			checksum = checksums[len(saved)]
 		}
+		file, err := receiveFile(c.Request.Context(), part, checksum, config)
+		if err != nil {
+			return saved, err
+		}
+		saved = append(saved, *file)
+	}
+	if len(values[checksumField]) > len(saved) {
+		return saved, &uploadError{http.StatusBadRequest, fmt.Sprintf("Every '%s' field must come before the file it belongs to", checksumField), "INVALID_CHECKSUM"}
+	}
+
+	// Check if file is required
+	if config.Required && len(saved) == 0 {
+		return nil, &uploadError{http.StatusBadRequest, fmt.Sprintf("File field '%s' is required", config.FieldName), "FILE_REQUIRED"}
+	}
+
+	// Hand the other fields to the handler as ParseMultipartForm would
+	c.Request.MultipartForm = &multipart.Form{Value: values, File: map[string][]*multipart.FileHeader{}}
+	c.Request.PostForm = values
+	c.Request.Form = c.Request.URL.Query()
+	for key, vs := range values {
+		c.Request.Form[key] = append(c.Request.Form[key], vs...)
+	}
+	return saved, nil
+}
+
+// receiveFile checks the name and first bytes of one file part and
+// streams it to the upload storage under config.KeyPrefix. A checksum,
+// when given, must match what was received.
+func receiveFile(ctx context.Context, part *multipart.Part, checksum string, config FileUploadConfig) (*UploadedFile, error) {
+	filename := part.FileName()
+	head, contentType, err := sniffUpload(part, filename, bodyError, config)
+	if err != nil {
+		return nil, err
+	}
+	return saveUpload(ctx, io.MultiReader(bytes.NewReader(head), part), bodyError, filename, checksum, head, contentType, config)
+}
+
+// SaveStagedUpload applies the checks of FileUploadMiddleware to a file
+// received some other way, such as a resumable upload, and saves it to the
+// upload storage under config.KeyPrefix. staged holds the whole file; it
+// is left for the caller to remove. A checksum, when given, must match it.
+func SaveStagedUpload(ctx context.Context, staged *os.File, filename, checksum string, config FileUploadConfig) (*UploadedFile, error) {
+	if uploadStorage == nil {
+		return nil, &uploadError{http.StatusInternalServerError, "File storage is not configured", "STORAGE_NOT_CONFIGURED"}
+	}
+	info, err := staged.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > config.MaxFileSize {
+		return nil, fileTooLarge(config)
+	}
+	if _, err := staged.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	readErr := func(err error) error { return err }
+	head, contentType, err := sniffUpload(staged, filename, readErr, config)
+	if err != nil {
+		return nil, err
+	}
+	return saveUpload(ctx, io.MultiReader(bytes.NewReader(head), staged), readErr, filename, checksum, head, contentType, config)
+}
+
+// sniffUpload checks the extension of filename and the type detected from
+// the first bytes of r, and returns those bytes and the type. readErr maps
+// a failure to read r to the response.
+func sniffUpload(r io.Reader, filename string, readErr func(error) error, config FileUploadConfig) ([]byte, string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !contains(config.AllowedExts, ext) {
+		return nil, "", validationError("file extension '%s' not allowed. Allowed extensions: %v", ext, config.AllowedExts)
+	}
+	head := make([]byte, filesafe.SniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return nil, "", validationError("failed to read file for validation")
+		}
+		return nil, "", readErr(err)
+	}
+	head = head[:n]
+	contentType := filesafe.DetectType(head)
+	if err := checkType(contentType, config); err != nil {
+		return nil, "", err
+	}
+	return head, contentType, nil
+}
+
+// errUploadTooLarge stops an upload stream past the size limit
+var errUploadTooLarge = errors.New("upload exceeds the size limit")
 
-		// Save files and store normalized paths in context
-		var savedPaths []string
-		for _, fileHeader := range files {
-			// Create upload directory if it doesn't exist
-			if err := os.MkdirAll(config.UploadPath, 0755); err != nil {
-				c.JSON(http.StatusInternalServerError, models.APIResponse{
-					Success: false,
-					Message: "Failed to create upload directory",
-					Error:   "DIRECTORY_CREATION_FAILED",
-				})
-				c.Abort()
-				return
+// uploadStream passes a file on to the upload storage, hashing it and
+// stopping one byte past the size limit. It keeps the error reading the
+// file failed with, as storage backends may wrap or replace it.
+type uploadStream struct {
+	r     io.Reader
+	limit int64
+	size  int64
+	hash  hash.Hash
+	err   error
+}
+
+func newUploadStream(r io.Reader, limit int64) *uploadStream {
+	return &uploadStream{r: io.LimitReader(r, limit+1), limit: limit, hash: sha256.New()}
+}
+
+func (s *uploadStream) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.size += int64(n)
+	s.hash.Write(p[:n])
+	if s.size > s.limit {
+		return n, errUploadTooLarge
+	}
+	if err != nil && err != io.EOF && s.err == nil {
+		s.err = err
+	}
+	return n, err
+}
+
+// saveUpload streams a file whose name and first bytes passed their checks
+// to the upload storage, hashing it and having it scanned on the way, so
+// it is never held in memory or staged on disk. The stored file is then
+// checked in place and, when it is sanitized or its metadata stripped,
+// replaced by the cleaned copy. r yields the whole file, head included,
+// and readErr maps a failure to read it to the response. Nothing is kept
+// when a check fails; until then the file sits under a key nobody knows.
+func saveUpload(ctx context.Context, r io.Reader, readErr func(error) error, filename, checksum string, head []byte, contentType string, config FileUploadConfig) (upload *UploadedFile, err error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	key := storage.NewKey(config.KeyPrefix, filename)
+	defer func() {
+		if err != nil {
+			if err := uploadStorage.Delete(context.WithoutCancel(ctx), key); err != nil && err != storage.ErrNotFound {
+				log.Printf("Failed to delete refused upload %s: %v", key, err)
 			}
+		}
+	}()
+
+	reportStage(ctx, models.UploadStageSaving)
+	stream := newUploadStream(r, config.MaxFileSize)
+	scan, scanErr, saveErr := saveStream(ctx, key, stream, filename, contentType)
+	switch {
+	case stream.size > config.MaxFileSize:
+		return nil, fileTooLarge(config)
+	case stream.err != nil:
+		return nil, readErr(stream.err)
+	case saveErr != nil:
+		return nil, saveError(saveErr)
+	case scanErr != nil:
+		return nil, scanErr
+	}
+	size, hash := stream.size, stream.hash
+	if err := verifyChecksum(hash, checksum); err != nil {
+		return nil, err
+	}
 
-			// Generate unique filename
-			filename := generateUniqueFilename(fileHeader.Filename)
-			path := filepath.Join(config.UploadPath, filename)
-
-			// Save file
-			if err := c.SaveUploadedFile(fileHeader, path); err != nil {
-				c.JSON(http.StatusInternalServerError, models.APIResponse{
-					Success: false,
-					Message: "Failed to save file",
-					Error:   "FILE_SAVE_FAILED",
-				})
-				c.Abort()
-				return
+	stored, err := uploadStorage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer stored.Close()
+	file, ok := stored.(multipart.File)
+	if !ok {
+		return nil, fmt.Errorf("storage returned a %T, which cannot be checked in place", stored)
+	}
+
+	reportStage(ctx, models.UploadStageChecking)
+	storedType := contentType
+	if contentType == "application/zip" {
+		contentType = filesafe.DetectZipType(file, size)
+		if !filesafe.TypeAllowed(config.AllowedTypes, contentType) {
+			return nil, validationError("file type '%s' not allowed. Allowed types: %v", contentType, config.AllowedTypes)
+		}
+	}
+	sanitized, err := checkContent(file, size, ext, contentType, head, config)
+	if err != nil {
+		return nil, validationError("%s", err.Error())
+	}
+
+	switch {
+	case sanitized != nil:
+		hash.Reset()
+		hash.Write(sanitized)
+		key, size, err = replaceStored(ctx, key, filename, contentType, bytes.NewReader(sanitized))
+	case config.StripMetadata && imaging.StripsMetadata(contentType):
+		// Save the image without its metadata in place of the original
+		reportStage(ctx, models.UploadStageStripping)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		hash.Reset()
+		pr, pw := io.Pipe()
+		stripped := make(chan error, 1)
+		go func() {
+			err := imaging.StripMetadata(io.MultiWriter(pw, hash), file, contentType)
+			pw.CloseWithError(err)
+			stripped <- err
+		}()
+		key, size, err = replaceStored(ctx, key, filename, contentType, pr)
+		// let the stripper finish should saving stop reading early
+		pr.CloseWithError(io.ErrClosedPipe)
+		if stripErr := <-stripped; stripErr != nil {
+			if stripErr == imaging.ErrUnsupportedImage || stripErr == imaging.ErrImageTooLarge {
+				return nil, validationError("image file is corrupt or too large")
 			}
+			return nil, stripErr
+		}
+	case contentType != storedType:
+		// Save the archive again under the type of the format built on it
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		key, size, err = replaceStored(ctx, key, filename, contentType, file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	variants := saveVariantsOf(ctx, key, size, contentType, config)
+	return &UploadedFile{Key: key, OriginalName: filename, Size: size, ContentType: contentType, Checksum: hex.EncodeToString(hash.Sum(nil)), Scan: scan, Variants: variants}, nil
+}
+
+// saveStream saves stream under key, feeding it to the upload scanner
+// along the way when one is set. It returns the scan and the errors of
+// scanning and of saving.
+func saveStream(ctx context.Context, key string, stream io.Reader, filename, contentType string) (*models.FileScan, error, error) {
+	if uploadScanner == nil {
+		return nil, nil, uploadStorage.Save(ctx, key, stream, -1, contentType)
+	}
+	// the file is scanned as it is saved
+	reportStage(ctx, models.UploadStageScanning)
+	pr, pw := io.Pipe()
+	type scanResult struct {
+		scan *models.FileScan
+		err  error
+	}
+	scanned := make(chan scanResult, 1)
+	go func() {
+		scan, err := scanFile(ctx, pr, filename)
+		// keep the upload flowing should the scanner stop reading early
+		io.Copy(io.Discard, pr)
+		scanned <- scanResult{scan, err}
+	}()
+	err := uploadStorage.Save(ctx, key, io.TeeReader(stream, pw), -1, contentType)
+	pw.CloseWithError(err)
+	result := <-scanned
+	return result.scan, result.err, err
+}
 
-			// Convert path to forward slashes for URL compatibility
-			savedPaths = append(savedPaths, filepath.ToSlash(path))
+// replaceStored saves the contents of r under a new key for filename and
+// deletes the file under key, returning the new key and the size saved.
+// On failure the file under key is left as it is.
+func replaceStored(ctx context.Context, key, filename, contentType string, r io.Reader) (string, int64, error) {
+	replacement := storage.NewKey(path.Dir(key), filename)
+	counted := &countingReader{r: r}
+	if err := uploadStorage.Save(ctx, replacement, counted, -1, contentType); err != nil {
+		if err := uploadStorage.Delete(context.WithoutCancel(ctx), replacement); err != nil && err != storage.ErrNotFound {
+			log.Printf("Failed to delete upload %s: %v", replacement, err)
 		}
-		c.Set("uploadedFiles", savedPaths)
-		c.Set("uploadConfig", config)
-		c.Next()
+		return key, 0, saveError(err)
+	}
+	if err := uploadStorage.Delete(ctx, key); err != nil && err != storage.ErrNotFound {
+		log.Printf("Failed to delete replaced upload %s: %v", key, err)
+	}
+	return replacement, counted.n, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// saveError is the response to the upload storage failing to save a file
+func saveError(err error) error {
+	if err == nil {
+		return nil
 	}
+	log.Printf("Failed to save upload: %v", err)
+	return &uploadError{http.StatusInternalServerError, "Failed to save file", "FILE_SAVE_FAILED"}
 }
 
-// validateFile validates a single file against the configuration
-func validateFile(fileHeader *multipart.FileHeader, config FileUploadConfig) error {
-	// Check file size
-	if fileHeader.Size > config.MaxFileSize {
-		return fmt.Errorf("file size exceeds maximum allowed size of %d bytes", config.MaxFileSize)
+// saveVariantsOf saves the variants config asks for of the image stored
+// under key. The encoders only work on files, so the image is copied to
+// config.TempDir for them, and only when there are variants to make.
+func saveVariantsOf(ctx context.Context, key string, size int64, contentType string, config FileUploadConfig) []models.FileVariant {
+	if uploadTranscoder == nil || !uploadTranscoder.Transcodes(contentType) || len(config.Variants) == 0 {
+		return nil
 	}
+	src, err := spoolStored(ctx, key, config)
+	if err != nil {
+		log.Printf("Failed to copy %s for its variants: %v", key, err)
+		return nil
+	}
+	defer os.Remove(src)
+	return saveVariants(ctx, src, key, size, contentType, config)
+}
 
-	// Check file extension
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	if !contains(config.AllowedExts, ext) {
-		return fmt.Errorf("file extension '%s' not allowed. Allowed extensions: %v", ext, config.AllowedExts)
+// spoolStored copies the file stored under key to a file in
+// config.TempDir and returns its name
+func spoolStored(ctx context.Context, key string, config FileUploadConfig) (string, error) {
+	stored, err := uploadStorage.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer stored.Close()
+	if err := os.MkdirAll(config.TempDir, 0755); err != nil {
+		return "", err
 	}
+	spooled, err := os.CreateTemp(config.TempDir, "variant-src-*"+path.Ext(key))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(spooled, stored); err != nil {
+		spooled.Close()
+		os.Remove(spooled.Name())
+		return "", err
+	}
+	if err := spooled.Close(); err != nil {
+		os.Remove(spooled.Name())
+		return "", err
+	}
+	return spooled.Name(), nil
+}
+
+// saveVariants transcodes the image in the file src, saved under key, to
+// the formats of config.Variants and saves those smaller than the
+// original next to it. Variants only save bandwidth, so one that fails is
+// logged and left out rather than failing the upload.
+func saveVariants(ctx context.Context, src, key string, size int64, contentType string, config FileUploadConfig) []models.FileVariant {
+	if uploadTranscoder == nil || !uploadTranscoder.Transcodes(contentType) || len(config.Variants) == 0 {
+		return nil
+	}
+	reportStage(ctx, models.UploadStageTranscoding)
+	var variants []models.FileVariant
+	for _, format := range config.Variants {
+		if !uploadTranscoder.Available(format) {
+			continue
+		}
+		variant, err := saveVariant(ctx, src, key, size, format, config)
+		if err != nil {
+			log.Printf("Failed to save %s variant of %s: %v", format, key, err)
+			continue
+		}
+		if variant != nil {
+			variants = append(variants, *variant)
+		}
+	}
+	return variants
+}
 
-	// Open file to check MIME type
-	file, err := fileHeader.Open()
+// saveVariant saves the image in src as format under key with its
+// extension replaced. It returns nil when the variant is no smaller.
+func saveVariant(ctx context.Context, src, key string, size int64, format string, config FileUploadConfig) (*models.FileVariant, error) {
+	placeholder, err := os.CreateTemp(config.TempDir, "variant-*."+format)
 	if err != nil {
-		return fmt.Errorf("failed to open file for validation")
+		return nil, err
 	}
-	defer file.Close()
+	placeholder.Close()
+	defer os.Remove(placeholder.Name())
+	if err := uploadTranscoder.Transcode(ctx, src, placeholder.Name(), format); err != nil {
+		return nil, err
+	}
+	// the encoder may have replaced the file rather than written to it
+	transcoded, err := os.Open(placeholder.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer transcoded.Close()
+	info, err := transcoded.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 || info.Size() >= size {
+		return nil, nil
+	}
+	variant := &models.FileVariant{
+		Format:      format,
+		Key:         strings.TrimSuffix(key, filepath.Ext(key)) + "." + format,
+		Size:        info.Size(),
+		ContentType: imaging.FormatContentType(format),
+	}
+	if err := uploadStorage.Save(ctx, variant.Key, transcoded, variant.Size, variant.ContentType); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
 
-	// Read first 512 bytes to detect content type
-	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
+// scanFile has the upload scanner check the file as it was uploaded. It
+// returns nil without a scanner. Files that cannot be scanned are refused
+// rather than kept unchecked.
+func scanFile(ctx context.Context, file io.Reader, filename string) (*models.FileScan, error) {
+	if uploadScanner == nil {
+		return nil, nil
+	}
+	result, err := uploadScanner.Scan(ctx, file)
 	if err != nil {
-		return fmt.Errorf("failed to read file for validation")
+		log.Printf("Failed to scan upload %s: %v", filename, err)
+		return nil, &uploadError{http.StatusServiceUnavailable, "File could not be scanned for viruses, try again later", "SCAN_FAILED"}
+	}
+	if result.Infected {
+		log.Printf("Refused infected upload %s: %s", filename, result.Signature)
+		return nil, &uploadError{http.StatusUnprocessableEntity, fmt.Sprintf("file is infected with %s", result.Signature), "FILE_INFECTED"}
 	}
+	return &models.FileScan{Engine: "clamav", Result: models.FileScanClean, ScannedAt: time.Now()}, nil
+}
 
-	// Detect content type
-	contentType := http.DetectContentType(buffer)
-	if !contains(config.AllowedTypes, contentType) {
-		return fmt.Errorf("file type '%s' not allowed. Allowed types: %v", contentType, config.AllowedTypes)
+// checkType checks the type detected from a file's first bytes against
+// config. ZIP archives pass, since the formats built on them, such as
+// Office documents, are only told apart once the whole file is staged.
+func checkType(contentType string, config FileUploadConfig) error {
+	if contentType == "application/zip" || filesafe.TypeAllowed(config.AllowedTypes, contentType) {
+		return nil
 	}
+	return validationError("file type '%s' not allowed. Allowed types: %v", contentType, config.AllowedTypes)
+}
+
+// verifyChecksum compares the hash of a received file with the hex SHA-256
+// the client sent, if any. It is checked before sanitizing, which changes
+// the contents.
+func verifyChecksum(hash hash.Hash, checksum string) error {
+	if checksum == "" || strings.EqualFold(strings.TrimSpace(checksum), hex.EncodeToString(hash.Sum(nil))) {
+		return nil
+	}
+	return &uploadError{http.StatusBadRequest, "file does not match its checksum", "CHECKSUM_MISMATCH"}
+}
+
+func fileTooLarge(config FileUploadConfig) error {
+	return &uploadError{http.StatusRequestEntityTooLarge, fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", config.MaxFileSize), "FILE_TOO_LARGE"}
+}
+
+// bodyError maps a failure to read the request body to its response
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &uploadError{http.StatusRequestEntityTooLarge, "Request body is too large", "REQUEST_TOO_LARGE"}
+	}
+	return &uploadError{http.StatusBadRequest, "Failed to parse multipart form", "INVALID_FORM_DATA"}
+}
+
+func validationError(format string, args ...any) error {
+	return &uploadError{http.StatusBadRequest, fmt.Sprintf(format, args...), "FILE_VALIDATION_FAILED"}
+}
+
+// checkContent applies the policies for file types that are dangerous to
+// store as uploaded: SVG images are sanitized, archives, Office documents
+// included, are checked for decompression bombs and other files must not
+// pass for another type as well
+func checkContent(file multipart.File, size int64, ext, contentType string, head []byte, config FileUploadConfig) ([]byte, error) {
+	switch {
+	case ext == ".svg" || contentType == "image/svg+xml" || (strings.HasPrefix(contentType, "text/") && filesafe.IsSVG(head)):
+		var clean bytes.Buffer
+		if err := filesafe.SanitizeSVG(&clean, file); err != nil {
+			return nil, fmt.Errorf("SVG file is not a well-formed SVG document")
+		}
+		return clean.Bytes(), nil
+	case filesafe.IsZip(contentType):
+		if err := filesafe.CheckZip(file, size, config.Archive); err != nil {
+			return nil, archiveError(err, config.Archive)
+		}
+	case contentType == "application/gzip":
+		if err := filesafe.CheckGzip(file, size, config.Archive); err != nil {
+			return nil, archiveError(err, config.Archive)
+		}
+	default:
+		if err := filesafe.CheckPolyglot(file, size, head, contentType); err != nil {
+			return nil, fmt.Errorf("file is not a plain %s file: it also holds another type of content", contentType)
+		}
+	}
+	return nil, nil
+}
 
-	return nil
+// archiveError describes why an archive was refused
+func archiveError(err error, limits filesafe.ArchiveLimits) error {
+	switch err {
+	case filesafe.ErrTooManyEntries:
+		return fmt.Errorf("archive has more than %d entries", limits.MaxEntries)
+	case filesafe.ErrArchiveTooLarge:
+		return fmt.Errorf("archive expands beyond %d bytes", limits.MaxBytes)
+	case filesafe.ErrCompressionRatio:
+		return fmt.Errorf("archive expands to more than %d times its size", limits.MaxRatio)
+	default:
+		return fmt.Errorf("archive is corrupt")
+	}
 }
 
 // contains checks if a slice contains a string
@@ -220,21 +797,3 @@ func contains(slice []string, item string) bool {
 	// return false
 	return slices.Contains(slice, item)
 }
-
-// SingleImageUpload - Middleware for single image upload
-func SingleImageUpload() gin.HandlerFunc {
-	return FileUploadMiddleware(ImageUploadConfig())
-}
-
-// SingleDocumentUpload - Middleware for single document upload
-func SingleDocumentUpload() gin.HandlerFunc {
-	return FileUploadMiddleware(DocumentUploadConfig())
-}
-
-// MultipleImageUpload - Middleware for multiple image uploads (max 5)
-func MultipleImageUpload(maxFiles int) gin.HandlerFunc {
-	config := ImageUploadConfig()
-	config.MaxFiles = maxFiles
-	config.FieldName = "images"
-	return FileUploadMiddleware(config)
-}
\ No newline at end of file
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Position names a point in the request pipeline where plugins run
+type Position string
+
+const (
+	// PreAuth runs on every request, after logging, CORS and recovery but
+	// before any route middleware
+	PreAuth Position = "pre-auth"
+	// PostAuth runs as soon as a user, client or action token has been
+	// authenticated, with the identity already in the context
+	PostAuth Position = "post-auth"
+	// PreHandler runs after all route middleware, right before the handler
+	PreHandler Position = "pre-handler"
+)
+
+type plugin struct {
+	name    string
+	handler gin.HandlerFunc
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = map[Position][]plugin{}
+)
+
+// RegisterPlugin inserts handler at position for every route, so downstream
+// projects can extend the pipeline without editing the route setup. Plugins
+// run in registration order and inline with the surrounding middleware: to
+// stop a request a plugin writes a response and calls c.Abort(), and it
+// must not call c.Next(). Registering the same name twice at one position
+// panics.
+func RegisterPlugin(position Position, name string, handler gin.HandlerFunc) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	for _, p := range plugins[position] {
+		if p.name == name {
+			panic(fmt.Sprintf("middleware: plugin %q already registered at %s", name, position))
+		}
+	}
+	plugins[position] = append(plugins[position], plugin{name: name, handler: handler})
+}
+
+// runPlugins runs the plugins at position and reports whether the request
+// may continue
+func runPlugins(c *gin.Context, position Position) bool {
+	pluginsMu.RLock()
+	registered := plugins[position]
+	pluginsMu.RUnlock()
+
+	for _, p := range registered {
+		p.handler(c)
+		if c.IsAborted() {
+			return false
+		}
+	}
+	return true
+}
+
+// PreAuthPlugins is the global middleware that runs the PreAuth plugins
+func PreAuthPlugins() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if runPlugins(c, PreAuth) {
+			c.Next()
+		}
+	}
+}
+
+// WithPlugins wraps a route handler so the PreHandler plugins run right
+// before it
+func WithPlugins(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if runPlugins(c, PreHandler) {
+			handler(c)
+		}
+	}
+}
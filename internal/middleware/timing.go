@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"expvar"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"user-management-api/pkg/router"
+	"user-management-api/pkg/timing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeTiming publishes, per route, the number of requests and the total
+// microseconds spent in each layer, on /debug/vars
+var (
+	routeTiming   = expvar.NewMap("route_timing")
+	routeTimingMu sync.Mutex
+)
+
+// responseClasses counts responses of matched routes by status class (2xx,
+// 3xx, 4xx, 5xx), also on /debug/vars
+var responseClasses = expvar.NewMap("response_classes")
+
+// LayerTiming puts a timing.Budget in every request's context and, once
+// the request is done, adds its per-layer breakdown to the route_timing
+// metric. With logTimings each request's breakdown is also logged. It must
+// be the first middleware after logging so that the rest count as
+// middleware time.
+func LayerTiming(logTimings bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, budget := timing.WithBudget(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		stop := timing.Track(ctx, timing.LayerMiddleware)
+		c.Next()
+		stop()
+
+		total, spent := budget.Finish()
+		route := c.FullPath()
+		if route == "" {
+			// Unmatched paths would give every scanner probe its own entry
+			return
+		}
+		route = c.Request.Method + " " + route
+		recordRouteTiming(route, total, spent)
+		responseClasses.Add(fmt.Sprintf("%dxx", c.Writer.Status()/100), 1)
+		if logTimings {
+			log.Printf("timing: %s %d %s", route, c.Writer.Status(), formatTiming(total, spent))
+		}
+	}
+}
+
+// TimedHandler times a handler at its boundary instead of in every service
+// method: the call is charged to the service layer, apart from reading the
+// JSON body and writing the response through the Context, which are
+// charged to the handler layer, as is binding with handlers.Bind. Queries the services run are charged to
+// the repository layer as usual.
+func TimedHandler(handler router.HandlerFunc) router.HandlerFunc {
+	return func(c router.Context) {
+		defer timing.Track(c.Request().Context(), timing.LayerService)()
+		handler(timedContext{c})
+	}
+}
+
+// timedContext charges the request and response work of a handler to the
+// handler layer
+type timedContext struct {
+	router.Context
+}
+
+func (c timedContext) BindJSON(v any) error {
+	defer timing.Track(c.Request().Context(), timing.LayerHandler)()
+	return c.Context.BindJSON(v)
+}
+
+func (c timedContext) JSON(status int, v any) {
+	defer timing.Track(c.Request().Context(), timing.LayerHandler)()
+	c.Context.JSON(status, v)
+}
+
+func (c timedContext) Redirect(status int, location string) {
+	defer timing.Track(c.Request().Context(), timing.LayerHandler)()
+	c.Context.Redirect(status, location)
+}
+
+func recordRouteTiming(route string, total time.Duration, spent map[string]time.Duration) {
+	routeTimingMu.Lock()
+	stats, ok := routeTiming.Get(route).(*expvar.Map)
+	if !ok {
+		stats = new(expvar.Map)
+		routeTiming.Set(route, stats)
+	}
+	routeTimingMu.Unlock()
+
+	stats.Add("requests", 1)
+	stats.Add("total_us", total.Microseconds())
+	for _, layer := range timing.Layers {
+		stats.Add(layer+"_us", spent[layer].Microseconds())
+	}
+}
+
+// ResponseClassCounts returns how many responses of each status class have
+// been sent since startup
+func ResponseClassCounts() map[string]int64 {
+	counts := make(map[string]int64)
+	responseClasses.Do(func(kv expvar.KeyValue) {
+		if n, ok := kv.Value.(*expvar.Int); ok {
+			counts[kv.Key] = n.Value()
+		}
+	})
+	return counts
+}
+
+func formatTiming(total time.Duration, spent map[string]time.Duration) string {
+	parts := []string{fmt.Sprintf("total=%s", total)}
+	for _, layer := range timing.Layers {
+		parts = append(parts, fmt.Sprintf("%s=%s", layer, spent[layer]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// FieldChange is one field of a record moving from Old to New. Old is nil
+// on creation and New on deletion.
+type FieldChange struct {
+	Field string `json:"field" bson:"field" example:"role"`
+	Old   any    `json:"old" bson:"old" swaggertype:"string" example:"user"`
+	New   any    `json:"new" bson:"new" swaggertype:"string" example:"admin"`
+	// Redacted is set when the values were withheld
+	Redacted bool `json:"redacted,omitempty" bson:"redacted,omitempty"`
+}
+
+// AuditLog records one create, update or delete of a record with its
+// field-level diff. ActorID made the change acting as EffectiveID, which
+// differ under impersonation and delegation.
+type AuditLog struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Resource    string             `json:"resource" bson:"resource" example:"users"`
+	ResourceID  primitive.ObjectID `json:"resource_id" bson:"resource_id"`
+	Action      string             `json:"action" bson:"action" example:"update"`
+	Changes     []FieldChange      `json:"changes" bson:"changes"`
+	ActorID     primitive.ObjectID `json:"actor_id" bson:"actor_id"`
+	EffectiveID primitive.ObjectID `json:"effective_id" bson:"effective_id"`
+	ActorType   string             `json:"actor_type,omitempty" bson:"actor_type,omitempty" example:"user"`
+	ClientID    string             `json:"client_id,omitempty" bson:"client_id,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Auth event types
+const (
+	AuthEventLogin          = "login"
+	AuthEventLoginFailed    = "login_failed"
+	AuthEventLogout         = "logout"
+	AuthEventPasswordChange = "password_change"
+	AuthEventTokenRefresh   = "token_refresh"
+	AuthEventImpersonation  = "impersonation"
+)
+
+// AuthEvent is one entry of the authentication audit log. UserID is the
+// effective principal, the user the action was performed as; it is unset
+// for failed attempts against unknown accounts, and Email keeps what was
+// tried. ActorID is who actually acted: the same user, an impersonating
+// admin or a delegate. OAuth clients are named by ClientID instead.
+type AuthEvent struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Type      string              `json:"type" bson:"type" example:"login"`
+	UserID    *primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	ActorID   *primitive.ObjectID `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	ActorType string              `json:"actor_type,omitempty" bson:"actor_type,omitempty" example:"user"`
+	ClientID  string              `json:"client_id,omitempty" bson:"client_id,omitempty"`
+	Email     string              `json:"email,omitempty" bson:"email,omitempty" example:"johndoe@example.com"`
+	Method    string              `json:"method,omitempty" bson:"method,omitempty" example:"password"`
+	Reason    string              `json:"reason,omitempty" bson:"reason,omitempty" example:"INVALID_CREDENTIALS"`
+	IP        string              `json:"ip,omitempty" bson:"ip,omitempty" example:"203.0.113.7"`
+	UserAgent string              `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// AuthEventQuery filters the auth event log; every filter is optional.
+// UserID matches the effective principal and ActorID the acting one.
+type AuthEventQuery struct {
+	UserID  string    `form:"user_id" validate:"omitempty,len=24,hexadecimal"`
+	ActorID string    `form:"actor_id" validate:"omitempty,len=24,hexadecimal"`
+	Type    string    `form:"type" validate:"omitempty,oneof=login login_failed logout password_change token_refresh impersonation"`
+	From    time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page    int       `form:"page" validate:"omitempty,min=1"`
+	Limit   int       `form:"limit" validate:"omitempty,min=1,max=100"`
+}
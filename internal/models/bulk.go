@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bulk user operations
+const (
+	BulkActionDelete     = "delete"
+	BulkActionDeactivate = "deactivate"
+)
+
+// BulkUserRequest lists the users a bulk operation applies to
+type BulkUserRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100,dive,len=24,hexadecimal" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+}
+
+// BulkOperation is the audit record of one bulk operation: who ran it,
+// the users it changed and the requested IDs that did not exist
+type BulkOperation struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Action      string               `json:"action" bson:"action" example:"deactivate"`
+	ActorID     primitive.ObjectID   `json:"actor_id" bson:"actor_id"`
+	EffectiveID primitive.ObjectID   `json:"effective_id" bson:"effective_id"`
+	ActorType   string               `json:"actor_type,omitempty" bson:"actor_type,omitempty" example:"user"`
+	ClientID    string               `json:"client_id,omitempty" bson:"client_id,omitempty"`
+	UserIDs     []primitive.ObjectID `json:"user_ids" bson:"user_ids"`
+	Missing     []string             `json:"missing" bson:"missing"`
+	CreatedAt   time.Time            `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OAuthClient is a registered client for the client_credentials grant, and
+// for the authorization_code grant when it has redirect URIs. Only a hash of
+// the secret is stored.
+type OAuthClient struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ClientID     string             `json:"client_id" bson:"client_id"`
+	SecretHash   string             `json:"-" bson:"secret_hash"`
+	Name         string             `json:"name" bson:"name"`
+	Scopes       []string           `json:"scopes" bson:"scopes"`
+	RedirectURIs []string           `json:"redirect_uris,omitempty" bson:"redirect_uris,omitempty"`
+	IsActive     bool               `json:"is_active" bson:"is_active"`
+	CreatedBy    primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateClientRequest struct {
+	Name   string   `json:"name" validate:"required,min=3,max=100" example:"billing-service"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,required,max=64" example:"users:read"`
+	// RedirectURIs enables the authorization_code grant for this client
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,max=10,dive,url" example:"https://app.example.com/callback"`
+}
+
+// CreateClientResponse is the only time the client secret is revealed
+type CreateClientResponse struct {
+	Client       *OAuthClient `json:"client"`
+	ClientSecret string       `json:"client_secret" example:"4f3c2a..."`
+}
+
+// TokenRequest is the RFC 6749 token request (form encoded)
+type TokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"`
+	// authorization_code grant
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// AuthorizeRequest is the RFC 6749 authorization request. PKCE with S256 is
+// required.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// AuthorizationCode is a short-lived, single-use code issued after the user
+// consents. Only a hash of the code is stored.
+type AuthorizationCode struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	CodeHash      string             `bson:"code_hash"`
+	ClientID      string             `bson:"client_id"`
+	UserID        primitive.ObjectID `bson:"user_id"`
+	RedirectURI   string             `bson:"redirect_uri"`
+	Scopes        []string           `bson:"scopes"`
+	CodeChallenge string             `bson:"code_challenge"`
+	ExpiresAt     time.Time          `bson:"expires_at"`
+	CreatedAt     time.Time          `bson:"created_at"`
+}
+
+// TokenResponse is the RFC 6749 token response
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int    `json:"expires_in" example:"3600"`
+	Scope       string `json:"scope" example:"users:read"`
+}
+
+// OAuthError is the RFC 6749 error response
+type OAuthError struct {
+	Error            string `json:"error" example:"invalid_client"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
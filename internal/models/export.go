@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportTemplate is a saved column selection for the user export, owned by
+// the admin who created it
+type ExportTemplate struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OwnerID    primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	Name       string             `json:"name" bson:"name"`
+	Columns    []string           `json:"columns" bson:"columns"`
+	DateFormat string             `json:"date_format" bson:"date_format"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateExportTemplateRequest struct {
+	Name       string   `json:"name" validate:"required,min=1,max=100" example:"Contact list"`
+	Columns    []string `json:"columns" validate:"required,min=1,max=50,dive,required" example:"email,first_name,last_name"`
+	DateFormat string   `json:"date_format" validate:"omitempty,oneof=rfc3339 date datetime unix" enums:"rfc3339,date,datetime,unix" example:"date"`
+}
+
+// ExportUsersQuery selects the format and columns of a user export and the
+// users in it. Columns come from a saved template or are given explicitly;
+// explicit values override the template.
+type ExportUsersQuery struct {
+	Format     string     `form:"format" validate:"omitempty,oneof=csv json"`
+	Template   string     `form:"template" validate:"omitempty,len=24,hexadecimal"`
+	Columns    string     `form:"columns" validate:"omitempty,max=1000"`
+	DateFormat string     `form:"date_format" validate:"omitempty,oneof=rfc3339 date datetime unix"`
+	Role       string     `form:"role" validate:"omitempty,max=50"`
+	Status     UserStatus `form:"status" validate:"omitempty,oneof=pending active suspended banned deactivated"`
+	Search     string     `form:"q" validate:"omitempty,max=200"`
+	Tags       []string   `form:"tag" validate:"omitempty,max=10,dive,max=40"`
+}
+
+// ExportColumnsResponse lists the columns the user export supports
+type ExportColumnsResponse struct {
+	Columns []string `json:"columns"`
+}
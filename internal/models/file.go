@@ -0,0 +1,170 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// File visibilities; public files are served to anyone with their URL,
+// private ones only through share links and download tokens
+const (
+	FileVisibilityPublic  = "public"
+	FileVisibilityPrivate = "private"
+)
+
+// FileScanClean is the result of a scan that found nothing
+const FileScanClean = "clean"
+
+// File statuses: a presigned upload stays pending until it is confirmed
+const (
+	FileStatusPending = "pending"
+	FileStatusReady   = "ready"
+)
+
+// File is the metadata of an uploaded file, whose contents are kept in the
+// upload storage under Key. Only public files have a URL.
+type File struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	OwnerID      primitive.ObjectID `json:"owner_id" bson:"owner_id" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	OriginalName string             `json:"original_name" bson:"original_name" example:"report.pdf"`
+	Key          string             `json:"key" bson:"key" example:"documents/report_1700000000_63a5e3e3e4b0a7e3e3e3e3e3.pdf"`
+	URL          string             `json:"url,omitempty" bson:"-"`
+	Size         int64              `json:"size" bson:"size" example:"52428800"`
+	ContentType  string             `json:"content_type" bson:"content_type" example:"application/pdf"`
+	// Checksum is the hex SHA-256 of the contents. Files with the same
+	// checksum share one stored copy, so Key can be shared too. While a
+	// presigned upload is pending it is the checksum the client announced.
+	Checksum   string    `json:"checksum,omitempty" bson:"checksum,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Visibility string    `json:"visibility" bson:"visibility" enums:"public,private" example:"public"`
+	Status     string    `json:"status" bson:"status" enums:"pending,ready" example:"ready"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+	// Scan is the virus scan the file passed; files stored while no
+	// scanner was configured have none
+	Scan *FileScan `json:"scan,omitempty" bson:"scan,omitempty"`
+	// Variants are copies of an image in other formats, saved when its
+	// upload profile asks for them and smaller than the original
+	Variants []FileVariant `json:"variants,omitempty" bson:"variants,omitempty"`
+}
+
+// FileVariant is a copy of an uploaded image in another format, such as
+// WebP or AVIF, stored under its own key
+type FileVariant struct {
+	Format      string `json:"format" bson:"format" enums:"webp,avif" example:"webp"`
+	Key         string `json:"key" bson:"key" example:"images/photo_1700000000_63a5e3e3e4b0a7e3e3e3e3e3.webp"`
+	URL         string `json:"url,omitempty" bson:"-"`
+	Size        int64  `json:"size" bson:"size" example:"48213"`
+	ContentType string `json:"content_type" bson:"content_type" example:"image/webp"`
+}
+
+// FileScan records the virus scan of a file. Infected files are refused,
+// so a recorded scan always found the file clean.
+type FileScan struct {
+	Engine    string    `json:"engine" bson:"engine" example:"clamav"`
+	Result    string    `json:"result" bson:"result" example:"clean"`
+	ScannedAt time.Time `json:"scanned_at" bson:"scanned_at"`
+}
+
+// PresignUploadRequest describes a file the client wants to upload straight
+// to storage. It is checked against the upload profile before a URL is
+// handed out.
+type PresignUploadRequest struct {
+	Filename    string `json:"filename" validate:"required,max=255" example:"report.pdf"`
+	ContentType string `json:"content_type" validate:"required,max=255" example:"application/pdf"`
+	Size        int64  `json:"size" validate:"required,min=1" example:"52428800"`
+	// Profile is the upload profile to check the file against; any when empty
+	Profile string `json:"profile" validate:"omitempty,max=64" example:"document"`
+	// Checksum is the hex SHA-256 the uploaded file must have, if given
+	Checksum string `json:"checksum" validate:"omitempty,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	// Visibility of the file once confirmed; public when empty
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public private" enums:"public,private" example:"private"`
+}
+
+// PresignUploadResponse tells the client where to PUT the file and how to
+// confirm it afterwards
+type PresignUploadResponse struct {
+	Key     string            `json:"key" example:"documents/report_1700000000_63a5e3e3e4b0a7e3e3e3e3e3.pdf"`
+	Profile string            `json:"profile" example:"document"`
+	Method  string            `json:"method" example:"PUT"`
+	URL     string            `json:"url" example:"https://bucket.s3.amazonaws.com/documents/report_1700000000_63a5e3e3e4b0a7e3e3e3e3e3.pdf?X-Amz-Signature=..."`
+	Headers map[string]string `json:"headers"`
+	// Token is passed to POST /files/presign/confirm once the file is up
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UpdateFileRequest changes who can fetch a file
+type UpdateFileRequest struct {
+	Visibility string `json:"visibility" validate:"required,oneof=public private" enums:"public,private" example:"private"`
+}
+
+// ShareFileRequest asks for a link to a file that lasts TTLSeconds, a day
+// by default
+type ShareFileRequest struct {
+	TTLSeconds int `json:"ttl_seconds" validate:"omitempty,min=60,max=604800" example:"86400"`
+}
+
+// FileShareLink lets anyone holding URL download the file until ExpiresAt,
+// whatever its visibility
+type FileShareLink struct {
+	URL       string    `json:"url" example:"https://api.example.com/api/v1/files/shared/63a5e3e3e4b0a7e3e3e3e3e3?token=..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ArchiveFilesRequest lists the files to download together as a ZIP
+type ArchiveFilesRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100,dive,len=24,hexadecimal" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+}
+
+// ConfirmUploadRequest reports that a presigned upload has finished
+type ConfirmUploadRequest struct {
+	Key     string `json:"key" validate:"required,max=1024" example:"documents/report_1700000000_63a5e3e3e4b0a7e3e3e3e3e3.pdf"`
+	Profile string `json:"profile" validate:"required,max=64" example:"document"`
+	Token   string `json:"token" validate:"required"`
+}
+
+// ResumableUpload is a file sent in chunks with the tus protocol. Offset
+// bytes of Length have arrived; once all have, the file goes through the
+// same checks as any upload, including Checksum when the client sent one,
+// is saved with Visibility, and FileID is the record it was saved as.
+type ResumableUpload struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	OwnerID    primitive.ObjectID  `json:"owner_id" bson:"owner_id" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Profile    string              `json:"profile" bson:"profile" example:"document"`
+	Filename   string              `json:"filename" bson:"filename" example:"backup.zip"`
+	Length     int64               `json:"length" bson:"length" example:"524288000"`
+	Offset     int64               `json:"offset" bson:"offset" example:"104857600"`
+	Checksum   string              `json:"checksum,omitempty" bson:"checksum,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Visibility string              `json:"visibility" bson:"visibility" enums:"public,private" example:"public"`
+	FileID     *primitive.ObjectID `json:"file_id,omitempty" bson:"file_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	CreatedAt  time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at" bson:"updated_at"`
+	ExpiresAt  time.Time           `json:"expires_at" bson:"expires_at"`
+}
+
+// Stages of a resumable upload reported in UploadProgressEvents: its
+// chunks arriving, the checks and saving of the finished file, and how it
+// ended
+const (
+	UploadStageReceiving   = "receiving"
+	UploadStageScanning    = "scanning"
+	UploadStageChecking    = "checking"
+	UploadStageStripping   = "stripping"
+	UploadStageSaving      = "saving"
+	UploadStageTranscoding = "transcoding"
+	UploadStageRecording   = "recording"
+	UploadStageCompleted   = "completed"
+	UploadStageFailed      = "failed"
+)
+
+// UploadProgressEvent reports the stage a resumable upload has reached,
+// with Offset bytes of Length received. FileID is set once it completed
+// and Error once it failed.
+type UploadProgressEvent struct {
+	Stage  string              `json:"stage" enums:"receiving,scanning,checking,stripping,saving,transcoding,recording,completed,failed" example:"scanning"`
+	Offset int64               `json:"offset" example:"524288000"`
+	Length int64               `json:"length" example:"524288000"`
+	FileID *primitive.ObjectID `json:"file_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Error  string              `json:"error,omitempty" example:"File is infected"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Grant statuses. A grant requested by the grantee stays pending until the
+// owner consents; grants created by the owner are active immediately.
+const (
+	GrantStatusPending = "pending"
+	GrantStatusActive  = "active"
+	GrantStatusRevoked = "revoked"
+)
+
+// Grant gives GranteeID limited, revocable access to OwnerID's resources
+type Grant struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OwnerID   primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	GranteeID primitive.ObjectID `json:"grantee_id" bson:"grantee_id"`
+	Scopes    []string           `json:"scopes" bson:"scopes"`
+	Status    string             `json:"status" bson:"status"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// CreateGrantRequest is sent by the owner to grant access directly
+type CreateGrantRequest struct {
+	GranteeID      string   `json:"grantee_id" validate:"required,len=24,hexadecimal" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Scopes         []string `json:"scopes" validate:"required,min=1,dive,oneof=profile:read files:read" example:"profile:read"`
+	ExpiresInHours int      `json:"expires_in_hours" validate:"omitempty,min=1,max=8760" example:"72"`
+}
+
+// RequestGrantRequest is sent by a user asking an owner for access
+type RequestGrantRequest struct {
+	OwnerID        string   `json:"owner_id" validate:"required,len=24,hexadecimal" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Scopes         []string `json:"scopes" validate:"required,min=1,dive,oneof=profile:read files:read" example:"files:read"`
+	ExpiresInHours int      `json:"expires_in_hours" validate:"omitempty,min=1,max=8760" example:"72"`
+}
+
+// GrantListResponse separates grants the user gave from grants they hold
+type GrantListResponse struct {
+	Given    []*Grant `json:"given"`
+	Received []*Grant `json:"received"`
+}
+
+// IsUsable reports whether the grant currently confers access
+func (g *Grant) IsUsable(now time.Time) bool {
+	if g.Status != GrantStatusActive {
+		return false
+	}
+	return g.ExpiresAt == nil || g.ExpiresAt.After(now)
+}
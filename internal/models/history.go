@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserChange is one immutable entry in a user's change history: a single
+// field moving from OldValue to NewValue. OldValue is nil when the user was
+// created. ActorID made the change acting as EffectiveID, which differ
+// under impersonation and delegation.
+type UserChange struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Field       string             `json:"field" bson:"field" example:"role"`
+	OldValue    any                `json:"old_value" bson:"old_value" swaggertype:"string" example:"user"`
+	NewValue    any                `json:"new_value" bson:"new_value" swaggertype:"string" example:"admin"`
+	ActorID     primitive.ObjectID `json:"actor_id" bson:"actor_id"`
+	EffectiveID primitive.ObjectID `json:"effective_id" bson:"effective_id"`
+	ActorType   string             `json:"actor_type,omitempty" bson:"actor_type,omitempty" example:"user"`
+	ClientID    string             `json:"client_id,omitempty" bson:"client_id,omitempty"`
+	ChangedAt   time.Time          `json:"changed_at" bson:"changed_at"`
+	// Redacted is set on responses whose values were withheld
+	Redacted bool `json:"redacted,omitempty" bson:"-"`
+}
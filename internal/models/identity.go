@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Identity links a user to an account at an external OAuth provider. A
+// user has at most one identity per provider, and a provider account is
+// linked to at most one user.
+type Identity struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	UserID   primitive.ObjectID `json:"-" bson:"user_id"`
+	Provider string             `json:"provider" bson:"provider" example:"google"`
+	// Subject is the provider's id for the account
+	Subject  string    `json:"subject" bson:"subject" example:"110248495921238986420"`
+	Email    string    `json:"email,omitempty" bson:"email,omitempty" example:"john@gmail.com"`
+	LinkedAt time.Time `json:"linked_at" bson:"linked_at"`
+}
+
+type IdentityListResponse struct {
+	Identities []*Identity `json:"identities"`
+	// Providers are the providers available for linking
+	Providers []string `json:"providers" example:"google,github"`
+}
+
+type IdentityLinkResponse struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.google.com/o/oauth2/v2/auth?..."`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IPBan tracks failed sign-in attempts from one IP address and the
+// temporary bans they have earned. BanCount drives the escalation and is
+// forgotten once the record expires after a quiet period.
+type IPBan struct {
+	IP              string     `json:"ip" bson:"_id"`
+	FailedAttempts  int        `json:"failed_attempts" bson:"failed_attempts"`
+	WindowStartedAt time.Time  `json:"window_started_at" bson:"window_started_at"`
+	BanCount        int        `json:"ban_count" bson:"ban_count"`
+	BannedUntil     *time.Time `json:"banned_until,omitempty" bson:"banned_until,omitempty"`
+	ExpiresAt       time.Time  `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits on User.Metadata, which applications fill with their own data
+const (
+	MaxMetadataBytes  = 16 * 1024
+	MaxMetadataKeys   = 64
+	MaxMetadataKeyLen = 64
+	MaxMetadataDepth  = 5
+)
+
+// ValidateMetadata checks metadata against the size limits and rejects
+// keys MongoDB cannot store or would interpret
+func ValidateMetadata(metadata map[string]any) error {
+	if len(metadata) > MaxMetadataKeys {
+		return fmt.Errorf("metadata has more than %d keys", MaxMetadataKeys)
+	}
+	if err := validateMetadataValue(metadata, 1); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("metadata is not valid JSON: %w", err)
+	}
+	if len(encoded) > MaxMetadataBytes {
+		return fmt.Errorf("metadata is larger than %d bytes", MaxMetadataBytes)
+	}
+	return nil
+}
+
+func validateMetadataValue(value any, depth int) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if depth > MaxMetadataDepth {
+			return fmt.Errorf("metadata is nested deeper than %d levels", MaxMetadataDepth)
+		}
+		for key, item := range v {
+			if key == "" || len(key) > MaxMetadataKeyLen {
+				return fmt.Errorf("metadata keys must be 1 to %d characters", MaxMetadataKeyLen)
+			}
+			if strings.HasPrefix(key, "$") || strings.Contains(key, ".") {
+				return fmt.Errorf("metadata key %q must not start with $ or contain dots", key)
+			}
+			if err := validateMetadataValue(item, depth+1); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if depth > MaxMetadataDepth {
+			return fmt.Errorf("metadata is nested deeper than %d levels", MaxMetadataDepth)
+		}
+		for _, item := range v {
+			if err := validateMetadataValue(item, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MergeMetadata applies patch to metadata as a JSON merge patch (RFC 7396):
+// null removes a key, objects are merged recursively and anything else
+// replaces the current value. metadata is not modified.
+func MergeMetadata(metadata, patch map[string]any) map[string]any {
+	merged := make(map[string]any, len(metadata)+len(patch))
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		if patchObject, ok := value.(map[string]any); ok {
+			current, _ := MetadataObject(merged[key])
+			merged[key] = MergeMetadata(current, patchObject)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// MetadataObject returns value as a map when it is an object, whichever
+// form it was decoded in
+func MetadataObject(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, true
+	case primitive.M:
+		return v, true
+	case primitive.D:
+		return v.Map(), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OneTimeToken is an outstanding single-use token; only its hash is kept,
+// and it is deleted when used or once it expires
+type OneTimeToken struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Purpose   string             `json:"purpose" bson:"purpose"`
+	Subject   string             `json:"subject" bson:"subject"`
+	TokenHash string             `json:"-" bson:"token_hash"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+}
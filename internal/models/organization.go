@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles of a member within an organization, each granting what the ones
+// before it do: members see the organization and its members, admins
+// also invite, remove and change members and edit the organization, and
+// owners also appoint owners and delete the organization.
+const (
+	OrgRoleMember = "member"
+	OrgRoleAdmin  = "admin"
+	OrgRoleOwner  = "owner"
+)
+
+// Statuses of a membership
+const (
+	MembershipInvited = "invited"
+	MembershipActive  = "active"
+)
+
+// Organization groups users into a team. Every organization keeps at
+// least one owner.
+type Organization struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Name        string             `json:"name" bson:"name" example:"Acme"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" example:"Acme engineering"`
+	CreatedBy   primitive.ObjectID `json:"created_by" bson:"created_by" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	// Role is the requester's role in the organization
+	Role      string    `json:"role,omitempty" bson:"-" example:"owner"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Membership places a user in an organization with an org-scoped role.
+// Invited memberships become active when the user joins.
+type Membership struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	OrgID     primitive.ObjectID  `json:"org_id" bson:"org_id" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	UserID    primitive.ObjectID  `json:"user_id" bson:"user_id" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Role      string              `json:"role" bson:"role" example:"member"`
+	Status    string              `json:"status" bson:"status" example:"active"`
+	InvitedBy *primitive.ObjectID `json:"invited_by,omitempty" bson:"invited_by,omitempty"`
+	// User and Organization are populated when requested with
+	// ?expand=user or ?expand=organization
+	User         *UserResponse `json:"user,omitempty" bson:"-"`
+	Organization *Organization `json:"organization,omitempty" bson:"-"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	JoinedAt     *time.Time    `json:"joined_at,omitempty" bson:"joined_at,omitempty"`
+}
+
+type CreateOrganizationRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100" example:"Acme"`
+	Description string `json:"description" validate:"omitempty,max=1000" example:"Acme engineering"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name        string  `json:"name" validate:"omitempty,min=1,max=100" example:"Acme"`
+	Description *string `json:"description" validate:"omitempty,max=1000" example:"Acme engineering"`
+}
+
+// InviteMemberRequest invites an existing user by email
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email" example:"johndoe@example.com"`
+	Role  string `json:"role" validate:"required,oneof=owner admin member" example:"member"`
+}
+
+type UpdateMembershipRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member" example:"admin"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, so
+// middleware can tell them from JWTs and secret scanners can find them
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken is a long-lived token a user creates for scripts.
+// Only its hash is stored; the token itself is shown once, on creation.
+type PersonalAccessToken struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	UserID    primitive.ObjectID `json:"-" bson:"user_id"`
+	Name      string             `json:"name" bson:"name" example:"CI deploy script"`
+	TokenHash string             `json:"-" bson:"token_hash"`
+	// Hint is the end of the token, to tell tokens apart in listings
+	Hint       string     `json:"hint" bson:"hint" example:"3f9a"`
+	Scopes     []string   `json:"scopes" bson:"scopes" example:"profile:read"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at" bson:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name   string   `json:"name" validate:"required,max=100" example:"CI deploy script"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,required" example:"profile:read"`
+	// ExpiresInDays defaults to 30
+	ExpiresInDays int `json:"expires_in_days" validate:"omitempty,min=1,max=365" example:"90"`
+}
+
+// CreatedPersonalAccessToken carries the token itself, which cannot be
+// retrieved again
+type CreatedPersonalAccessToken struct {
+	Token string `json:"token" example:"pat_8c1d..."`
+	*PersonalAccessToken
+}
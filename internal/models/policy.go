@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Policy allows or denies a role an HTTP method on a path pattern; see
+// pkg/policy for the matching rules. Subject "*" applies to every role and
+// Action "*" to every method.
+type Policy struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Subject     string             `json:"subject" bson:"subject" example:"support"`
+	Object      string             `json:"object" bson:"object" example:"/api/v1/users/:id"`
+	Action      string             `json:"action" bson:"action" example:"DELETE"`
+	Effect      string             `json:"effect" bson:"effect" example:"deny"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" example:"Support may not delete users"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type CreatePolicyRequest struct {
+	Subject     string `json:"subject" validate:"required,max=50" example:"support"`
+	Object      string `json:"object" validate:"required,startswith=/,max=200" example:"/api/v1/users/:id"`
+	Action      string `json:"action" validate:"required,oneof=* GET POST PUT PATCH DELETE" example:"DELETE"`
+	Effect      string `json:"effect" validate:"required,oneof=allow deny" example:"deny"`
+	Description string `json:"description" validate:"omitempty,max=200" example:"Support may not delete users"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import "strings"
+
+// ProfileCompleteness tells onboarding UIs how far a user is through
+// filling in their profile. Score is the percentage of checklist fields
+// completed; Missing lists the rest in checklist order.
+type ProfileCompleteness struct {
+	Score     int           `json:"score" example:"75"`
+	Completed []string      `json:"completed" example:"first_name,last_name,email_verified"`
+	Missing   []ProfileHint `json:"missing"`
+}
+
+// ProfileHint is a checklist field the user has yet to complete
+type ProfileHint struct {
+	Field string `json:"field" example:"avatar"`
+	Hint  string `json:"hint" example:"Upload a profile picture"`
+}
+
+// profileCheck says whether a user has completed a field and what to
+// suggest when they have not
+type profileCheck struct {
+	hint string
+	done func(u *User) bool
+}
+
+// profileChecks are the fields a checklist may name besides metadata keys;
+// config.ProfileConfig accepts the same names
+var profileChecks = map[string]profileCheck{
+	"first_name":     {"Add your first name", func(u *User) bool { return u.FirstName != "" }},
+	"last_name":      {"Add your last name", func(u *User) bool { return u.LastName != "" }},
+	"avatar":         {"Upload a profile picture", func(u *User) bool { return u.Avatar != "" }},
+	"email_verified": {"Verify your email address", func(u *User) bool { return u.EmailVerified }},
+	"locale":         {"Choose your language", func(u *User) bool { return u.Locale != "" }},
+	"tags":           {"Pick your interests", func(u *User) bool { return len(u.Tags) > 0 }},
+}
+
+// ProfileChecklist scores profiles against a list of fields, given as
+// profileChecks names or metadata.<key> for a metadata key that must be
+// set. Unknown names are ignored.
+type ProfileChecklist struct {
+	fields []string
+}
+
+func NewProfileChecklist(fields []string) *ProfileChecklist {
+	return &ProfileChecklist{fields: fields}
+}
+
+// Evaluate scores u; with no fields to check, every profile is complete
+func (c *ProfileChecklist) Evaluate(u *User) *ProfileCompleteness {
+	result := &ProfileCompleteness{Completed: []string{}, Missing: []ProfileHint{}}
+	for _, field := range c.fields {
+		var check profileCheck
+		if key, ok := strings.CutPrefix(field, "metadata."); ok {
+			check = profileCheck{
+				hint: "Add your " + strings.ReplaceAll(key, "_", " "),
+				done: func(u *User) bool {
+					value, set := u.Metadata[key]
+					return set && value != nil && value != ""
+				},
+			}
+		} else if check, ok = profileChecks[field]; !ok {
+			continue
+		}
+
+		if check.done(u) {
+			result.Completed = append(result.Completed, field)
+		} else {
+			result.Missing = append(result.Missing, ProfileHint{Field: field, Hint: check.hint})
+		}
+	}
+
+	total := len(result.Completed) + len(result.Missing)
+	if total == 0 {
+		result.Score = 100
+		return result
+	}
+	result.Score = len(result.Completed) * 100 / total
+	return result
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Project is a resource owned by a single user. Owners manage their own
+// projects; admins can see and manage all of them.
+type Project struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	OwnerID     primitive.ObjectID `json:"owner_id" bson:"owner_id" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Name        string             `json:"name" bson:"name" example:"Website redesign"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" example:"New landing page and docs"`
+	// Owner is populated when requested with ?expand=owner
+	Owner     *UserResponse `json:"owner,omitempty" bson:"-"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+type CreateProjectRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100" example:"Website redesign"`
+	Description string `json:"description" validate:"omitempty,max=1000" example:"New landing page and docs"`
+}
+
+type UpdateProjectRequest struct {
+	Name        string  `json:"name" validate:"omitempty,min=1,max=100" example:"Website redesign"`
+	Description *string `json:"description" validate:"omitempty,max=1000" example:"New landing page and docs"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReportTypeUserProfile     = "user_profile"
+	ReportTypeMonthlyActivity = "monthly_activity"
+)
+
+// Report statuses. Reports are created pending and move to running and then
+// completed or failed as the background job progresses.
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusRunning   = "running"
+	ReportStatusCompleted = "completed"
+	ReportStatusFailed    = "failed"
+)
+
+// Report tracks an asynchronously generated PDF report
+type Report struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Type        string              `json:"type" bson:"type"`
+	RequestedBy primitive.ObjectID  `json:"requested_by" bson:"requested_by"`
+	SubjectID   *primitive.ObjectID `json:"subject_id,omitempty" bson:"subject_id,omitempty"`
+	Month       string              `json:"month,omitempty" bson:"month,omitempty"`
+	Status      string              `json:"status" bson:"status"`
+	Error       string              `json:"error,omitempty" bson:"error,omitempty"`
+	FilePath    string              `json:"-" bson:"file_path,omitempty"`
+	Size        int64               `json:"size,omitempty" bson:"size,omitempty"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+type CreateReportRequest struct {
+	Type   string `json:"type" validate:"required,oneof=user_profile monthly_activity" enums:"user_profile,monthly_activity" example:"user_profile"`
+	UserID string `json:"user_id" validate:"required_if=Type user_profile,omitempty,len=24,hexadecimal" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Month  string `json:"month" validate:"required_if=Type monthly_activity,omitempty,datetime=2006-01" example:"2024-05"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type APIResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
@@ -21,7 +23,98 @@ type Pagination struct {
 	TotalPages int `json:"total_pages"`
 }
 
+// TokenTypeBearer is the token_type of access tokens, sent back in the
+// Authorization header as "Bearer <token>"
+const TokenTypeBearer = "Bearer"
+
+// AuthResponse is returned by every sign-in. Clients should refresh before
+// expires_at instead of decoding the token; refresh_token is only present
+// when refresh tokens are enabled (JWT_REFRESH_TTL).
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	TokenType    string       `json:"token_type" example:"Bearer"`
+	ExpiresIn    int          `json:"expires_in" example:"14400"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	User         UserResponse `json:"user"`
+}
+
+// RefreshTokenRequest trades a refresh token for new tokens
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,len=96,hexadecimal"`
+}
+
+type ActionTokenResponse struct {
+	Token     string    `json:"token"`
+	Action    string    `json:"action" example:"file:download"`
+	Resource  string    `json:"resource" example:"images/avatar.png"`
+	ExpiresAt time.Time `json:"expires_at" example:"2023-01-01T12:05:00Z"`
+}
+
+// ImpersonationResponse carries a short-lived token acting as another user
+type ImpersonationResponse struct {
+	Token          string       `json:"token"`
+	ExpiresAt      time.Time    `json:"expires_at" example:"2023-01-01T12:15:00Z"`
+	ImpersonatedBy string       `json:"impersonated_by" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	User           UserResponse `json:"user"`
+}
+
+type AggregateBucket struct {
+	Key   any   `json:"key" swaggertype:"string" example:"admin"`
+	Count int64 `json:"count" example:"42"`
+}
+
+// UserAggregateResponse is a distribution of users over one dimension
+type UserAggregateResponse struct {
+	GroupBy     string            `json:"group_by" example:"role"`
+	Buckets     []AggregateBucket `json:"buckets"`
+	GeneratedAt time.Time         `json:"generated_at" example:"2023-01-01T12:00:00Z"`
+}
+
+// DailyCount is the number of users counted on one UTC day
+type DailyCount struct {
+	Date  string `json:"date" example:"2023-01-01"`
+	Count int64  `json:"count" example:"3"`
+}
+
+// UserStats are the headline figures of the admin dashboard.
+// SignupsPerDay covers the last 30 days, oldest first, including days
+// without signups.
+type UserStats struct {
+	Total         int64             `json:"total" example:"1250"`
+	Active        int64             `json:"active" example:"1100"`
+	ByRole        []AggregateBucket `json:"by_role"`
+	SignupsPerDay []DailyCount      `json:"signups_per_day"`
+	GeneratedAt   time.Time         `json:"generated_at" example:"2023-01-01T12:00:00Z"`
+}
+
+// UserChangesResponse lists users changed after a point in time. Clients
+// pass Cursor back as since on the next poll.
+type UserChangesResponse struct {
+	ChangedIDs []string  `json:"changed_ids"`
+	Cursor     time.Time `json:"cursor" example:"2023-01-01T12:00:00Z"`
+	HasMore    bool      `json:"has_more"`
+}
+
+// RouteInfo describes one route of the API and what it takes to call it;
+// empty fields mean the check does not apply
+type RouteInfo struct {
+	Method     string `json:"method" example:"GET"`
+	Path       string `json:"path" example:"/api/v1/users/:id"`
+	Auth       string `json:"auth,omitempty" example:"user" enums:"user,action"`
+	Action     string `json:"action,omitempty" example:"file:download"`
+	Permission string `json:"permission,omitempty" example:"users:read"`
+	Scope      string `json:"scope,omitempty" example:"users:read"`
+	RateLimit  string `json:"rate_limit,omitempty" example:"strict" enums:"moderate,strict"`
+	Upload     string `json:"upload,omitempty" example:"image" enums:"any,image,document,images"`
+	// Middleware names the extra middleware, in the order it runs
+	Middleware []string `json:"middleware,omitempty" example:"brute_force_protection"`
+}
+
+// PermissionInfo is one row of the permissions matrix: the roles granting a
+// permission and the routes checking it as role permission or token scope
+type PermissionInfo struct {
+	Permission string   `json:"permission" example:"users:read"`
+	Roles      []string `json:"roles" example:"admin"`
+	Routes     []string `json:"routes" example:"GET /api/v1/users"`
 }
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Role names a set of permissions given to users. A role also has the
+// permissions of the roles it inherits and outranks them, so checks for
+// an inherited role admit it too. System roles are the built-in ones: they
+// cannot be deleted, and admin and superadmin cannot be changed.
+type Role struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Name        string             `json:"name" bson:"name" example:"support"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty" example:"Read-only access to users"`
+	Permissions []string           `json:"permissions" bson:"permissions" example:"users:read,profile:read"`
+	Inherits    []string           `json:"inherits,omitempty" bson:"inherits,omitempty" example:"user"`
+	System      bool               `json:"system" bson:"system" example:"false"`
+	// SessionLimit overrides the global session limit for holders of the role
+	SessionLimit *SessionLimit `json:"session_limit,omitempty" bson:"session_limit,omitempty"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// SessionLimit caps the simultaneous sessions of users holding a role. A nil
+// MaxActive or an empty Policy falls back to the global setting; a MaxActive
+// of zero means no limit. Limits are not inherited.
+type SessionLimit struct {
+	MaxActive *int   `json:"max_active,omitempty" bson:"max_active,omitempty" validate:"omitempty,min=0,max=1000" example:"5"`
+	Policy    string `json:"policy,omitempty" bson:"policy,omitempty" validate:"omitempty,oneof=reject evict_oldest" enums:"reject,evict_oldest" example:"evict_oldest"`
+}
+
+// IsZero reports whether the limit overrides nothing
+func (l *SessionLimit) IsZero() bool {
+	return l == nil || (l.MaxActive == nil && l.Policy == "")
+}
+
+var (
+	sessionLimitsMu sync.RWMutex
+	sessionLimits   = map[string]SessionLimit{}
+)
+
+// SetSessionLimits replaces the session limits of every role
+func SetSessionLimits(limits map[string]SessionLimit) {
+	sessionLimitsMu.Lock()
+	defer sessionLimitsMu.Unlock()
+	sessionLimits = limits
+}
+
+// SessionLimitForRole returns the session limit set on role, if any
+func SessionLimitForRole(role string) (SessionLimit, bool) {
+	sessionLimitsMu.RLock()
+	defer sessionLimitsMu.RUnlock()
+	limit, ok := sessionLimits[role]
+	return limit, ok
+}
+
+type CreateRoleRequest struct {
+	Name         string        `json:"name" validate:"required,min=2,max=50,alphanum" example:"support"`
+	Description  string        `json:"description" validate:"omitempty,max=200" example:"Read-only access to users"`
+	Permissions  []string      `json:"permissions" validate:"required,dive,required" example:"users:read,profile:read"`
+	Inherits     []string      `json:"inherits" validate:"omitempty,dive,required" example:"user"`
+	SessionLimit *SessionLimit `json:"session_limit"`
+}
+
+// UpdateRoleRequest changes the fields given. An empty session_limit object
+// removes the role's override. Admin roles only accept session_limit.
+type UpdateRoleRequest struct {
+	Description  *string       `json:"description" validate:"omitempty,max=200" example:"Read-only access to users"`
+	Permissions  []string      `json:"permissions" validate:"omitempty,dive,required" example:"users:read,profile:read"`
+	Inherits     []string      `json:"inherits" validate:"omitempty,dive,required" example:"user"`
+	SessionLimit *SessionLimit `json:"session_limit"`
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"slices"
+	"sync"
+)
+
+// Token scopes checked by middleware.RequireScope
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeProfileRead   = "profile:read"
+	ScopeProfileWrite  = "profile:write"
+	ScopeFilesRead     = "files:read"
+	ScopeFilesWrite    = "files:write"
+	ScopeProjectsRead  = "projects:read"
+	ScopeProjectsWrite = "projects:write"
+	ScopeAuditRead     = "audit:read"
+)
+
+// Permissions checked by middleware.RequirePermission that are not needed
+// as token scopes by any route
+const (
+	PermissionUsersDelete      = "users:delete"
+	PermissionUsersExport      = "users:export"
+	PermissionUsersImpersonate = "users:impersonate"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesWrite       = "roles:write"
+	PermissionClientsManage    = "clients:manage"
+	PermissionMetricsRead      = "metrics:read"
+	PermissionPoliciesManage   = "policies:manage"
+)
+
+// PermissionUsersPII reveals the fields of other users' records tagged
+// authz:"users:pii"; it is checked when responses are written, not on routes
+const PermissionUsersPII = "users:pii"
+
+// Permissions lists everything a role can grant. A role's permissions are
+// also the scopes of its users' access tokens.
+var Permissions = []string{
+	ScopeUsersRead, ScopeUsersWrite, ScopeProfileRead, ScopeProfileWrite,
+	ScopeFilesRead, ScopeFilesWrite, ScopeProjectsRead, ScopeProjectsWrite,
+	ScopeAuditRead, PermissionUsersDelete, PermissionUsersExport, PermissionUsersImpersonate, PermissionRolesRead, PermissionRolesWrite,
+	PermissionClientsManage, PermissionMetricsRead, PermissionPoliciesManage,
+	PermissionUsersPII,
+}
+
+// DefaultRoles are the built-in roles, seeded into the roles collection
+// when missing. Support staff can look users up but not change or delete
+// them, and auditors can only read the audit trails.
+var DefaultRoles = map[string][]string{
+	RoleSuperadmin: Permissions,
+	RoleAdmin:      Permissions,
+	RoleSupport:    {ScopeUsersRead, PermissionUsersPII},
+	RoleAuditor:    {ScopeAuditRead},
+	RoleUser:       {ScopeProfileRead, ScopeProfileWrite, ScopeFilesRead, ScopeFilesWrite, ScopeProjectsRead, ScopeProjectsWrite},
+}
+
+// DefaultRoleInherits are the roles each built-in role inherits
+var DefaultRoleInherits = map[string][]string{
+	RoleSuperadmin: {RoleAdmin},
+	RoleAdmin:      {RoleUser},
+	RoleSupport:    {RoleUser},
+	RoleAuditor:    {RoleUser},
+}
+
+// Names of the built-in roles
+const (
+	RoleSuperadmin = "superadmin"
+	RoleAdmin      = "admin"
+	RoleSupport    = "support"
+	RoleAuditor    = "auditor"
+	RoleUser       = "user"
+)
+
+// CatalogueRoles always hold every permission and cannot be changed, so
+// there is always someone able to manage roles
+var CatalogueRoles = []string{RoleSuperadmin, RoleAdmin}
+
+var (
+	roleScopesMu sync.RWMutex
+	// roleScopes lists the permissions of each role, inherited ones
+	// included; it starts with the built-in roles and is replaced whenever
+	// roles are loaded or changed
+	roleScopes = DefaultRoles
+	// roleIncludes lists, for each role, itself and every role it inherits
+	// directly or through other roles
+	roleIncludes = map[string][]string{
+		RoleSuperadmin: {RoleSuperadmin, RoleAdmin, RoleUser},
+		RoleAdmin:      {RoleAdmin, RoleUser},
+		RoleSupport:    {RoleSupport, RoleUser},
+		RoleAuditor:    {RoleAuditor, RoleUser},
+		RoleUser:       {RoleUser},
+	}
+)
+
+// SetRoles replaces the permissions known for every role and the roles
+// each one includes; both must already be resolved through inheritance
+func SetRoles(scopes, includes map[string][]string) {
+	roleScopesMu.Lock()
+	defer roleScopesMu.Unlock()
+	roleScopes = scopes
+	roleIncludes = includes
+}
+
+// IncludedRoles returns role and every role it inherits
+func IncludedRoles(role string) []string {
+	roleScopesMu.RLock()
+	defer roleScopesMu.RUnlock()
+	return append([]string(nil), roleIncludes[role]...)
+}
+
+// RoleIncludes reports whether role is required or ranks above it by
+// inheriting it, so that a check for "user" also admits "admin"
+func RoleIncludes(role, required string) bool {
+	roleScopesMu.RLock()
+	defer roleScopesMu.RUnlock()
+	return slices.Contains(roleIncludes[role], required)
+}
+
+// CanManageRole reports whether users holding actor may manage users
+// holding role, or give them role: role must grant nothing actor lacks and
+// must not outrank actor by inheriting it, so admins cannot touch
+// superadmins
+func CanManageRole(actor, role string) bool {
+	roleScopesMu.RLock()
+	defer roleScopesMu.RUnlock()
+	if role != actor && slices.Contains(roleIncludes[role], actor) {
+		return false
+	}
+	granted := roleScopes[actor]
+	for _, permission := range roleScopes[role] {
+		if !slices.Contains(granted, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopesForRole returns the permissions of role; unknown roles get none
+func ScopesForRole(role string) []string {
+	roleScopesMu.RLock()
+	defer roleScopesMu.RUnlock()
+	return append([]string(nil), roleScopes[role]...)
+}
+
+// RolesWithPermission returns the roles granting permission, directly or
+// through inheritance, sorted by name
+func RolesWithPermission(permission string) []string {
+	roleScopesMu.RLock()
+	defer roleScopesMu.RUnlock()
+	var roles []string
+	for role, scopes := range roleScopes {
+		if slices.Contains(scopes, permission) {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+	return roles
+}
+
+// RoleExists reports whether role is defined
+func RoleExists(role string) bool {
+	roleScopesMu.RLock()
+	defer roleScopesMu.RUnlock()
+	_, ok := roleScopes[role]
+	return ok
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tombstone records that a document was deleted so sync clients can learn
+// about deletions. Tombstones expire after the configured retention.
+type Tombstone struct {
+	ID         primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	Collection string             `json:"collection" bson:"collection"`
+	DocumentID primitive.ObjectID `json:"document_id" bson:"document_id"`
+	DeletedAt  time.Time          `json:"deleted_at" bson:"deleted_at"`
+}
+
+// SyncResponse is one page of changes since a sync token. Clients store
+// NextToken and keep calling while HasMore is true.
+type SyncResponse struct {
+	Created   []*UserResponse `json:"created"`
+	Updated   []*UserResponse `json:"updated"`
+	Deleted   []string        `json:"deleted"`
+	NextToken string          `json:"next_token" example:"djE6MTY3MjU3NDQwMDAwMA"`
+	HasMore   bool            `json:"has_more"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits on User.Tags, the labels admins attach to users to segment them
+const (
+	MaxUserTags  = 32
+	MaxTagLength = 40
+)
+
+// tagPattern keeps tags usable in query strings and policy paths
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_:.-]*$`)
+
+// NormalizeTags lowercases and trims tags and drops duplicates, keeping the
+// first occurrence. Tags must start with a letter or digit and may contain
+// letters, digits and _ : . - only.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) > MaxTagLength || !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// UserTagsRequest lists tags to add to a user
+type UserTagsRequest struct {
+	Tags []string `json:"tags" validate:"required,min=1,max=32,dive,required,max=40" example:"beta,enterprise"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TrustedDevice is a device the user has vouched for. Until ExpiresAt,
+// sign-ins from it skip the checks meant for unfamiliar devices.
+type TrustedDevice struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	UserID primitive.ObjectID `json:"-" bson:"user_id"`
+	// Fingerprint hashes the user agent and the client's X-Device-ID header
+	Fingerprint string    `json:"-" bson:"fingerprint"`
+	Name        string    `json:"name,omitempty" bson:"name,omitempty" example:"Work laptop"`
+	UserAgent   string    `json:"user_agent" bson:"user_agent"`
+	IP          string    `json:"ip" bson:"ip" example:"203.0.113.7"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+type TrustDeviceRequest struct {
+	Name string `json:"name" validate:"omitempty,max=100" example:"Work laptop"`
+}
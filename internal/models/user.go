@@ -8,17 +8,72 @@ import (
 )
 
 type User struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Username  string             `json:"username" bson:"username" validate:"required,min=3,max=20"`
-	Email     string             `json:"email" bson:"email" validate:"required,email"`
-	Password  string             `json:"-" bson:"password" validate:"required,min=6"`
-	FirstName string             `json:"first_name" bson:"first_name" validate:"required,min=2,max=50"`
-	LastName  string             `json:"last_name" bson:"last_name" validate:"required,min=2,max=50"`
-	Role      string             `json:"role" bson:"role" validate:"required,oneof=admin user"`
-	Avatar    string             `json:"avatar,omitempty" bson:"avatar,omitempty"`
-	IsActive  bool               `json:"is_active" bson:"is_active"`
-	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Username      string             `json:"username" bson:"username" validate:"required,min=3,max=20"`
+	Email         string             `json:"email" bson:"email" validate:"required,email"`
+	Password      string             `json:"-" bson:"password" validate:"required,min=6"`
+	FirstName     string             `json:"first_name" bson:"first_name" validate:"required,min=2,max=50"`
+	LastName      string             `json:"last_name" bson:"last_name" validate:"required,min=2,max=50"`
+	Role          string             `json:"role" bson:"role" validate:"required,max=50"`
+	Avatar        string             `json:"avatar,omitempty" bson:"avatar,omitempty"`
+	Status        UserStatus         `json:"status" bson:"status"`
+	EmailVerified bool               `json:"email_verified" bson:"email_verified"`
+	Locale        string             `json:"locale,omitempty" bson:"locale,omitempty"` // BCP 47 tag choosing the email language
+	Preferences   UserPreferences    `json:"preferences" bson:"preferences"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+
+	// Metadata holds schemaless data attached by applications built on the
+	// API, within the limits checked by ValidateMetadata
+	Metadata map[string]any `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	// Tags are labels admins attach to segment users; see NormalizeTags
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+
+	// PasswordChangedAt drives the password expiry policy; users created
+	// before it was tracked count from CreatedAt
+	PasswordChangedAt *time.Time `json:"-" bson:"password_changed_at,omitempty"`
+	// PasswordChangeRequired is set with a temporary password from an admin
+	// and makes sign-in fail until the user chooses a password of their own
+	PasswordChangeRequired bool `json:"password_change_required,omitempty" bson:"password_change_required,omitempty"`
+
+	// Activity, kept by ActivityService outside of regular updates
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" bson:"last_login_at,omitempty"`
+	LastSeenAt  *time.Time `json:"last_seen_at,omitempty" bson:"last_seen_at,omitempty"`
+	LoginCount  int64      `json:"login_count" bson:"login_count"`
+
+	// StatusReason explains the last status change, made at StatusChangedAt
+	StatusReason    string     `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty"`
+
+	// Erasure: Deletion is set while a deletion request waits out its
+	// grace period, AnonymizedAt once the personal data has been scrubbed
+	Deletion     *DeletionRequest `json:"deletion,omitempty" bson:"deletion,omitempty"`
+	AnonymizedAt *time.Time       `json:"anonymized_at,omitempty" bson:"anonymized_at,omitempty"`
+
+	// Invitation is set on users an admin invited until they accept by
+	// choosing a password; until then they cannot sign in
+	Invitation *Invitation `json:"invitation,omitempty" bson:"invitation,omitempty"`
+}
+
+// IsActive reports whether the user can sign in
+func (u *User) IsActive() bool {
+	return u.Status == UserStatusActive
+}
+
+// Invitation records who invited a pending user and when
+type Invitation struct {
+	InvitedBy primitive.ObjectID `json:"invited_by" bson:"invited_by"`
+	InvitedAt time.Time          `json:"invited_at" bson:"invited_at" example:"2023-01-01T12:00:00Z"`
+}
+
+// DeletionRequest is a user's pending request to be erased. The account is
+// deactivated meanwhile and anonymized once DueAt passes, unless an admin
+// cancels the request first; cancelling restores the account to
+// PreviousStatus.
+type DeletionRequest struct {
+	RequestedAt    time.Time  `json:"requested_at" bson:"requested_at" example:"2023-01-01T12:00:00Z"`
+	DueAt          time.Time  `json:"due_at" bson:"due_at" example:"2023-01-31T12:00:00Z"`
+	PreviousStatus UserStatus `json:"-" bson:"previous_status"`
 }
 
 //	type CreateUserRequest struct {
@@ -32,11 +87,24 @@ type User struct {
 type CreateUserRequest struct {
 	Username  string                `form:"username" binding:"required,min=3,max=20"`
 	Email     string                `form:"email" binding:"required,email"`
-	Password  string                `form:"password" binding:"required"`
+	Password  string                `form:"password" binding:"required" sanitize:"-"`
 	FirstName string                `form:"first_name" binding:"required"`
 	LastName  string                `form:"last_name" binding:"required"`
 	Role      string                `form:"role" binding:"required"`
-	Avatar    *multipart.FileHeader `form:"avatar"` //optional
+	Locale    string                `form:"locale" binding:"omitempty,bcp47_language_tag"`
+	Avatar    *multipart.FileHeader `form:"avatar" swaggerignore:"true"` // optional, saved by the upload middleware before binding
+}
+
+// RegisterRequest is what anyone may sign up with. It carries no role:
+// self-registered users always get the user role.
+type RegisterRequest struct {
+	Username  string                `form:"username" binding:"required,min=3,max=20"`
+	Email     string                `form:"email" binding:"required,email"`
+	Password  string                `form:"password" binding:"required" sanitize:"-"`
+	FirstName string                `form:"first_name" binding:"required"`
+	LastName  string                `form:"last_name" binding:"required"`
+	Locale    string                `form:"locale" binding:"omitempty,bcp47_language_tag"`
+	Avatar    *multipart.FileHeader `form:"avatar" swaggerignore:"true"` // optional, saved by the upload middleware before binding
 }
 
 type UpdateUserRequest struct {
@@ -44,27 +112,190 @@ type UpdateUserRequest struct {
 	Email     string `json:"email" validate:"omitempty,email" example:"johndoe_new@example.com"`
 	FirstName string `json:"first_name" validate:"omitempty,min=1,max=50" example:"John"`
 	LastName  string `json:"last_name" validate:"omitempty,min=1,max=50" example:"Doe"`
-	Role      string `json:"role" validate:"omitempty,oneof=admin user" enums:"admin,user" example:"user"`
+	Role      string `json:"role" validate:"omitempty,max=50" example:"user"`
 	Avatar    string `json:"avatar,omitempty" example:"https://example.com/profile.jpg"`
-	IsActive  *bool  `json:"is_active" example:"true"`
+	Locale    string `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag" example:"fr"`
+}
+
+// PatchUserRequest changes only the fields present in the body. Unlike
+// UpdateUserRequest, an empty string clears first_name, last_name, avatar
+// and locale; username, email and role cannot be cleared.
+type PatchUserRequest struct {
+	Username  *string `json:"username" validate:"omitnil,min=3,max=20" example:"johndoe"`
+	Email     *string `json:"email" validate:"omitnil,email" example:"johndoe_new@example.com"`
+	FirstName *string `json:"first_name" validate:"omitempty,max=50" example:"John"`
+	LastName  *string `json:"last_name" validate:"omitempty,max=50" example:""`
+	Role      *string `json:"role" validate:"omitnil,min=1,max=50" example:"user"`
+	Avatar    *string `json:"avatar" example:""`
+	Locale    *string `json:"locale" validate:"omitempty,bcp47_language_tag" example:"fr"`
+}
+
+// AsPatch returns req as a patch, treating empty strings as not provided
+func (req *UpdateUserRequest) AsPatch() *PatchUserRequest {
+	provided := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+	return &PatchUserRequest{
+		Username:  provided(req.Username),
+		Email:     provided(req.Email),
+		FirstName: provided(req.FirstName),
+		LastName:  provided(req.LastName),
+		Role:      provided(req.Role),
+		Avatar:    provided(req.Avatar),
+		Locale:    provided(req.Locale),
+	}
+}
+
+// UserPreferences holds settings users manage for themselves
+type UserPreferences struct {
+	// LoginAlertsOptOut stops emails about sign-ins from a new IP or device
+	LoginAlertsOptOut bool `json:"login_alerts_opt_out" bson:"login_alerts_opt_out" example:"false"`
+}
+
+type UpdatePreferencesRequest struct {
+	LoginAlertsOptOut *bool `json:"login_alerts_opt_out" validate:"required" example:"true"`
+}
+
+// InviteUserRequest creates a user who sets their own password through an
+// emailed invitation link
+type InviteUserRequest struct {
+	Username  string `json:"username" validate:"required,min=3,max=20" example:"johndoe"`
+	Email     string `json:"email" validate:"required,email" example:"johndoe@example.com"`
+	FirstName string `json:"first_name" validate:"required,min=1,max=50" example:"John"`
+	LastName  string `json:"last_name" validate:"required,min=1,max=50" example:"Doe"`
+	Role      string `json:"role" validate:"required,max=50" example:"user"`
+	Locale    string `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag" example:"fr"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" form:"token" validate:"required,len=96,hexadecimal"`
+	Password string `json:"password" form:"password" validate:"required,min=6" sanitize:"-" example:"password123"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email" example:"johndoe@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" form:"token" validate:"required,len=96,hexadecimal"`
+	Password string `json:"password" form:"password" validate:"required,min=6" sanitize:"-" example:"newpassword123"`
+}
+
+// Admin password reset methods
+const (
+	PasswordResetEmail             = "email"
+	PasswordResetTemporaryPassword = "temporary_password"
+)
+
+// AdminResetPasswordRequest picks how an admin resets a user's password:
+// by emailing the user a reset link, or by setting a temporary password
+// the user must change at the next sign-in
+type AdminResetPasswordRequest struct {
+	Method string `json:"method" validate:"required,oneof=email temporary_password" enums:"email,temporary_password" example:"email"`
+}
+
+// AdminResetPasswordResponse reports the reset. TemporaryPassword is only
+// ever shown here, once.
+type AdminResetPasswordResponse struct {
+	Method            string     `json:"method" example:"temporary_password"`
+	TemporaryPassword string     `json:"temporary_password,omitempty" example:"b1f0c2e9d84a7365f2c1e0aa"`
+	LinkExpiresAt     *time.Time `json:"link_expires_at,omitempty" example:"2023-01-01T13:00:00Z"`
+}
+
+// ChangeExpiredPasswordRequest replaces a password rejected at login as
+// expired; the current password stands in for a session
+type ChangeExpiredPasswordRequest struct {
+	Email           string `json:"email" validate:"required,email" example:"johndoe@example.com"`
+	CurrentPassword string `json:"current_password" validate:"required" sanitize:"-" example:"password123"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,nefield=CurrentPassword" sanitize:"-" example:"newpassword123"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,len=96,hexadecimal"`
+}
+
+// AvailabilityQuery asks whether a username and/or email are still free
+// to register with
+type AvailabilityQuery struct {
+	Username string `form:"username" validate:"required_without=Email,omitempty,min=3,max=20" example:"johndoe"`
+	Email    string `form:"email" validate:"required_without=Username,omitempty,email" example:"johndoe@example.com"`
+}
+
+// AvailabilityResponse answers only for the fields that were asked about
+type AvailabilityResponse struct {
+	UsernameAvailable *bool `json:"username_available,omitempty" example:"true"`
+	EmailAvailable    *bool `json:"email_available,omitempty" example:"false"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email" example:"johndoe@example.com"`
-	Password string `json:"password" validate:"required" example:"password123"`
+	Password string `json:"password" validate:"required" sanitize:"-" example:"password123"`
 }
 
+// ActionTokenRequest asks for a short-lived token limited to one action on
+// one resource, e.g. handing a download link to a browser
+type ActionTokenRequest struct {
+	Action     string `json:"action" validate:"required,oneof=file:download" enums:"file:download" example:"file:download"`
+	Resource   string `json:"resource" validate:"required,max=512" example:"images/avatar.png"`
+	TTLSeconds int    `json:"ttl_seconds" validate:"omitempty,min=1,max=900" example:"300"`
+}
+
+// MaxBatchUsers caps how many users one batch request may fetch
+const MaxBatchUsers = 100
+
+type BatchUserRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100,dive,len=24,hexadecimal" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+}
+
+// BatchUserResponse lists the users that were found, in request order, and
+// the requested IDs that do not exist
+type BatchUserResponse struct {
+	Users   []*UserResponse `json:"users"`
+	Missing []string        `json:"missing"`
+}
+
+// UserResponse is a user as shown to clients. Fields tagged authz are
+// only shown to the user themselves and to roles holding that permission;
+// see handlers.redactFields.
 type UserResponse struct {
-	ID        primitive.ObjectID `json:"id" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
-	Username  string             `json:"username" example:"johndoe"`
-	Email     string             `json:"email" example:"johndoe@example.com"`
-	FirstName string             `json:"first_name" example:"John"`
-	LastName  string             `json:"last_name" example:"Doe"`
-	Role      string             `json:"role" example:"user"`
-	Avatar    string             `json:"avatar,omitempty" example:"https://example.com/profile.jpg"`
-	IsActive  bool               `json:"is_active" example:"true"`
-	CreatedAt time.Time          `json:"created_at" example:"2023-01-01T12:00:00Z"`
-	UpdatedAt time.Time          `json:"updated_at" example:"2023-01-01T12:00:00Z"`
+	ID                     primitive.ObjectID `json:"id" example:"63a5e3e3e4b0a7e3e3e3e3e3"`
+	Username               string             `json:"username" example:"johndoe"`
+	Email                  string             `json:"email" authz:"users:pii" example:"johndoe@example.com"`
+	FirstName              string             `json:"first_name" example:"John"`
+	LastName               string             `json:"last_name" example:"Doe"`
+	Role                   string             `json:"role" example:"user"`
+	Avatar                 string             `json:"avatar,omitempty" example:"https://example.com/profile.jpg"`
+	Status                 UserStatus         `json:"status" authz:"users:pii" example:"active"`
+	StatusReason           string             `json:"status_reason,omitempty" authz:"users:pii" example:"Chargeback under review"`
+	PasswordChangeRequired bool               `json:"password_change_required,omitempty" authz:"users:pii" example:"false"`
+	EmailVerified          bool               `json:"email_verified" example:"true"`
+	Locale                 string             `json:"locale,omitempty" example:"fr"`
+	Preferences            UserPreferences    `json:"preferences"`
+	Metadata               map[string]any     `json:"metadata,omitempty"`
+	Tags                   []string           `json:"tags,omitempty" example:"beta,enterprise"`
+	CreatedAt              time.Time          `json:"created_at" example:"2023-01-01T12:00:00Z"`
+	UpdatedAt              time.Time          `json:"updated_at" example:"2023-01-01T12:00:00Z"`
+	LastLoginAt            *time.Time         `json:"last_login_at,omitempty" authz:"users:pii" example:"2023-01-02T08:30:00Z"`
+	LastSeenAt             *time.Time         `json:"last_seen_at,omitempty" authz:"users:pii" example:"2023-01-02T09:15:00Z"`
+	LoginCount             int64              `json:"login_count" authz:"users:pii" example:"12"`
+	Deletion               *DeletionRequest   `json:"deletion,omitempty" authz:"users:pii"`
+	AnonymizedAt           *time.Time         `json:"anonymized_at,omitempty" example:"2023-01-31T12:00:00Z"`
+	Invitation             *Invitation        `json:"invitation,omitempty"`
+
+	// Redacted names the fields withheld from the requester
+	Redacted []string `json:"redacted,omitempty" example:"email,status"`
+
+	// Completeness scores the profile against the onboarding checklist; it
+	// is only set on the user's own profile
+	Completeness *ProfileCompleteness `json:"completeness,omitempty"`
+}
+
+// OwnerID lets users see every field of their own record
+func (u *UserResponse) OwnerID() primitive.ObjectID {
+	return u.ID
 }
 
 // PaginatedUserResponse represents a paginated list of users.
@@ -78,15 +309,28 @@ type PaginatedUserResponse struct {
 
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		FirstName: u.FirstName,
-		LastName:  u.LastName,
-		Role:      u.Role,
-		Avatar:    u.Avatar,
-		IsActive:  u.IsActive,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:                     u.ID,
+		Username:               u.Username,
+		Email:                  u.Email,
+		FirstName:              u.FirstName,
+		LastName:               u.LastName,
+		Role:                   u.Role,
+		Avatar:                 u.Avatar,
+		Status:                 u.Status,
+		StatusReason:           u.StatusReason,
+		PasswordChangeRequired: u.PasswordChangeRequired,
+		EmailVerified:          u.EmailVerified,
+		Locale:                 u.Locale,
+		Preferences:            u.Preferences,
+		Metadata:               u.Metadata,
+		Tags:                   u.Tags,
+		CreatedAt:              u.CreatedAt,
+		UpdatedAt:              u.UpdatedAt,
+		LastLoginAt:            u.LastLoginAt,
+		LastSeenAt:             u.LastSeenAt,
+		LoginCount:             u.LoginCount,
+		Deletion:               u.Deletion,
+		AnonymizedAt:           u.AnonymizedAt,
+		Invitation:             u.Invitation,
 	}
 }
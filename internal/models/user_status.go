@@ -0,0 +1,58 @@
+package models
+
+import "slices"
+
+// UserStatus is where a user stands in the account lifecycle. Only active
+// users can sign in or use their tokens.
+type UserStatus string
+
+const (
+	// UserStatusPending users were invited and have not chosen a password
+	UserStatusPending UserStatus = "pending"
+	UserStatusActive  UserStatus = "active"
+	// UserStatusSuspended users are locked out for a while, e.g. during an
+	// investigation
+	UserStatusSuspended UserStatus = "suspended"
+	// UserStatusBanned users are locked out for breaking the rules
+	UserStatusBanned UserStatus = "banned"
+	// UserStatusDeactivated users closed their account or had it closed,
+	// including while a deletion request is pending
+	UserStatusDeactivated UserStatus = "deactivated"
+)
+
+// UserStatuses lists every status
+var UserStatuses = []UserStatus{UserStatusPending, UserStatusActive, UserStatusSuspended, UserStatusBanned, UserStatusDeactivated}
+
+// userStatusTransitions lists the statuses each status can change to.
+// Banned users are only let back in explicitly, never merely deactivated,
+// so that a ban is not lost when the account is later reactivated.
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusPending:     {UserStatusActive, UserStatusBanned, UserStatusDeactivated},
+	UserStatusActive:      {UserStatusSuspended, UserStatusBanned, UserStatusDeactivated},
+	UserStatusSuspended:   {UserStatusActive, UserStatusBanned, UserStatusDeactivated},
+	UserStatusBanned:      {UserStatusActive},
+	UserStatusDeactivated: {UserStatusActive},
+}
+
+// CanBecome reports whether a user can change from s to status
+func (s UserStatus) CanBecome(status UserStatus) bool {
+	return slices.Contains(userStatusTransitions[s], status)
+}
+
+// StatusesBecoming returns the statuses that can change to status
+func StatusesBecoming(status UserStatus) []UserStatus {
+	var from []UserStatus
+	for _, s := range UserStatuses {
+		if s.CanBecome(status) {
+			from = append(from, s)
+		}
+	}
+	return from
+}
+
+// ChangeUserStatusRequest moves a user to another status. The reason is
+// kept on the user and in its history.
+type ChangeUserStatusRequest struct {
+	Status UserStatus `json:"status" validate:"required,oneof=pending active suspended banned deactivated" enums:"pending,active,suspended,banned,deactivated" example:"suspended"`
+	Reason string     `json:"reason" validate:"omitempty,max=500" example:"Chargeback under review"`
+}
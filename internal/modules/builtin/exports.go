@@ -0,0 +1,50 @@
+package builtin
+
+import (
+	"context"
+	"user-management-api/internal/config"
+	"user-management-api/internal/handlers"
+	"user-management-api/internal/modules"
+	"user-management-api/internal/repository/interfaces"
+	mongorepo "user-management-api/internal/repository/mongo"
+	"user-management-api/internal/routes"
+	"user-management-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ExportsModule exports users as CSV or JSON and keeps saved export
+// templates
+type ExportsModule struct {
+	cfg     *config.Config
+	handler *handlers.ExportHandler
+}
+
+func NewExportsModule(cfg *config.Config, db *mongo.Database, userRepo interfaces.UserRepository) *ExportsModule {
+	templateRepo := mongorepo.NewExportTemplateRepository(db)
+	return &ExportsModule{
+		cfg:     cfg,
+		handler: handlers.NewExportHandler(services.NewExportService(templateRepo, userRepo)),
+	}
+}
+
+func (m *ExportsModule) Name() string { return "exports" }
+
+func (m *ExportsModule) Routes(rg *gin.RouterGroup) {
+	routes.Register(rg, m.cfg, routes.ExportRoutes(m.handler))
+}
+
+func (m *ExportsModule) Migrations() []modules.Migration {
+	return []modules.Migration{
+		func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("export_templates").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "name", Value: 1}},
+			})
+			return err
+		},
+	}
+}
+
+func (m *ExportsModule) Workers() []modules.Worker { return nil }
@@ -0,0 +1,117 @@
+package builtin
+
+import (
+	"context"
+	"log"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/handlers"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/modules"
+	mongorepo "user-management-api/internal/repository/mongo"
+	"user-management-api/internal/routes"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/cdn"
+	"user-management-api/pkg/clamav"
+	"user-management-api/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FilesModule serves file uploads and downloads from the upload storage
+// and keeps their metadata in the files collection. Resumable uploads in
+// progress are kept in resumable_uploads until they expire. Stored files
+// nothing refers to anymore are collected in the background. grants
+// decides who may read another user's files.
+type FilesModule struct {
+	cfg     *config.Config
+	handler *handlers.FileHandler
+	grants  middleware.GrantChecker
+	gc      *services.FileGCService
+}
+
+func NewFilesModule(cfg *config.Config, db *mongo.Database, store storage.Storage, scanner *clamav.Client, cdn *cdn.CDN, grants middleware.GrantChecker) *FilesModule {
+	fileRepo := mongorepo.NewFileRepository(db)
+	fileService := services.NewFileService(fileRepo, store, scanner, cdn, cfg.Uploads, cfg.JWT.Secret, cfg.Server.PublicURL)
+	resumableService := services.NewResumableUploadService(mongorepo.NewResumableUploadRepository(db), cfg.Uploads)
+	return &FilesModule{
+		cfg:     cfg,
+		handler: handlers.NewFileHandler(store, fileService, resumableService, cfg.Uploads),
+		grants:  grants,
+		gc:      services.NewFileGCService(fileRepo, mongorepo.NewUserRepository(db), store, cfg.Uploads.GCMinAge),
+	}
+}
+
+func (m *FilesModule) Name() string { return "files" }
+
+func (m *FilesModule) Routes(rg *gin.RouterGroup) {
+	routes.Register(rg, m.cfg, routes.FileRoutes(m.handler, m.grants))
+}
+
+func (m *FilesModule) Migrations() []modules.Migration {
+	return []modules.Migration{
+		// files with identical contents share a key, which used to be unique
+		func(ctx context.Context, db *mongo.Database) error {
+			indexes := db.Collection("files").Indexes()
+			specs, err := indexes.ListSpecifications(ctx)
+			if err != nil {
+				return err
+			}
+			for _, spec := range specs {
+				if spec.Name == "key_1" && spec.Unique != nil && *spec.Unique {
+					_, err := indexes.DropOne(ctx, spec.Name)
+					return err
+				}
+			}
+			return nil
+		},
+		func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("files").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "key", Value: 1}}},
+				{Keys: bson.D{{Key: "checksum", Value: 1}}},
+				{Keys: bson.D{{Key: "variants.key", Value: 1}}},
+				{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			})
+			return err
+		},
+		func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("resumable_uploads").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0),
+			})
+			return err
+		},
+	}
+}
+
+// Workers collects orphaned files once per FILE_GC_INTERVAL until
+// shutdown, unless it is zero. Every server runs the collection; runs that
+// overlap only find less to remove.
+func (m *FilesModule) Workers() []modules.Worker {
+	if m.cfg.Uploads.GCInterval == 0 {
+		return nil
+	}
+	return []modules.Worker{
+		func(ctx context.Context) {
+			ticker := time.NewTicker(m.cfg.Uploads.GCInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					result, err := m.gc.Run(ctx, false, nil)
+					if err != nil {
+						log.Printf("file gc failed: %v", err)
+						continue
+					}
+					if result.Orphaned > 0 {
+						log.Printf("file gc: removed %d of %d orphaned files (%d bytes) out of %d stored", result.Deleted, result.Orphaned, result.Bytes, result.Scanned)
+					}
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package builtin
+
+import (
+	"context"
+	"log"
+	"user-management-api/internal/config"
+	"user-management-api/internal/handlers"
+	"user-management-api/internal/modules"
+	"user-management-api/internal/repository/interfaces"
+	mongorepo "user-management-api/internal/repository/mongo"
+	"user-management-api/internal/routes"
+	"user-management-api/internal/services"
+	"user-management-api/pkg/jobs"
+	"user-management-api/pkg/pdf"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReportsModule renders PDF user reports in the background job queue
+type ReportsModule struct {
+	cfg     *config.Config
+	service *services.ReportService
+	handler *handlers.ReportHandler
+}
+
+// NewReportsModule fails when no wkhtmltopdf binary is available to render
+// reports with
+func NewReportsModule(cfg *config.Config, db *mongo.Database, userRepo interfaces.UserRepository, tombstoneRepo interfaces.TombstoneRepository, jobQueue *jobs.Queue) (*ReportsModule, error) {
+	renderer, err := pdf.NewWkhtmltopdfRenderer(cfg.Report.Wkhtmltopdf)
+	if err != nil {
+		return nil, err
+	}
+	reportService := services.NewReportService(mongorepo.NewReportRepository(db), userRepo, tombstoneRepo, renderer, jobQueue, cfg.Report.Path, services.ReportBranding{
+		Name:  cfg.Report.BrandName,
+		Color: cfg.Report.BrandColor,
+	})
+	return &ReportsModule{
+		cfg:     cfg,
+		service: reportService,
+		handler: handlers.NewReportHandler(reportService),
+	}, nil
+}
+
+func (m *ReportsModule) Name() string { return "reports" }
+
+func (m *ReportsModule) Routes(rg *gin.RouterGroup) {
+	routes.Register(rg, m.cfg, routes.ReportRoutes(m.handler))
+}
+
+func (m *ReportsModule) Migrations() []modules.Migration {
+	return []modules.Migration{
+		func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("reports").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "requested_by", Value: 1}, {Key: "created_at", Value: -1}},
+			})
+			return err
+		},
+	}
+}
+
+// Workers re-queues reports interrupted by the last shutdown
+func (m *ReportsModule) Workers() []modules.Worker {
+	return []modules.Worker{
+		func(ctx context.Context) {
+			if err := m.service.RecoverInterrupted(ctx); err != nil {
+				log.Printf("failed to recover interrupted reports: %v", err)
+			}
+		},
+	}
+}
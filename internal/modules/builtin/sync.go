@@ -0,0 +1,37 @@
+package builtin
+
+import (
+	"user-management-api/internal/config"
+	"user-management-api/internal/handlers"
+	"user-management-api/internal/modules"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/internal/routes"
+	"user-management-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncModule serves delta sync to clients mirroring the user directory.
+// Tombstones are written on every user deletion, so they stay in the core
+// even when the module is disabled.
+type SyncModule struct {
+	cfg     *config.Config
+	handler *handlers.SyncHandler
+}
+
+func NewSyncModule(cfg *config.Config, userRepo interfaces.UserRepository, tombstoneRepo interfaces.TombstoneRepository) *SyncModule {
+	return &SyncModule{
+		cfg:     cfg,
+		handler: handlers.NewSyncHandler(services.NewSyncService(userRepo, tombstoneRepo, cfg.Sync.TombstoneRetention)),
+	}
+}
+
+func (m *SyncModule) Name() string { return "sync" }
+
+func (m *SyncModule) Routes(rg *gin.RouterGroup) {
+	routes.Register(rg, m.cfg, routes.SyncRoutes(m.handler))
+}
+
+func (m *SyncModule) Migrations() []modules.Migration { return nil }
+
+func (m *SyncModule) Workers() []modules.Worker { return nil }
@@ -0,0 +1,192 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/modules"
+	"user-management-api/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TelemetryModule posts anonymous usage statistics to the maintainers of
+// the starter. It is only registered when TELEMETRY_ENABLED is set, and
+// reports nothing that identifies the deployment or its users: a random
+// installation ID, the version, Go version, enabled modules and request
+// and error rates rounded into coarse buckets.
+type TelemetryModule struct {
+	cfg     config.TelemetryConfig
+	db      *mongo.Database
+	summary *config.Summary
+	client  *http.Client
+}
+
+// TelemetryReport is the body posted to the telemetry endpoint
+type TelemetryReport struct {
+	InstallationID string   `json:"installation_id"`
+	Version        string   `json:"version"`
+	GoVersion      string   `json:"go_version"`
+	OS             string   `json:"os"`
+	Arch           string   `json:"arch"`
+	Modules        []string `json:"modules"`
+	// Requests and the error rates cover the period since the last report
+	Requests      string `json:"requests"`
+	ClientErrors  string `json:"client_error_rate"`
+	ServerErrors  string `json:"server_error_rate"`
+	IntervalHours int    `json:"interval_hours"`
+}
+
+// NewTelemetryModule reads the version and enabled modules from summary
+// when reporting, so they may be filled in after the module is created
+func NewTelemetryModule(cfg config.TelemetryConfig, db *mongo.Database, summary *config.Summary) *TelemetryModule {
+	return &TelemetryModule{
+		cfg:     cfg,
+		db:      db,
+		summary: summary,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (m *TelemetryModule) Name() string { return "telemetry" }
+
+func (m *TelemetryModule) Routes(rg *gin.RouterGroup) {}
+
+func (m *TelemetryModule) Migrations() []modules.Migration { return nil }
+
+// Workers reports once per interval until shutdown. Failed reports are
+// logged and dropped rather than retried.
+func (m *TelemetryModule) Workers() []modules.Worker {
+	return []modules.Worker{
+		func(ctx context.Context) {
+			installationID, err := m.installationID(ctx)
+			if err != nil {
+				log.Printf("telemetry disabled: %v", err)
+				return
+			}
+			log.Printf("telemetry: reporting anonymous usage to %s every %s", m.cfg.Endpoint, m.cfg.Interval)
+
+			previous := middleware.ResponseClassCounts()
+			ticker := time.NewTicker(m.cfg.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					current := middleware.ResponseClassCounts()
+					if err := m.send(ctx, m.report(installationID, previous, current)); err != nil {
+						log.Printf("telemetry report failed: %v", err)
+					}
+					previous = current
+				}
+			}
+		},
+	}
+}
+
+// installationID returns the random ID this database reports under,
+// creating it on first use so restarts and replicas share one ID
+func (m *TelemetryModule) installationID(ctx context.Context) (string, error) {
+	id, err := utils.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	var doc struct {
+		InstallationID string `bson:"installation_id"`
+	}
+	err = m.db.Collection("telemetry").FindOneAndUpdate(ctx,
+		bson.M{"_id": "installation"},
+		bson.M{"$setOnInsert": bson.M{"installation_id": id, "created_at": time.Now()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return "", err
+	}
+	return doc.InstallationID, nil
+}
+
+// report builds the report for the period between two response counts
+func (m *TelemetryModule) report(installationID string, previous, current map[string]int64) TelemetryReport {
+	delta := func(class string) int64 { return current[class] - previous[class] }
+	total := delta("2xx") + delta("3xx") + delta("4xx") + delta("5xx")
+	return TelemetryReport{
+		InstallationID: installationID,
+		Version:        m.summary.Version,
+		GoVersion:      runtime.Version(),
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		Modules:        m.summary.Modules,
+		Requests:       volumeBucket(total),
+		ClientErrors:   rateBucket(delta("4xx"), total),
+		ServerErrors:   rateBucket(delta("5xx"), total),
+		IntervalHours:  int(m.cfg.Interval / time.Hour),
+	}
+}
+
+func (m *TelemetryModule) send(ctx context.Context, report TelemetryReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return nil
+}
+
+// volumeBucket rounds a request count to its order of magnitude
+func volumeBucket(n int64) string {
+	switch {
+	case n == 0:
+		return "0"
+	case n < 100:
+		return "1-99"
+	case n < 1000:
+		return "100-999"
+	case n < 10000:
+		return "1k-10k"
+	case n < 100000:
+		return "10k-100k"
+	default:
+		return "100k+"
+	}
+}
+
+// rateBucket rounds the share errors make of total to a coarse range
+func rateBucket(errors, total int64) string {
+	if total == 0 || errors == 0 {
+		return "0%"
+	}
+	rate := float64(errors) / float64(total)
+	switch {
+	case rate < 0.01:
+		return "<1%"
+	case rate < 0.05:
+		return "1-5%"
+	case rate < 0.2:
+		return "5-20%"
+	default:
+		return "20%+"
+	}
+}
@@ -0,0 +1,65 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"user-management-api/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Module is an optional feature that plugs its own routes, database
+// migrations and background workers into the server, so a whole feature
+// can be switched off through DISABLED_MODULES
+type Module interface {
+	// Name identifies the module in DISABLED_MODULES and in logs
+	Name() string
+	// Routes registers the module's endpoints under /api/v1
+	Routes(rg *gin.RouterGroup)
+	Migrations() []Migration
+	Workers() []Worker
+}
+
+// Migration prepares the database for a module, e.g. by creating indexes.
+// Migrations run at every startup and must be idempotent.
+type Migration func(ctx context.Context, db *mongo.Database) error
+
+// Worker runs in the background until ctx is cancelled at shutdown; workers
+// doing one-off startup work may return early
+type Worker func(ctx context.Context)
+
+// Enabled returns the modules not disabled by configuration
+func Enabled(cfg config.ModulesConfig, mods ...Module) []Module {
+	enabled := make([]Module, 0, len(mods))
+	for _, mod := range mods {
+		if !cfg.IsEnabled(mod.Name()) {
+			log.Printf("module %s disabled", mod.Name())
+			continue
+		}
+		enabled = append(enabled, mod)
+	}
+	return enabled
+}
+
+// Migrate runs the migrations of every module, in order
+func Migrate(ctx context.Context, db *mongo.Database, mods []Module) error {
+	for _, mod := range mods {
+		for _, migration := range mod.Migrations() {
+			if err := migration(ctx, db); err != nil {
+				return fmt.Errorf("module %s: %w", mod.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// StartWorkers starts the workers of every module in their own goroutine
+func StartWorkers(ctx context.Context, mods []Module) {
+	for _, mod := range mods {
+		for _, worker := range mod.Workers() {
+			go worker(ctx)
+		}
+	}
+}
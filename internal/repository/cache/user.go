@@ -0,0 +1,245 @@
+// Package cache wraps repositories with short-lived in-process caches.
+// Every write made through a wrapper invalidates what it could have made
+// stale; writes made by other instances are only picked up once entries
+// expire, which bounds how long a cached result can lag behind.
+package cache
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxLoginEntries bounds the cache, which a credential-stuffing run with
+// random addresses would otherwise fill with negative entries
+const maxLoginEntries = 10000
+
+// loginCacheMetrics counts login lookups by outcome, published on
+// /debug/vars; the hit rate is (hits + negative_hits) / lookups
+var loginCacheMetrics = expvar.NewMap("login_cache")
+
+type loginEntry struct {
+	user    *models.User // nil caches "no such user"
+	expires time.Time
+}
+
+// UserRepository caches the lookups users signing in make by email, both
+// found and not found, for ttl. Only GetByEmailForLogin reads the cache.
+type UserRepository struct {
+	interfaces.UserRepository
+	ttl time.Duration
+
+	mu      sync.Mutex
+	byEmail map[string]loginEntry
+	emails  map[primitive.ObjectID]string
+	// generation changes on every invalidation so that a lookup racing a
+	// write does not cache what it read before the write
+	generation uint64
+}
+
+// NewUserRepository wraps repo; a ttl of zero disables the cache
+func NewUserRepository(repo interfaces.UserRepository, ttl time.Duration) *UserRepository {
+	return &UserRepository{
+		UserRepository: repo,
+		ttl:            ttl,
+		byEmail:        make(map[string]loginEntry),
+		emails:         make(map[primitive.ObjectID]string),
+	}
+}
+
+// GetByEmailForLogin is GetByEmail answered from the cache when possible.
+// The returned user is a shallow copy; its maps and slices are shared.
+func (r *UserRepository) GetByEmailForLogin(ctx context.Context, email string) (*models.User, error) {
+	if r.ttl <= 0 {
+		return r.UserRepository.GetByEmail(ctx, email)
+	}
+	loginCacheMetrics.Add("lookups", 1)
+
+	r.mu.Lock()
+	entry, ok := r.byEmail[email]
+	generation := r.generation
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		if entry.user == nil {
+			loginCacheMetrics.Add("negative_hits", 1)
+			return nil, mongo.ErrNoDocuments
+		}
+		loginCacheMetrics.Add("hits", 1)
+		user := *entry.user
+		return &user, nil
+	}
+	loginCacheMetrics.Add("misses", 1)
+
+	user, err := r.UserRepository.GetByEmail(ctx, email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	r.store(email, user, generation)
+	if user == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *UserRepository) store(email string, user *models.User, generation uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation != r.generation {
+		return
+	}
+	now := time.Now()
+	if len(r.byEmail) >= maxLoginEntries {
+		for key, entry := range r.byEmail {
+			if now.After(entry.expires) {
+				r.forget(key)
+			}
+		}
+		if len(r.byEmail) >= maxLoginEntries {
+			r.byEmail = make(map[string]loginEntry)
+			r.emails = make(map[primitive.ObjectID]string)
+		}
+	}
+	r.byEmail[email] = loginEntry{user: user, expires: now.Add(r.ttl)}
+	if user != nil {
+		r.emails[user.ID] = email
+	}
+}
+
+// forget drops the entry for email; r.mu must be held
+func (r *UserRepository) forget(email string) {
+	if entry, ok := r.byEmail[email]; ok {
+		if entry.user != nil {
+			delete(r.emails, entry.user.ID)
+		}
+		delete(r.byEmail, email)
+	}
+}
+
+// invalidate drops the entries of the given users and addresses
+func (r *UserRepository) invalidate(ids []primitive.ObjectID, emails ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	for _, id := range ids {
+		if email, ok := r.emails[id]; ok {
+			r.forget(email)
+		}
+	}
+	for _, email := range emails {
+		r.forget(email)
+	}
+	loginCacheMetrics.Add("invalidations", 1)
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	err := r.UserRepository.Create(ctx, user)
+	r.invalidate(nil, user.Email)
+	return err
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	err := r.UserRepository.Update(ctx, user)
+	r.invalidate([]primitive.ObjectID{user.ID}, user.Email)
+	return err
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	err := r.UserRepository.Delete(ctx, id)
+	r.invalidate([]primitive.ObjectID{id})
+	return err
+}
+
+func (r *UserRepository) DeleteMany(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	n, err := r.UserRepository.DeleteMany(ctx, ids)
+	r.invalidate(ids)
+	return n, err
+}
+
+func (r *UserRepository) Deactivate(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	n, err := r.UserRepository.Deactivate(ctx, ids)
+	r.invalidate(ids)
+	return n, err
+}
+
+func (r *UserRepository) SetStatus(ctx context.Context, id primitive.ObjectID, from, to models.UserStatus, reason string, at time.Time) (*models.User, error) {
+	user, err := r.UserRepository.SetStatus(ctx, id, from, to, reason, at)
+	r.invalidate([]primitive.ObjectID{id})
+	return user, err
+}
+
+func (r *UserRepository) UpdatePreferences(ctx context.Context, id primitive.ObjectID, prefs models.UserPreferences) error {
+	err := r.UserRepository.UpdatePreferences(ctx, id, prefs)
+	r.invalidate([]primitive.ObjectID{id})
+	return err
+}
+
+func (r *UserRepository) UpdateMetadata(ctx context.Context, id primitive.ObjectID, metadata map[string]any) error {
+	err := r.UserRepository.UpdateMetadata(ctx, id, metadata)
+	r.invalidate([]primitive.ObjectID{id})
+	return err
+}
+
+func (r *UserRepository) AddTags(ctx context.Context, id primitive.ObjectID, tags []string) (*models.User, error) {
+	user, err := r.UserRepository.AddTags(ctx, id, tags)
+	r.invalidate([]primitive.ObjectID{id})
+	return user, err
+}
+
+func (r *UserRepository) RemoveTags(ctx context.Context, id primitive.ObjectID, tags []string) (*models.User, error) {
+	user, err := r.UserRepository.RemoveTags(ctx, id, tags)
+	r.invalidate([]primitive.ObjectID{id})
+	return user, err
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := r.UserRepository.MarkEmailVerified(ctx, id)
+	r.invalidate([]primitive.ObjectID{id})
+	return user, err
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	err := r.UserRepository.UpdatePassword(ctx, id, passwordHash)
+	r.invalidate([]primitive.ObjectID{id})
+	return err
+}
+
+func (r *UserRepository) SetTemporaryPassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	err := r.UserRepository.SetTemporaryPassword(ctx, id, passwordHash)
+	r.invalidate([]primitive.ObjectID{id})
+	return err
+}
+
+func (r *UserRepository) ScheduleDeletion(ctx context.Context, id primitive.ObjectID, req models.DeletionRequest) error {
+	err := r.UserRepository.ScheduleDeletion(ctx, id, req)
+	r.invalidate([]primitive.ObjectID{id})
+	return err
+}
+
+func (r *UserRepository) CancelDeletion(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.User, error) {
+	user, err := r.UserRepository.CancelDeletion(ctx, id, now)
+	r.invalidate([]primitive.ObjectID{id})
+	return user, err
+}
+
+func (r *UserRepository) Anonymize(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.User, error) {
+	user, err := r.UserRepository.Anonymize(ctx, id, now)
+	if user != nil {
+		r.invalidate([]primitive.ObjectID{id}, user.Email)
+	} else {
+		r.invalidate([]primitive.ObjectID{id})
+	}
+	return user, err
+}
+
+func (r *UserRepository) AcceptInvitation(ctx context.Context, id primitive.ObjectID, passwordHash string) (*models.User, error) {
+	user, err := r.UserRepository.AcceptInvitation(ctx, id, passwordHash)
+	r.invalidate([]primitive.ObjectID{id})
+	return user, err
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditLogRepository is append-only; entries are only removed once they
+// are past retention, and only changed when a user is erased
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	// ListByResource returns a page of the record's entries, newest first
+	ListByResource(ctx context.Context, resource string, id primitive.ObjectID, page, limit int) ([]*models.AuditLog, int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// RedactFields blanks the old and new values of the given fields in
+	// the record's entries and marks them redacted
+	RedactFields(ctx context.Context, resource string, id primitive.ObjectID, fields []string) error
+}
@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"user-management-api/internal/models"
+)
+
+// AuthEventFilter narrows an auth event query; zero fields match everything
+type AuthEventFilter struct {
+	UserID   *primitive.ObjectID
+	ActorID  *primitive.ObjectID
+	Type     string
+	From, To time.Time
+}
+
+type AuthEventRepository interface {
+	Create(ctx context.Context, event *models.AuthEvent) error
+	Find(ctx context.Context, filter AuthEventFilter, page, limit int) ([]*models.AuthEvent, int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// AnonymizeUser removes the email, IP address and user agent from the
+	// events of a user, as principal or as actor, keeping the events
+	AnonymizeUser(ctx context.Context, userID primitive.ObjectID) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"user-management-api/internal/models"
+)
+
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, code *models.AuthorizationCode) error
+	Consume(ctx context.Context, codeHash string) (*models.AuthorizationCode, error)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+)
+
+type BulkOperationRepository interface {
+	Create(ctx context.Context, operation *models.BulkOperation) error
+	List(ctx context.Context, page, limit int) ([]*models.BulkOperation, int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
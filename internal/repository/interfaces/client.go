@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type ClientRepository interface {
+	Create(ctx context.Context, client *models.OAuthClient) error
+	GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	List(ctx context.Context) ([]*models.OAuthClient, error)
+	Deactivate(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type ExportTemplateRepository interface {
+	Create(ctx context.Context, template *models.ExportTemplate) error
+	GetByID(ctx context.Context, id, ownerID primitive.ObjectID) (*models.ExportTemplate, error)
+	ListByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]*models.ExportTemplate, error)
+	Delete(ctx context.Context, id, ownerID primitive.ObjectID) error
+}
@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type FileRepository interface {
+	Create(ctx context.Context, file *models.File) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.File, error)
+	// GetByKey returns ownerID's file stored under key
+	GetByKey(ctx context.Context, ownerID primitive.ObjectID, key string) (*models.File, error)
+	// GetByChecksum returns a ready file with the given contents
+	GetByChecksum(ctx context.Context, checksum string) (*models.File, error)
+	// CountByKey counts the files sharing the blob under key
+	CountByKey(ctx context.Context, key string) (int64, error)
+	// KeyVisibility returns public when any file stored under key, or with
+	// a variant there, is public, private when all are, and an empty
+	// string when none is
+	KeyVisibility(ctx context.Context, key string) (string, error)
+	// ForEachKey calls fn with the key of every file and of each of its
+	// variants, stopping at the first error
+	ForEachKey(ctx context.Context, fn func(key string) error) error
+	// List returns a page of ownerID's files, newest first
+	List(ctx context.Context, ownerID primitive.ObjectID, page, limit int) ([]*models.File, int64, error)
+	// Update saves the file's key, size, type, checksum, visibility,
+	// status and scan
+	Update(ctx context.Context, file *models.File) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type GrantRepository interface {
+	Create(ctx context.Context, grant *models.Grant) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Grant, error)
+	ListByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]*models.Grant, error)
+	ListByGrantee(ctx context.Context, granteeID primitive.ObjectID) ([]*models.Grant, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error
+	FindActive(ctx context.Context, ownerID, granteeID primitive.ObjectID, scope string) (*models.Grant, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type IdentityRepository interface {
+	// Create stores a new identity, failing with a duplicate key error when
+	// the provider account is already linked
+	Create(ctx context.Context, identity *models.Identity) error
+	GetBySubject(ctx context.Context, provider, subject string) (*models.Identity, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.Identity, error)
+	Delete(ctx context.Context, userID primitive.ObjectID, provider string) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
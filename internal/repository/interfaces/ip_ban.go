@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+)
+
+type IPBanRepository interface {
+	Get(ctx context.Context, ip string) (*models.IPBan, error)
+	// RecordFailure counts a failed attempt, starting a new window when the
+	// current one is older than window, and returns the updated record
+	RecordFailure(ctx context.Context, ip string, window, retention time.Duration) (*models.IPBan, error)
+	Ban(ctx context.Context, ip string, until time.Time, retention time.Duration) error
+	ResetFailures(ctx context.Context, ip string) error
+}
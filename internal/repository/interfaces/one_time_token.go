@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// OneTimeTokenRepository stores one-time tokens; it satisfies
+// utils.OneTimeTokenStore
+type OneTimeTokenRepository interface {
+	Save(ctx context.Context, purpose, subject, tokenHash string, expiresAt time.Time) error
+	Consume(ctx context.Context, purpose, tokenHash string) (subject string, expiresAt time.Time, found bool, err error)
+	Revoke(ctx context.Context, purpose, subject string) (bool, error)
+	// DeleteExpired removes tokens of every purpose that expired before
+	// before
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"user-management-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *models.Organization) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error)
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Organization, error)
+	Update(ctx context.Context, org *models.Organization) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type MembershipRepository interface {
+	Create(ctx context.Context, membership *models.Membership) error
+	Get(ctx context.Context, orgID, userID primitive.ObjectID) (*models.Membership, error)
+	// ListByOrg returns a page of an organization's memberships with the
+	// given status, oldest first; an empty status matches any
+	ListByOrg(ctx context.Context, orgID primitive.ObjectID, status string, page, limit int) ([]*models.Membership, int64, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID, status string) ([]*models.Membership, error)
+	// MemberIDs returns the users with an active membership in orgID
+	MemberIDs(ctx context.Context, orgID primitive.ObjectID) ([]primitive.ObjectID, error)
+	CountRole(ctx context.Context, orgID primitive.ObjectID, role string) (int64, error)
+	Activate(ctx context.Context, id primitive.ObjectID) (*models.Membership, error)
+	UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByOrg(ctx context.Context, orgID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
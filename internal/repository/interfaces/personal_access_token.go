@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"user-management-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error)
+	CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	Touch(ctx context.Context, id primitive.ObjectID) error
+	Delete(ctx context.Context, userID, id primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
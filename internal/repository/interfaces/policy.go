@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"user-management-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PolicyRepository interface {
+	Create(ctx context.Context, policy *models.Policy) error
+	List(ctx context.Context) ([]*models.Policy, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type ProjectRepository interface {
+	Create(ctx context.Context, project *models.Project) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error)
+	// List returns a page of projects, newest first; a nil ownerID lists
+	// every owner's projects
+	List(ctx context.Context, ownerID *primitive.ObjectID, page, limit int) ([]*models.Project, int64, error)
+	Update(ctx context.Context, project *models.Project) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
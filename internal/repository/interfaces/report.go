@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type ReportRepository interface {
+	Create(ctx context.Context, report *models.Report) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Report, error)
+	ListByRequester(ctx context.Context, requesterID primitive.ObjectID) ([]*models.Report, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status, reason string) error
+	Complete(ctx context.Context, id primitive.ObjectID, filePath string, size int64) error
+	FailUnfinished(ctx context.Context, reason string) (int64, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type ResumableUploadRepository interface {
+	Create(ctx context.Context, upload *models.ResumableUpload) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.ResumableUpload, error)
+	// SetOffset records how many bytes of the upload have arrived
+	SetOffset(ctx context.Context, id primitive.ObjectID, offset int64) error
+	// Complete records the file the finished upload was saved as
+	Complete(ctx context.Context, id, fileID primitive.ObjectID) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
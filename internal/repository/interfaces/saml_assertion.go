@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// SAMLAssertionRepository remembers the IDs of accepted SAML assertions
+// until they expire, so none can be replayed
+type SAMLAssertionRepository interface {
+	// Claim records id and reports whether it was new; false means the
+	// assertion was accepted before
+	Claim(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
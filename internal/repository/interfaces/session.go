@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"user-management-api/internal/models"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	// ListActive returns the user's unexpired, unrevoked sessions, oldest
+	// first; sessions created in the same millisecond are ordered by ID so
+	// that every caller sees the same order
+	ListActive(ctx context.Context, userID primitive.ObjectID) ([]*models.Session, error)
+	Revoke(ctx context.Context, ids ...primitive.ObjectID) error
+	// Delete removes a session outright, for one that was never used
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// Seen reports whether the user has signed in before from the IP and
+	// from the user agent
+	Seen(ctx context.Context, userID primitive.ObjectID, ip, userAgent string) (ipSeen, userAgentSeen bool, err error)
+	// DeleteExpired removes sessions, revoked or not, that expired before
+	// before
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"user-management-api/internal/models"
+)
+
+type TombstoneRepository interface {
+	Create(ctx context.Context, collection string, documentID primitive.ObjectID) error
+	ListSince(ctx context.Context, collection string, since time.Time, limit int) ([]*models.Tombstone, error)
+	CountBetween(ctx context.Context, collection string, from, to time.Time) (int64, error)
+}
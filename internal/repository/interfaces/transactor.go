@@ -0,0 +1,10 @@
+package interfaces
+
+import "context"
+
+// Transactor runs fn in a database transaction. Repositories called with
+// the context fn receives take part in it. Deployments without
+// transactions (a standalone MongoDB) run fn without one.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
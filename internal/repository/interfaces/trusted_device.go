@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"user-management-api/internal/models"
+)
+
+type TrustedDeviceRepository interface {
+	// Upsert trusts the device, renewing it when already trusted
+	Upsert(ctx context.Context, device *models.TrustedDevice) (*models.TrustedDevice, error)
+	// ListActive returns the user's unexpired devices, newest first
+	ListActive(ctx context.Context, userID primitive.ObjectID) ([]*models.TrustedDevice, error)
+	Delete(ctx context.Context, userID, id primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+	IsTrusted(ctx context.Context, userID primitive.ObjectID, fingerprint string) (bool, error)
+}
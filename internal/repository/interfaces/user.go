@@ -3,15 +3,84 @@ package interfaces
 import (
 	"context"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
 	"user-management-api/internal/models"
 )
 
+// UserFilter narrows a user listing; zero fields match everything
+type UserFilter struct {
+	// IDs limits the listing to these users when not nil
+	IDs []primitive.ObjectID
+	// Search runs a full-text search over usernames, emails and names and
+	// orders the listing by relevance when not empty
+	Search string
+	// Role limits the listing to holders of this role when not empty
+	Role string
+	// Status limits the listing to users with that status when set
+	Status models.UserStatus
+	// Tags limits the listing to users holding every one of these tags
+	Tags []string
+}
+
+// LoginLookup finds the user signing in with an email address. It may
+// answer from a short-lived cache, so it is meant for sign-ins only.
+type LoginLookup interface {
+	GetByEmailForLogin(ctx context.Context, email string) (*models.User, error)
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// Exists reports whether a user has value as its email or username
+	Exists(ctx context.Context, field, value string) (bool, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
-	List(ctx context.Context, page, limit int) ([]*models.User, int64, error)
+	List(ctx context.Context, filter UserFilter, page, limit int) ([]*models.User, int64, error)
+	DeleteMany(ctx context.Context, ids []primitive.ObjectID) (int64, error)
+	// Deactivate deactivates the users among ids whose status allows it
+	Deactivate(ctx context.Context, ids []primitive.ObjectID) (int64, error)
+	// SetStatus moves a user from status from to to, recording reason, and
+	// returns the updated user, or mongo.ErrNoDocuments when its status is
+	// no longer from or a deletion is pending
+	SetStatus(ctx context.Context, id primitive.ObjectID, from, to models.UserStatus, reason string, at time.Time) (*models.User, error)
+	ChangedSince(ctx context.Context, since time.Time, limit int) ([]*models.User, error)
+	ListUpdatedSince(ctx context.Context, since time.Time, limit int) ([]*models.User, error)
+	ForEach(ctx context.Context, filter UserFilter, fn func(*models.User) error) error
+	CountBy(ctx context.Context, groupBy string) ([]models.AggregateBucket, error)
+	// Stats computes the totals, role distribution and daily signups since
+	// the given time in one pass; the daily counts skip days without signups
+	Stats(ctx context.Context, signupsSince time.Time) (*models.UserStats, error)
+	UpdatePreferences(ctx context.Context, id primitive.ObjectID, prefs models.UserPreferences) error
+	UpdateMetadata(ctx context.Context, id primitive.ObjectID, metadata map[string]any) error
+	// AddTags and RemoveTags change a user's tags and return the updated user
+	AddTags(ctx context.Context, id primitive.ObjectID, tags []string) (*models.User, error)
+	RemoveTags(ctx context.Context, id primitive.ObjectID, tags []string) (*models.User, error)
+	MarkEmailVerified(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	// UpdatePassword sets a password the user chose, clearing any demand to
+	// change it; SetTemporaryPassword sets one the user must change
+	UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error
+	SetTemporaryPassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error
+	CountBetween(ctx context.Context, field string, from, to time.Time) (int64, error)
+	// RecordLogin sets the last login and last seen times to at and counts
+	// the login; TouchLastSeen only moves the last seen time forward
+	RecordLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	TouchLastSeen(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	// ScheduleDeletion deactivates a user and records its deletion request,
+	// returning mongo.ErrNoDocuments when one is already pending or the
+	// user was anonymized. CancelDeletion undoes it while DueAt is after now
+	// and returns the restored user.
+	ScheduleDeletion(ctx context.Context, id primitive.ObjectID, req models.DeletionRequest) error
+	CancelDeletion(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.User, error)
+	// ListDeletionDue returns up to limit users whose deletion is due by now
+	ListDeletionDue(ctx context.Context, now time.Time, limit int) ([]*models.User, error)
+	// Anonymize scrubs the personal data of a user whose deletion is due by
+	// now and returns the user as it was before, or mongo.ErrNoDocuments
+	// when the deletion was cancelled or has already been carried out
+	Anonymize(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.User, error)
+	// AcceptInvitation activates an invited user with its first password
+	// and returns it, or mongo.ErrNoDocuments when it is not pending
+	AcceptInvitation(ctx context.Context, id primitive.ObjectID, passwordHash string) (*models.User, error)
 }
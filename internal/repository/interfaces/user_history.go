@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+	"user-management-api/internal/models"
+)
+
+// UserHistoryRepository is append-only; entries are never removed, and only
+// changed when a user is erased
+type UserHistoryRepository interface {
+	Append(ctx context.Context, changes []*models.UserChange) error
+	// ListAfter returns the user's changes made after t, newest first
+	ListAfter(ctx context.Context, userID primitive.ObjectID, t time.Time) ([]*models.UserChange, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]*models.UserChange, int64, error)
+	// RedactFields blanks the old and new values of the user's entries for
+	// the given fields
+	RedactFields(ctx context.Context, userID primitive.ObjectID, fields []string) error
+}
@@ -0,0 +1,79 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type auditLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditLogRepository(db *mongo.Database) interfaces.AuditLogRepository {
+	return &auditLogRepository{
+		collection: db.Collection("audit_logs"),
+	}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *auditLogRepository) ListByResource(ctx context.Context, resource string, id primitive.ObjectID, page, limit int) ([]*models.AuditLog, int64, error) {
+	filter := bson.M{"resource": resource, "resource_id": id}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*models.AuditLog{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// DeleteBefore removes the entries recorded before before
+func (r *auditLogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *auditLogRepository) RedactFields(ctx context.Context, resource string, id primitive.ObjectID, fields []string) error {
+	filter := bson.M{"resource": resource, "resource_id": id}
+	update := bson.M{"$set": bson.M{
+		"changes.$[change].old":      nil,
+		"changes.$[change].new":      nil,
+		"changes.$[change].redacted": true,
+	}}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"change.field": bson.M{"$in": fields}}},
+	})
+	_, err := r.collection.UpdateMany(ctx, filter, update, opts)
+	return err
+}
@@ -0,0 +1,93 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type authEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuthEventRepository(db *mongo.Database) interfaces.AuthEventRepository {
+	return &authEventRepository{
+		collection: db.Collection("auth_events"),
+	}
+}
+
+func (r *authEventRepository) Create(ctx context.Context, event *models.AuthEvent) error {
+	event.ID = primitive.NewObjectID()
+	event.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+// Find returns a page of matching events, newest first
+func (r *authEventRepository) Find(ctx context.Context, filter interfaces.AuthEventFilter, page, limit int) ([]*models.AuthEvent, int64, error) {
+	query := bson.M{}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+	if filter.ActorID != nil {
+		query["actor_id"] = *filter.ActorID
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	createdAt := bson.M{}
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []*models.AuthEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// DeleteBefore removes the events recorded before before
+func (r *authEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *authEventRepository) AnonymizeUser(ctx context.Context, userID primitive.ObjectID) error {
+	filter := bson.M{"$or": []bson.M{{"user_id": userID}, {"actor_id": userID}}}
+	update := bson.M{"$unset": bson.M{"email": "", "ip": "", "user_agent": ""}}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,46 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type authorizationCodeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuthorizationCodeRepository(db *mongo.Database) interfaces.AuthorizationCodeRepository {
+	return &authorizationCodeRepository{
+		collection: db.Collection("oauth_codes"),
+	}
+}
+
+func (r *authorizationCodeRepository) Create(ctx context.Context, code *models.AuthorizationCode) error {
+	code.ID = primitive.NewObjectID()
+	code.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, code)
+	return err
+}
+
+// Consume deletes and returns an unexpired code, so a code can be redeemed
+// only once even under concurrent requests
+func (r *authorizationCodeRepository) Consume(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	filter := bson.M{
+		"code_hash":  codeHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var code models.AuthorizationCode
+	err := r.collection.FindOneAndDelete(ctx, filter).Decode(&code)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
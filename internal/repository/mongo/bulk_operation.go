@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type bulkOperationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBulkOperationRepository(db *mongo.Database) interfaces.BulkOperationRepository {
+	return &bulkOperationRepository{
+		collection: db.Collection("bulk_operations"),
+	}
+}
+
+func (r *bulkOperationRepository) Create(ctx context.Context, operation *models.BulkOperation) error {
+	operation.ID = primitive.NewObjectID()
+	operation.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, operation)
+	return err
+}
+
+func (r *bulkOperationRepository) List(ctx context.Context, page, limit int) ([]*models.BulkOperation, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	operations := []*models.BulkOperation{}
+	if err := cursor.All(ctx, &operations); err != nil {
+		return nil, 0, err
+	}
+	return operations, total, nil
+}
+
+// DeleteBefore removes the operations recorded before before
+func (r *bulkOperationRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type clientRepository struct {
+	collection *mongo.Collection
+}
+
+func NewClientRepository(db *mongo.Database) interfaces.ClientRepository {
+	return &clientRepository{
+		collection: db.Collection("oauth_clients"),
+	}
+}
+
+func (r *clientRepository) Create(ctx context.Context, client *models.OAuthClient) error {
+	client.ID = primitive.NewObjectID()
+	client.CreatedAt = time.Now()
+	client.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, client)
+	return err
+}
+
+func (r *clientRepository) GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	err := r.collection.FindOne(ctx, bson.M{"client_id": clientID}).Decode(&client)
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *clientRepository) List(ctx context.Context) ([]*models.OAuthClient, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	clients := []*models.OAuthClient{}
+	for cursor.Next(ctx) {
+		var client models.OAuthClient
+		if err := cursor.Decode(&client); err != nil {
+			return nil, err
+		}
+		clients = append(clients, &client)
+	}
+
+	return clients, nil
+}
+
+func (r *clientRepository) Deactivate(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"is_active":  false,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
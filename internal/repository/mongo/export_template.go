@@ -0,0 +1,73 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type exportTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExportTemplateRepository(db *mongo.Database) interfaces.ExportTemplateRepository {
+	return &exportTemplateRepository{
+		collection: db.Collection("export_templates"),
+	}
+}
+
+func (r *exportTemplateRepository) Create(ctx context.Context, template *models.ExportTemplate) error {
+	template.ID = primitive.NewObjectID()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, template)
+	return err
+}
+
+func (r *exportTemplateRepository) GetByID(ctx context.Context, id, ownerID primitive.ObjectID) (*models.ExportTemplate, error) {
+	var template models.ExportTemplate
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "owner_id": ownerID}).Decode(&template)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *exportTemplateRepository) ListByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]*models.ExportTemplate, error) {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"owner_id": ownerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*models.ExportTemplate{}
+	for cursor.Next(ctx) {
+		var template models.ExportTemplate
+		if err := cursor.Decode(&template); err != nil {
+			return nil, err
+		}
+		templates = append(templates, &template)
+	}
+
+	return templates, nil
+}
+
+func (r *exportTemplateRepository) Delete(ctx context.Context, id, ownerID primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "owner_id": ownerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type fileRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFileRepository(db *mongo.Database) interfaces.FileRepository {
+	return &fileRepository{
+		collection: db.Collection("files"),
+	}
+}
+
+func (r *fileRepository) Create(ctx context.Context, file *models.File) error {
+	file.ID = primitive.NewObjectID()
+	file.CreatedAt = time.Now()
+	file.UpdatedAt = file.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, file)
+	return err
+}
+
+func (r *fileRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.File, error) {
+	var file models.File
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&file)
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *fileRepository) GetByKey(ctx context.Context, ownerID primitive.ObjectID, key string) (*models.File, error) {
+	var file models.File
+	err := r.collection.FindOne(ctx, bson.M{"owner_id": ownerID, "key": key}).Decode(&file)
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *fileRepository) GetByChecksum(ctx context.Context, checksum string) (*models.File, error) {
+	var file models.File
+	filter := bson.M{"checksum": checksum, "status": models.FileStatusReady}
+	err := r.collection.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "_id", Value: 1}})).Decode(&file)
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *fileRepository) CountByKey(ctx context.Context, key string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"key": key})
+}
+
+func (r *fileRepository) KeyVisibility(ctx context.Context, key string) (string, error) {
+	var file models.File
+	filter := bson.M{"$or": bson.A{bson.M{"key": key}, bson.M{"variants.key": key}}}
+	// "public" sorts after "private"
+	opts := options.FindOne().SetSort(bson.D{{Key: "visibility", Value: -1}}).SetProjection(bson.M{"visibility": 1})
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&file)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return file.Visibility, nil
+}
+
+func (r *fileRepository) ForEachKey(ctx context.Context, fn func(key string) error) error {
+	opts := options.Find().SetProjection(bson.M{"key": 1, "variants.key": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file models.File
+		if err := cursor.Decode(&file); err != nil {
+			return err
+		}
+		if err := fn(file.Key); err != nil {
+			return err
+		}
+		for _, variant := range file.Variants {
+			if err := fn(variant.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *fileRepository) List(ctx context.Context, ownerID primitive.ObjectID, page, limit int) ([]*models.File, int64, error) {
+	filter := bson.M{"owner_id": ownerID}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	files := []*models.File{}
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, 0, err
+	}
+	return files, total, nil
+}
+
+func (r *fileRepository) Update(ctx context.Context, file *models.File) error {
+	file.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"key":          file.Key,
+			"size":         file.Size,
+			"content_type": file.ContentType,
+			"checksum":     file.Checksum,
+			"visibility":   file.Visibility,
+			"status":       file.Status,
+			"scan":         file.Scan,
+			"updated_at":   file.UpdatedAt,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": file.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *fileRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
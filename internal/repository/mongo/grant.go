@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type grantRepository struct {
+	collection *mongo.Collection
+}
+
+func NewGrantRepository(db *mongo.Database) interfaces.GrantRepository {
+	return &grantRepository{
+		collection: db.Collection("grants"),
+	}
+}
+
+func (r *grantRepository) Create(ctx context.Context, grant *models.Grant) error {
+	grant.ID = primitive.NewObjectID()
+	grant.CreatedAt = time.Now()
+	grant.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, grant)
+	return err
+}
+
+func (r *grantRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Grant, error) {
+	var grant models.Grant
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&grant)
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (r *grantRepository) ListByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]*models.Grant, error) {
+	return r.find(ctx, bson.M{"owner_id": ownerID})
+}
+
+func (r *grantRepository) ListByGrantee(ctx context.Context, granteeID primitive.ObjectID) ([]*models.Grant, error) {
+	return r.find(ctx, bson.M{"grantee_id": granteeID})
+}
+
+func (r *grantRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *grantRepository) FindActive(ctx context.Context, ownerID, granteeID primitive.ObjectID, scope string) (*models.Grant, error) {
+	filter := bson.M{
+		"owner_id":   ownerID,
+		"grantee_id": granteeID,
+		"scopes":     scope,
+		"status":     models.GrantStatusActive,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+
+	var grant models.Grant
+	err := r.collection.FindOne(ctx, filter).Decode(&grant)
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (r *grantRepository) find(ctx context.Context, filter bson.M) ([]*models.Grant, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	grants := []*models.Grant{}
+	for cursor.Next(ctx) {
+		var grant models.Grant
+		if err := cursor.Decode(&grant); err != nil {
+			return nil, err
+		}
+		grants = append(grants, &grant)
+	}
+
+	return grants, nil
+}
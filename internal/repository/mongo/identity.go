@@ -0,0 +1,72 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type identityRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdentityRepository(db *mongo.Database) interfaces.IdentityRepository {
+	return &identityRepository{
+		collection: db.Collection("identities"),
+	}
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *models.Identity) error {
+	identity.ID = primitive.NewObjectID()
+	identity.LinkedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, identity)
+	return err
+}
+
+func (r *identityRepository) GetBySubject(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	err := r.collection.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]*models.Identity, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "linked_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	identities := []*models.Identity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *identityRepository) Delete(ctx context.Context, userID primitive.ObjectID, provider string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID, "provider": provider})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *identityRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ipBanRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIPBanRepository(db *mongo.Database) interfaces.IPBanRepository {
+	return &ipBanRepository{
+		collection: db.Collection("ip_bans"),
+	}
+}
+
+func (r *ipBanRepository) Get(ctx context.Context, ip string) (*models.IPBan, error) {
+	var ban models.IPBan
+	err := r.collection.FindOne(ctx, bson.M{"_id": ip}).Decode(&ban)
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// RecordFailure uses a pipeline update so the window check and the
+// increment happen atomically across instances
+func (r *ipBanRepository) RecordFailure(ctx context.Context, ip string, window, retention time.Duration) (*models.IPBan, error) {
+	now := time.Now()
+	inWindow := bson.M{"$gt": bson.A{"$window_started_at", now.Add(-window)}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failed_attempts": bson.M{"$cond": bson.A{
+				inWindow,
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failed_attempts", 0}}, 1}},
+				1,
+			}},
+			"window_started_at": bson.M{"$cond": bson.A{inWindow, "$window_started_at", now}},
+			"ban_count":         bson.M{"$ifNull": bson.A{"$ban_count", 0}},
+			"expires_at":        bson.M{"$max": bson.A{"$expires_at", now.Add(retention)}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var ban models.IPBan
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": ip}, update, opts).Decode(&ban)
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+func (r *ipBanRepository) Ban(ctx context.Context, ip string, until time.Time, retention time.Duration) error {
+	update := bson.M{
+		"$set": bson.M{
+			"banned_until":    until,
+			"failed_attempts": 0,
+			"expires_at":      until.Add(retention),
+		},
+		"$inc": bson.M{"ban_count": 1},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": ip}, update)
+	return err
+}
+
+// ResetFailures clears the attempt counter after a successful sign-in but
+// keeps the ban count, so a repeat offender still escalates
+func (r *ipBanRepository) ResetFailures(ctx context.Context, ip string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": ip}, bson.M{"$set": bson.M{"failed_attempts": 0}})
+	return err
+}
@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type oneTimeTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOneTimeTokenRepository(db *mongo.Database) interfaces.OneTimeTokenRepository {
+	return &oneTimeTokenRepository{
+		collection: db.Collection("one_time_tokens"),
+	}
+}
+
+// Save keeps at most one token per purpose and subject
+func (r *oneTimeTokenRepository) Save(ctx context.Context, purpose, subject, tokenHash string, expiresAt time.Time) error {
+	token := models.OneTimeToken{
+		Purpose:   purpose,
+		Subject:   subject,
+		TokenHash: tokenHash,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	filter := bson.M{"purpose": purpose, "subject": subject}
+	_, err := r.collection.ReplaceOne(ctx, filter, token, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Consume deletes the token in the same operation that finds it, so
+// concurrent requests cannot both use it. Expired tokens the TTL index has
+// not removed yet are deleted too; the caller rejects them by expiresAt.
+func (r *oneTimeTokenRepository) Consume(ctx context.Context, purpose, tokenHash string) (string, time.Time, bool, error) {
+	filter := bson.M{
+		"purpose":    purpose,
+		"token_hash": tokenHash,
+	}
+	var token models.OneTimeToken
+	err := r.collection.FindOneAndDelete(ctx, filter).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", time.Time{}, false, nil
+		}
+		return "", time.Time{}, false, err
+	}
+	return token.Subject, token.ExpiresAt, true, nil
+}
+
+func (r *oneTimeTokenRepository) Revoke(ctx context.Context, purpose, subject string) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"purpose": purpose, "subject": subject})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *oneTimeTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
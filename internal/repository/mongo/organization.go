@@ -0,0 +1,237 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type organizationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrganizationRepository(db *mongo.Database) interfaces.OrganizationRepository {
+	return &organizationRepository{
+		collection: db.Collection("organizations"),
+	}
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	org.ID = primitive.NewObjectID()
+	org.CreatedAt = time.Now()
+	org.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, org)
+	return err
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Organization, error) {
+	var org models.Organization
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&org)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Organization, error) {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orgs := []*models.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) error {
+	org.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":        org.Name,
+			"description": org.Description,
+			"updated_at":  org.UpdatedAt,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": org.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *organizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+type membershipRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMembershipRepository(db *mongo.Database) interfaces.MembershipRepository {
+	return &membershipRepository{
+		collection: db.Collection("memberships"),
+	}
+}
+
+func (r *membershipRepository) Create(ctx context.Context, membership *models.Membership) error {
+	membership.ID = primitive.NewObjectID()
+	membership.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, membership)
+	return err
+}
+
+func (r *membershipRepository) Get(ctx context.Context, orgID, userID primitive.ObjectID) (*models.Membership, error) {
+	var membership models.Membership
+	err := r.collection.FindOne(ctx, bson.M{"org_id": orgID, "user_id": userID}).Decode(&membership)
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *membershipRepository) ListByOrg(ctx context.Context, orgID primitive.ObjectID, status string, page, limit int) ([]*models.Membership, int64, error) {
+	filter := bson.M{"org_id": orgID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"created_at": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	memberships := []*models.Membership{}
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, 0, err
+	}
+	return memberships, total, nil
+}
+
+func (r *membershipRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, status string) ([]*models.Membership, error) {
+	filter := bson.M{"user_id": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	memberships := []*models.Membership{}
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *membershipRepository) MemberIDs(ctx context.Context, orgID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := bson.M{"org_id": orgID, "status": models.MembershipActive}
+	opts := options.Find().SetProjection(bson.M{"user_id": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ids := []primitive.ObjectID{}
+	for cursor.Next(ctx) {
+		var membership models.Membership
+		if err := cursor.Decode(&membership); err != nil {
+			return nil, err
+		}
+		ids = append(ids, membership.UserID)
+	}
+	return ids, cursor.Err()
+}
+
+func (r *membershipRepository) CountRole(ctx context.Context, orgID primitive.ObjectID, role string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"org_id": orgID, "role": role, "status": models.MembershipActive})
+}
+
+func (r *membershipRepository) Activate(ctx context.Context, id primitive.ObjectID) (*models.Membership, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"status":    models.MembershipActive,
+			"joined_at": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var membership models.Membership
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": models.MembershipInvited}, update, opts).Decode(&membership)
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *membershipRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *membershipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *membershipRepository) DeleteByOrg(ctx context.Context, orgID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"org_id": orgID})
+	return err
+}
+
+func (r *membershipRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
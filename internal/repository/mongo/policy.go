@@ -0,0 +1,58 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type policyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPolicyRepository(db *mongo.Database) interfaces.PolicyRepository {
+	return &policyRepository{
+		collection: db.Collection("policies"),
+	}
+}
+
+func (r *policyRepository) Create(ctx context.Context, policy *models.Policy) error {
+	policy.ID = primitive.NewObjectID()
+	policy.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, policy)
+	return err
+}
+
+func (r *policyRepository) List(ctx context.Context) ([]*models.Policy, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "object", Value: 1}, {Key: "subject", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	policies := []*models.Policy{}
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *policyRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type projectRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProjectRepository(db *mongo.Database) interfaces.ProjectRepository {
+	return &projectRepository{
+		collection: db.Collection("projects"),
+	}
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *models.Project) error {
+	project.ID = primitive.NewObjectID()
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, project)
+	return err
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error) {
+	var project models.Project
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&project)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) List(ctx context.Context, ownerID *primitive.ObjectID, page, limit int) ([]*models.Project, int64, error) {
+	filter := bson.M{}
+	if ownerID != nil {
+		filter["owner_id"] = *ownerID
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	projects := []*models.Project{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, 0, err
+	}
+	return projects, total, nil
+}
+
+func (r *projectRepository) Update(ctx context.Context, project *models.Project) error {
+	project.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":        project.Name,
+			"description": project.Description,
+			"updated_at":  project.UpdatedAt,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": project.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *projectRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type reportRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReportRepository(db *mongo.Database) interfaces.ReportRepository {
+	return &reportRepository{
+		collection: db.Collection("reports"),
+	}
+}
+
+func (r *reportRepository) Create(ctx context.Context, report *models.Report) error {
+	report.ID = primitive.NewObjectID()
+	report.CreatedAt = time.Now()
+	report.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, report)
+	return err
+}
+
+func (r *reportRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Report, error) {
+	var report models.Report
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&report)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *reportRepository) ListByRequester(ctx context.Context, requesterID primitive.ObjectID) ([]*models.Report, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetLimit(100)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"requested_by": requesterID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []*models.Report{}
+	for cursor.Next(ctx) {
+		var report models.Report
+		if err := cursor.Decode(&report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, &report)
+	}
+
+	return reports, nil
+}
+
+func (r *reportRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status, reason string) error {
+	set := bson.M{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	if reason != "" {
+		set["error"] = reason
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *reportRepository) Complete(ctx context.Context, id primitive.ObjectID, filePath string, size int64) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.ReportStatusCompleted,
+			"file_path":    filePath,
+			"size":         size,
+			"completed_at": now,
+			"updated_at":   now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FailUnfinished marks every pending or running report as failed. Jobs live
+// in process memory, so after a restart nothing will ever finish them.
+func (r *reportRepository) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	filter := bson.M{"status": bson.M{"$in": []string{models.ReportStatusPending, models.ReportStatusRunning}}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.ReportStatusFailed,
+			"error":      reason,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
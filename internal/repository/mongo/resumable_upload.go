@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type resumableUploadRepository struct {
+	collection *mongo.Collection
+}
+
+func NewResumableUploadRepository(db *mongo.Database) interfaces.ResumableUploadRepository {
+	return &resumableUploadRepository{
+		collection: db.Collection("resumable_uploads"),
+	}
+}
+
+func (r *resumableUploadRepository) Create(ctx context.Context, upload *models.ResumableUpload) error {
+	upload.ID = primitive.NewObjectID()
+	upload.CreatedAt = time.Now()
+	upload.UpdatedAt = upload.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, upload)
+	return err
+}
+
+func (r *resumableUploadRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ResumableUpload, error) {
+	var upload models.ResumableUpload
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&upload)
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *resumableUploadRepository) SetOffset(ctx context.Context, id primitive.ObjectID, offset int64) error {
+	return r.update(ctx, id, bson.M{"offset": offset})
+}
+
+func (r *resumableUploadRepository) Complete(ctx context.Context, id, fileID primitive.ObjectID) error {
+	return r.update(ctx, id, bson.M{"file_id": fileID})
+}
+
+func (r *resumableUploadRepository) update(ctx context.Context, id primitive.ObjectID, set bson.M) error {
+	set["updated_at"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *resumableUploadRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type roleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRoleRepository(db *mongo.Database) interfaces.RoleRepository {
+	return &roleRepository{
+		collection: db.Collection("roles"),
+	}
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
+	role.ID = primitive.NewObjectID()
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, role)
+	return err
+}
+
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&role)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]*models.Role, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	roles := []*models.Role{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) Update(ctx context.Context, role *models.Role) error {
+	role.UpdatedAt = time.Now()
+
+	set := bson.M{
+		"description": role.Description,
+		"permissions": role.Permissions,
+		"inherits":    role.Inherits,
+		"updated_at":  role.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if role.SessionLimit.IsZero() {
+		update["$unset"] = bson.M{"session_limit": ""}
+	} else {
+		set["session_limit"] = role.SessionLimit
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": role.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *roleRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type samlAssertionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSAMLAssertionRepository(db *mongo.Database) interfaces.SAMLAssertionRepository {
+	return &samlAssertionRepository{
+		collection: db.Collection("saml_assertions"),
+	}
+}
+
+// Claim inserts the assertion ID as the document _id, so of two requests
+// carrying the same assertion only one insert succeeds
+func (r *samlAssertionRepository) Claim(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	_, err := r.collection.InsertOne(ctx, bson.M{
+		"_id":        id,
+		"created_at": time.Now(),
+		"expires_at": expiresAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type tombstoneRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTombstoneRepository(db *mongo.Database) interfaces.TombstoneRepository {
+	return &tombstoneRepository{
+		collection: db.Collection("tombstones"),
+	}
+}
+
+func (r *tombstoneRepository) Create(ctx context.Context, collection string, documentID primitive.ObjectID) error {
+	tombstone := &models.Tombstone{
+		ID:         primitive.NewObjectID(),
+		Collection: collection,
+		DocumentID: documentID,
+		DeletedAt:  time.Now(),
+	}
+
+	_, err := r.collection.InsertOne(ctx, tombstone)
+	return err
+}
+
+func (r *tombstoneRepository) ListSince(ctx context.Context, collection string, since time.Time, limit int) ([]*models.Tombstone, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"deleted_at": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"collection": collection, "deleted_at": bson.M{"$gt": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tombstones []*models.Tombstone
+	for cursor.Next(ctx) {
+		var tombstone models.Tombstone
+		if err := cursor.Decode(&tombstone); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, &tombstone)
+	}
+
+	return tombstones, nil
+}
+
+func (r *tombstoneRepository) CountBetween(ctx context.Context, collection string, from, to time.Time) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"collection": collection, "deleted_at": bson.M{"$gte": from, "$lt": to}})
+}
@@ -0,0 +1,41 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// illegalOperation is the error code of transactions sent to a standalone
+// server, which only replica sets and sharded clusters support
+const illegalOperation = 20
+
+type transactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor(db *mongo.Database) interfaces.Transactor {
+	return &transactor{
+		client: db.Client(),
+	}
+}
+
+func (t *transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		return nil, fn(sc)
+	})
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == illegalOperation {
+		// the first write failed, so nothing was written yet
+		return fn(ctx)
+	}
+	return err
+}
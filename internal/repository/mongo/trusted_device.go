@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type trustedDeviceRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTrustedDeviceRepository(db *mongo.Database) interfaces.TrustedDeviceRepository {
+	return &trustedDeviceRepository{
+		collection: db.Collection("trusted_devices"),
+	}
+}
+
+func (r *trustedDeviceRepository) Upsert(ctx context.Context, device *models.TrustedDevice) (*models.TrustedDevice, error) {
+	filter := bson.M{"user_id": device.UserID, "fingerprint": device.Fingerprint}
+	update := bson.M{
+		"$set": bson.M{
+			"name":       device.Name,
+			"user_agent": device.UserAgent,
+			"ip":         device.IP,
+			"expires_at": device.ExpiresAt,
+		},
+		"$setOnInsert": bson.M{"created_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved models.TrustedDevice
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (r *trustedDeviceRepository) ListActive(ctx context.Context, userID primitive.ObjectID) ([]*models.TrustedDevice, error) {
+	filter := bson.M{"user_id": userID, "expires_at": bson.M{"$gt": time.Now()}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	devices := []*models.TrustedDevice{}
+	if err := cursor.All(ctx, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// Delete only removes devices belonging to userID
+func (r *trustedDeviceRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *trustedDeviceRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// IsTrusted checks the expiry itself, since the TTL monitor only runs once
+// a minute
+func (r *trustedDeviceRepository) IsTrusted(ctx context.Context, userID primitive.ObjectID, fingerprint string) (bool, error) {
+	filter := bson.M{
+		"user_id":     userID,
+		"fingerprint": fingerprint,
+		"expires_at":  bson.M{"$gt": time.Now()},
+	}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"fmt"
 	"time"
 	"user-management-api/internal/models"
 	"user-management-api/internal/repository/interfaces"
@@ -26,6 +27,9 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	user.ID = primitive.NewObjectID()
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
+	if user.PasswordChangedAt == nil {
+		user.PasswordChangedAt = &user.CreatedAt
+	}
 
 	_, err := r.collection.InsertOne(ctx, user)
 	return err
@@ -40,6 +44,25 @@ func (r *userRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*m
 	return &user, nil
 }
 
+func (r *userRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.User, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
@@ -58,6 +81,18 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	return &user, nil
 }
 
+// Exists counts at most one document, answered from the unique index
+func (r *userRepository) Exists(ctx context.Context, field, value string) (bool, error) {
+	if field != "email" && field != "username" {
+		return false, fmt.Errorf("unsupported exists field %q", field)
+	}
+	n, err := r.collection.CountDocuments(ctx, bson.M{field: value}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	user.UpdatedAt = time.Now()
 
@@ -68,7 +103,8 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 			"first_name": user.FirstName,
 			"last_name":  user.LastName,
 			"role":       user.Role,
-			"is_active":  user.IsActive,
+			"avatar":     user.Avatar,
+			"locale":     user.Locale,
 			"updated_at": user.UpdatedAt,
 		},
 	}
@@ -82,11 +118,62 @@ func (r *userRepository) Delete(ctx context.Context, id primitive.ObjectID) erro
 	return err
 }
 
-func (r *userRepository) List(ctx context.Context, page, limit int) ([]*models.User, int64, error) {
+func (r *userRepository) DeleteMany(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// Deactivate deactivates the users among ids whose status allows it and
+// that have no deletion pending
+func (r *userRepository) Deactivate(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":      bson.M{"$in": ids},
+		"status":   bson.M{"$in": models.StatusesBecoming(models.UserStatusDeactivated)},
+		"deletion": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": models.UserStatusDeactivated, "status_changed_at": now, "updated_at": now},
+		"$unset": bson.M{"status_reason": ""},
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *userRepository) SetStatus(ctx context.Context, id primitive.ObjectID, from, to models.UserStatus, reason string, at time.Time) (*models.User, error) {
+	filter := bson.M{"_id": id, "status": from, "deletion": bson.M{"$exists": false}}
+	set := bson.M{"status": to, "status_changed_at": at, "updated_at": at}
+	update := bson.M{"$set": set}
+	if reason != "" {
+		set["status_reason"] = reason
+	} else {
+		update["$unset"] = bson.M{"status_reason": ""}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) List(ctx context.Context, filter interfaces.UserFilter, page, limit int) ([]*models.User, int64, error) {
 	skip := (page - 1) * limit
+	query := userFilterQuery(filter)
+	sort := bson.D{{Key: "created_at", Value: -1}}
+	if filter.Search != "" {
+		sort = append(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}, sort...)
+	}
 
 	// Count total documents
-	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	total, err := r.collection.CountDocuments(ctx, query)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -95,9 +182,9 @@ func (r *userRepository) List(ctx context.Context, page, limit int) ([]*models.U
 	opts := options.Find().
 		SetSkip(int64(skip)).
 		SetLimit(int64(limit)).
-		SetSort(bson.M{"created_at": -1})
+		SetSort(sort)
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.collection.Find(ctx, query, opts)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -114,3 +201,431 @@ func (r *userRepository) List(ctx context.Context, page, limit int) ([]*models.U
 
 	return users, total, nil
 }
+
+func (r *userRepository) ChangedSince(ctx context.Context, since time.Time, limit int) ([]*models.User, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"updated_at": 1}).
+		SetProjection(bson.M{"_id": 1, "updated_at": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"updated_at": bson.M{"$gt": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
+func (r *userRepository) ListUpdatedSince(ctx context.Context, since time.Time, limit int) ([]*models.User, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.M{"updated_at": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"updated_at": bson.M{"$gt": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
+// userFilterQuery turns filter into a query on the users collection
+func userFilterQuery(filter interfaces.UserFilter) bson.M {
+	query := bson.M{}
+	if filter.IDs != nil {
+		query["_id"] = bson.M{"$in": filter.IDs}
+	}
+	if filter.Search != "" {
+		query["$text"] = bson.M{"$search": filter.Search}
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+	return query
+}
+
+// ForEach streams the users matching filter to fn in creation order,
+// stopping at the first error. Search only narrows the users here; it does
+// not change the order.
+func (r *userRepository) ForEach(ctx context.Context, filter interfaces.UserFilter, fn func(*models.User) error) error {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+
+	cursor, err := r.collection.Find(ctx, userFilterQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// aggregateGroupKeys maps supported group_by values to $group expressions
+var aggregateGroupKeys = map[string]any{
+	"role":          "$role",
+	"status":        "$status",
+	"created_month": bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$created_at"}},
+}
+
+func (r *userRepository) CountBy(ctx context.Context, groupBy string) ([]models.AggregateBucket, error) {
+	groupKey, ok := aggregateGroupKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported group_by %q", groupBy)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": groupKey, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	buckets := []models.AggregateBucket{}
+	for cursor.Next(ctx) {
+		var row struct {
+			Key   any   `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, models.AggregateBucket{Key: row.Key, Count: row.Count})
+	}
+
+	return buckets, nil
+}
+
+func (r *userRepository) Stats(ctx context.Context, signupsSince time.Time) (*models.UserStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$facet", Value: bson.M{
+			"total":  bson.A{bson.M{"$count": "count"}},
+			"active": bson.A{bson.M{"$match": bson.M{"status": models.UserStatusActive}}, bson.M{"$count": "count"}},
+			"by_role": bson.A{
+				bson.M{"$group": bson.M{"_id": "$role", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"signups": bson.A{
+				bson.M{"$match": bson.M{"created_at": bson.M{"$gte": signupsSince}}},
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$created_at"}},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	type bucket struct {
+		Key   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	var facets struct {
+		Total   []bucket `bson:"total"`
+		Active  []bucket `bson:"active"`
+		ByRole  []bucket `bson:"by_role"`
+		Signups []bucket `bson:"signups"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&facets); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := &models.UserStats{
+		ByRole:        make([]models.AggregateBucket, 0, len(facets.ByRole)),
+		SignupsPerDay: make([]models.DailyCount, 0, len(facets.Signups)),
+	}
+	if len(facets.Total) > 0 {
+		stats.Total = facets.Total[0].Count
+	}
+	if len(facets.Active) > 0 {
+		stats.Active = facets.Active[0].Count
+	}
+	for _, b := range facets.ByRole {
+		stats.ByRole = append(stats.ByRole, models.AggregateBucket{Key: b.Key, Count: b.Count})
+	}
+	for _, b := range facets.Signups {
+		stats.SignupsPerDay = append(stats.SignupsPerDay, models.DailyCount{Date: b.Key, Count: b.Count})
+	}
+	return stats, nil
+}
+
+// CountBetween counts users whose timestamp field falls in [from, to)
+func (r *userRepository) CountBetween(ctx context.Context, field string, from, to time.Time) (int64, error) {
+	if field != "created_at" && field != "updated_at" {
+		return 0, fmt.Errorf("unsupported count field %q", field)
+	}
+	return r.collection.CountDocuments(ctx, bson.M{field: bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *userRepository) UpdatePreferences(ctx context.Context, id primitive.ObjectID, prefs models.UserPreferences) error {
+	update := bson.M{"$set": bson.M{"preferences": prefs, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateMetadata(ctx context.Context, id primitive.ObjectID, metadata map[string]any) error {
+	update := bson.M{"$set": bson.M{"metadata": metadata, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *userRepository) AddTags(ctx context.Context, id primitive.ObjectID, tags []string) (*models.User, error) {
+	update := bson.M{
+		"$addToSet": bson.M{"tags": bson.M{"$each": tags}},
+		"$set":      bson.M{"updated_at": time.Now()},
+	}
+	return r.updateTags(ctx, id, update)
+}
+
+func (r *userRepository) RemoveTags(ctx context.Context, id primitive.ObjectID, tags []string) (*models.User, error) {
+	update := bson.M{
+		"$pullAll": bson.M{"tags": tags},
+		"$set":     bson.M{"updated_at": time.Now()},
+	}
+	return r.updateTags(ctx, id, update)
+}
+
+func (r *userRepository) updateTags(ctx context.Context, id primitive.ObjectID, update bson.M) (*models.User, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var user models.User
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RecordLogin leaves updated_at alone so sign-ins do not show up as
+// changes to delta sync clients
+func (r *userRepository) RecordLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"last_login_at": at},
+		"$max": bson.M{"last_seen_at": at},
+		"$inc": bson.M{"login_count": 1},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *userRepository) TouchLastSeen(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$max": bson.M{"last_seen_at": at}})
+	return err
+}
+
+// MarkEmailVerified flags the user's address as verified and returns the
+// updated user
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	update := bson.M{"$set": bson.M{"email_verified": true, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdatePassword sets a new password hash and restarts its expiry clock
+func (r *userRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set":   bson.M{"password": passwordHash, "password_changed_at": now, "updated_at": now},
+		"$unset": bson.M{"password_change_required": ""},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *userRepository) SetTemporaryPassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		"password":                 passwordHash,
+		"password_changed_at":      now,
+		"password_change_required": true,
+		"updated_at":               now,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id primitive.ObjectID, req models.DeletionRequest) error {
+	filter := bson.M{"_id": id, "deletion": bson.M{"$exists": false}, "anonymized_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"deletion": req, "status": models.UserStatusDeactivated, "updated_at": req.RequestedAt}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *userRepository) CancelDeletion(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.User, error) {
+	filter := bson.M{"_id": id, "deletion.due_at": bson.M{"$gt": now}}
+	// A pipeline, so the restored state can be read from the request
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"status": "$deletion.previous_status", "updated_at": now}}},
+		{{Key: "$unset", Value: "deletion"}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) ListDeletionDue(ctx context.Context, now time.Time, limit int) ([]*models.User, error) {
+	filter := bson.M{"deletion.due_at": bson.M{"$lte": now}, "anonymized_at": bson.M{"$exists": false}}
+	opts := options.Find().SetSort(bson.D{{Key: "deletion.due_at", Value: 1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Anonymize keeps what aggregate reporting needs (id, role, creation date
+// and login count) and replaces the rest. Username and email get
+// placeholders derived from the id so they stay unique.
+func (r *userRepository) Anonymize(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.User, error) {
+	filter := bson.M{"_id": id, "deletion.due_at": bson.M{"$lte": now}, "anonymized_at": bson.M{"$exists": false}}
+	placeholder := "deleted-" + id.Hex()
+	update := bson.M{
+		"$set": bson.M{
+			"username":       placeholder,
+			"email":          placeholder + "@invalid",
+			"password":       "",
+			"first_name":     "",
+			"last_name":      "",
+			"status":         models.UserStatusDeactivated,
+			"email_verified": false,
+			"preferences":    models.UserPreferences{},
+			"anonymized_at":  now,
+			"updated_at":     now,
+		},
+		"$unset": bson.M{
+			"avatar":              "",
+			"locale":              "",
+			"metadata":            "",
+			"password_changed_at": "",
+			"last_login_at":       "",
+			"last_seen_at":        "",
+			"status_reason":       "",
+			"deletion":            "",
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var user models.User
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AcceptInvitation also marks the email verified, since the invitation
+// link was delivered to it
+func (r *userRepository) AcceptInvitation(ctx context.Context, id primitive.ObjectID, passwordHash string) (*models.User, error) {
+	now := time.Now()
+	filter := bson.M{"_id": id, "invitation": bson.M{"$exists": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"password":            passwordHash,
+			"password_changed_at": now,
+			"status":              models.UserStatusActive,
+			"email_verified":      true,
+			"updated_at":          now,
+		},
+		"$unset": bson.M{"invitation": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
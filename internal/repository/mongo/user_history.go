@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type userHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUserHistoryRepository(db *mongo.Database) interfaces.UserHistoryRepository {
+	return &userHistoryRepository{
+		collection: db.Collection("user_history"),
+	}
+}
+
+func (r *userHistoryRepository) Append(ctx context.Context, changes []*models.UserChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	now := time.Now()
+	docs := make([]interface{}, len(changes))
+	for i, change := range changes {
+		change.ID = primitive.NewObjectID()
+		if change.ChangedAt.IsZero() {
+			change.ChangedAt = now
+		}
+		docs[i] = change
+	}
+
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *userHistoryRepository) ListAfter(ctx context.Context, userID primitive.ObjectID, t time.Time) ([]*models.UserChange, error) {
+	filter := bson.M{"user_id": userID, "changed_at": bson.M{"$gt": t}}
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []*models.UserChange
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ListByUser returns a page of the user's history, newest first
+func (r *userHistoryRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]*models.UserChange, int64, error) {
+	filter := bson.M{"user_id": userID}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "changed_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []*models.UserChange
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
+
+func (r *userHistoryRepository) RedactFields(ctx context.Context, userID primitive.ObjectID, fields []string) error {
+	filter := bson.M{"user_id": userID, "field": bson.M{"$in": fields}}
+	update := bson.M{"$set": bson.M{"old_value": nil, "new_value": nil}}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"user-management-api/internal/config"
+	"user-management-api/internal/handlers"
+	"user-management-api/internal/models"
+)
+
+// apiBasePath is where the versioned API and the modules are served
+const apiBasePath = "/api/v1"
+
+// Describe lists the routes cfg would serve, with their access rules,
+// without building handlers, connecting to the database or loading the
+// modules. Modules are included when enabled in cfg.Modules, so a module
+// that fails to start at runtime is still listed.
+func Describe(cfg *config.Config) []models.RouteInfo {
+	var h Handlers
+	if cfg.SAML.Enabled {
+		h.SAML = &handlers.SAMLHandler{}
+	}
+
+	var routes []models.RouteInfo
+	for _, r := range rootRoutes(cfg, h, nil) {
+		routes = append(routes, describe("", r))
+	}
+	for _, r := range apiRoutes(cfg, h, nil, nil) {
+		routes = append(routes, describe(apiBasePath, r))
+	}
+	for name, table := range moduleRoutes() {
+		if !cfg.Modules.IsEnabled(name) {
+			continue
+		}
+		for _, r := range table {
+			routes = append(routes, describe(apiBasePath, r))
+		}
+	}
+	sortMatrix(routes)
+	return routes
+}
+
+// moduleRoutes are the route tables of the built-in modules by module name
+func moduleRoutes() map[string][]Route {
+	return map[string][]Route{
+		"files":   FileRoutes(&handlers.FileHandler{}, nil),
+		"exports": ExportRoutes(&handlers.ExportHandler{}),
+		"reports": ReportRoutes(&handlers.ReportHandler{}),
+		"sync":    SyncRoutes(&handlers.SyncHandler{}),
+	}
+}
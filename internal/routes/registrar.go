@@ -0,0 +1,173 @@
+package routes
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"user-management-api/internal/config"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/httpserver"
+	"user-management-api/pkg/router"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ways a route authenticates its caller
+const (
+	// AuthNone leaves the route public
+	AuthNone = ""
+	// AuthUser requires a user access token
+	AuthUser = "user"
+	// AuthAction requires an action token for Route.Action; the resource
+	// comes from the path parameter Route.ActionParam
+	AuthAction = "action"
+)
+
+// Rate limit profiles, see pkg/httpserver
+const (
+	RateLimitNone     = ""
+	RateLimitModerate = "moderate"
+	RateLimitStrict   = "strict"
+)
+
+// RateLimitProfiles describes each rate limit profile, for the startup
+// summary
+func RateLimitProfiles() map[string]string {
+	return map[string]string{
+		RateLimitModerate: httpserver.ModerateProfile.String(),
+		RateLimitStrict:   httpserver.StrictProfile.String(),
+	}
+}
+
+// Upload profiles, see config.UploadConfig; routes may also name profiles
+// added through UPLOAD_PROFILES
+const (
+	UploadNone     = ""
+	UploadAny      = config.UploadProfileAny
+	UploadImage    = config.UploadProfileImage
+	UploadDocument = config.UploadProfileDocument
+	UploadImages   = config.UploadProfileImages
+	UploadAvatar   = config.UploadProfileAvatar
+)
+
+// Route declares one endpoint and its access rules. Register turns it into
+// middleware in a fixed order: authentication, permission, scope, rate
+// limit, upload, then Middleware, then the PreHandler plugins and Handler.
+type Route struct {
+	Method  string
+	Path    string
+	Handler router.HandlerFunc
+
+	Auth        string
+	Action      string
+	ActionParam string
+	// Permission is checked against the role, Scope against the token
+	Permission string
+	Scope      string
+	RateLimit  string
+	Upload     string
+
+	// Middleware runs after the declared checks, for the rules that need
+	// more than a name such as brute-force protection or delegation
+	Middleware []Middleware
+}
+
+// Middleware is a handler with the name it is listed under in the route
+// matrix
+type Middleware struct {
+	Name    string
+	Handler gin.HandlerFunc
+}
+
+var (
+	matrixMu sync.Mutex
+	matrix   []models.RouteInfo
+)
+
+// Register adds routes to rg and records them in the route matrix
+func Register(rg *gin.RouterGroup, cfg *config.Config, routes []Route) {
+	basePath := strings.TrimSuffix(rg.BasePath(), "/")
+	matrixMu.Lock()
+	defer matrixMu.Unlock()
+
+	for _, r := range routes {
+		var chain []gin.HandlerFunc
+		switch r.Auth {
+		case AuthUser:
+			chain = append(chain, middleware.AuthMidddleware(cfg))
+		case AuthAction:
+			chain = append(chain, middleware.RequireActionToken(cfg, r.Action, r.ActionParam))
+		case AuthNone:
+		default:
+			panic("routes: unknown auth " + r.Auth + " for " + r.Method + " " + r.Path)
+		}
+		if r.Permission != "" {
+			chain = append(chain, middleware.RequirePermission(r.Permission))
+		}
+		if r.Scope != "" {
+			chain = append(chain, middleware.RequireScope(r.Scope))
+		}
+		switch r.RateLimit {
+		case RateLimitModerate:
+			chain = append(chain, httpserver.ModerateRateLimit())
+		case RateLimitStrict:
+			chain = append(chain, httpserver.StrictRateLimit())
+		case RateLimitNone:
+		default:
+			panic("routes: unknown rate limit " + r.RateLimit + " for " + r.Method + " " + r.Path)
+		}
+		if r.Upload != UploadNone {
+			profile, ok := cfg.Uploads.Profiles[r.Upload]
+			if !ok {
+				panic("routes: unknown upload profile " + r.Upload + " for " + r.Method + " " + r.Path)
+			}
+			chain = append(chain, middleware.FileUploadMiddleware(middleware.NewFileUploadConfig(cfg.Uploads, profile)))
+		}
+		for _, m := range r.Middleware {
+			chain = append(chain, m.Handler)
+		}
+		chain = append(chain, middleware.WithPlugins(router.Gin(middleware.TimedHandler(r.Handler))))
+
+		rg.Handle(r.Method, r.Path, chain...)
+		matrix = append(matrix, describe(basePath, r))
+	}
+}
+
+// describe returns the matrix entry of r served under basePath
+func describe(basePath string, r Route) models.RouteInfo {
+	info := models.RouteInfo{
+		Method:     r.Method,
+		Path:       basePath + r.Path,
+		Auth:       r.Auth,
+		Action:     r.Action,
+		Permission: r.Permission,
+		Scope:      r.Scope,
+		RateLimit:  r.RateLimit,
+		Upload:     r.Upload,
+	}
+	for _, m := range r.Middleware {
+		info.Middleware = append(info.Middleware, m.Name)
+	}
+	return info
+}
+
+// Matrix lists every registered route with its access rules, sorted by
+// path then method
+func Matrix() []models.RouteInfo {
+	matrixMu.Lock()
+	defer matrixMu.Unlock()
+
+	routes := append([]models.RouteInfo(nil), matrix...)
+	sortMatrix(routes)
+	return routes
+}
+
+func sortMatrix(routes []models.RouteInfo) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+}
@@ -4,50 +4,46 @@ import (
 	"user-management-api/internal/config"
 	"user-management-api/internal/handlers"
 	"user-management-api/internal/middleware"
+	"user-management-api/internal/modules"
+	"user-management-api/pkg/httpserver"
+	"user-management-api/pkg/router"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// SetupRoutes configures all the application routes
-func SetupRoutes(cfg *config.Config, healthHandler *handlers.HealthHandler, authHandler *handlers.AuthHandler, userHandler *handlers.UserHandler, fileHandler *handlers.FileHandler) *gin.Engine {
-	if cfg.Server.Env == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	}
-	
-	router := gin.New()
-
-	// Apply global middleware
-	router.Use(middleware.LoggingMiddleware())
-	router.Use(middleware.CORSMiddleware())
-	router.Use(gin.Recovery())
-
-	// Health check endpoint
-	router.GET("/health", healthHandler.HealthCheck)
+// SetupRoutes configures all the application routes from the route tables
+// in table.go and the enabled modules
+func SetupRoutes(cfg *config.Config, h Handlers, grantChecker middleware.GrantChecker, bruteForceGuard middleware.BruteForceGuard, mods []modules.Module) *gin.Engine {
+	// Standard logging, CORS and recovery stack, then downstream plugins
+	router := httpserver.NewEngine(cfg.Server.Env == "production")
+	router.Use(middleware.LayerTiming(cfg.Server.LogLayerTimings))
+	router.Use(middleware.PreAuthPlugins())
 
-	router.Static("/api/v1/uploads", "./uploads")
+	Register(&router.RouterGroup, cfg, rootRoutes(cfg, h, bruteForceGuard))
 
 	// Swagger documentation endpoint
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Setup API routes
-	setupAPIRoutes(router, cfg, authHandler, userHandler, fileHandler)
+	// API v1 routes, then those of the enabled optional modules
+	v1 := router.Group(apiBasePath)
+	Register(v1, cfg, apiRoutes(cfg, h, grantChecker, bruteForceGuard))
+	for _, mod := range mods {
+		mod.Routes(v1)
+	}
 
 	return router
 }
 
-// setupAPIRoutes configures the API v1 routes
-func setupAPIRoutes(router *gin.Engine, cfg *config.Config, authHandler *handlers.AuthHandler, userHandler *handlers.UserHandler, fileHandler *handlers.FileHandler) {
-	v1 := router.Group("/api/v1")
-	{
-		// Authentication routes
-		SetupAuthRoutes(v1, authHandler)
-		
-		// User routes
-		SetupUserRoutes(v1, cfg, userHandler)
-		
-		// File routes
-		SetupFileRoutes(v1, cfg, fileHandler)
-	}
-}
\ No newline at end of file
+// SetupDegraded returns the router served while the database is
+// unreachable: /health reports the service degraded and every other
+// request is refused with 503
+func SetupDegraded(cfg *config.Config) *gin.Engine {
+	engine := httpserver.NewEngine(cfg.Server.Env == "production")
+	engine.GET("/health", router.Gin(handlers.DegradedHealthCheck))
+	engine.NoRoute(func(c *gin.Context) {
+		c.Header("Retry-After", "5")
+	}, router.Gin(handlers.ServiceUnavailable))
+	return engine
+}
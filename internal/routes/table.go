@@ -0,0 +1,283 @@
+package routes
+
+import (
+	"expvar"
+	"net/http"
+	"user-management-api/internal/config"
+	"user-management-api/internal/handlers"
+	"user-management-api/internal/middleware"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/router"
+)
+
+// Handlers holds the handlers of the core routes. SAML is nil when single
+// sign-on is disabled.
+type Handlers struct {
+	Health    *handlers.HealthHandler
+	Auth      *handlers.AuthHandler
+	User      *handlers.UserHandler
+	Grant     *handlers.GrantHandler
+	SAML      *handlers.SAMLHandler
+	Client    *handlers.ClientHandler
+	Page      *handlers.PageHandler
+	AuthEvent *handlers.AuthEventHandler
+	Device    *handlers.DeviceHandler
+	Token     *handlers.PersonalAccessTokenHandler
+	Project   *handlers.ProjectHandler
+	Org       *handlers.OrganizationHandler
+	Identity  *handlers.IdentityHandler
+	Role      *handlers.RoleHandler
+	Policy    *handlers.PolicyHandler
+	Route     *handlers.RouteHandler
+	Erasure   *handlers.ErasureHandler
+	Status    *handlers.UserStatusHandler
+}
+
+// rootRoutes are served outside the versioned API
+func rootRoutes(cfg *config.Config, h Handlers, bruteForceGuard middleware.BruteForceGuard) []Route {
+	routes := []Route{
+		{Method: http.MethodGet, Path: "/health", Handler: h.Health.HealthCheck},
+
+		// Server-rendered pages for email links and OAuth consent
+		{Method: http.MethodGet, Path: "/auth/verify-email", Handler: h.Page.VerifyEmail, RateLimit: RateLimitModerate},
+		{Method: http.MethodGet, Path: "/auth/reset-password", Handler: h.Page.ResetPasswordForm},
+		{Method: http.MethodPost, Path: "/auth/reset-password", Handler: h.Page.ResetPassword, RateLimit: RateLimitStrict},
+		{Method: http.MethodGet, Path: "/auth/accept-invite", Handler: h.Page.AcceptInvitationForm},
+		{Method: http.MethodPost, Path: "/auth/accept-invite", Handler: h.Page.AcceptInvitation, RateLimit: RateLimitStrict},
+		{Method: http.MethodGet, Path: "/oauth/authorize", Handler: h.Page.Consent},
+		{Method: http.MethodPost, Path: "/oauth/authorize", Handler: h.Page.Authorize, RateLimit: RateLimitStrict,
+			Middleware: []Middleware{bruteForceMiddleware(bruteForceGuard)}},
+
+		// Runtime and throttling metrics, and the build and configuration
+		// summary, for admins
+		{Method: http.MethodGet, Path: "/debug/vars", Handler: router.Wrap(expvar.Handler()), Auth: AuthUser, Permission: models.PermissionMetricsRead},
+		{Method: http.MethodGet, Path: "/version", Handler: h.Health.Version, Auth: AuthUser, Permission: models.PermissionMetricsRead},
+	}
+
+	// Public signing keys, only meaningful with asymmetric JWT algorithms
+	if cfg.JWT.Algorithm != "HS256" {
+		routes = append(routes, Route{Method: http.MethodGet, Path: "/.well-known/jwks.json", Handler: h.Auth.JWKS})
+	}
+	return routes
+}
+
+func bruteForceMiddleware(guard middleware.BruteForceGuard) Middleware {
+	return Middleware{Name: "brute_force_protection", Handler: middleware.BruteForceProtection(guard)}
+}
+
+// apiRoutes are the core routes under /api/v1
+func apiRoutes(cfg *config.Config, h Handlers, grantChecker middleware.GrantChecker, bruteForceGuard middleware.BruteForceGuard) []Route {
+	bruteForce := bruteForceMiddleware(bruteForceGuard)
+	botDetection := Middleware{Name: "bot_detection", Handler: middleware.BotDetection(cfg.Bots, bruteForceGuard)}
+
+	routes := []Route{
+		// Authentication, with rate limiting against brute force
+		{Method: http.MethodPost, Path: "/auth/register", Handler: h.Auth.Register, RateLimit: RateLimitModerate, Upload: UploadAvatar,
+			Middleware: []Middleware{botDetection}},
+		{Method: http.MethodPost, Path: "/auth/login", Handler: h.Auth.Login, RateLimit: RateLimitStrict,
+			Middleware: []Middleware{bruteForce, botDetection}},
+		{Method: http.MethodGet, Path: "/auth/availability", Handler: h.Auth.CheckAvailability, RateLimit: RateLimitStrict},
+		{Method: http.MethodPost, Path: "/auth/forgot-password", Handler: h.Auth.ForgotPassword, RateLimit: RateLimitStrict},
+		{Method: http.MethodPost, Path: "/auth/reset-password", Handler: h.Auth.ResetPassword, RateLimit: RateLimitStrict},
+		{Method: http.MethodPost, Path: "/auth/accept-invite", Handler: h.Auth.AcceptInvitation, RateLimit: RateLimitStrict},
+		{Method: http.MethodPost, Path: "/auth/change-expired-password", Handler: h.Auth.ChangeExpiredPassword, RateLimit: RateLimitStrict,
+			Middleware: []Middleware{bruteForce}},
+		{Method: http.MethodPost, Path: "/auth/verify-email", Handler: h.Auth.VerifyEmail, RateLimit: RateLimitModerate},
+		{Method: http.MethodPost, Path: "/auth/refresh", Handler: h.Auth.Refresh, RateLimit: RateLimitStrict},
+		{Method: http.MethodPost, Path: "/auth/logout", Handler: h.Auth.Logout, Auth: AuthUser},
+		{Method: http.MethodPost, Path: "/auth/action-token", Handler: h.Auth.IssueActionToken, Auth: AuthUser, RateLimit: RateLimitModerate},
+
+		// Auth event audit log, readable by auditors
+		{Method: http.MethodGet, Path: "/auth-events", Handler: h.AuthEvent.ListAuthEvents, Auth: AuthUser, Permission: models.ScopeAuditRead, Scope: models.ScopeAuditRead},
+
+		// OAuth2 client credentials and client management
+		{Method: http.MethodPost, Path: "/auth/token", Handler: h.Client.Token, RateLimit: RateLimitStrict,
+			Middleware: []Middleware{bruteForce}},
+		{Method: http.MethodGet, Path: "/clients", Handler: h.Client.ListClients, Auth: AuthUser, Permission: models.PermissionClientsManage},
+		{Method: http.MethodPost, Path: "/clients", Handler: h.Client.RegisterClient, Auth: AuthUser, Permission: models.PermissionClientsManage},
+		{Method: http.MethodDelete, Path: "/clients/:id", Handler: h.Client.DeactivateClient, Auth: AuthUser, Permission: models.PermissionClientsManage},
+
+		// The current user's profile, read by the user or a delegate
+		{Method: http.MethodGet, Path: "/users/profile", Handler: h.User.GetProfile, Auth: AuthUser, Scope: models.ScopeProfileRead,
+			Middleware: []Middleware{{Name: "on_behalf_of", Handler: middleware.OnBehalfOf(grantChecker, models.ScopeProfileRead)}}},
+		{Method: http.MethodPut, Path: "/users/profile/preferences", Handler: h.User.UpdatePreferences, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodPut, Path: "/users/profile/avatar", Handler: h.User.UploadProfileAvatar, Auth: AuthUser, Scope: models.ScopeProfileWrite, RateLimit: RateLimitStrict, Upload: UploadAvatar},
+		{Method: http.MethodDelete, Path: "/users/profile/avatar", Handler: h.User.DeleteProfileAvatar, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+
+		// Delegated access grants owned by or given to the current user
+		{Method: http.MethodGet, Path: "/users/profile/grants", Handler: h.Grant.ListGrants, Auth: AuthUser},
+		{Method: http.MethodPost, Path: "/users/profile/grants", Handler: h.Grant.CreateGrant, Auth: AuthUser},
+		{Method: http.MethodPost, Path: "/users/profile/grants/requests", Handler: h.Grant.RequestGrant, Auth: AuthUser},
+		{Method: http.MethodPost, Path: "/users/profile/grants/:id/approve", Handler: h.Grant.ApproveGrant, Auth: AuthUser},
+		{Method: http.MethodDelete, Path: "/users/profile/grants/:id", Handler: h.Grant.RevokeGrant, Auth: AuthUser},
+
+		// User management
+		{Method: http.MethodGet, Path: "/users", Handler: h.User.ListUsers, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodPost, Path: "/users", Handler: h.User.CreateUser, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPost, Path: "/users/invite", Handler: h.Auth.InviteUser, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPost, Path: "/users/bulk-delete", Handler: h.User.BulkDeleteUsers, Auth: AuthUser, Permission: models.PermissionUsersDelete, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPost, Path: "/users/bulk-deactivate", Handler: h.User.BulkDeactivateUsers, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodGet, Path: "/users/bulk-operations", Handler: h.User.ListBulkOperations, Auth: AuthUser, Permission: models.ScopeAuditRead, Scope: models.ScopeAuditRead},
+		{Method: http.MethodGet, Path: "/users/search", Handler: h.User.SearchUsers, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/batch", Handler: h.User.BatchGetUsers, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/aggregate", Handler: h.User.AggregateUsers, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/stats", Handler: h.User.GetUserStats, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/changes", Handler: h.User.WatchUserChanges, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodPost, Path: "/users/batch", Handler: h.User.BatchGetUsers, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/:id", Handler: h.User.GetUser, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/:id/history", Handler: h.User.GetUserHistory, Auth: AuthUser, Permission: models.ScopeAuditRead, Scope: models.ScopeAuditRead},
+		{Method: http.MethodGet, Path: "/users/:id/audit", Handler: h.User.GetUserAudit, Auth: AuthUser, Permission: models.ScopeAuditRead, Scope: models.ScopeAuditRead},
+		{Method: http.MethodPatch, Path: "/users/:id/metadata", Handler: h.User.UpdateUserMetadata, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPost, Path: "/users/:id/tags", Handler: h.User.AddUserTags, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodDelete, Path: "/users/:id/tags/:tag", Handler: h.User.RemoveUserTag, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPut, Path: "/users/:id/avatar", Handler: h.User.UploadUserAvatar, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite, RateLimit: RateLimitStrict, Upload: UploadAvatar},
+		{Method: http.MethodPut, Path: "/users/:id", Handler: h.User.UpdateUser, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPatch, Path: "/users/:id", Handler: h.User.PatchUser, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodDelete, Path: "/users/:id", Handler: h.User.DeleteUser, Auth: AuthUser, Permission: models.PermissionUsersDelete, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodDelete, Path: "/users/:id/deletion", Handler: h.Erasure.CancelDeletion, Auth: AuthUser, Permission: models.PermissionUsersDelete, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPut, Path: "/users/:id/status", Handler: h.Status.ChangeUserStatus, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite},
+		{Method: http.MethodPost, Path: "/users/:id/reset-password", Handler: h.Auth.AdminResetPassword, Auth: AuthUser, Permission: models.ScopeUsersWrite, Scope: models.ScopeUsersWrite, RateLimit: RateLimitStrict},
+		{Method: http.MethodPost, Path: "/users/:id/impersonate", Handler: h.Auth.Impersonate, Auth: AuthUser, Permission: models.PermissionUsersImpersonate, Scope: models.ScopeUsersWrite, RateLimit: RateLimitStrict},
+
+		// Roles and the permissions they grant
+		{Method: http.MethodGet, Path: "/permissions", Handler: h.Role.ListPermissions, Auth: AuthUser, Permission: models.PermissionRolesRead},
+		{Method: http.MethodGet, Path: "/roles", Handler: h.Role.ListRoles, Auth: AuthUser, Permission: models.PermissionRolesRead},
+		{Method: http.MethodPost, Path: "/roles", Handler: h.Role.CreateRole, Auth: AuthUser, Permission: models.PermissionRolesWrite},
+		{Method: http.MethodGet, Path: "/roles/:name", Handler: h.Role.GetRole, Auth: AuthUser, Permission: models.PermissionRolesRead},
+		{Method: http.MethodPut, Path: "/roles/:name", Handler: h.Role.UpdateRole, Auth: AuthUser, Permission: models.PermissionRolesWrite},
+		{Method: http.MethodDelete, Path: "/roles/:name", Handler: h.Role.DeleteRole, Auth: AuthUser, Permission: models.PermissionRolesWrite},
+
+		// Runtime access policies on top of role permissions, and the
+		// route matrix they apply to
+		{Method: http.MethodGet, Path: "/policies", Handler: h.Policy.ListPolicies, Auth: AuthUser, Permission: models.PermissionPoliciesManage},
+		{Method: http.MethodPost, Path: "/policies", Handler: h.Policy.CreatePolicy, Auth: AuthUser, Permission: models.PermissionPoliciesManage},
+		{Method: http.MethodDelete, Path: "/policies/:id", Handler: h.Policy.DeletePolicy, Auth: AuthUser, Permission: models.PermissionPoliciesManage},
+		{Method: http.MethodGet, Path: "/routes", Handler: h.Route.ListRoutes, Auth: AuthUser, Permission: models.PermissionPoliciesManage},
+		{Method: http.MethodGet, Path: "/routes/permissions", Handler: h.Route.ListPermissions, Auth: AuthUser, Permission: models.PermissionPoliciesManage},
+
+		// Trusted devices of the current user
+		{Method: http.MethodGet, Path: "/users/me/devices", Handler: h.Device.ListDevices, Auth: AuthUser, Scope: models.ScopeProfileRead},
+		{Method: http.MethodPost, Path: "/users/me/devices", Handler: h.Device.TrustDevice, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodDelete, Path: "/users/me/devices/:id", Handler: h.Device.RevokeDevice, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+
+		// Self-service account deletion, anonymized after a grace period
+		{Method: http.MethodDelete, Path: "/users/me", Handler: h.Erasure.RequestDeletion, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+
+		// Personal access tokens of the current user
+		{Method: http.MethodGet, Path: "/users/profile/tokens", Handler: h.Token.ListTokens, Auth: AuthUser, Scope: models.ScopeProfileRead},
+		{Method: http.MethodPost, Path: "/users/profile/tokens", Handler: h.Token.CreateToken, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodDelete, Path: "/users/profile/tokens/:id", Handler: h.Token.RevokeToken, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+
+		// Projects owned by the current user
+		{Method: http.MethodGet, Path: "/projects", Handler: h.Project.ListProjects, Auth: AuthUser, Scope: models.ScopeProjectsRead},
+		{Method: http.MethodPost, Path: "/projects", Handler: h.Project.CreateProject, Auth: AuthUser, Scope: models.ScopeProjectsWrite},
+		{Method: http.MethodGet, Path: "/projects/:id", Handler: h.Project.GetProject, Auth: AuthUser, Scope: models.ScopeProjectsRead},
+		{Method: http.MethodPut, Path: "/projects/:id", Handler: h.Project.UpdateProject, Auth: AuthUser, Scope: models.ScopeProjectsWrite},
+		{Method: http.MethodDelete, Path: "/projects/:id", Handler: h.Project.DeleteProject, Auth: AuthUser, Scope: models.ScopeProjectsWrite},
+
+		// Organizations and their members; what a member may do is decided
+		// by their role in the organization
+		{Method: http.MethodGet, Path: "/organizations", Handler: h.Org.ListOrganizations, Auth: AuthUser, Scope: models.ScopeProfileRead},
+		{Method: http.MethodPost, Path: "/organizations", Handler: h.Org.CreateOrganization, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodGet, Path: "/organizations/invitations", Handler: h.Org.ListInvitations, Auth: AuthUser, Scope: models.ScopeProfileRead},
+		{Method: http.MethodGet, Path: "/organizations/:id", Handler: h.Org.GetOrganization, Auth: AuthUser, Scope: models.ScopeProfileRead},
+		{Method: http.MethodPut, Path: "/organizations/:id", Handler: h.Org.UpdateOrganization, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodDelete, Path: "/organizations/:id", Handler: h.Org.DeleteOrganization, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodGet, Path: "/organizations/:id/members", Handler: h.Org.ListMembers, Auth: AuthUser, Scope: models.ScopeProfileRead},
+		{Method: http.MethodPost, Path: "/organizations/:id/invitations", Handler: h.Org.InviteMember, Auth: AuthUser, Scope: models.ScopeProfileWrite, RateLimit: RateLimitModerate},
+		{Method: http.MethodPost, Path: "/organizations/:id/join", Handler: h.Org.JoinOrganization, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodPost, Path: "/organizations/:id/leave", Handler: h.Org.LeaveOrganization, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodPut, Path: "/organizations/:id/members/:user_id", Handler: h.Org.UpdateMember, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodDelete, Path: "/organizations/:id/members/:user_id", Handler: h.Org.RemoveMember, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+
+		// External sign-in identities; the callbacks come back from the
+		// provider without a token
+		{Method: http.MethodGet, Path: "/users/me/identities", Handler: h.Identity.ListIdentities, Auth: AuthUser, Scope: models.ScopeProfileRead},
+		{Method: http.MethodPost, Path: "/users/me/identities/:provider", Handler: h.Identity.LinkIdentity, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodDelete, Path: "/users/me/identities/:provider", Handler: h.Identity.UnlinkIdentity, Auth: AuthUser, Scope: models.ScopeProfileWrite},
+		{Method: http.MethodGet, Path: "/users/me/identities/:provider/callback", Handler: h.Identity.LinkCallback, RateLimit: RateLimitModerate},
+		{Method: http.MethodGet, Path: "/auth/oauth/:provider/login", Handler: h.Identity.Login, RateLimit: RateLimitModerate},
+		{Method: http.MethodGet, Path: "/auth/oauth/:provider/callback", Handler: h.Identity.LoginCallback, RateLimit: RateLimitModerate},
+	}
+
+	// SAML SSO, only when enabled
+	if h.SAML != nil {
+		routes = append(routes,
+			Route{Method: http.MethodGet, Path: "/auth/saml/metadata", Handler: h.SAML.Metadata},
+			Route{Method: http.MethodGet, Path: "/auth/saml/login", Handler: h.SAML.Login, RateLimit: RateLimitModerate},
+			Route{Method: http.MethodPost, Path: "/auth/saml/acs", Handler: h.SAML.AssertionConsumer, RateLimit: RateLimitModerate},
+			Route{Method: http.MethodGet, Path: "/auth/saml/slo", Handler: h.SAML.SingleLogout, RateLimit: RateLimitModerate},
+		)
+	}
+	return routes
+}
+
+// FileRoutes are the routes of the files module. Delegates holding a
+// files:read grant can list, read and download the owner's files.
+func FileRoutes(h *handlers.FileHandler, grantChecker middleware.GrantChecker) []Route {
+	onBehalfOf := []Middleware{{Name: "on_behalf_of", Handler: middleware.OnBehalfOf(grantChecker, models.ScopeFilesRead)}}
+	return []Route{
+		{Method: http.MethodPost, Path: "/files/upload", Handler: h.UploadFile, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitModerate, Upload: UploadAny},
+		{Method: http.MethodPost, Path: "/files/upload/image", Handler: h.UploadImage, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitStrict, Upload: UploadImage},
+		{Method: http.MethodPost, Path: "/files/upload/document", Handler: h.UploadDocument, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitModerate, Upload: UploadDocument},
+		{Method: http.MethodPost, Path: "/files/upload/images", Handler: h.UploadFile, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitStrict, Upload: UploadImages},
+
+		// Metadata of the caller's uploaded files
+		{Method: http.MethodGet, Path: "/files", Handler: h.ListFiles, Auth: AuthUser, Scope: models.ScopeFilesRead, Middleware: onBehalfOf},
+		{Method: http.MethodGet, Path: "/files/:id", Handler: h.GetFile, Auth: AuthUser, Scope: models.ScopeFilesRead, Middleware: onBehalfOf},
+		{Method: http.MethodPatch, Path: "/files/:id", Handler: h.UpdateFile, Auth: AuthUser, Scope: models.ScopeFilesWrite},
+		{Method: http.MethodDelete, Path: "/files/:id", Handler: h.DeleteFile, Auth: AuthUser, Scope: models.ScopeFilesWrite},
+		{Method: http.MethodPost, Path: "/files/:id/share", Handler: h.ShareFile, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitModerate},
+		{Method: http.MethodPost, Path: "/files/archive", Handler: h.ArchiveFiles, Auth: AuthUser, Scope: models.ScopeFilesRead, RateLimit: RateLimitModerate},
+
+		// Resumable uploads with the tus protocol
+		{Method: http.MethodPost, Path: "/files/uploads", Handler: h.CreateResumableUpload, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitModerate},
+		{Method: http.MethodHead, Path: "/files/uploads/:id", Handler: h.ResumableUploadOffset, Auth: AuthUser, Scope: models.ScopeFilesWrite},
+		{Method: http.MethodGet, Path: "/files/uploads/:id", Handler: h.GetResumableUpload, Auth: AuthUser, Scope: models.ScopeFilesRead},
+		{Method: http.MethodGet, Path: "/files/uploads/:id/events", Handler: h.ResumableUploadEvents, Auth: AuthUser, Scope: models.ScopeFilesRead},
+		{Method: http.MethodPatch, Path: "/files/uploads/:id", Handler: h.PatchResumableUpload, Auth: AuthUser, Scope: models.ScopeFilesWrite},
+		{Method: http.MethodDelete, Path: "/files/uploads/:id", Handler: h.DeleteResumableUpload, Auth: AuthUser, Scope: models.ScopeFilesWrite},
+
+		// Direct uploads to S3 or GCS storage
+		{Method: http.MethodPost, Path: "/files/presign", Handler: h.PresignUpload, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitModerate},
+		{Method: http.MethodPost, Path: "/files/presign/confirm", Handler: h.ConfirmUpload, Auth: AuthUser, Scope: models.ScopeFilesWrite, RateLimit: RateLimitModerate},
+
+		// Download authorized by a short-lived file:download action token
+		{Method: http.MethodGet, Path: "/files/download/*path", Handler: h.DownloadFile, Auth: AuthAction, Action: "file:download", ActionParam: "path",
+			Middleware: onBehalfOf},
+
+		// Share links from POST /files/:id/share, signed with a file:share token
+		{Method: http.MethodGet, Path: "/files/shared/:id", Handler: h.SharedFile, Auth: AuthAction, Action: "file:share", ActionParam: "id"},
+
+		// Public file URLs of the local storage backend
+		{Method: http.MethodGet, Path: "/uploads/*key", Handler: h.ServeUpload},
+	}
+}
+
+// ExportRoutes are the routes of the exports module
+func ExportRoutes(h *handlers.ExportHandler) []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/users/export", Handler: h.ExportUsers, Auth: AuthUser, Permission: models.PermissionUsersExport, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/export/columns", Handler: h.ListExportColumns, Auth: AuthUser, Permission: models.PermissionUsersExport, Scope: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/users/export/templates", Handler: h.ListTemplates, Auth: AuthUser, Permission: models.PermissionUsersExport, Scope: models.ScopeUsersRead},
+		{Method: http.MethodPost, Path: "/users/export/templates", Handler: h.CreateTemplate, Auth: AuthUser, Permission: models.PermissionUsersExport, Scope: models.ScopeUsersRead},
+		{Method: http.MethodDelete, Path: "/users/export/templates/:id", Handler: h.DeleteTemplate, Auth: AuthUser, Permission: models.PermissionUsersExport, Scope: models.ScopeUsersRead},
+	}
+}
+
+// ReportRoutes are the routes of the reports module
+func ReportRoutes(h *handlers.ReportHandler) []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/reports", Handler: h.ListReports, Auth: AuthUser, Permission: models.ScopeUsersRead},
+		{Method: http.MethodPost, Path: "/reports", Handler: h.CreateReport, Auth: AuthUser, Permission: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/reports/:id", Handler: h.GetReport, Auth: AuthUser, Permission: models.ScopeUsersRead},
+		{Method: http.MethodGet, Path: "/reports/:id/download", Handler: h.DownloadReport, Auth: AuthUser, Permission: models.ScopeUsersRead},
+	}
+}
+
+// SyncRoutes are the routes of the sync module
+func SyncRoutes(h *handlers.SyncHandler) []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/sync", Handler: h.Sync, Auth: AuthUser, Permission: models.ScopeUsersRead, Scope: models.ScopeUsersRead},
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// activityWriteTimeout bounds the background last-seen writes, which
+// outlive the request that triggered them
+const activityWriteTimeout = 5 * time.Second
+
+// ActivityService keeps users' last login, last seen time and login count
+// up to date. Last-seen writes happen at most once per interval per user
+// and off the request path, so authenticating a request stays cheap.
+type ActivityService struct {
+	userRepo interfaces.UserRepository
+	interval time.Duration
+
+	mu       sync.Mutex
+	lastSeen map[primitive.ObjectID]time.Time
+}
+
+func NewActivityService(userRepo interfaces.UserRepository, interval time.Duration) *ActivityService {
+	return &ActivityService{
+		userRepo: userRepo,
+		interval: interval,
+		lastSeen: make(map[primitive.ObjectID]time.Time),
+	}
+}
+
+// RecordLogin counts a sign-in. Failures are logged and never block it.
+func (s *ActivityService) RecordLogin(ctx context.Context, userID primitive.ObjectID) {
+	now := time.Now()
+	if err := s.userRepo.RecordLogin(ctx, userID, now); err != nil {
+		log.Printf("activity: recording login of %s: %v", userID.Hex(), err)
+		return
+	}
+	s.mu.Lock()
+	s.lastSeen[userID] = now
+	s.mu.Unlock()
+}
+
+// RecordActivity notes that the user made an authenticated request
+func (s *ActivityService) RecordActivity(ctx context.Context, userID primitive.ObjectID) {
+	now := time.Now()
+	s.mu.Lock()
+	if now.Sub(s.lastSeen[userID]) < s.interval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSeen[userID] = now
+	s.forgetIdle(now)
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), activityWriteTimeout)
+		defer cancel()
+		if err := s.userRepo.TouchLastSeen(ctx, userID, now); err != nil {
+			log.Printf("activity: recording last seen of %s: %v", userID.Hex(), err)
+		}
+	}()
+}
+
+// forgetIdle drops users not seen within the interval once the map has
+// grown, as they would be written on their next request anyway. s.mu must
+// be held.
+func (s *ActivityService) forgetIdle(now time.Time) {
+	if len(s.lastSeen) < 10000 {
+		return
+	}
+	for id, seen := range s.lastSeen {
+		if now.Sub(seen) >= s.interval {
+			delete(s.lastSeen, id)
+		}
+	}
+}
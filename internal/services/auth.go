@@ -2,31 +2,99 @@ package services
 
 import (
 	"context"
+	"log"
 	"time"
 	"user-management-api/internal/models"
 	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/auth"
 	"user-management-api/pkg/errors"
 	"user-management-api/pkg/utils"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Lifetimes of the links emailed for verification and password resets
+const (
+	verifyTokenTTL = 7 * 24 * time.Hour
+	resetTokenTTL  = time.Hour
+)
+
+// One-time token purposes
+const (
+	tokenPurposeEmailVerify   = "email_verify"
+	tokenPurposePasswordReset = "password_reset"
+)
+
 type AuthService struct {
 	userRepo  interfaces.UserRepository
+	logins    interfaces.LoginLookup
+	notifier  *NotificationService
+	sessions  *SessionService
+	history   *HistoryService
+	events    *AuthEventService
+	tokens    *utils.OneTimeTokens
+	throttle  *RegistrationThrottle
+	avatars   *AvatarService
 	jwtSecret string
-	jwtExpiry string
+	accessTTL time.Duration
+	// passwordMaxAge of zero disables password expiry
+	passwordMaxAge time.Duration
 }
 
-func NewAuthService(userRepo interfaces.UserRepository, jwtSecret, jwtExpiry string) *AuthService {
+func NewAuthService(userRepo interfaces.UserRepository, logins interfaces.LoginLookup, notifier *NotificationService, sessions *SessionService, history *HistoryService, events *AuthEventService, tokens *utils.OneTimeTokens, throttle *RegistrationThrottle, avatars *AvatarService, jwtSecret string, accessTTL, passwordMaxAge time.Duration) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
-		jwtExpiry: jwtExpiry,
+		userRepo:       userRepo,
+		logins:         logins,
+		notifier:       notifier,
+		sessions:       sessions,
+		history:        history,
+		events:         events,
+		tokens:         tokens,
+		throttle:       throttle,
+		avatars:        avatars,
+		jwtSecret:      jwtSecret,
+		accessTTL:      accessTTL,
+		passwordMaxAge: passwordMaxAge,
 	}
 }
 
-func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
-	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+// Authenticate checks an email and password pair and returns the active
+// user they belong to. Every attempt is recorded in the auth event log.
+func (s *AuthService) Authenticate(ctx context.Context, email, password string, client models.LoginContext) (*models.User, error) {
+	user, err := s.authenticate(ctx, email, password)
+	if err != nil {
+		s.recordLoginFailure(ctx, email, user, err, client)
+		return nil, err
+	}
+	s.events.RecordFor(ctx, models.AuthEventLogin, user, client, "password")
+	return user, nil
+}
+
+// recordLoginFailure logs a failed password sign-in, attributed to user
+// when the account is known
+func (s *AuthService) recordLoginFailure(ctx context.Context, email string, user *models.User, err error, client models.LoginContext) {
+	event := &models.AuthEvent{
+		Type:      models.AuthEventLoginFailed,
+		Email:     email,
+		Method:    "password",
+		Reason:    "INTERNAL_ERROR",
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	}
+	if appErr, ok := err.(*errors.AppError); ok {
+		event.Reason = appErr.Type
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+	s.events.Record(ctx, event)
+}
+
+// authenticate returns the user even when the password is wrong so the
+// failed attempt can be attributed
+func (s *AuthService) authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.logins.GetByEmailForLogin(ctx, email)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.ErrInvalidCredentials
@@ -34,35 +102,137 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 		return nil, errors.ErrInternalServer
 	}
 	// Check if user is active
-	if !user.IsActive {
-		return nil, errors.ErrUnAuthorized
+	if !user.IsActive() {
+		return user, errors.ErrUnAuthorized
 	}
 
 	// Verify password
-	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return nil, errors.ErrInvalidCredentials
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return user, errors.ErrInvalidCredentials
+	}
+	if user.PasswordChangeRequired {
+		return user, errors.ErrPasswordChangeRequired
+	}
+	if s.passwordExpired(user) {
+		return user, errors.ErrPasswordExpired
+	}
+	return user, nil
+}
+
+// passwordExpired reports whether user's password is older than the
+// configured maximum age
+func (s *AuthService) passwordExpired(user *models.User) bool {
+	if s.passwordMaxAge <= 0 {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > s.passwordMaxAge
+}
+
+// ChangeExpiredPassword replaces a password that login rejected as expired,
+// or as a temporary one set by an admin, ends the user's other sessions and
+// signs them in. The current password must still be right, so the endpoint
+// is no easier to abuse than login itself.
+func (s *AuthService) ChangeExpiredPassword(ctx context.Context, req *models.ChangeExpiredPasswordRequest, client models.LoginContext) (*models.AuthResponse, error) {
+	user, err := s.authenticate(ctx, req.Email, req.CurrentPassword)
+	if err != nil && err != errors.ErrPasswordExpired && err != errors.ErrPasswordChangeRequired {
+		s.recordLoginFailure(ctx, req.Email, user, err, client)
+		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Email, user.Role, s.jwtSecret, 24*time.Hour)
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
 		return nil, errors.ErrInternalServer
 	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	before := *user
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	user.PasswordChangeRequired = false
+	s.history.Record(ctx, user.ID, &before, user)
+	s.revokeResetTokens(ctx, user.ID)
+	s.sessions.EndAll(ctx, user.ID)
+	method := "expired"
+	if before.PasswordChangeRequired {
+		method = "temporary_password"
+	}
+	s.events.RecordFor(ctx, models.AuthEventPasswordChange, user, client, method)
+	s.events.RecordFor(ctx, models.AuthEventLogin, user, client, "password")
 
-	return &models.AuthResponse{
-		Token: token,
-		User:  *user.ToResponse(),
-	}, nil
+	return s.startSession(ctx, user, client)
 }
 
-func (s *AuthService) Register(ctx context.Context, req *models.CreateUserRequest, imagePath string) (*models.AuthResponse, error) {
-	// Check if user already exists
-	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
-		return nil, errors.ErrUserExists
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, client models.LoginContext) (*models.AuthResponse, error) {
+	user, err := s.Authenticate(ctx, req.Email, req.Password, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, user, client)
+}
+
+// Logout ends the session the caller's token belongs to. Tokens issued
+// without a session cannot be revoked and simply run out.
+func (s *AuthService) Logout(ctx context.Context, userID primitive.ObjectID, sessionID *primitive.ObjectID, client models.LoginContext) error {
+	if sessionID != nil {
+		if err := s.sessions.End(ctx, *sessionID); err != nil {
+			return err
+		}
+	}
+	s.events.Record(ctx, &models.AuthEvent{
+		Type:      models.AuthEventLogout,
+		UserID:    &userID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+	return nil
+}
+
+// startSession opens a session for user and issues tokens bound to it
+func (s *AuthService) startSession(ctx context.Context, user *models.User, client models.LoginContext) (*models.AuthResponse, error) {
+	session, err := s.sessions.Start(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.sessions.issueTokens(ctx, user, session, s.jwtSecret, s.accessTTL)
+}
+
+// Refresh trades a refresh token for a new access token and a new refresh
+// token within the same session; the old refresh token stops working
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, client models.LoginContext) (*models.AuthResponse, error) {
+	session, err := s.sessions.consumeRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
 	}
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if !user.IsActive() {
+		return nil, errors.ErrUnAuthorized
+	}
+	s.events.RecordFor(ctx, models.AuthEventTokenRefresh, user, client, "refresh_token")
+	return s.sessions.issueTokens(ctx, user, session, s.jwtSecret, s.accessTTL)
+}
 
-	if _, err := s.userRepo.GetByUsername(ctx, req.Username); err == nil {
-		return nil, errors.ErrUserExists
+// Register creates a user and signs them in. avatarKey, when set, is the
+// storage key of an uploaded image that becomes the user's avatar.
+// Self-registered users always get the user role; other roles are only
+// granted through UserService, by someone allowed to manage them.
+func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest, avatarKey string, client models.LoginContext) (*models.AuthResponse, error) {
+	if err := s.throttle.Allow(req.Email, client.IP); err != nil {
+		return nil, err
 	}
 
 	// Hash password
@@ -71,6 +241,13 @@ func (s *AuthService) Register(ctx context.Context, req *models.CreateUserReques
 		return nil, errors.ErrInternalServer
 	}
 
+	var avatar string
+	if avatarKey != "" {
+		if avatar, err = s.avatars.Process(ctx, avatarKey); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create user
 	user := &models.User{
 		Username:  req.Username,
@@ -78,23 +255,323 @@ func (s *AuthService) Register(ctx context.Context, req *models.CreateUserReques
 		Password:  hashedPassword,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
-		Role:      req.Role,
-		Avatar:    imagePath,
-		IsActive:  true,
+		Role:      models.RoleUser,
+		Locale:    req.Locale,
+		Avatar:    avatar,
+		Status:    models.UserStatusActive,
+	}
+	if err := beforeUserWrite(ctx, BeforeCreate, user, nil); err != nil {
+		s.avatars.Remove(ctx, avatar)
+		return nil, err
 	}
 
+	// the unique email and username indexes decide between concurrent
+	// registrations; looking the user up first would leave a window for both
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.avatars.Remove(ctx, avatar)
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrUserExists
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, user.ID, nil, user)
+	afterUserWrite(ctx, AfterCreate, user, nil)
+	s.events.RecordFor(ctx, models.AuthEventLogin, user, client, "register")
+	s.sendVerification(ctx, user)
+
+	return s.startSession(ctx, user, client)
+}
+
+// CheckAvailability reports whether the username and email in query, where
+// given, are free to register with. Registration still decides races.
+func (s *AuthService) CheckAvailability(ctx context.Context, query *models.AvailabilityQuery) (*models.AvailabilityResponse, error) {
+	var result models.AvailabilityResponse
+	var err error
+	if query.Username != "" {
+		if result.UsernameAvailable, err = s.available(ctx, "username", query.Username); err != nil {
+			return nil, err
+		}
+	}
+	if query.Email != "" {
+		if result.EmailAvailable, err = s.available(ctx, "email", query.Email); err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
+
+func (s *AuthService) available(ctx context.Context, field, value string) (*bool, error) {
+	taken, err := s.userRepo.Exists(ctx, field, value)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	available := !taken
+	return &available, nil
+}
+
+// sendVerification emails a fresh verification link. Failures are logged
+// rather than returned so they never undo a successful registration.
+func (s *AuthService) sendVerification(ctx context.Context, user *models.User) {
+	token, err := s.tokens.Issue(ctx, tokenPurposeEmailVerify, user.ID.Hex(), verifyTokenTTL)
+	if err != nil {
+		log.Printf("verification token for %s: %v", user.ID.Hex(), err)
+		return
+	}
+	if err := s.notifier.SendEmailVerification(user, token); err != nil {
+		log.Printf("verification email for %s: %v", user.ID.Hex(), err)
+	}
+}
+
+// VerifyEmail marks the owner of an emailed verification token as verified
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	id, err := s.consumeToken(ctx, tokenPurposeEmailVerify, token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.MarkEmailVerified(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	before := *user
+	before.EmailVerified = false
+	s.history.Record(ctx, user.ID, &before, user)
+	return user, nil
+}
+
+// ForgotPassword emails a reset link if the address belongs to an active
+// user. It reports success either way so callers cannot probe for accounts.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return errors.ErrInternalServer
+	}
+	if !user.IsActive() {
+		return nil
+	}
+
+	token, err := s.tokens.Issue(ctx, tokenPurposePasswordReset, user.ID.Hex(), resetTokenTTL)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	if err := s.notifier.SendPasswordReset(user, token, resetTokenTTL); err != nil {
+		log.Printf("password reset email for %s: %v", user.ID.Hex(), err)
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// ResetPassword sets a new password using an emailed reset token and
+// signs the user out everywhere
+func (s *AuthService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	id, err := s.consumeToken(ctx, tokenPurposePasswordReset, req.Token)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrInvalidToken
+		}
+		return errors.ErrInternalServer
+	}
+	if err := s.userRepo.UpdatePassword(ctx, id, hashedPassword); err != nil {
+		return errors.ErrInternalServer
+	}
+	before := *user
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	user.PasswordChangeRequired = false
+	s.history.Record(ctx, user.ID, &before, user)
+	s.sessions.EndAll(ctx, user.ID)
+	s.events.Record(ctx, &models.AuthEvent{
+		Type:   models.AuthEventPasswordChange,
+		UserID: &user.ID,
+		Email:  user.Email,
+		Method: "reset_token",
+	})
+	return nil
+}
+
+// temporaryPasswordBytes is the entropy of admin-issued temporary passwords
+const temporaryPasswordBytes = 12
+
+// AdminResetPassword resets another user's password on an admin's behalf,
+// either by emailing them a reset link or by replacing the password with a
+// temporary one that sign-in refuses until the user changes it. A temporary
+// password also ends the user's sessions; it is returned once and not kept.
+func (s *AuthService) AdminResetPassword(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, req *models.AdminResetPasswordRequest) (*models.AdminResetPasswordResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if user.AnonymizedAt != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+	if !user.IsActive() {
+		return nil, errors.ErrUserNotActive
+	}
+
+	if req.Method == models.PasswordResetEmail {
+		token, err := s.tokens.Issue(ctx, tokenPurposePasswordReset, user.ID.Hex(), resetTokenTTL)
+		if err != nil {
+			return nil, errors.ErrInternalServer
+		}
+		if err := s.notifier.SendPasswordReset(user, token, resetTokenTTL); err != nil {
+			log.Printf("password reset email for %s: %v", user.ID.Hex(), err)
+			return nil, errors.ErrInternalServer
+		}
+		expiresAt := time.Now().Add(resetTokenTTL)
+		log.Printf("password reset link sent: admin=%s target=%s", actorID.Hex(), user.ID.Hex())
+		return &models.AdminResetPasswordResponse{Method: req.Method, LinkExpiresAt: &expiresAt}, nil
+	}
+
+	password, err := utils.RandomToken(temporaryPasswordBytes)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
 		return nil, errors.ErrInternalServer
 	}
+	if err := s.userRepo.SetTemporaryPassword(ctx, user.ID, hashedPassword); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	before := *user
+	now := time.Now()
+	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	user.PasswordChangeRequired = true
+	s.history.Record(ctx, actorID, &before, user)
+	s.revokeResetTokens(ctx, user.ID)
+	s.sessions.EndAll(ctx, user.ID)
+	s.events.Record(ctx, &models.AuthEvent{
+		Type:      models.AuthEventPasswordChange,
+		UserID:    &user.ID,
+		Email:     user.Email,
+		Method:    "admin_reset",
+		ActorID:   &actorID,
+		ActorType: auth.ActorUser,
+	})
+	return &models.AdminResetPasswordResponse{Method: req.Method, TemporaryPassword: password}, nil
+}
+
+// revokeResetTokens invalidates a reset link sent before the password was
+// changed, so a leaked email cannot undo the change. Failures are logged;
+// the link still expires on its own.
+func (s *AuthService) revokeResetTokens(ctx context.Context, id primitive.ObjectID) {
+	if err := s.tokens.Revoke(ctx, tokenPurposePasswordReset, id.Hex()); err != nil {
+		log.Printf("revoke reset token for %s: %v", id.Hex(), err)
+	}
+}
+
+// consumeToken uses up an emailed one-time token and returns the ID of the
+// user it was issued to
+func (s *AuthService) consumeToken(ctx context.Context, purpose, token string) (primitive.ObjectID, error) {
+	subject, err := s.tokens.Consume(ctx, purpose, token)
+	if err != nil {
+		if err == utils.ErrInvalidOneTimeToken {
+			return primitive.NilObjectID, errors.ErrInvalidToken
+		}
+		return primitive.NilObjectID, errors.ErrInternalServer
+	}
+	id, err := primitive.ObjectIDFromHex(subject)
+	if err != nil {
+		return primitive.NilObjectID, errors.ErrInvalidToken
+	}
+	return id, nil
+}
+
+// defaultActionTokenTTL applies when the caller does not ask for a lifetime
+const defaultActionTokenTTL = 5 * time.Minute
+
+// IssueActionToken mints a short-lived token scoped to a single action
+func (s *AuthService) IssueActionToken(ctx context.Context, userID primitive.ObjectID, req *models.ActionTokenRequest) (*models.ActionTokenResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUnAuthorized
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if !user.IsActive() {
+		return nil, errors.ErrUnAuthorized
+	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Email, user.Role, s.jwtSecret, 24*time.Hour)
+	ttl := defaultActionTokenTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	token, err := auth.GenerateActionToken(user.ID, req.Action, req.Resource, s.jwtSecret, ttl)
 	if err != nil {
 		return nil, errors.ErrInternalServer
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User:  *user.ToResponse(),
+	return &models.ActionTokenResponse{
+		Token:     token,
+		Action:    req.Action,
+		Resource:  req.Resource,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// impersonationTTL bounds how long an admin can act as another user
+const impersonationTTL = 15 * time.Minute
+
+// Impersonate issues a short-lived token for the target user on behalf of
+// an admin. Admins cannot impersonate themselves or other admins, and every
+// attempt is logged.
+func (s *AuthService) Impersonate(ctx context.Context, adminID, targetID primitive.ObjectID) (*models.ImpersonationResponse, error) {
+	target, err := s.userRepo.GetByID(ctx, targetID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if target.ID == adminID || models.RoleIncludes(target.Role, models.RoleAdmin) || !target.IsActive() {
+		log.Printf("impersonation denied: admin=%s target=%s", adminID.Hex(), targetID.Hex())
+		return nil, errors.ErrCannotImpersonate
+	}
+
+	token, err := auth.GenerateImpersonationJWT(target.ID, target.Email, target.Role, models.ScopesForRole(target.Role), adminID, s.jwtSecret, impersonationTTL)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	log.Printf("impersonation started: admin=%s target=%s expires_in=%s", adminID.Hex(), target.ID.Hex(), impersonationTTL)
+	s.events.Record(ctx, &models.AuthEvent{
+		Type:      models.AuthEventImpersonation,
+		UserID:    &target.ID,
+		Email:     target.Email,
+		ActorID:   &adminID,
+		ActorType: auth.ActorImpersonator,
+	})
+
+	return &models.ImpersonationResponse{
+		Token:          token,
+		ExpiresAt:      time.Now().Add(impersonationTTL),
+		ImpersonatedBy: adminID.Hex(),
+		User:           *target.ToResponse(),
 	}, nil
 }
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/jobs"
+	"user-management-api/pkg/mailer"
+	"user-management-api/pkg/storage"
+	"user-management-api/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The fakes embed the repository interfaces they stand in for, so a test
+// panics on any method they do not implement.
+
+// fakeUserRepo keeps users in memory and enforces the unique email and
+// username indexes like MongoDB does
+type fakeUserRepo struct {
+	interfaces.UserRepository
+	mu    sync.Mutex
+	users []*models.User
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Email == user.Email || existing.Username == user.Username {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error"}}}
+		}
+	}
+	user.ID = primitive.NewObjectID()
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *fakeUserRepo) RecordLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return nil
+}
+
+type fakeSessionRepo struct {
+	interfaces.SessionRepository
+	mu       sync.Mutex
+	sessions []*models.Session
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = time.Now()
+	r.sessions = append(r.sessions, session)
+	return nil
+}
+
+func (r *fakeSessionRepo) ListActive(ctx context.Context, userID primitive.ObjectID) ([]*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []*models.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeSessionRepo) Revoke(ctx context.Context, ids ...primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.RevokedAt == nil && slices.Contains(ids, session.ID) {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = slices.DeleteFunc(r.sessions, func(session *models.Session) bool { return session.ID == id })
+	return nil
+}
+
+func (r *fakeSessionRepo) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	active, _ := r.ListActive(ctx, userID)
+	return int64(len(active)), nil
+}
+
+func (r *fakeSessionRepo) Seen(ctx context.Context, userID primitive.ObjectID, ip, userAgent string) (bool, bool, error) {
+	return true, true, nil
+}
+
+type fakeHistoryRepo struct {
+	interfaces.UserHistoryRepository
+}
+
+func (fakeHistoryRepo) Append(ctx context.Context, changes []*models.UserChange) error { return nil }
+
+type fakeAuditRepo struct{ interfaces.AuditLogRepository }
+
+func (fakeAuditRepo) Create(ctx context.Context, entry *models.AuditLog) error { return nil }
+
+type fakeAuthEventRepo struct{ interfaces.AuthEventRepository }
+
+func (fakeAuthEventRepo) Create(ctx context.Context, event *models.AuthEvent) error { return nil }
+
+type fakeTokenStore struct{ utils.OneTimeTokenStore }
+
+func (fakeTokenStore) Save(ctx context.Context, purpose, subject, tokenHash string, expiresAt time.Time) error {
+	return nil
+}
+
+type discardMailer struct{}
+
+func (discardMailer) Send(ctx context.Context, msg *mailer.Message) error { return nil }
+
+func newTestAuthService(t *testing.T, userRepo *fakeUserRepo) *AuthService {
+	t.Helper()
+	queue := jobs.NewQueue(1, 64)
+	t.Cleanup(func() { queue.Shutdown(context.Background()) })
+	notifier, err := NewNotificationService(discardMailer{}, queue, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := utils.NewOneTimeTokens(fakeTokenStore{}, []byte("test-secret"))
+	history := NewHistoryService(fakeHistoryRepo{}, fakeAuditRepo{}, userRepo)
+	events := NewAuthEventService(fakeAuthEventRepo{})
+	sessions := NewSessionService(&fakeSessionRepo{}, notifier, nil, NewActivityService(userRepo, time.Minute), tokens, time.Hour, 0, 0, SessionLimitReject)
+	throttle := NewRegistrationThrottle(RegistrationThrottlePolicy{})
+	avatars := NewAvatarService(userRepo, history, storage.NewLocal(t.TempDir(), "/uploads"), 256)
+	return NewAuthService(userRepo, nil, notifier, sessions, history, events, tokens, throttle, avatars, "test-secret", time.Minute, 0)
+}
+
+func TestRegisterConcurrentSameEmail(t *testing.T) {
+	userRepo := &fakeUserRepo{}
+	service := newTestAuthService(t, userRepo)
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	results := make([]error, attempts)
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = service.Register(context.Background(), &models.RegisterRequest{
+				Username: fmt.Sprintf("racer%d", i),
+				Email:    "race@example.com",
+				Password: "Sup3r-secret-pass",
+			}, "", models.LoginContext{IP: "203.0.113.1", UserAgent: "test"})
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range results {
+		switch err {
+		case nil:
+			succeeded++
+		case errors.ErrUserExists:
+		default:
+			t.Errorf("Register returned %v, want nil or ErrUserExists", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d registrations succeeded, want exactly 1", succeeded)
+	}
+	if len(userRepo.users) != 1 {
+		t.Errorf("%d accounts created, want 1", len(userRepo.users))
+	}
+	if errors.ErrUserExists.Code != 409 {
+		t.Errorf("ErrUserExists has status %d, want 409", errors.ErrUserExists.Code)
+	}
+}
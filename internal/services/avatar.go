@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strings"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/imaging"
+	"user-management-api/pkg/storage"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// avatarPrefix is the storage key prefix avatars are saved under
+const avatarPrefix = "avatars/"
+
+// AvatarService turns uploaded images into avatars: each upload is cropped
+// to a square, scaled to size pixels and saved as PNG under avatars/ in
+// the upload storage. The upload itself is removed once processed.
+type AvatarService struct {
+	userRepo interfaces.UserRepository
+	history  *HistoryService
+	store    storage.Storage
+	size     int
+}
+
+func NewAvatarService(userRepo interfaces.UserRepository, history *HistoryService, store storage.Storage, size int) *AvatarService {
+	return &AvatarService{
+		userRepo: userRepo,
+		history:  history,
+		store:    store,
+		size:     size,
+	}
+}
+
+// Process resizes the image uploaded under uploadKey, saves the avatar and
+// returns its URL. The upload is removed whether or not it was usable.
+func (s *AvatarService) Process(ctx context.Context, uploadKey string) (string, error) {
+	defer s.delete(ctx, uploadKey)
+
+	upload, err := s.store.Open(ctx, uploadKey)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+	defer upload.Close()
+	// uploads through the avatar profile are a few megabytes at most
+	content, err := io.ReadAll(upload)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+	avatar, err := imaging.Avatar(bytes.NewReader(content), s.size)
+	if err != nil {
+		if err == imaging.ErrUnsupportedImage || err == imaging.ErrImageTooLarge {
+			return "", errors.ErrInvalidAvatar
+		}
+		return "", errors.ErrInternalServer
+	}
+
+	key := avatarPrefix + primitive.NewObjectID().Hex() + ".png"
+	if err := s.store.Save(ctx, key, bytes.NewReader(avatar), int64(len(avatar)), "image/png"); err != nil {
+		return "", errors.ErrInternalServer
+	}
+	return s.store.URL(key), nil
+}
+
+// Set replaces the avatar of a user whose role actorRole can manage with
+// the image uploaded under uploadKey
+func (s *AvatarService) Set(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, uploadKey string) (*models.UserResponse, error) {
+	user, err := s.avatarUser(ctx, actorRole, id)
+	if err != nil {
+		return nil, err
+	}
+	url, err := s.Process(ctx, uploadKey)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.write(ctx, actorID, user, url)
+	if err != nil {
+		s.Remove(ctx, url)
+		return nil, err
+	}
+	return changed, nil
+}
+
+// Clear removes the avatar of a user whose role actorRole can manage
+func (s *AvatarService) Clear(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID) (*models.UserResponse, error) {
+	user, err := s.avatarUser(ctx, actorRole, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Avatar == "" {
+		return user.ToResponse(), nil
+	}
+	return s.write(ctx, actorID, user, "")
+}
+
+// write stores url as the user's avatar; the replaced avatar is removed by
+// RemoveReplaced once the update hooks run
+func (s *AvatarService) write(ctx context.Context, actorID primitive.ObjectID, user *models.User, url string) (*models.UserResponse, error) {
+	updated := *user
+	updated.Avatar = url
+	if err := beforeUserWrite(ctx, BeforeUpdate, &updated, user); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Update(ctx, &updated); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, actorID, user, &updated)
+	afterUserWrite(ctx, AfterUpdate, &updated, user)
+	return updated.ToResponse(), nil
+}
+
+// avatarUser loads a user whose avatar actorRole may change
+func (s *AvatarService) avatarUser(ctx context.Context, actorRole string, id primitive.ObjectID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if user.AnonymizedAt != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+	return user, nil
+}
+
+// Remove deletes the avatar file behind url. URLs that do not point at a
+// stored avatar, such as links set through PATCH, are left alone.
+func (s *AvatarService) Remove(ctx context.Context, url string) {
+	name, ok := strings.CutPrefix(url, s.store.URL(avatarPrefix))
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return
+	}
+	s.delete(ctx, avatarPrefix+name)
+}
+
+// delete removes a stored file, logging failures other than it being gone
+func (s *AvatarService) delete(ctx context.Context, key string) {
+	if err := s.store.Delete(context.WithoutCancel(ctx), key); err != nil && err != storage.ErrNotFound {
+		log.Printf("Failed to remove %s: %v", key, err)
+	}
+}
+
+// RemoveReplaced is a user hook deleting the avatar a user no longer has,
+// after an update changed it or the user was deleted or anonymized
+func (s *AvatarService) RemoveReplaced(ctx context.Context, input *UserHookInput) error {
+	switch input.Event {
+	case AfterUpdate, AfterAnonymize:
+		if input.Previous != nil && input.Previous.Avatar != input.User.Avatar {
+			s.Remove(ctx, input.Previous.Avatar)
+		}
+	case AfterDelete:
+		s.Remove(ctx, input.User.Avatar)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"user-management-api/internal/repository/interfaces"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BruteForcePolicy decides when an IP address is banned and for how long.
+// Each ban lasts twice as long as the previous one, up to MaxBan.
+type BruteForcePolicy struct {
+	MaxFailures int
+	Window      time.Duration
+	BaseBan     time.Duration
+	MaxBan      time.Duration
+	// Retention is how long an address must stay quiet before its ban
+	// history is forgotten
+	Retention time.Duration
+}
+
+// BruteForceService tracks failed sign-in attempts per IP address and bans
+// addresses that fail too often. State lives in the database, so bans
+// survive restarts and are shared between instances.
+type BruteForceService struct {
+	banRepo interfaces.IPBanRepository
+	policy  BruteForcePolicy
+}
+
+func NewBruteForceService(banRepo interfaces.IPBanRepository, policy BruteForcePolicy) *BruteForceService {
+	return &BruteForceService{
+		banRepo: banRepo,
+		policy:  policy,
+	}
+}
+
+// BannedUntil reports whether ip is currently banned and until when
+func (s *BruteForceService) BannedUntil(ctx context.Context, ip string) (time.Time, bool, error) {
+	ban, err := s.banRepo.Get(ctx, ip)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, err
+	}
+	if ban.BannedUntil == nil || !time.Now().Before(*ban.BannedUntil) {
+		return time.Time{}, false, nil
+	}
+	return *ban.BannedUntil, true, nil
+}
+
+// RecordFailure counts a failed attempt and bans ip once it reaches
+// MaxFailures within Window
+func (s *BruteForceService) RecordFailure(ctx context.Context, ip string) error {
+	ban, err := s.banRepo.RecordFailure(ctx, ip, s.policy.Window, s.policy.Retention)
+	if err != nil {
+		return err
+	}
+	if ban.FailedAttempts < s.policy.MaxFailures {
+		return nil
+	}
+
+	duration := s.banDuration(ban.BanCount)
+	log.Printf("banning %s for %s after %d failed attempts (ban #%d)", ip, duration, ban.FailedAttempts, ban.BanCount+1)
+	return s.banRepo.Ban(ctx, ip, time.Now().Add(duration), s.policy.Retention)
+}
+
+// RecordSuccess resets the attempt counter for ip
+func (s *BruteForceService) RecordSuccess(ctx context.Context, ip string) error {
+	return s.banRepo.ResetFailures(ctx, ip)
+}
+
+// banDuration doubles BaseBan for every earlier ban, capped at MaxBan
+func (s *BruteForceService) banDuration(previousBans int) time.Duration {
+	duration := s.policy.BaseBan
+	for i := 0; i < previousBans && duration < s.policy.MaxBan; i++ {
+		duration *= 2
+	}
+	if duration > s.policy.MaxBan {
+		duration = s.policy.MaxBan
+	}
+	return duration
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/repository/interfaces"
+)
+
+// CleanupTask purges one category of expired data and reports how many
+// items it removed
+type CleanupTask struct {
+	Name string
+	Run  func(ctx context.Context, now time.Time) (int64, error)
+}
+
+// CleanupResult is the outcome of one CleanupTask
+type CleanupResult struct {
+	Name     string
+	Deleted  int64
+	Duration time.Duration
+	Err      error
+}
+
+// CleanupService purges data past its expiry or retention. It backs the
+// cleanup command, which is meant to run from cron; most collections also
+// expire on their own through TTL indexes, which run only about once a
+// minute and do not cover everything.
+type CleanupService struct {
+	tasks []CleanupTask
+}
+
+func NewCleanupService(cfg config.CleanupConfig, tokenRepo interfaces.OneTimeTokenRepository, sessionRepo interfaces.SessionRepository, authEventRepo interfaces.AuthEventRepository, bulkRepo interfaces.BulkOperationRepository, auditRepo interfaces.AuditLogRepository) *CleanupService {
+	return &CleanupService{tasks: []CleanupTask{
+		{Name: "one_time_tokens", Run: func(ctx context.Context, now time.Time) (int64, error) {
+			return tokenRepo.DeleteExpired(ctx, now)
+		}},
+		// ended sessions are kept for a while so new device alerts still
+		// recognize recent devices
+		{Name: "sessions", Run: func(ctx context.Context, now time.Time) (int64, error) {
+			return sessionRepo.DeleteExpired(ctx, now.Add(-cfg.SessionRetention))
+		}},
+		{Name: "auth_events", Run: func(ctx context.Context, now time.Time) (int64, error) {
+			return authEventRepo.DeleteBefore(ctx, now.Add(-cfg.AuditRetention))
+		}},
+		{Name: "bulk_operations", Run: func(ctx context.Context, now time.Time) (int64, error) {
+			return bulkRepo.DeleteBefore(ctx, now.Add(-cfg.AuditRetention))
+		}},
+		{Name: "audit_logs", Run: func(ctx context.Context, now time.Time) (int64, error) {
+			return auditRepo.DeleteBefore(ctx, now.Add(-cfg.AuditRetention))
+		}},
+		{Name: "temp_uploads", Run: func(ctx context.Context, now time.Time) (int64, error) {
+			return removeFilesBefore(ctx, cfg.TempUploadDir, now.Add(-cfg.TempUploadRetention))
+		}},
+	}}
+}
+
+// Run runs every task, carrying on past failures, and returns their
+// results in order
+func (s *CleanupService) Run(ctx context.Context) []CleanupResult {
+	results := make([]CleanupResult, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		start := time.Now()
+		deleted, err := task.Run(ctx, start)
+		results = append(results, CleanupResult{
+			Name:     task.Name,
+			Deleted:  deleted,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+	return results
+}
+
+// removeFilesBefore deletes the regular files below dir last modified
+// before before. A missing dir has nothing to clean.
+func removeFilesBefore(ctx context.Context, dir string, before time.Time) (int64, error) {
+	var removed int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(before) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
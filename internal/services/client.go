@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// clientTokenTTL is the lifetime of tokens issued at the token endpoint
+const clientTokenTTL = time.Hour
+
+// authorizationCodeTTL bounds the time between consent and code exchange
+const authorizationCodeTTL = 10 * time.Minute
+
+// ClientService registers OAuth2 clients and implements the
+// client_credentials grant for service-to-service calls and the
+// authorization_code grant (with PKCE) for apps acting for a user
+type ClientService struct {
+	clientRepo interfaces.ClientRepository
+	codeRepo   interfaces.AuthorizationCodeRepository
+	userRepo   interfaces.UserRepository
+	jwtSecret  string
+}
+
+func NewClientService(clientRepo interfaces.ClientRepository, codeRepo interfaces.AuthorizationCodeRepository, userRepo interfaces.UserRepository, jwtSecret string) *ClientService {
+	return &ClientService{
+		clientRepo: clientRepo,
+		codeRepo:   codeRepo,
+		userRepo:   userRepo,
+		jwtSecret:  jwtSecret,
+	}
+}
+
+// Register creates a client and returns its secret, which is not stored
+func (s *ClientService) Register(ctx context.Context, adminID primitive.ObjectID, req *models.CreateClientRequest) (*models.CreateClientResponse, error) {
+	clientID, err := utils.RandomToken(12)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	secretHash, err := utils.HashPassword(secret)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	client := &models.OAuthClient{
+		ClientID:     "cl_" + clientID,
+		SecretHash:   secretHash,
+		Name:         req.Name,
+		Scopes:       req.Scopes,
+		RedirectURIs: req.RedirectURIs,
+		IsActive:     true,
+		CreatedBy:    adminID,
+	}
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.CreateClientResponse{
+		Client:       client,
+		ClientSecret: secret,
+	}, nil
+}
+
+func (s *ClientService) List(ctx context.Context) ([]*models.OAuthClient, error) {
+	clients, err := s.clientRepo.List(ctx)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return clients, nil
+}
+
+// Deactivate stops a client from obtaining new tokens
+func (s *ClientService) Deactivate(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.clientRepo.Deactivate(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrClientNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// IssueToken handles a token request for either supported grant
+func (s *ClientService) IssueToken(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	switch req.GrantType {
+	case "client_credentials":
+		return s.clientCredentials(ctx, req)
+	case "authorization_code":
+		return s.exchangeCode(ctx, req)
+	default:
+		return nil, errors.ErrUnsupportedGrant
+	}
+}
+
+// authenticate checks the client's credentials
+func (s *ClientService) authenticate(ctx context.Context, clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" || secret == "" {
+		return nil, errors.ErrInvalidClient
+	}
+
+	client, err := s.clientRepo.GetByClientID(ctx, clientID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidClient
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if !client.IsActive || !utils.CheckPasswordHash(secret, client.SecretHash) {
+		return nil, errors.ErrInvalidClient
+	}
+	return client, nil
+}
+
+// clientCredentials issues a machine token. Requested scopes must be a
+// subset of the client's registered scopes; when none are requested all are
+// granted.
+func (s *ClientService) clientCredentials(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	client, err := s.authenticate(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, err := requestedScopes(req.Scope, client.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateClientJWT(client.ClientID, scopes, s.jwtSecret, clientTokenTTL)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(clientTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// ValidateAuthorization checks an authorization request before the consent
+// page is shown. Errors here must be shown to the user rather than sent to
+// the redirect URI, which is not yet trusted.
+func (s *ClientService) ValidateAuthorization(ctx context.Context, req *models.AuthorizeRequest) (*models.OAuthClient, []string, error) {
+	if req.ResponseType != "code" || req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, nil, errors.ErrUnsupportedResponseType
+	}
+
+	client, err := s.clientRepo.GetByClientID(ctx, req.ClientID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, errors.ErrInvalidClient
+		}
+		return nil, nil, errors.ErrInternalServer
+	}
+	if !client.IsActive {
+		return nil, nil, errors.ErrInvalidClient
+	}
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return nil, nil, errors.ErrInvalidRedirectURI
+	}
+
+	scopes, err := requestedScopes(req.Scope, client.Scopes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, scopes, nil
+}
+
+// Authorize records the user's consent and returns the redirect URL carrying
+// a fresh authorization code. The client only receives scopes the user's
+// own role holds.
+func (s *ClientService) Authorize(ctx context.Context, req *models.AuthorizeRequest, user *models.User) (string, error) {
+	_, scopes, err := s.ValidateAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	roleScopes := models.ScopesForRole(user.Role)
+	granted := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if slices.Contains(roleScopes, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	code, err := utils.RandomToken(32)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+	err = s.codeRepo.Create(ctx, &models.AuthorizationCode{
+		CodeHash:      utils.HashToken(code),
+		ClientID:      req.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        granted,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(authorizationCodeTTL),
+	})
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+
+	return redirectWith(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// DenyURL is where to send the user after they refuse consent. The request
+// must already have passed ValidateAuthorization.
+func (s *ClientService) DenyURL(req *models.AuthorizeRequest) string {
+	return redirectWith(req.RedirectURI, url.Values{"error": {"access_denied"}, "state": {req.State}})
+}
+
+// exchangeCode redeems an authorization code for a user access token
+func (s *ClientService) exchangeCode(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	client, err := s.authenticate(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.codeRepo.Consume(ctx, utils.HashToken(req.Code))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidGrant
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI || !verifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return nil, errors.ErrInvalidGrant
+	}
+
+	user, err := s.userRepo.GetByID(ctx, code.UserID)
+	if err != nil || !user.IsActive() {
+		return nil, errors.ErrInvalidGrant
+	}
+
+	token, err := auth.GenerateDelegatedJWT(user.ID, user.Email, user.Role, code.Scopes, code.ClientID, s.jwtSecret, clientTokenTTL)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(clientTokenTTL.Seconds()),
+		Scope:       strings.Join(code.Scopes, " "),
+	}, nil
+}
+
+// requestedScopes parses a space separated scope parameter, which must be a
+// subset of allowed; an empty parameter means all of allowed
+func requestedScopes(scope string, allowed []string) ([]string, error) {
+	if scope == "" {
+		return allowed, nil
+	}
+	scopes := strings.Fields(scope)
+	for _, s := range scopes {
+		if !slices.Contains(allowed, s) {
+			return nil, errors.ErrInvalidScope
+		}
+	}
+	return scopes, nil
+}
+
+// verifyPKCE checks an S256 code verifier against the stored challenge
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func redirectWith(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
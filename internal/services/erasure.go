@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// erasureBatchSize caps how many due users one pass anonymizes
+const erasureBatchSize = 100
+
+// ErasureService carries out users' requests to be forgotten. A request
+// deactivates the account at once and ends its sessions; once the grace
+// period has passed the account is anonymized rather than deleted, so
+// aggregate records that refer to it stay consistent.
+type ErasureService struct {
+	userRepo      interfaces.UserRepository
+	sessionRepo   interfaces.SessionRepository
+	tokenRepo     interfaces.PersonalAccessTokenRepository
+	deviceRepo    interfaces.TrustedDeviceRepository
+	authEventRepo interfaces.AuthEventRepository
+	history       *HistoryService
+	gracePeriod   time.Duration
+}
+
+func NewErasureService(userRepo interfaces.UserRepository, sessionRepo interfaces.SessionRepository, tokenRepo interfaces.PersonalAccessTokenRepository, deviceRepo interfaces.TrustedDeviceRepository, authEventRepo interfaces.AuthEventRepository, history *HistoryService, gracePeriod time.Duration) *ErasureService {
+	return &ErasureService{
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		tokenRepo:     tokenRepo,
+		deviceRepo:    deviceRepo,
+		authEventRepo: authEventRepo,
+		history:       history,
+		gracePeriod:   gracePeriod,
+	}
+}
+
+// Request schedules the user's anonymization after the grace period,
+// deactivating the account and signing it out everywhere meanwhile.
+// Requesting again returns the pending request unchanged.
+func (s *ErasureService) Request(ctx context.Context, userID primitive.ObjectID) (*models.DeletionRequest, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if user.AnonymizedAt != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if user.Deletion != nil {
+		return user.Deletion, nil
+	}
+
+	now := time.Now()
+	req := models.DeletionRequest{RequestedAt: now, DueAt: now.Add(s.gracePeriod), PreviousStatus: user.Status}
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, req); err != nil {
+		if err == mongo.ErrNoDocuments {
+			// a concurrent request got there first
+			if current, err := s.userRepo.GetByID(ctx, userID); err == nil && current.Deletion != nil {
+				return current.Deletion, nil
+			}
+		}
+		return nil, errors.ErrInternalServer
+	}
+
+	signOutEverywhere(ctx, s.sessionRepo, s.tokenRepo, userID, "pending deletion")
+	after := *user
+	after.Status = models.UserStatusDeactivated
+	after.Deletion = &req
+	s.history.Record(ctx, userID, user, &after)
+	return &req, nil
+}
+
+// Cancel withdraws a user's pending deletion while its grace period runs,
+// restoring the account as it was; the user must sign in again
+func (s *ErasureService) Cancel(ctx context.Context, actorID primitive.ObjectID, actorRole string, userID primitive.ObjectID) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+
+	restored, err := s.userRepo.CancelDeletion(ctx, userID, time.Now())
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrDeletionNotPending
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, actorID, user, restored)
+	return restored.ToResponse(), nil
+}
+
+// AnonymizeDue anonymizes the users whose grace period ended by now and
+// returns how many it anonymized. Users another instance anonymized or an
+// admin restored in the meantime are skipped.
+func (s *ErasureService) AnonymizeDue(ctx context.Context, now time.Time) (int64, error) {
+	var anonymized int64
+	for {
+		users, err := s.userRepo.ListDeletionDue(ctx, now, erasureBatchSize)
+		if err != nil {
+			return anonymized, err
+		}
+		for _, user := range users {
+			done, err := s.anonymize(ctx, user.ID, now)
+			if err != nil {
+				return anonymized, err
+			}
+			if done {
+				anonymized++
+			}
+		}
+		if len(users) < erasureBatchSize {
+			return anonymized, nil
+		}
+	}
+}
+
+// anonymize scrubs the data kept about the user elsewhere before the user
+// itself, so that a failure leaves the user due and the next pass retries
+// it; every step can safely run twice
+func (s *ErasureService) anonymize(ctx context.Context, userID primitive.ObjectID, now time.Time) (bool, error) {
+	if err := s.history.Erase(ctx, userID); err != nil {
+		return false, err
+	}
+	if err := s.tokenRepo.DeleteByUser(ctx, userID); err != nil {
+		return false, err
+	}
+	if err := s.deviceRepo.DeleteByUser(ctx, userID); err != nil {
+		return false, err
+	}
+	if err := s.authEventRepo.AnonymizeUser(ctx, userID); err != nil {
+		return false, err
+	}
+
+	before, err := s.userRepo.Anonymize(ctx, userID, now)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	after, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	// recorded as the user's own change, without the personal values
+	s.history.Record(ctx, userID, before, after)
+	afterUserWrite(ctx, AfterAnonymize, after, before)
+	return true, nil
+}
+
+// AnonymizeEvery runs AnonymizeDue every interval until ctx is cancelled
+func (s *ErasureService) AnonymizeEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			count, err := s.AnonymizeDue(ctx, now)
+			if count > 0 {
+				log.Printf("anonymized %d users whose deletion was due", count)
+			}
+			if err != nil {
+				log.Printf("failed to anonymize users due for deletion: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Relation populates one related document on a resource when it is named
+// in the ?expand= parameter. Resources declare their relations once; the
+// related documents of a whole page are loaded with a single lookup.
+type Relation[T any] interface {
+	Name() string
+	expand(ctx context.Context, items []T) error
+}
+
+// Loader fetches related documents by ID in one batch
+type Loader[R any] func(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]R, error)
+
+type belongsTo[T, R any] struct {
+	name string
+	key  func(T) primitive.ObjectID
+	load Loader[R]
+	set  func(T, R)
+}
+
+// BelongsTo declares a relation to the document whose ID key returns. set
+// stores the loaded document on the item; items whose related document
+// no longer exists are left as they are.
+func BelongsTo[T, R any](name string, key func(T) primitive.ObjectID, load Loader[R], set func(T, R)) Relation[T] {
+	return &belongsTo[T, R]{name: name, key: key, load: load, set: set}
+}
+
+func (r *belongsTo[T, R]) Name() string {
+	return r.name
+}
+
+func (r *belongsTo[T, R]) expand(ctx context.Context, items []T) error {
+	seen := make(map[primitive.ObjectID]bool)
+	var ids []primitive.ObjectID
+	for _, item := range items {
+		if id := r.key(item); !id.IsZero() && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	related, err := r.load(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if doc, ok := related[r.key(item)]; ok {
+			r.set(item, doc)
+		}
+	}
+	return nil
+}
+
+// Expand populates the requested relations on items. Naming a relation
+// the resource does not declare is rejected with ErrInvalidExpand.
+func Expand[T any](ctx context.Context, items []T, requested []string, relations ...Relation[T]) error {
+	for _, name := range requested {
+		var relation Relation[T]
+		for _, candidate := range relations {
+			if candidate.Name() == name {
+				relation = candidate
+				break
+			}
+		}
+		if relation == nil {
+			return errors.ErrInvalidExpand
+		}
+		if err := relation.expand(ctx, items); err != nil {
+			return errors.ErrInternalServer
+		}
+	}
+	return nil
+}
+
+// UserLoader loads users as their public representation
+func UserLoader(userRepo interfaces.UserRepository) Loader[*models.UserResponse] {
+	return func(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.UserResponse, error) {
+		users, err := userRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[primitive.ObjectID]*models.UserResponse, len(users))
+		for _, user := range users {
+			byID[user.ID] = user.ToResponse()
+		}
+		return byID, nil
+	}
+}
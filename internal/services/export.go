@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"io"
+	"strings"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/export"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// userExportTable lists the user fields that may be exported. Passwords are
+// deliberately absent.
+var userExportTable = export.NewTable(
+	export.Column[*models.User]{Key: "id", Header: "ID", Value: func(u *models.User) any { return u.ID.Hex() }},
+	export.Column[*models.User]{Key: "username", Header: "Username", Value: func(u *models.User) any { return u.Username }},
+	export.Column[*models.User]{Key: "email", Header: "Email", Value: func(u *models.User) any { return u.Email }},
+	export.Column[*models.User]{Key: "first_name", Header: "First Name", Value: func(u *models.User) any { return u.FirstName }},
+	export.Column[*models.User]{Key: "last_name", Header: "Last Name", Value: func(u *models.User) any { return u.LastName }},
+	export.Column[*models.User]{Key: "role", Header: "Role", Value: func(u *models.User) any { return u.Role }},
+	export.Column[*models.User]{Key: "status", Header: "Status", Value: func(u *models.User) any { return string(u.Status) }},
+	export.Column[*models.User]{Key: "tags", Header: "Tags", Value: func(u *models.User) any { return strings.Join(u.Tags, " ") }},
+	export.Column[*models.User]{Key: "avatar", Header: "Avatar", Value: func(u *models.User) any { return u.Avatar }},
+	export.Column[*models.User]{Key: "created_at", Header: "Created At", Value: func(u *models.User) any { return u.CreatedAt }},
+	export.Column[*models.User]{Key: "updated_at", Header: "Updated At", Value: func(u *models.User) any { return u.UpdatedAt }},
+)
+
+// ExportService manages export templates and writes user exports
+type ExportService struct {
+	templateRepo interfaces.ExportTemplateRepository
+	userRepo     interfaces.UserRepository
+}
+
+func NewExportService(templateRepo interfaces.ExportTemplateRepository, userRepo interfaces.UserRepository) *ExportService {
+	return &ExportService{
+		templateRepo: templateRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// Columns returns every column the user export supports
+func (s *ExportService) Columns() *models.ExportColumnsResponse {
+	return &models.ExportColumnsResponse{Columns: userExportTable.Keys()}
+}
+
+func (s *ExportService) CreateTemplate(ctx context.Context, ownerID primitive.ObjectID, req *models.CreateExportTemplateRequest) (*models.ExportTemplate, error) {
+	template := &models.ExportTemplate{
+		OwnerID:    ownerID,
+		Name:       req.Name,
+		Columns:    req.Columns,
+		DateFormat: req.DateFormat,
+	}
+	if template.DateFormat == "" {
+		template.DateFormat = export.DefaultDateFormat
+	}
+	if err := userExportTable.Validate(export.Options{Columns: template.Columns, DateFormat: template.DateFormat}); err != nil {
+		return nil, errors.ErrInvalidExport
+	}
+
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return template, nil
+}
+
+func (s *ExportService) ListTemplates(ctx context.Context, ownerID primitive.ObjectID) ([]*models.ExportTemplate, error) {
+	templates, err := s.templateRepo.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return templates, nil
+}
+
+func (s *ExportService) DeleteTemplate(ctx context.Context, ownerID, id primitive.ObjectID) error {
+	if err := s.templateRepo.Delete(ctx, id, ownerID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrTemplateNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// UserExport is a resolved user export request
+type UserExport struct {
+	Format  string
+	Options export.Options
+	Filter  interfaces.UserFilter
+}
+
+// ResolveUserExport turns a template and/or explicit columns, the format
+// and the filters into a user export. It runs before any output is written
+// so that a bad request can still get a normal error response.
+func (s *ExportService) ResolveUserExport(ctx context.Context, ownerID primitive.ObjectID, query *models.ExportUsersQuery) (*UserExport, error) {
+	var opts export.Options
+
+	if query.Template != "" {
+		id, err := primitive.ObjectIDFromHex(query.Template)
+		if err != nil {
+			return nil, errors.ErrInvalidInput
+		}
+		template, err := s.templateRepo.GetByID(ctx, id, ownerID)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errors.ErrTemplateNotFound
+			}
+			return nil, errors.ErrInternalServer
+		}
+		opts.Columns = template.Columns
+		opts.DateFormat = template.DateFormat
+	}
+
+	if query.Columns != "" {
+		opts.Columns = nil
+		for _, col := range strings.Split(query.Columns, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				opts.Columns = append(opts.Columns, col)
+			}
+		}
+	}
+	if query.DateFormat != "" {
+		opts.DateFormat = query.DateFormat
+	}
+
+	if err := userExportTable.Validate(opts); err != nil {
+		return nil, errors.ErrInvalidExport
+	}
+	tags, err := models.NormalizeTags(query.Tags)
+	if err != nil {
+		return nil, errors.ErrInvalidTags
+	}
+	return &UserExport{
+		Format:  query.Format,
+		Options: opts,
+		Filter: interfaces.UserFilter{
+			Role:   query.Role,
+			Status: query.Status,
+			Search: query.Search,
+			Tags:   tags,
+		},
+	}, nil
+}
+
+// WriteUsers streams the users matching the export's filter to w, one at
+// a time, so exports of any size are never held in memory
+func (s *ExportService) WriteUsers(ctx context.Context, w io.Writer, userExport *UserExport) error {
+	ew, err := userExportTable.NewWriter(w, userExport.Format, userExport.Options)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.ForEach(ctx, userExport.Filter, ew.Write); err != nil {
+		return err
+	}
+	return ew.Flush()
+}
@@ -0,0 +1,708 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/cdn"
+	"user-management-api/pkg/clamav"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/filesafe"
+	"user-management-api/pkg/imaging"
+	"user-management-api/pkg/storage"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// actionConfirmUpload scopes the token that confirms a presigned
+	// upload; users cannot mint it through POST /auth/action-token
+	actionConfirmUpload = "file:confirm"
+	// confirmGrace keeps the confirmation token valid after the upload URL
+	// expires, for uploads that started just in time
+	confirmGrace = time.Hour
+	// actionShareFile scopes the token of a share link, which users can
+	// only get through POST /files/{id}/share
+	actionShareFile = "file:share"
+	// defaultShareTTL is how long a share link lasts unless asked otherwise
+	defaultShareTTL = 24 * time.Hour
+)
+
+// uncheckedTypes are accepted only through the upload middleware, which
+// sanitizes SVG images and checks archives before they are stored. So are
+// the formats built on ZIP archives, Office documents included.
+var uncheckedTypes = []string{"text/xml", "image/svg+xml", "application/zip", "application/x-gzip", "application/gzip"}
+
+func uncheckedType(contentType string) bool {
+	return slices.Contains(uncheckedTypes, contentType) || filesafe.IsZip(contentType)
+}
+
+// FileService keeps the metadata of uploaded files in the files
+// collection. It also lets clients upload straight to object storage:
+// Presign checks the file against an upload profile and returns a URL to
+// PUT it to, Confirm checks what actually arrived and deletes it when the
+// profile does not accept it. With a scanner, confirmed files are also
+// scanned for viruses. Private files are only served through share links
+// under publicURL and download tokens. With a CDN, the URLs of public
+// files are signed CDN URLs instead of the storage backend's.
+type FileService struct {
+	fileRepo  interfaces.FileRepository
+	store     storage.Storage
+	scanner   *clamav.Client
+	cdn       *cdn.CDN
+	uploads   config.UploadConfig
+	jwtSecret string
+	publicURL string
+}
+
+func NewFileService(fileRepo interfaces.FileRepository, store storage.Storage, scanner *clamav.Client, cdn *cdn.CDN, uploads config.UploadConfig, jwtSecret, publicURL string) *FileService {
+	return &FileService{
+		fileRepo:  fileRepo,
+		store:     store,
+		scanner:   scanner,
+		cdn:       cdn,
+		uploads:   uploads,
+		jwtSecret: jwtSecret,
+		publicURL: publicURL,
+	}
+}
+
+// Record saves the metadata of files the upload middleware stored for
+// ownerID, filling in their IDs and URLs. Files are public unless their
+// Visibility says otherwise. A file whose contents are already stored is
+// pointed at that copy and its own is removed.
+func (s *FileService) Record(ctx context.Context, ownerID primitive.ObjectID, files []*models.File) error {
+	for _, file := range files {
+		switch file.Visibility {
+		case "":
+			file.Visibility = models.FileVisibilityPublic
+		case models.FileVisibilityPublic, models.FileVisibilityPrivate:
+		default:
+			return errors.ErrInvalidVisibility
+		}
+	}
+	for _, file := range files {
+		file.OwnerID = ownerID
+		file.Status = models.FileStatusReady
+		s.dedupe(ctx, file)
+		if err := s.fileRepo.Create(ctx, file); err != nil {
+			return errors.ErrInternalServer
+		}
+		s.setURLs(file)
+	}
+	return nil
+}
+
+// List returns a page of the caller's own files, newest first
+func (s *FileService) List(ctx context.Context, userID primitive.ObjectID, page, limit int) (*models.PaginatedResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	files, total, err := s.fileRepo.List(ctx, userID, page, limit)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	for _, file := range files {
+		s.setURLs(file)
+	}
+
+	return &models.PaginatedResponse{
+		Success: true,
+		Message: "Files retrieved successfully",
+		Data:    files,
+		Pagination: models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      int(total),
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+func (s *FileService) Get(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID) (*models.File, error) {
+	file, err := s.getAccessible(ctx, userID, role, id)
+	if err != nil {
+		return nil, err
+	}
+	s.setURLs(file)
+	return file, nil
+}
+
+// Delete removes the file and its variants from storage, unless other
+// records share them, then its record. A record whose file is already gone is removed as well.
+func (s *FileService) Delete(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID) error {
+	file, err := s.getAccessible(ctx, userID, role, id)
+	if err != nil {
+		return err
+	}
+	references, err := s.fileRepo.CountByKey(ctx, file.Key)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	if references <= 1 {
+		for _, key := range storedKeys(file) {
+			if err := s.store.Delete(ctx, key); err != nil && err != storage.ErrNotFound {
+				log.Printf("Failed to remove %s: %v", key, err)
+				return errors.ErrInternalServer
+			}
+		}
+	}
+	if err := s.fileRepo.Delete(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrFileNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// ResolveArchive loads the files a ZIP download asks for, in the order
+// given and without repeats, before anything is written. A file the caller
+// cannot reach, or a presigned upload still pending, fails the request.
+func (s *FileService) ResolveArchive(ctx context.Context, userID primitive.ObjectID, role string, ids []string) ([]*models.File, error) {
+	files := make([]*models.File, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, hexID := range ids {
+		id, err := primitive.ObjectIDFromHex(hexID)
+		if err != nil {
+			return nil, errors.ErrFileNotFound
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		file, err := s.getAccessible(ctx, userID, role, id)
+		if err != nil {
+			return nil, err
+		}
+		if file.Status != models.FileStatusReady {
+			return nil, errors.ErrFileNotFound
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// WriteArchive streams files to w as a ZIP, one entry per file under its
+// original name, reading each from storage as it goes so the archive is
+// never held in memory. Files that are already compressed are stored
+// rather than deflated again.
+func (s *FileService) WriteArchive(ctx context.Context, w io.Writer, files []*models.File) error {
+	archive := zip.NewWriter(w)
+	names := make(map[string]int, len(files))
+	for _, file := range files {
+		header := &zip.FileHeader{
+			Name:     archiveName(names, file.OriginalName),
+			Method:   zip.Deflate,
+			Modified: file.CreatedAt,
+		}
+		if compressedTypes[file.ContentType] || strings.HasPrefix(file.ContentType, "video/") || strings.HasPrefix(file.ContentType, "audio/") {
+			header.Method = zip.Store
+		}
+		entry, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		contents, err := s.store.Open(ctx, file.Key)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", file.Key, err)
+		}
+		_, err = io.Copy(entry, contents)
+		contents.Close()
+		if err != nil {
+			return fmt.Errorf("copy %s: %w", file.Key, err)
+		}
+	}
+	return archive.Close()
+}
+
+// compressedTypes gain nothing from being deflated again
+var compressedTypes = map[string]bool{
+	"image/jpeg":         true,
+	"image/png":          true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"application/zip":    true,
+	"application/gzip":   true,
+	"application/x-gzip": true,
+	"application/pdf":    true,
+}
+
+// archiveName makes a file's name unique within an archive, numbering
+// repeats as "report (2).pdf". names counts the names used so far.
+func archiveName(names map[string]int, original string) string {
+	name := path.Base(strings.ReplaceAll(original, `\`, "/"))
+	if name == "." || name == "/" {
+		name = "file"
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for names[candidate] > 0 {
+		names[name]++
+		candidate = fmt.Sprintf("%s (%d)%s", base, names[name], ext)
+	}
+	names[candidate]++
+	return candidate
+}
+
+// SetVisibility makes a file public or private. Making a file private
+// hides its stored copy only once no public file shares it.
+func (s *FileService) SetVisibility(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, visibility string) (*models.File, error) {
+	file, err := s.getAccessible(ctx, userID, role, id)
+	if err != nil {
+		return nil, err
+	}
+	file.Visibility = visibility
+	if err := s.fileRepo.Update(ctx, file); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrFileNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.setURLs(file)
+	return file, nil
+}
+
+// Share returns a link anyone can download the file from until ttl
+// elapses, a day when zero, whether or not it is public. Links are signed
+// rather than stored; deleting the file is the only way to revoke them.
+func (s *FileService) Share(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, ttl time.Duration) (*models.FileShareLink, error) {
+	file, err := s.getAccessible(ctx, userID, role, id)
+	if err != nil {
+		return nil, err
+	}
+	if file.Status != models.FileStatusReady {
+		return nil, errors.ErrFileNotFound
+	}
+	if ttl <= 0 {
+		ttl = defaultShareTTL
+	}
+	token, err := auth.GenerateActionToken(userID, actionShareFile, file.ID.Hex(), s.jwtSecret, ttl)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return &models.FileShareLink{
+		URL:       s.publicURL + "/api/v1/files/shared/" + file.ID.Hex() + "?token=" + url.QueryEscape(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// GetShared loads a file for its share link, whose token was checked
+func (s *FileService) GetShared(ctx context.Context, id primitive.ObjectID) (*models.File, error) {
+	file, err := s.fileRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrFileNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if file.Status != models.FileStatusReady {
+		return nil, errors.ErrFileNotFound
+	}
+	return file, nil
+}
+
+// CanServe reports whether the stored copy under key may be served to
+// anyone: it is, unless every file stored there is private. Keys no file
+// refers to, such as avatars, are served.
+func (s *FileService) CanServe(ctx context.Context, key string) (bool, error) {
+	visibility, err := s.fileRepo.KeyVisibility(ctx, key)
+	if err != nil {
+		return false, errors.ErrInternalServer
+	}
+	return visibility != models.FileVisibilityPrivate, nil
+}
+
+// CanDownload reports whether userID, holding a download token for key,
+// may fetch it: private copies only go to the owner of a file stored there
+func (s *FileService) CanDownload(ctx context.Context, userID primitive.ObjectID, key string) (bool, error) {
+	if servable, err := s.CanServe(ctx, key); err != nil || servable {
+		return servable, err
+	}
+	if _, err := s.fileRepo.GetByKey(ctx, userID, key); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, errors.ErrInternalServer
+	}
+	return true, nil
+}
+
+// getAccessible loads a file the caller owns, or any file for admins.
+// Other files are reported as not found so their existence is not
+// revealed.
+func (s *FileService) getAccessible(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID) (*models.File, error) {
+	file, err := s.fileRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrFileNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if file.OwnerID != userID && !models.RoleIncludes(role, models.RoleAdmin) {
+		return nil, errors.ErrFileNotFound
+	}
+	return file, nil
+}
+
+// Presign returns a URL the file described by req can be PUT to, and the
+// token that confirms it
+func (s *FileService) Presign(ctx context.Context, userID primitive.ObjectID, req *models.PresignUploadRequest) (*models.PresignUploadResponse, error) {
+	direct, ok := s.store.(storage.DirectUploader)
+	if !ok {
+		return nil, errors.ErrDirectUploadUnsupported
+	}
+	name := req.Profile
+	if name == "" {
+		name = config.UploadProfileAny
+	}
+	profile, err := s.profile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the base name only, whichever separator the client used
+	filename := path.Base(strings.ReplaceAll(req.Filename, `\`, "/"))
+	ext := strings.ToLower(path.Ext(filename))
+	contentType, _, _ := strings.Cut(strings.ToLower(req.ContentType), ";")
+	contentType = strings.TrimSpace(contentType)
+	switch {
+	case filename == "." || filename == "/":
+		return nil, fileRejected("filename is invalid")
+	case !slices.Contains(profile.AllowedExts, ext):
+		return nil, fileRejected("file extension '%s' not allowed. Allowed extensions: %v", ext, profile.AllowedExts)
+	case !slices.Contains(profile.AllowedTypes, contentType):
+		return nil, fileRejected("file type '%s' not allowed. Allowed types: %v", contentType, profile.AllowedTypes)
+	case ext == ".svg" || uncheckedType(contentType):
+		return nil, fileRejected("SVG files and archives, Office documents included, must be uploaded through the API")
+	case req.Size > profile.MaxFileSize:
+		return nil, fileRejected("file size exceeds maximum allowed size of %d bytes", profile.MaxFileSize)
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.FileVisibilityPublic
+	}
+
+	key := storage.NewKey(s.uploads.KeyPrefix(profile), filename)
+	url, err := direct.PresignPut(ctx, key, contentType, s.uploads.PresignTTL)
+	if err != nil {
+		log.Printf("Failed to presign upload %s: %v", key, err)
+		return nil, errors.ErrInternalServer
+	}
+	pending := &models.File{
+		OwnerID:      userID,
+		OriginalName: filename,
+		Key:          key,
+		Size:         req.Size,
+		ContentType:  contentType,
+		Checksum:     strings.ToLower(req.Checksum),
+		Visibility:   visibility,
+		Status:       models.FileStatusPending,
+	}
+	if err := s.fileRepo.Create(ctx, pending); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	token, err := auth.GenerateActionToken(userID, actionConfirmUpload, name+":"+key, s.jwtSecret, s.uploads.PresignTTL+confirmGrace)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.PresignUploadResponse{
+		Key:       key,
+		Profile:   name,
+		Method:    http.MethodPut,
+		URL:       url,
+		Headers:   map[string]string{"Content-Type": contentType},
+		Token:     token,
+		ExpiresAt: time.Now().Add(s.uploads.PresignTTL),
+	}, nil
+}
+
+// Confirm checks a finished presigned upload against its profile, sniffing
+// the type from the file's first bytes as the upload middleware does, and
+// against the checksum the client announced. Images lose their metadata
+// unless the profile keeps it. It then marks its record ready, sharing the stored copy of identical contents if there is one. A
+// file that fails the checks is deleted along with its record.
+func (s *FileService) Confirm(ctx context.Context, userID primitive.ObjectID, req *models.ConfirmUploadRequest) (*models.File, error) {
+	direct, ok := s.store.(storage.DirectUploader)
+	if !ok {
+		return nil, errors.ErrDirectUploadUnsupported
+	}
+	claims, err := auth.ValidateActionToken(req.Token, s.jwtSecret, actionConfirmUpload, req.Profile+":"+req.Key)
+	if err != nil || claims.UserID != userID {
+		return nil, errors.ErrInvalidToken
+	}
+	profile, err := s.profile(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.fileRepo.GetByKey(ctx, userID, req.Key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUploadNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if file.Status == models.FileStatusReady {
+		s.setURLs(file)
+		return file, nil
+	}
+
+	info, err := direct.Stat(ctx, req.Key)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return nil, errors.ErrUploadNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if info.Size > profile.MaxFileSize {
+		s.discard(ctx, file)
+		return nil, fileRejected("file size exceeds maximum allowed size of %d bytes", profile.MaxFileSize)
+	}
+	contentType, checksum, err := s.inspect(ctx, req.Key)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if !filesafe.TypeAllowed(profile.AllowedTypes, contentType) || uncheckedType(contentType) {
+		s.discard(ctx, file)
+		return nil, fileRejected("file type '%s' not allowed. Allowed types: %v", contentType, profile.AllowedTypes)
+	}
+	if file.Checksum != "" && file.Checksum != checksum {
+		s.discard(ctx, file)
+		return nil, errors.ErrChecksumMismatch
+	}
+	scan, err := s.scan(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size
+	if !profile.KeepMetadata && imaging.StripsMetadata(contentType) {
+		size, checksum, err = s.stripMetadata(ctx, req.Key, contentType)
+		if err == imaging.ErrUnsupportedImage || err == imaging.ErrImageTooLarge {
+			s.discard(ctx, file)
+			return nil, fileRejected("image file is corrupt or too large")
+		}
+		if err != nil {
+			log.Printf("Failed to strip metadata from %s: %v", req.Key, err)
+			return nil, errors.ErrInternalServer
+		}
+	}
+
+	file.Size = size
+	file.ContentType = contentType
+	file.Checksum = checksum
+	file.Scan = scan
+	s.dedupe(ctx, file)
+	file.Status = models.FileStatusReady
+	if err := s.fileRepo.Update(ctx, file); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	s.setURLs(file)
+	return file, nil
+}
+
+// profile returns the upload profile called name. Avatars are resized on
+// upload, so their profile cannot be uploaded to directly.
+func (s *FileService) profile(name string) (config.UploadProfile, error) {
+	profile, ok := s.uploads.Profiles[name]
+	if !ok || name == config.UploadProfileAvatar {
+		return profile, errors.ErrUnknownUploadProfile
+	}
+	return profile, nil
+}
+
+// inspect reads the stored file once to detect its type from its first
+// bytes, without parameters, and compute its hex SHA-256
+func (s *FileService) inspect(ctx context.Context, key string) (string, string, error) {
+	file, err := s.store.Open(ctx, key)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	contents := io.TeeReader(file, hash)
+	head := make([]byte, filesafe.SniffLen)
+	n, err := io.ReadFull(contents, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", "", err
+	}
+	if _, err := io.Copy(io.Discard, contents); err != nil {
+		return "", "", err
+	}
+	return filesafe.DetectType(head[:n]), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// stripMetadata saves the image stored under key again without its
+// metadata and returns its new size and hex SHA-256. The stripped copy is
+// spooled to the upload temp dir first, as the stored one cannot be read
+// while it is being overwritten.
+func (s *FileService) stripMetadata(ctx context.Context, key, contentType string) (int64, string, error) {
+	stored, err := s.store.Open(ctx, key)
+	if err != nil {
+		return 0, "", err
+	}
+	defer stored.Close()
+	src, ok := stored.(io.ReadSeeker)
+	if !ok {
+		return 0, "", fmt.Errorf("storage returned a %T, which cannot be seeked", stored)
+	}
+	spool, err := os.CreateTemp(s.uploads.TempDir, "strip-*")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hash := sha256.New()
+	if err := imaging.StripMetadata(io.MultiWriter(spool, hash), src, contentType); err != nil {
+		return 0, "", err
+	}
+	size, err := spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, "", err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+	if err := s.store.Save(ctx, key, spool, size, contentType); err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// dedupe points a file about to be recorded at the stored copy of
+// identical contents, if there is one, and removes its own. The file keeps
+// its own copy when that cannot be removed.
+func (s *FileService) dedupe(ctx context.Context, file *models.File) {
+	if file.Checksum == "" {
+		return
+	}
+	existing, err := s.fileRepo.GetByChecksum(ctx, file.Checksum)
+	if err != nil || existing.Key == file.Key || existing.Size != file.Size {
+		return
+	}
+	if err := s.store.Delete(ctx, file.Key); err != nil && err != storage.ErrNotFound {
+		log.Printf("Failed to remove duplicate %s: %v", file.Key, err)
+		return
+	}
+	for _, variant := range file.Variants {
+		if err := s.store.Delete(ctx, variant.Key); err != nil && err != storage.ErrNotFound {
+			log.Printf("Failed to remove duplicate %s: %v", variant.Key, err)
+		}
+	}
+	file.Key, file.Variants = existing.Key, existing.Variants
+}
+
+// storedKeys lists the keys a file's contents are stored under: its own
+// and its variants'
+func storedKeys(file *models.File) []string {
+	keys := []string{file.Key}
+	for _, variant := range file.Variants {
+		keys = append(keys, variant.Key)
+	}
+	return keys
+}
+
+// setURLs fills in the URLs of a public file and its variants
+func (s *FileService) setURLs(file *models.File) {
+	if file.Visibility == models.FileVisibilityPrivate {
+		file.URL = ""
+		for i := range file.Variants {
+			file.Variants[i].URL = ""
+		}
+		return
+	}
+	file.URL = s.fileURL(file.Key)
+	for i := range file.Variants {
+		file.Variants[i].URL = s.fileURL(file.Variants[i].Key)
+	}
+}
+
+// fileURL is where the stored copy under key is fetched from: a signed
+// CDN URL when there is a CDN, else the storage backend's URL
+func (s *FileService) fileURL(key string) string {
+	if s.cdn == nil {
+		return s.store.URL(key)
+	}
+	signed, err := s.cdn.URL(key)
+	if err != nil {
+		log.Printf("Failed to sign CDN URL of %s: %v", key, err)
+		return s.store.URL(key)
+	}
+	return signed
+}
+
+// validChecksum reports whether checksum is a hex-encoded SHA-256
+func validChecksum(checksum string) bool {
+	decoded, err := hex.DecodeString(checksum)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+// scan has the scanner check the stored file, discarding it when it is
+// infected. It returns nil without a scanner.
+func (s *FileService) scan(ctx context.Context, file *models.File) (*models.FileScan, error) {
+	if s.scanner == nil {
+		return nil, nil
+	}
+	contents, err := s.store.Open(ctx, file.Key)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	defer contents.Close()
+	result, err := s.scanner.Scan(ctx, contents)
+	if err != nil {
+		log.Printf("Failed to scan %s: %v", file.Key, err)
+		return nil, errors.ErrScanFailed
+	}
+	if result.Infected {
+		log.Printf("Removing infected upload %s: %s", file.Key, result.Signature)
+		s.discard(ctx, file)
+		infected := *errors.ErrFileInfected
+		infected.Message = fmt.Sprintf("file is infected with %s", result.Signature)
+		return nil, &infected
+	}
+	return &models.FileScan{Engine: "clamav", Result: models.FileScanClean, ScannedAt: time.Now()}, nil
+}
+
+// fileRejected is ErrFileNotAllowed with the reason as its message, as
+// the upload middleware reports it
+func fileRejected(format string, args ...any) error {
+	rejected := *errors.ErrFileNotAllowed
+	rejected.Message = fmt.Sprintf(format, args...)
+	return &rejected
+}
+
+// discard removes a rejected file and its record, logging failures other
+// than the file being gone
+func (s *FileService) discard(ctx context.Context, file *models.File) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.store.Delete(ctx, file.Key); err != nil && err != storage.ErrNotFound {
+		log.Printf("Failed to remove %s: %v", file.Key, err)
+	}
+	if err := s.fileRepo.Delete(ctx, file.ID); err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Failed to remove the record of %s: %v", file.Key, err)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/storage"
+)
+
+// FileGCResult sums up one garbage collection run
+type FileGCResult struct {
+	// Scanned counts the files in storage, Orphaned those nothing refers
+	// to and Deleted the orphans removed
+	Scanned  int64
+	Orphaned int64
+	Deleted  int64
+	// Bytes is the size of the orphans
+	Bytes int64
+}
+
+// FileGCService removes the files in storage nothing refers to: blobs left
+// by uploads whose record failed to be saved, by deletions that failed
+// halfway or by avatars replaced while their removal failed. A stored file
+// is kept while a file record or one of its variants is stored under its
+// key, or while a user's avatar URL points at it. Files younger than
+// minAge are always kept, since uploads are stored before they are
+// recorded.
+type FileGCService struct {
+	fileRepo interfaces.FileRepository
+	userRepo interfaces.UserRepository
+	store    storage.Storage
+	minAge   time.Duration
+}
+
+func NewFileGCService(fileRepo interfaces.FileRepository, userRepo interfaces.UserRepository, store storage.Storage, minAge time.Duration) *FileGCService {
+	return &FileGCService{
+		fileRepo: fileRepo,
+		userRepo: userRepo,
+		store:    store,
+		minAge:   minAge,
+	}
+}
+
+// Run lists the storage before loading what refers to it, so files stored
+// and recorded in between are too young to be collected. Each orphan is
+// looked up once more right before it is deleted, since an upload with
+// the same contents may have come to share it. Orphans are passed to
+// onOrphan when it is not nil; with dryRun they are only reported.
+// Failures to delete an orphan are logged and the run carries on.
+func (s *FileGCService) Run(ctx context.Context, dryRun bool, onOrphan func(key string, obj *storage.Object)) (*FileGCResult, error) {
+	result := &FileGCResult{}
+	cutoff := time.Now().Add(-s.minAge)
+	candidates := make(map[string]*storage.Object)
+	err := s.store.List(ctx, func(key string, obj *storage.Object) error {
+		result.Scanned++
+		if obj.Modified.Before(cutoff) {
+			candidates[key] = obj
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("list storage: %w", err)
+	}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+
+	err = s.fileRepo.ForEachKey(ctx, func(key string) error {
+		delete(candidates, key)
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("list file keys: %w", err)
+	}
+	// avatars, and any other stored file a user's avatar URL points at
+	base := s.store.URL("")
+	err = s.userRepo.ForEach(ctx, interfaces.UserFilter{}, func(user *models.User) error {
+		if key, ok := strings.CutPrefix(user.Avatar, base); ok {
+			delete(candidates, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("list avatars: %w", err)
+	}
+
+	for key, obj := range candidates {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		visibility, err := s.fileRepo.KeyVisibility(ctx, key)
+		if err != nil {
+			return result, fmt.Errorf("look up %s: %w", key, err)
+		}
+		if visibility != "" {
+			continue
+		}
+		result.Orphaned++
+		result.Bytes += obj.Size
+		if onOrphan != nil {
+			onOrphan(key, obj)
+		}
+		if dryRun {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil && err != storage.ErrNotFound {
+			log.Printf("Failed to remove orphaned file %s: %v", key, err)
+			continue
+		}
+		result.Deleted++
+	}
+	return result, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GrantService manages delegated access between users. An owner consents
+// either by creating a grant directly or by approving a pending request.
+type GrantService struct {
+	grantRepo interfaces.GrantRepository
+	userRepo  interfaces.UserRepository
+}
+
+func NewGrantService(grantRepo interfaces.GrantRepository, userRepo interfaces.UserRepository) *GrantService {
+	return &GrantService{
+		grantRepo: grantRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Grant gives another user access to the owner's resources right away
+func (s *GrantService) Grant(ctx context.Context, ownerID primitive.ObjectID, req *models.CreateGrantRequest) (*models.Grant, error) {
+	granteeID, err := primitive.ObjectIDFromHex(req.GranteeID)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	return s.create(ctx, ownerID, granteeID, req.Scopes, req.ExpiresInHours, models.GrantStatusActive)
+}
+
+// Request records a pending grant that the owner still has to approve
+func (s *GrantService) Request(ctx context.Context, granteeID primitive.ObjectID, req *models.RequestGrantRequest) (*models.Grant, error) {
+	ownerID, err := primitive.ObjectIDFromHex(req.OwnerID)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	return s.create(ctx, ownerID, granteeID, req.Scopes, req.ExpiresInHours, models.GrantStatusPending)
+}
+
+// List returns the grants a user has given and received
+func (s *GrantService) List(ctx context.Context, userID primitive.ObjectID) (*models.GrantListResponse, error) {
+	given, err := s.grantRepo.ListByOwner(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	received, err := s.grantRepo.ListByGrantee(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return &models.GrantListResponse{Given: given, Received: received}, nil
+}
+
+// Approve activates a pending request; only the owner may consent
+func (s *GrantService) Approve(ctx context.Context, ownerID, grantID primitive.ObjectID) (*models.Grant, error) {
+	grant, err := s.getOwned(ctx, grantID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if grant.Status != models.GrantStatusPending {
+		return nil, errors.ErrInvalidInput
+	}
+	if err := s.grantRepo.UpdateStatus(ctx, grant.ID, models.GrantStatusActive); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	grant.Status = models.GrantStatusActive
+	return grant, nil
+}
+
+// Revoke ends a grant. The owner can revoke anything they gave and the
+// grantee can give up access they hold.
+func (s *GrantService) Revoke(ctx context.Context, userID, grantID primitive.ObjectID) error {
+	grant, err := s.grantRepo.GetByID(ctx, grantID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrGrantNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	if grant.OwnerID != userID && grant.GranteeID != userID {
+		return errors.ErrGrantNotFound
+	}
+	if err := s.grantRepo.UpdateStatus(ctx, grant.ID, models.GrantStatusRevoked); err != nil {
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// HasGrant reports whether granteeID currently holds scope on ownerID's
+// resources. It satisfies middleware.GrantChecker.
+func (s *GrantService) HasGrant(ctx context.Context, ownerID, granteeID primitive.ObjectID, scope string) (bool, error) {
+	if _, err := s.grantRepo.FindActive(ctx, ownerID, granteeID, scope); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *GrantService) create(ctx context.Context, ownerID, granteeID primitive.ObjectID, scopes []string, expiresInHours int, status string) (*models.Grant, error) {
+	if ownerID == granteeID {
+		return nil, errors.ErrInvalidInput
+	}
+	if _, err := s.userRepo.GetByID(ctx, ownerID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if _, err := s.userRepo.GetByID(ctx, granteeID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+
+	grant := &models.Grant{
+		OwnerID:   ownerID,
+		GranteeID: granteeID,
+		Scopes:    scopes,
+		Status:    status,
+	}
+	if expiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(expiresInHours) * time.Hour)
+		grant.ExpiresAt = &expiresAt
+	}
+
+	if err := s.grantRepo.Create(ctx, grant); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return grant, nil
+}
+
+func (s *GrantService) getOwned(ctx context.Context, grantID, ownerID primitive.ObjectID) (*models.Grant, error) {
+	grant, err := s.grantRepo.GetByID(ctx, grantID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrGrantNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if grant.OwnerID != ownerID {
+		return nil, errors.ErrGrantNotFound
+	}
+	return grant, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"reflect"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// userHistoryField is a user field tracked in the change history; set
+// restores a recorded value when rebuilding past states
+type userHistoryField struct {
+	name string
+	get  func(*models.User) any
+	set  func(*models.User, any)
+	// secret fields record that they changed but never their values
+	secret bool
+	// personal fields have their recorded values blanked when the user is
+	// erased
+	personal bool
+}
+
+var userHistoryFields = []userHistoryField{
+	{name: "username", get: func(u *models.User) any { return u.Username }, set: func(u *models.User, v any) { u.Username, _ = v.(string) }, personal: true},
+	{name: "email", get: func(u *models.User) any { return u.Email }, set: func(u *models.User, v any) { u.Email, _ = v.(string) }, personal: true},
+	{name: "password", get: func(u *models.User) any { return u.Password }, set: func(u *models.User, v any) {}, secret: true},
+	{name: "first_name", get: func(u *models.User) any { return u.FirstName }, set: func(u *models.User, v any) { u.FirstName, _ = v.(string) }, personal: true},
+	{name: "last_name", get: func(u *models.User) any { return u.LastName }, set: func(u *models.User, v any) { u.LastName, _ = v.(string) }, personal: true},
+	{name: "role", get: func(u *models.User) any { return u.Role }, set: func(u *models.User, v any) { u.Role, _ = v.(string) }},
+	{name: "avatar", get: func(u *models.User) any { return u.Avatar }, set: func(u *models.User, v any) { u.Avatar, _ = v.(string) }, personal: true},
+	{name: "locale", get: func(u *models.User) any { return u.Locale }, set: func(u *models.User, v any) { u.Locale, _ = v.(string) }, personal: true},
+	{name: "status", get: func(u *models.User) any { return string(u.Status) }, set: func(u *models.User, v any) { s, _ := v.(string); u.Status = models.UserStatus(s) }},
+	{name: "status_reason", get: func(u *models.User) any { return u.StatusReason }, set: func(u *models.User, v any) { u.StatusReason, _ = v.(string) }, personal: true},
+	{name: "email_verified", get: func(u *models.User) any { return u.EmailVerified }, set: func(u *models.User, v any) { u.EmailVerified, _ = v.(bool) }},
+	{name: "preferences.login_alerts_opt_out", get: func(u *models.User) any { return u.Preferences.LoginAlertsOptOut }, set: func(u *models.User, v any) { u.Preferences.LoginAlertsOptOut, _ = v.(bool) }},
+	{name: "metadata", get: func(u *models.User) any { return u.Metadata }, set: func(u *models.User, v any) { u.Metadata, _ = models.MetadataObject(v) }, personal: true},
+}
+
+// redactedHistoryFields are stored but withheld when the history is read
+var redactedHistoryFields = map[string]bool{
+	"password": true,
+	"email":    true,
+}
+
+// HistoryService keeps the immutable per-user change history written by
+// the other services whenever they modify a user, and the audit log with
+// one entry per create, update or delete
+type HistoryService struct {
+	historyRepo interfaces.UserHistoryRepository
+	auditRepo   interfaces.AuditLogRepository
+	userRepo    interfaces.UserRepository
+}
+
+func NewHistoryService(historyRepo interfaces.UserHistoryRepository, auditRepo interfaces.AuditLogRepository, userRepo interfaces.UserRepository) *HistoryService {
+	return &HistoryService{
+		historyRepo: historyRepo,
+		auditRepo:   auditRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// historyPrincipal attributes a change made as actorID, taking the acting
+// principal from the request when there is one
+func historyPrincipal(ctx context.Context, actorID primitive.ObjectID) auth.Principal {
+	principal := auth.Principal{EffectiveID: actorID, ActorID: actorID, ActorType: auth.ActorUser}
+	if p, ok := auth.PrincipalFrom(ctx); ok && p.EffectiveID == actorID {
+		principal = p
+	}
+	return principal
+}
+
+// Record appends an entry for every tracked field that differs between
+// before and after, and an audit log entry with all of them; before is nil
+// when the user was just created. actorID is the effective principal; the
+// acting one comes from the request's principal when there is one. History
+// is written after the change itself, so failures are logged rather than
+// undoing it.
+func (s *HistoryService) Record(ctx context.Context, actorID primitive.ObjectID, before, after *models.User) {
+	principal := historyPrincipal(ctx, actorID)
+
+	var changes []*models.UserChange
+	for _, field := range userHistoryFields {
+		var oldValue any
+		if before != nil {
+			oldValue = field.get(before)
+		}
+		newValue := field.get(after)
+		// DeepEqual because metadata is a map
+		if before != nil && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		change := &models.UserChange{
+			UserID:      after.ID,
+			Field:       field.name,
+			ActorID:     principal.ActorID,
+			EffectiveID: principal.EffectiveID,
+			ActorType:   principal.ActorType,
+			ClientID:    principal.ClientID,
+		}
+		// anonymizing a user must not copy its personal data into history
+		if !field.secret && !(field.personal && after.AnonymizedAt != nil) {
+			change.OldValue = oldValue
+			change.NewValue = newValue
+		}
+		changes = append(changes, change)
+	}
+
+	if err := s.historyRepo.Append(ctx, changes); err != nil {
+		log.Printf("failed to record history for user %s: %v", after.ID.Hex(), err)
+	}
+
+	if before != nil && len(changes) == 0 {
+		return
+	}
+	action := models.AuditActionUpdate
+	if before == nil {
+		action = models.AuditActionCreate
+	}
+	diff := make([]models.FieldChange, len(changes))
+	for i, change := range changes {
+		diff[i] = models.FieldChange{Field: change.Field, Old: change.OldValue, New: change.NewValue}
+	}
+	s.audit(ctx, principal, after.ID, action, diff)
+}
+
+// RecordDelete adds the audit log entry of a deleted user, keeping the
+// values of the fields it had
+func (s *HistoryService) RecordDelete(ctx context.Context, actorID primitive.ObjectID, user *models.User) {
+	var diff []models.FieldChange
+	for _, field := range userHistoryFields {
+		change := models.FieldChange{Field: field.name}
+		if !field.secret {
+			change.Old = field.get(user)
+		}
+		diff = append(diff, change)
+	}
+	s.audit(ctx, historyPrincipal(ctx, actorID), user.ID, models.AuditActionDelete, diff)
+}
+
+// Erase blanks the values of the user's personal fields throughout its
+// history and audit log, keeping the record of what changed and when
+func (s *HistoryService) Erase(ctx context.Context, userID primitive.ObjectID) error {
+	var fields []string
+	for _, field := range userHistoryFields {
+		if field.personal {
+			fields = append(fields, field.name)
+		}
+	}
+	if err := s.historyRepo.RedactFields(ctx, userID, fields); err != nil {
+		return err
+	}
+	return s.auditRepo.RedactFields(ctx, "users", userID, fields)
+}
+
+func (s *HistoryService) audit(ctx context.Context, principal auth.Principal, userID primitive.ObjectID, action string, diff []models.FieldChange) {
+	entry := &models.AuditLog{
+		Resource:    "users",
+		ResourceID:  userID,
+		Action:      action,
+		Changes:     diff,
+		ActorID:     principal.ActorID,
+		EffectiveID: principal.EffectiveID,
+		ActorType:   principal.ActorType,
+		ClientID:    principal.ClientID,
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("failed to record %s audit log for user %s: %v", action, userID.Hex(), err)
+	}
+}
+
+// Audit returns a page of the user's audit log, newest first, with the
+// values of sensitive fields redacted like in the history
+func (s *HistoryService) Audit(ctx context.Context, userID primitive.ObjectID, page, limit int) (*models.PaginatedResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	entries, total, err := s.auditRepo.ListByResource(ctx, "users", userID, page, limit)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	for _, entry := range entries {
+		for i := range entry.Changes {
+			if redactedHistoryFields[entry.Changes[i].Field] {
+				entry.Changes[i].Old = nil
+				entry.Changes[i].New = nil
+				entry.Changes[i].Redacted = true
+			}
+		}
+	}
+
+	return &models.PaginatedResponse{
+		Success: true,
+		Message: "Audit log retrieved successfully",
+		Data:    entries,
+		Pagination: models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      int(total),
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// List returns a page of the user's history, newest first, with the values
+// of sensitive fields redacted. History outlives the user, so deleted users
+// can still be looked up.
+func (s *HistoryService) List(ctx context.Context, userID primitive.ObjectID, page, limit int) (*models.PaginatedResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	changes, total, err := s.historyRepo.ListByUser(ctx, userID, page, limit)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	for _, change := range changes {
+		if redactedHistoryFields[change.Field] {
+			change.OldValue = nil
+			change.NewValue = nil
+			change.Redacted = true
+		}
+	}
+
+	return &models.PaginatedResponse{
+		Success: true,
+		Message: "History retrieved successfully",
+		Data:    changes,
+		Pagination: models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      int(total),
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// UserAsOf rebuilds the user as they were at asOf by undoing, newest first,
+// every change recorded after it. Deleted users cannot be rebuilt, and
+// asOf must not predate the user's creation.
+func (s *HistoryService) UserAsOf(ctx context.Context, userID primitive.ObjectID, asOf time.Time) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if asOf.Before(user.CreatedAt) {
+		return nil, errors.ErrUserNotFound
+	}
+
+	changes, err := s.historyRepo.ListAfter(ctx, userID, asOf)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	fields := make(map[string]userHistoryField, len(userHistoryFields))
+	for _, field := range userHistoryFields {
+		fields[field.name] = field
+	}
+	for _, change := range changes {
+		// creation entries have no old value to go back to
+		if field, ok := fields[change.Field]; ok && change.OldValue != nil {
+			field.set(user, change.OldValue)
+		}
+	}
+	if len(changes) > 0 {
+		// the last change still in effect is unknown, so report asOf
+		user.UpdatedAt = asOf
+	}
+	return user.ToResponse(), nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/oauth"
+	"user-management-api/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// One-time token purposes carrying the OAuth state of a link or sign-in.
+// The provider name is appended, so state issued for one provider is
+// rejected by another.
+const (
+	purposeIdentityLink  = "identity_link:"
+	purposeIdentityLogin = "identity_login:"
+)
+
+// oauthStateTTL bounds how long the user may take at the provider
+const oauthStateTTL = 10 * time.Minute
+
+// IdentityService links user accounts to external OAuth providers and
+// signs users in through the identities they have linked
+type IdentityService struct {
+	identityRepo interfaces.IdentityRepository
+	userRepo     interfaces.UserRepository
+	sessions     *SessionService
+	events       *AuthEventService
+	tokens       *utils.OneTimeTokens
+	providers    map[string]*oauth.Provider
+	publicURL    string
+	jwtSecret    string
+	accessTTL    time.Duration
+}
+
+func NewIdentityService(identityRepo interfaces.IdentityRepository, userRepo interfaces.UserRepository, sessions *SessionService, events *AuthEventService, tokens *utils.OneTimeTokens, providers []*oauth.Provider, publicURL, jwtSecret string, accessTTL time.Duration) *IdentityService {
+	byName := make(map[string]*oauth.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name] = provider
+	}
+	return &IdentityService{
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		sessions:     sessions,
+		events:       events,
+		tokens:       tokens,
+		providers:    byName,
+		publicURL:    publicURL,
+		jwtSecret:    jwtSecret,
+		accessTTL:    accessTTL,
+	}
+}
+
+// List returns the user's linked identities and the providers available
+func (s *IdentityService) List(ctx context.Context, userID primitive.ObjectID) (*models.IdentityListResponse, error) {
+	identities, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	providers := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	return &models.IdentityListResponse{
+		Identities: identities,
+		Providers:  providers,
+	}, nil
+}
+
+// StartLink returns the provider URL that lets the user approve linking
+func (s *IdentityService) StartLink(ctx context.Context, userID primitive.ObjectID, providerName string) (*models.IdentityLinkResponse, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.tokens.Issue(ctx, purposeIdentityLink+provider.Name, userID.Hex(), oauthStateTTL)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return &models.IdentityLinkResponse{
+		AuthorizationURL: provider.AuthCodeURL(state, s.linkRedirectURL(provider)),
+	}, nil
+}
+
+// CompleteLink handles the provider's redirect after the user approved
+// linking. The state identifies the user, since the browser arrives
+// without a bearer token.
+func (s *IdentityService) CompleteLink(ctx context.Context, providerName, code, state string) (*models.Identity, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := s.tokens.Consume(ctx, purposeIdentityLink+provider.Name, state)
+	if err != nil {
+		if err == utils.ErrInvalidOneTimeToken {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	userID, err := primitive.ObjectIDFromHex(subject)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	info, err := s.fetchUser(ctx, provider, code, s.linkRedirectURL(provider))
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	for _, identity := range linked {
+		if identity.Provider != provider.Name {
+			continue
+		}
+		if identity.Subject == info.Subject {
+			return identity, nil
+		}
+		return nil, errors.ErrProviderAlreadyLinked
+	}
+
+	// an external account whose address is another user's would let that
+	// account's holder sign in as this user, or hide a duplicate account
+	if info.Email != "" {
+		owner, err := s.userRepo.GetByEmail(ctx, info.Email)
+		if err == nil && owner.ID != userID {
+			return nil, errors.ErrIdentityEmailConflict
+		}
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, errors.ErrInternalServer
+		}
+	}
+
+	identity := &models.Identity{
+		UserID:   userID,
+		Provider: provider.Name,
+		Subject:  info.Subject,
+		Email:    info.Email,
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrIdentityLinked
+		}
+		return nil, errors.ErrInternalServer
+	}
+	return identity, nil
+}
+
+// Unlink removes the user's identity at a provider. An account without a
+// password signs in only through its identities, so its last one stays.
+func (s *IdentityService) Unlink(ctx context.Context, userID primitive.ObjectID, providerName string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrUserNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	linked, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	if user.Password == "" && len(linked) == 1 && linked[0].Provider == providerName {
+		return errors.ErrLastSignInMethod
+	}
+
+	if err := s.identityRepo.Delete(ctx, userID, providerName); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrIdentityNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// StartLogin returns the provider URL that starts signing in
+func (s *IdentityService) StartLogin(ctx context.Context, providerName string) (string, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	// the state is bound to nothing but this attempt
+	nonce, err := utils.RandomToken(16)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+	state, err := s.tokens.Issue(ctx, purposeIdentityLogin+provider.Name, nonce, oauthStateTTL)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+	return provider.AuthCodeURL(state, s.loginRedirectURL(provider)), nil
+}
+
+// CompleteLogin signs in the user linked to the provider account
+func (s *IdentityService) CompleteLogin(ctx context.Context, providerName, code, state string, client models.LoginContext) (*models.AuthResponse, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.tokens.Consume(ctx, purposeIdentityLogin+provider.Name, state); err != nil {
+		if err == utils.ErrInvalidOneTimeToken {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+
+	info, err := s.fetchUser(ctx, provider, code, s.loginRedirectURL(provider))
+	if err != nil {
+		return nil, err
+	}
+	method := "oauth:" + provider.Name
+
+	identity, err := s.identityRepo.GetBySubject(ctx, provider.Name, info.Subject)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, errors.ErrInternalServer
+		}
+		s.events.Record(ctx, &models.AuthEvent{
+			Type:      models.AuthEventLoginFailed,
+			Email:     info.Email,
+			Method:    method,
+			Reason:    errors.ErrIdentityNotLinked.Type,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+		})
+		return nil, errors.ErrIdentityNotLinked
+	}
+
+	user, err := s.userRepo.GetByID(ctx, identity.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrIdentityNotLinked
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if !user.IsActive() {
+		s.events.Record(ctx, &models.AuthEvent{
+			Type:      models.AuthEventLoginFailed,
+			UserID:    &user.ID,
+			Email:     user.Email,
+			Method:    method,
+			Reason:    errors.ErrUnAuthorized.Type,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+		})
+		return nil, errors.ErrUnAuthorized
+	}
+	s.events.RecordFor(ctx, models.AuthEventLogin, user, client, method)
+
+	session, err := s.sessions.Start(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.sessions.issueTokens(ctx, user, session, s.jwtSecret, s.accessTTL)
+}
+
+// RemoveUserIdentities is an AfterDelete user hook that drops a deleted
+// user's identities, so the provider accounts can be linked again
+func (s *IdentityService) RemoveUserIdentities(ctx context.Context, input *UserHookInput) error {
+	return s.identityRepo.DeleteByUser(ctx, input.User.ID)
+}
+
+func (s *IdentityService) provider(name string) (*oauth.Provider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, errors.ErrProviderNotFound
+	}
+	return provider, nil
+}
+
+// fetchUser exchanges the code and returns the provider account behind it
+func (s *IdentityService) fetchUser(ctx context.Context, provider *oauth.Provider, code, redirectURL string) (*oauth.UserInfo, error) {
+	accessToken, err := provider.Exchange(ctx, code, redirectURL)
+	if err != nil {
+		log.Printf("oauth: %v", err)
+		return nil, errors.ErrSSOFailed
+	}
+	info, err := provider.FetchUser(ctx, accessToken)
+	if err != nil {
+		log.Printf("oauth: %v", err)
+		return nil, errors.ErrSSOFailed
+	}
+	return info, nil
+}
+
+func (s *IdentityService) linkRedirectURL(provider *oauth.Provider) string {
+	return s.publicURL + "/api/v1/users/me/identities/" + provider.Name + "/callback"
+}
+
+func (s *IdentityService) loginRedirectURL(provider *oauth.Provider) string {
+	return s.publicURL + "/api/v1/auth/oauth/" + provider.Name + "/callback"
+}
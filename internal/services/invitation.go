@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// invitationTokenTTL is how long an emailed invitation link stays valid
+const invitationTokenTTL = 7 * 24 * time.Hour
+
+const tokenPurposeInvitation = "invitation"
+
+// Invite creates an inactive user without a password and emails them a link
+// to choose one. Inviting an address whose invitation is still pending sends
+// a fresh link instead of failing, so admins can resend expired invitations.
+func (s *AuthService) Invite(ctx context.Context, actorID primitive.ObjectID, actorRole string, req *models.InviteUserRequest) (*models.User, error) {
+	if err := checkRole(req.Role); err != nil {
+		return nil, err
+	}
+	if !models.CanManageRole(actorRole, req.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+
+	user := &models.User{
+		Username:  req.Username,
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Role:      req.Role,
+		Locale:    req.Locale,
+		Status:    models.UserStatusPending,
+		Invitation: &models.Invitation{
+			InvitedBy: actorID,
+			InvitedAt: time.Now(),
+		},
+	}
+	if err := beforeUserWrite(ctx, BeforeCreate, user, nil); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrInternalServer
+		}
+		pending, err := s.pendingInvitation(ctx, req.Email)
+		if err != nil {
+			return nil, err
+		}
+		if !models.CanManageRole(actorRole, pending.Role) {
+			return nil, errors.ErrRoleNotManageable
+		}
+		return pending, s.sendInvitation(ctx, pending)
+	}
+	s.history.Record(ctx, actorID, nil, user)
+	afterUserWrite(ctx, AfterCreate, user, nil)
+
+	return user, s.sendInvitation(ctx, user)
+}
+
+// pendingInvitation returns the user holding email if they were invited and
+// have not accepted yet; any other owner of the address is a conflict
+func (s *AuthService) pendingInvitation(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// the duplicate was the username
+			return nil, errors.ErrUserExists
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if user.Invitation == nil {
+		return nil, errors.ErrUserExists
+	}
+	return user, nil
+}
+
+// sendInvitation issues a new invitation link, replacing any earlier one
+func (s *AuthService) sendInvitation(ctx context.Context, user *models.User) error {
+	if err := s.tokens.Revoke(ctx, tokenPurposeInvitation, user.ID.Hex()); err != nil {
+		log.Printf("revoke invitation token for %s: %v", user.ID.Hex(), err)
+	}
+	token, err := s.tokens.Issue(ctx, tokenPurposeInvitation, user.ID.Hex(), invitationTokenTTL)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	if err := s.notifier.SendInvitation(user, token, invitationTokenTTL); err != nil {
+		log.Printf("invitation email for %s: %v", user.ID.Hex(), err)
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// AcceptInvitation sets the invited user's first password and activates them
+func (s *AuthService) AcceptInvitation(ctx context.Context, req *models.AcceptInvitationRequest) (*models.User, error) {
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	id, err := s.consumeToken(ctx, tokenPurposeInvitation, req.Token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.AcceptInvitation(ctx, id, hashedPassword)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	before := *user
+	before.Password = ""
+	before.PasswordChangedAt = nil
+	before.Status = models.UserStatusPending
+	s.history.Record(ctx, user.ID, &before, user)
+	s.events.Record(ctx, &models.AuthEvent{
+		Type:   models.AuthEventPasswordChange,
+		UserID: &user.ID,
+		Email:  user.Email,
+		Method: "invitation",
+	})
+	return user, nil
+}
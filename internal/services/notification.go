@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/jobs"
+	"user-management-api/pkg/mailer"
+)
+
+// Email templates live under templates/email/<locale>/<name>.{txt,html},
+// one directory per BCP 47 language tag ("en", "fr", "pt-BR"). The .txt file
+// defines "subject" and "body"; the .html file is the HTML alternative.
+// Every template must exist in each of requiredEmailLocales, which is checked
+// when the NotificationService is built; other locales may translate any
+// subset.
+//
+//go:embed templates/email
+var emailTemplateFS embed.FS
+
+// defaultEmailLocale ends every fallback chain
+const defaultEmailLocale = "en"
+
+var requiredEmailLocales = []string{"en", "es", "fr"}
+
+// emailTemplate pairs the text template (defining "subject" and "body")
+// with the HTML alternative of one email
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// emailTemplateNames are the templates NotificationService sends
+var emailTemplateNames = []string{"verify_email", "password_reset", "login_alert", "invitation"}
+
+// loadEmailTemplates parses the templates named names from fsys, laid out
+// as templates/email/<locale>/<name>.{txt,html}, and returns them by name
+// and locale. A template missing from a required locale is an error.
+func loadEmailTemplates(fsys fs.FS, names ...string) (map[string]map[string]*emailTemplate, error) {
+	dirs, err := fs.ReadDir(fsys, "templates/email")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]map[string]*emailTemplate, len(names))
+	for _, name := range names {
+		templates[name] = make(map[string]*emailTemplate)
+		for _, dir := range dirs {
+			if !dir.IsDir() {
+				continue
+			}
+			locale := dir.Name()
+			base := "templates/email/" + locale + "/" + name
+			if _, err := fs.Stat(fsys, base+".txt"); err != nil {
+				continue
+			}
+			text, err := texttemplate.ParseFS(fsys, base+".txt")
+			if err != nil {
+				return nil, err
+			}
+			html, err := htmltemplate.ParseFS(fsys, base+".html")
+			if err != nil {
+				return nil, err
+			}
+			templates[name][strings.ToLower(locale)] = &emailTemplate{text: text, html: html}
+		}
+		for _, locale := range requiredEmailLocales {
+			if _, ok := templates[name][locale]; !ok {
+				return nil, fmt.Errorf("email template %q has no %q translation", name, locale)
+			}
+		}
+	}
+	return templates, nil
+}
+
+// localeFallbacks lists the locales to try for a BCP 47 tag, most specific
+// first: "pt-BR" yields pt-br, pt, then the default
+func localeFallbacks(locale string) []string {
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	var chain []string
+	for tag != "" {
+		chain = append(chain, tag)
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return append(chain, defaultEmailLocale)
+}
+
+// template picks the best translation of name for locale
+func (s *NotificationService) template(name, locale string) (*emailTemplate, error) {
+	translations, ok := s.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	for _, candidate := range localeFallbacks(locale) {
+		if tmpl, ok := translations[candidate]; ok {
+			return tmpl, nil
+		}
+	}
+	return nil, fmt.Errorf("email template %q has no translation for %q", name, locale)
+}
+
+type emailData struct {
+	User             *models.User
+	Link             string
+	ExpiresInMinutes int
+	ExpiresInDays    int
+	Session          *models.Session
+}
+
+// NotificationService renders transactional emails and sends them on the
+// background job queue so requests never wait on the mail relay
+type NotificationService struct {
+	mailer    mailer.Mailer
+	queue     *jobs.Queue
+	baseURL   string
+	templates map[string]map[string]*emailTemplate
+}
+
+// NewNotificationService builds links in emails against baseURL, the public
+// URL of this server. It fails when an email template is missing or does
+// not parse.
+func NewNotificationService(m mailer.Mailer, queue *jobs.Queue, baseURL string) (*NotificationService, error) {
+	templates, err := loadEmailTemplates(emailTemplateFS, emailTemplateNames...)
+	if err != nil {
+		return nil, err
+	}
+	return &NotificationService{
+		mailer:    m,
+		queue:     queue,
+		baseURL:   baseURL,
+		templates: templates,
+	}, nil
+}
+
+func (s *NotificationService) SendEmailVerification(user *models.User, token string) error {
+	return s.send("verify_email", user, emailData{
+		User: user,
+		Link: s.link("/auth/verify-email", token),
+	})
+}
+
+func (s *NotificationService) SendPasswordReset(user *models.User, token string, ttl time.Duration) error {
+	return s.send("password_reset", user, emailData{
+		User:             user,
+		Link:             s.link("/auth/reset-password", token),
+		ExpiresInMinutes: int(ttl.Minutes()),
+	})
+}
+
+// SendInvitation sends an invited user the link to choose a password
+func (s *NotificationService) SendInvitation(user *models.User, token string, ttl time.Duration) error {
+	return s.send("invitation", user, emailData{
+		User:          user,
+		Link:          s.link("/auth/accept-invite", token),
+		ExpiresInDays: int(ttl.Hours() / 24),
+	})
+}
+
+// SendLoginAlert tells the user about a sign-in from a new IP or device
+func (s *NotificationService) SendLoginAlert(user *models.User, session *models.Session) error {
+	return s.send("login_alert", user, emailData{
+		User:    user,
+		Session: session,
+	})
+}
+
+func (s *NotificationService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *NotificationService) send(name string, user *models.User, data emailData) error {
+	tmpl, err := s.template(name, user.Locale)
+	if err != nil {
+		return err
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return err
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: subject.String(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}
+	return s.queue.Enqueue("email "+name, func(ctx context.Context) error {
+		return s.mailer.Send(ctx, msg)
+	})
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+	"user-management-api/internal/models"
+)
+
+func TestEmailTemplatesHaveRequiredLocales(t *testing.T) {
+	templates, err := loadEmailTemplates(emailTemplateFS, emailTemplateNames...)
+	if err != nil {
+		t.Fatalf("loading email templates: %v", err)
+	}
+
+	data := emailData{
+		User:             &models.User{FirstName: "Ada", Email: "ada@example.com"},
+		Link:             "https://example.com/link?token=abc",
+		ExpiresInMinutes: 60,
+		ExpiresInDays:    7,
+		Session:          &models.Session{IP: "203.0.113.1", UserAgent: "curl", CreatedAt: time.Now()},
+	}
+	for _, name := range emailTemplateNames {
+		for _, locale := range requiredEmailLocales {
+			tmpl, ok := templates[name][locale]
+			if !ok {
+				t.Errorf("%s: no %q translation", name, locale)
+				continue
+			}
+			var subject, body, html bytes.Buffer
+			if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+				t.Errorf("%s/%s subject: %v", locale, name, err)
+			}
+			if err := tmpl.text.ExecuteTemplate(&body, "body", data); err != nil {
+				t.Errorf("%s/%s body: %v", locale, name, err)
+			}
+			if err := tmpl.html.Execute(&html, data); err != nil {
+				t.Errorf("%s/%s html: %v", locale, name, err)
+			}
+			if strings.TrimSpace(subject.String()) == "" || strings.TrimSpace(body.String()) == "" || strings.TrimSpace(html.String()) == "" {
+				t.Errorf("%s/%s renders empty", locale, name)
+			}
+		}
+	}
+}
+
+func TestLoadEmailTemplatesMissingLocale(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, locale := range []string{"en", "es"} {
+		fsys["templates/email/"+locale+"/welcome.txt"] = &fstest.MapFile{Data: []byte(`{{define "subject"}}Hi{{end}}{{define "body"}}Hi{{end}}`)}
+		fsys["templates/email/"+locale+"/welcome.html"] = &fstest.MapFile{Data: []byte(`<p>Hi</p>`)}
+	}
+
+	if _, err := loadEmailTemplates(fsys, "welcome"); err == nil || !strings.Contains(err.Error(), `"fr"`) {
+		t.Fatalf("want an error naming the missing fr translation, got %v", err)
+	}
+}
+
+func TestLocaleFallbacks(t *testing.T) {
+	got := strings.Join(localeFallbacks("pt_BR"), ",")
+	if got != "pt-br,pt,en" {
+		t.Fatalf("localeFallbacks(pt_BR) = %s", got)
+	}
+}
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// orgRoleRank orders the organization roles by what they grant
+var orgRoleRank = map[string]int{
+	models.OrgRoleMember: 1,
+	models.OrgRoleAdmin:  2,
+	models.OrgRoleOwner:  3,
+}
+
+// OrganizationService manages organizations and their memberships. Access
+// is decided by the caller's role within the organization; admins of the
+// application act as owners of every organization. Organizations the
+// caller does not belong to are reported as not found.
+type OrganizationService struct {
+	orgRepo        interfaces.OrganizationRepository
+	membershipRepo interfaces.MembershipRepository
+	userRepo       interfaces.UserRepository
+	relations      []Relation[*models.Membership]
+}
+
+func NewOrganizationService(orgRepo interfaces.OrganizationRepository, membershipRepo interfaces.MembershipRepository, userRepo interfaces.UserRepository) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:        orgRepo,
+		membershipRepo: membershipRepo,
+		userRepo:       userRepo,
+		relations: []Relation[*models.Membership]{
+			BelongsTo("user",
+				func(m *models.Membership) primitive.ObjectID { return m.UserID },
+				UserLoader(userRepo),
+				func(m *models.Membership, user *models.UserResponse) { m.User = user }),
+			BelongsTo("organization",
+				func(m *models.Membership) primitive.ObjectID { return m.OrgID },
+				organizationLoader(orgRepo),
+				func(m *models.Membership, org *models.Organization) { m.Organization = org }),
+		},
+	}
+}
+
+// Create makes an organization with the caller as its first owner
+func (s *OrganizationService) Create(ctx context.Context, userID primitive.ObjectID, req *models.CreateOrganizationRequest) (*models.Organization, error) {
+	org := &models.Organization{
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	owner := &models.Membership{
+		OrgID:    org.ID,
+		UserID:   userID,
+		Role:     models.OrgRoleOwner,
+		Status:   models.MembershipActive,
+		JoinedAt: &org.CreatedAt,
+	}
+	if err := s.membershipRepo.Create(ctx, owner); err != nil {
+		if err := s.orgRepo.Delete(ctx, org.ID); err != nil {
+			log.Printf("failed to remove organization %s without owner: %v", org.ID.Hex(), err)
+		}
+		return nil, errors.ErrInternalServer
+	}
+	org.Role = models.OrgRoleOwner
+	return org, nil
+}
+
+// ListMine returns the organizations the caller is an active member of
+func (s *OrganizationService) ListMine(ctx context.Context, userID primitive.ObjectID) ([]*models.Organization, error) {
+	memberships, err := s.membershipRepo.ListByUser(ctx, userID, models.MembershipActive)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if len(memberships) == 0 {
+		return []*models.Organization{}, nil
+	}
+
+	roles := make(map[primitive.ObjectID]string, len(memberships))
+	ids := make([]primitive.ObjectID, len(memberships))
+	for i, membership := range memberships {
+		roles[membership.OrgID] = membership.Role
+		ids[i] = membership.OrgID
+	}
+	orgs, err := s.orgRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	for _, org := range orgs {
+		org.Role = roles[org.ID]
+	}
+	return orgs, nil
+}
+
+// ListInvitations returns the caller's pending invitations with their
+// organizations
+func (s *OrganizationService) ListInvitations(ctx context.Context, userID primitive.ObjectID) ([]*models.Membership, error) {
+	invitations, err := s.membershipRepo.ListByUser(ctx, userID, models.MembershipInvited)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if err := Expand(ctx, invitations, []string{"organization"}, s.relations...); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (s *OrganizationService) Get(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID) (*models.Organization, error) {
+	org, _, err := s.authorize(ctx, userID, role, id, models.OrgRoleMember)
+	return org, err
+}
+
+func (s *OrganizationService) Update(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, req *models.UpdateOrganizationRequest) (*models.Organization, error) {
+	org, _, err := s.authorize(ctx, userID, role, id, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		org.Name = req.Name
+	}
+	if req.Description != nil {
+		org.Description = *req.Description
+	}
+
+	if err := s.orgRepo.Update(ctx, org); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrOrganizationNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	return org, nil
+}
+
+// Delete removes an organization and all of its memberships
+func (s *OrganizationService) Delete(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID) error {
+	if _, _, err := s.authorize(ctx, userID, role, id, models.OrgRoleOwner); err != nil {
+		return err
+	}
+	if err := s.orgRepo.Delete(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrOrganizationNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	if err := s.membershipRepo.DeleteByOrg(ctx, id); err != nil {
+		log.Printf("failed to remove memberships of organization %s: %v", id.Hex(), err)
+	}
+	return nil
+}
+
+// ListMembers returns a page of an organization's memberships, including
+// pending invitations
+func (s *OrganizationService) ListMembers(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, page, limit int, expand []string) (*models.PaginatedResponse, error) {
+	if _, _, err := s.authorize(ctx, userID, role, id, models.OrgRoleMember); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	memberships, total, err := s.membershipRepo.ListByOrg(ctx, id, "", page, limit)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if err := Expand(ctx, memberships, expand, s.relations...); err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Success: true,
+		Message: "Members retrieved successfully",
+		Data:    memberships,
+		Pagination: models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      int(total),
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// Invite invites an existing user to the organization. Callers cannot
+// grant a role above their own.
+func (s *OrganizationService) Invite(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, req *models.InviteMemberRequest) (*models.Membership, error) {
+	_, callerRole, err := s.authorize(ctx, userID, role, id, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if orgRoleRank[req.Role] > orgRoleRank[callerRole] {
+		return nil, errors.ErrOrgForbidden
+	}
+
+	invitee, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+
+	membership := &models.Membership{
+		OrgID:     id,
+		UserID:    invitee.ID,
+		Role:      req.Role,
+		Status:    models.MembershipInvited,
+		InvitedBy: &userID,
+	}
+	if err := s.membershipRepo.Create(ctx, membership); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrAlreadyMember
+		}
+		return nil, errors.ErrInternalServer
+	}
+	return membership, nil
+}
+
+// Join accepts the caller's invitation to the organization
+func (s *OrganizationService) Join(ctx context.Context, userID, id primitive.ObjectID) (*models.Membership, error) {
+	membership, err := s.membershipRepo.Get(ctx, id, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvitationNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if membership.Status == models.MembershipActive {
+		return nil, errors.ErrAlreadyMember
+	}
+
+	membership, err = s.membershipRepo.Activate(ctx, membership.ID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvitationNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	return membership, nil
+}
+
+// Leave removes the caller from the organization, or declines their
+// invitation. The last owner cannot leave.
+func (s *OrganizationService) Leave(ctx context.Context, userID, id primitive.ObjectID) error {
+	membership, err := s.membershipRepo.Get(ctx, id, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrOrganizationNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return s.removeMembership(ctx, membership)
+}
+
+// UpdateMember changes a member's role. Only owners may appoint or
+// demote owners.
+func (s *OrganizationService) UpdateMember(ctx context.Context, userID primitive.ObjectID, role string, id, memberID primitive.ObjectID, req *models.UpdateMembershipRequest) (*models.Membership, error) {
+	_, callerRole, err := s.authorize(ctx, userID, role, id, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := s.getMembership(ctx, id, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if orgRoleRank[req.Role] > orgRoleRank[callerRole] || orgRoleRank[membership.Role] > orgRoleRank[callerRole] {
+		return nil, errors.ErrOrgForbidden
+	}
+	if membership.Role == models.OrgRoleOwner && req.Role != models.OrgRoleOwner {
+		if err := s.checkOtherOwner(ctx, membership); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.membershipRepo.UpdateRole(ctx, membership.ID, req.Role); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrMembershipNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	membership.Role = req.Role
+	return membership, nil
+}
+
+// RemoveMember removes a member or withdraws an invitation. Only owners
+// may remove owners.
+func (s *OrganizationService) RemoveMember(ctx context.Context, userID primitive.ObjectID, role string, id, memberID primitive.ObjectID) error {
+	_, callerRole, err := s.authorize(ctx, userID, role, id, models.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+	membership, err := s.getMembership(ctx, id, memberID)
+	if err != nil {
+		return err
+	}
+	if orgRoleRank[membership.Role] > orgRoleRank[callerRole] {
+		return errors.ErrOrgForbidden
+	}
+	return s.removeMembership(ctx, membership)
+}
+
+// MemberIDs returns the active members of an organization
+func (s *OrganizationService) MemberIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	ids, err := s.membershipRepo.MemberIDs(ctx, id)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return ids, nil
+}
+
+// RemoveUserMemberships is an AfterDelete user hook that drops a deleted
+// user's memberships and invitations
+func (s *OrganizationService) RemoveUserMemberships(ctx context.Context, input *UserHookInput) error {
+	return s.membershipRepo.DeleteByUser(ctx, input.User.ID)
+}
+
+// authorize loads an organization the caller may act on with at least
+// minRole, and returns the caller's role in it. Application admins act as
+// owners.
+func (s *OrganizationService) authorize(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, minRole string) (*models.Organization, string, error) {
+	org, err := s.orgRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, "", errors.ErrOrganizationNotFound
+		}
+		return nil, "", errors.ErrInternalServer
+	}
+
+	callerRole := models.OrgRoleOwner
+	if !models.RoleIncludes(role, models.RoleAdmin) {
+		membership, err := s.membershipRepo.Get(ctx, id, userID)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, "", errors.ErrOrganizationNotFound
+			}
+			return nil, "", errors.ErrInternalServer
+		}
+		if membership.Status != models.MembershipActive {
+			return nil, "", errors.ErrOrganizationNotFound
+		}
+		callerRole = membership.Role
+	}
+	if orgRoleRank[callerRole] < orgRoleRank[minRole] {
+		return nil, "", errors.ErrOrgForbidden
+	}
+	org.Role = callerRole
+	return org, callerRole, nil
+}
+
+func (s *OrganizationService) getMembership(ctx context.Context, orgID, userID primitive.ObjectID) (*models.Membership, error) {
+	membership, err := s.membershipRepo.Get(ctx, orgID, userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrMembershipNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	return membership, nil
+}
+
+func (s *OrganizationService) removeMembership(ctx context.Context, membership *models.Membership) error {
+	if membership.Role == models.OrgRoleOwner && membership.Status == models.MembershipActive {
+		if err := s.checkOtherOwner(ctx, membership); err != nil {
+			return err
+		}
+	}
+	if err := s.membershipRepo.Delete(ctx, membership.ID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrMembershipNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// checkOtherOwner refuses to take away the only active owner
+func (s *OrganizationService) checkOtherOwner(ctx context.Context, owner *models.Membership) error {
+	if owner.Status != models.MembershipActive {
+		return nil
+	}
+	owners, err := s.membershipRepo.CountRole(ctx, owner.OrgID, models.OrgRoleOwner)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	if owners <= 1 {
+		return errors.ErrLastOwner
+	}
+	return nil
+}
+
+func organizationLoader(orgRepo interfaces.OrganizationRepository) Loader[*models.Organization] {
+	return func(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.Organization, error) {
+		orgs, err := orgRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[primitive.ObjectID]*models.Organization, len(orgs))
+		for _, org := range orgs {
+			byID[org.ID] = org
+		}
+		return byID, nil
+	}
+}
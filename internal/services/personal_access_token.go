@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// personalAccessTokenTTL applies when a token is created without an
+	// expiry
+	personalAccessTokenTTL = 30 * 24 * time.Hour
+	// maxPersonalAccessTokens caps the unexpired tokens a user can hold
+	maxPersonalAccessTokens = 50
+)
+
+// PersonalAccessTokenService lets users create long-lived, scoped tokens
+// for scripts. Tokens are stored hashed and authenticate requests in place
+// of a JWT.
+type PersonalAccessTokenService struct {
+	tokenRepo interfaces.PersonalAccessTokenRepository
+	userRepo  interfaces.UserRepository
+}
+
+func NewPersonalAccessTokenService(tokenRepo interfaces.PersonalAccessTokenRepository, userRepo interfaces.UserRepository) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Create issues a token for userID. Its scopes must be among granted, the
+// scopes of the token making the request, so a token can never be used to
+// widen its own access.
+func (s *PersonalAccessTokenService) Create(ctx context.Context, userID primitive.ObjectID, granted []string, req *models.CreatePersonalAccessTokenRequest) (*models.CreatedPersonalAccessToken, error) {
+	for _, scope := range req.Scopes {
+		if !slices.Contains(granted, scope) {
+			return nil, errors.ErrInvalidScope
+		}
+	}
+
+	count, err := s.tokenRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if count >= maxPersonalAccessTokens {
+		return nil, errors.ErrTokenLimit
+	}
+
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	plain := models.PersonalAccessTokenPrefix + secret
+
+	ttl := personalAccessTokenTTL
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	token := &models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: utils.HashToken(plain),
+		Hint:      plain[len(plain)-4:],
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return &models.CreatedPersonalAccessToken{Token: plain, PersonalAccessToken: token}, nil
+}
+
+func (s *PersonalAccessTokenService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.PersonalAccessToken, error) {
+	tokens, err := s.tokenRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return tokens, nil
+}
+
+// Revoke deletes one of the user's tokens; it stops working immediately
+func (s *PersonalAccessTokenService) Revoke(ctx context.Context, userID, id primitive.ObjectID) error {
+	if err := s.tokenRepo.Delete(ctx, userID, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrTokenNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// AuthenticatePersonalAccessToken resolves token to the claims of its
+// owner, as if they had presented an access token. The token's scopes are
+// narrowed to what the owner's role grants now, and tokens of deactivated
+// users are refused.
+func (s *PersonalAccessTokenService) AuthenticatePersonalAccessToken(ctx context.Context, token string) (*auth.JWTClaims, error) {
+	pat, err := s.tokenRepo.GetByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	user, err := s.userRepo.GetByID(ctx, pat.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if !user.IsActive() {
+		return nil, errors.ErrInvalidToken
+	}
+
+	roleScopes := models.ScopesForRole(user.Role)
+	scopes := make([]string, 0, len(pat.Scopes))
+	for _, scope := range pat.Scopes {
+		if slices.Contains(roleScopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if err := s.tokenRepo.Touch(ctx, pat.ID); err != nil {
+		log.Printf("failed to record use of access token %s: %v", pat.ID.Hex(), err)
+	}
+
+	return &auth.JWTClaims{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Role:     user.Role,
+		TokenUse: auth.TokenUsePersonal,
+		Scopes:   scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        pat.ID.Hex(),
+			ExpiresAt: jwt.NewNumericDate(pat.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(pat.CreatedAt),
+		},
+	}, nil
+}
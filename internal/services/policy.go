@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/policy"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PolicyService manages the policies collection and keeps the enforcer
+// consulted by middleware.AuthMidddleware in step with it
+type PolicyService struct {
+	policyRepo interfaces.PolicyRepository
+	enforcer   *policy.Enforcer
+}
+
+func NewPolicyService(policyRepo interfaces.PolicyRepository, enforcer *policy.Enforcer) *PolicyService {
+	return &PolicyService{
+		policyRepo: policyRepo,
+		enforcer:   enforcer,
+	}
+}
+
+// Load publishes every stored policy to the enforcer
+func (s *PolicyService) Load(ctx context.Context) error {
+	policies, err := s.policyRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.publish(policies)
+	return nil
+}
+
+// RefreshEvery reloads policies until ctx ends, so changes made through
+// other instances are picked up
+func (s *PolicyService) RefreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil {
+				log.Printf("failed to reload policies: %v", err)
+			}
+		}
+	}
+}
+
+func (s *PolicyService) List(ctx context.Context) ([]*models.Policy, error) {
+	policies, err := s.policyRepo.List(ctx)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return policies, nil
+}
+
+func (s *PolicyService) Create(ctx context.Context, req *models.CreatePolicyRequest) (*models.Policy, error) {
+	if req.Subject != policy.Wildcard {
+		if err := checkRole(req.Subject); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &models.Policy{
+		Subject:     req.Subject,
+		Object:      req.Object,
+		Action:      req.Action,
+		Effect:      req.Effect,
+		Description: req.Description,
+	}
+	if err := s.policyRepo.Create(ctx, p); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrPolicyExists
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.refresh(ctx)
+	return p, nil
+}
+
+func (s *PolicyService) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.policyRepo.Delete(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrPolicyNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	s.refresh(ctx)
+	return nil
+}
+
+// refresh republishes policies after a write; a failure only delays the
+// change until the next periodic reload
+func (s *PolicyService) refresh(ctx context.Context) {
+	if err := s.Load(ctx); err != nil {
+		log.Printf("failed to reload policies: %v", err)
+	}
+}
+
+func (s *PolicyService) publish(policies []*models.Policy) {
+	rules := make([]policy.Rule, 0, len(policies))
+	for _, p := range policies {
+		rules = append(rules, policy.Rule{
+			Subject: p.Subject,
+			Object:  p.Object,
+			Action:  p.Action,
+			Effect:  p.Effect,
+		})
+	}
+	s.enforcer.SetRules(rules)
+}
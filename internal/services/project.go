@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"math"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ProjectService manages user-owned projects. Every operation runs on
+// behalf of a user: owners reach their own projects and admins reach all
+// of them. Projects the caller cannot reach are reported as not found so
+// their existence is not revealed.
+type ProjectService struct {
+	projectRepo interfaces.ProjectRepository
+	relations   []Relation[*models.Project]
+}
+
+func NewProjectService(projectRepo interfaces.ProjectRepository, userRepo interfaces.UserRepository) *ProjectService {
+	return &ProjectService{
+		projectRepo: projectRepo,
+		relations: []Relation[*models.Project]{
+			BelongsTo("owner",
+				func(p *models.Project) primitive.ObjectID { return p.OwnerID },
+				UserLoader(userRepo),
+				func(p *models.Project, owner *models.UserResponse) { p.Owner = owner }),
+		},
+	}
+}
+
+func (s *ProjectService) Create(ctx context.Context, ownerID primitive.ObjectID, req *models.CreateProjectRequest) (*models.Project, error) {
+	project := &models.Project{
+		OwnerID:     ownerID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.projectRepo.Create(ctx, project); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return project, nil
+}
+
+func (s *ProjectService) Get(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, expand []string) (*models.Project, error) {
+	project, err := s.getAccessible(ctx, userID, role, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := Expand(ctx, []*models.Project{project}, expand, s.relations...); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// List returns the caller's projects, or every project for admins
+func (s *ProjectService) List(ctx context.Context, userID primitive.ObjectID, role string, page, limit int, expand []string) (*models.PaginatedResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	var ownerID *primitive.ObjectID
+	if !models.RoleIncludes(role, models.RoleAdmin) {
+		ownerID = &userID
+	}
+	projects, total, err := s.projectRepo.List(ctx, ownerID, page, limit)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if err := Expand(ctx, projects, expand, s.relations...); err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Success: true,
+		Message: "Projects retrieved successfully",
+		Data:    projects,
+		Pagination: models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      int(total),
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+func (s *ProjectService) Update(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID, req *models.UpdateProjectRequest) (*models.Project, error) {
+	project, err := s.getAccessible(ctx, userID, role, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		project.Name = req.Name
+	}
+	if req.Description != nil {
+		project.Description = *req.Description
+	}
+
+	if err := s.projectRepo.Update(ctx, project); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrProjectNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	return project, nil
+}
+
+func (s *ProjectService) Delete(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID) error {
+	if _, err := s.getAccessible(ctx, userID, role, id); err != nil {
+		return err
+	}
+	if err := s.projectRepo.Delete(ctx, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrProjectNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// getAccessible loads a project the caller owns, or any project for admins
+func (s *ProjectService) getAccessible(ctx context.Context, userID primitive.ObjectID, role string, id primitive.ObjectID) (*models.Project, error) {
+	project, err := s.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrProjectNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if project.OwnerID != userID && !models.RoleIncludes(role, models.RoleAdmin) {
+		return nil, errors.ErrProjectNotFound
+	}
+	return project, nil
+}
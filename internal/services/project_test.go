@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeProjectRepo keeps projects in memory in creation order
+type fakeProjectRepo struct {
+	mu       sync.Mutex
+	projects []*models.Project
+}
+
+func (r *fakeProjectRepo) Create(ctx context.Context, project *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	project.ID = primitive.NewObjectID()
+	r.projects = append(r.projects, project)
+	return nil
+}
+
+func (r *fakeProjectRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, project := range r.projects {
+		if project.ID == id {
+			copied := *project
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeProjectRepo) List(ctx context.Context, ownerID *primitive.ObjectID, page, limit int) ([]*models.Project, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []*models.Project
+	for i := len(r.projects) - 1; i >= 0; i-- {
+		if ownerID == nil || r.projects[i].OwnerID == *ownerID {
+			matched = append(matched, r.projects[i])
+		}
+	}
+	start := min((page-1)*limit, len(matched))
+	end := min(start+limit, len(matched))
+	return matched[start:end], int64(len(matched)), nil
+}
+
+func (r *fakeProjectRepo) Update(ctx context.Context, project *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.projects {
+		if existing.ID == project.ID {
+			r.projects[i] = project
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *fakeProjectRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.projects {
+		if existing.ID == id {
+			r.projects = append(r.projects[:i], r.projects[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func TestProjectCreateAndList(t *testing.T) {
+	ctx := context.Background()
+	service := NewProjectService(&fakeProjectRepo{}, &fakeUserRepo{})
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+
+	for _, name := range []string{"first", "second", "third"} {
+		project, err := service.Create(ctx, alice, &models.CreateProjectRequest{Name: name})
+		if err != nil {
+			t.Fatalf("Create(%s): %v", name, err)
+		}
+		if project.ID.IsZero() || project.OwnerID != alice || project.Name != name {
+			t.Fatalf("Create(%s) returned %+v", name, project)
+		}
+	}
+	if _, err := service.Create(ctx, bob, &models.CreateProjectRequest{Name: "bob's"}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := service.List(ctx, alice, models.RoleUser, 1, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects := page.Data.([]*models.Project)
+	if len(projects) != 2 || projects[0].Name != "third" || projects[1].Name != "second" {
+		t.Errorf("first page of alice's projects = %v, want third and second", projectNames(projects))
+	}
+	if page.Pagination.Total != 3 || page.Pagination.TotalPages != 2 {
+		t.Errorf("pagination = %+v, want 3 projects over 2 pages", page.Pagination)
+	}
+
+	page, err = service.List(ctx, bob, models.RoleUser, 1, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projects := page.Data.([]*models.Project); len(projects) != 1 || projects[0].OwnerID != bob {
+		t.Errorf("bob lists %v, want only his own project", projectNames(projects))
+	}
+
+	page, err = service.List(ctx, primitive.NewObjectID(), models.RoleAdmin, 1, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Pagination.Total != 4 {
+		t.Errorf("admin lists %d projects, want all 4", page.Pagination.Total)
+	}
+}
+
+func TestProjectAuthorization(t *testing.T) {
+	ctx := context.Background()
+	service := NewProjectService(&fakeProjectRepo{}, &fakeUserRepo{})
+	owner, other, admin := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	project, err := service.Create(ctx, owner, &models.CreateProjectRequest{Name: "private"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// other users must not learn the project exists
+	if _, err := service.Get(ctx, other, models.RoleUser, project.ID, nil); err != errors.ErrProjectNotFound {
+		t.Errorf("Get by another user: %v, want ErrProjectNotFound", err)
+	}
+	if _, err := service.Update(ctx, other, models.RoleUser, project.ID, &models.UpdateProjectRequest{Name: "taken"}); err != errors.ErrProjectNotFound {
+		t.Errorf("Update by another user: %v, want ErrProjectNotFound", err)
+	}
+	if err := service.Delete(ctx, other, models.RoleUser, project.ID); err != errors.ErrProjectNotFound {
+		t.Errorf("Delete by another user: %v, want ErrProjectNotFound", err)
+	}
+	if got, err := service.Get(ctx, owner, models.RoleUser, project.ID, nil); err != nil || got.Name != "private" {
+		t.Errorf("project changed by another user: %+v, %v", got, err)
+	}
+
+	description := "renamed by the owner"
+	updated, err := service.Update(ctx, owner, models.RoleUser, project.ID, &models.UpdateProjectRequest{Name: "mine", Description: &description})
+	if err != nil || updated.Name != "mine" || updated.Description != description {
+		t.Errorf("Update by owner: %+v, %v", updated, err)
+	}
+
+	if _, err := service.Get(ctx, admin, models.RoleAdmin, project.ID, nil); err != nil {
+		t.Errorf("Get by admin: %v", err)
+	}
+	if err := service.Delete(ctx, admin, models.RoleAdmin, project.ID); err != nil {
+		t.Errorf("Delete by admin: %v", err)
+	}
+	if _, err := service.Get(ctx, owner, models.RoleUser, project.ID, nil); err != errors.ErrProjectNotFound {
+		t.Errorf("Get after delete: %v, want ErrProjectNotFound", err)
+	}
+}
+
+func projectNames(projects []*models.Project) []string {
+	names := make([]string, len(projects))
+	for i, project := range projects {
+		names[i] = project.Name
+	}
+	return names
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"expvar"
+	"log"
+	"net/netip"
+	"strings"
+	"time"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/httpserver"
+
+	"golang.org/x/time/rate"
+)
+
+// registrationMetrics counts registration attempts by outcome, published
+// at /debug/vars for tuning the thresholds
+var registrationMetrics = expvar.NewMap("registration_throttle")
+
+// RegistrationThrottlePolicy caps registrations per email domain and per
+// IP block (/24 for IPv4, /48 for IPv6) within Window. A limit of zero
+// turns that check off; ExemptDomains (large webmail providers, say) are
+// never limited by domain.
+type RegistrationThrottlePolicy struct {
+	DomainLimit   int
+	IPBlockLimit  int
+	Window        time.Duration
+	ExemptDomains []string
+}
+
+// RegistrationThrottle blunts mass sign-up bursts that the per-IP request
+// limiter misses because they are spread across many addresses or aimed
+// at one domain. Counts are kept in memory, so each instance limits on
+// its own.
+type RegistrationThrottle struct {
+	byDomain *httpserver.RateLimiter
+	byBlock  *httpserver.RateLimiter
+	exempt   map[string]bool
+}
+
+func NewRegistrationThrottle(policy RegistrationThrottlePolicy) *RegistrationThrottle {
+	t := &RegistrationThrottle{exempt: make(map[string]bool)}
+	if policy.DomainLimit > 0 {
+		t.byDomain = httpserver.NewRateLimiter(rate.Limit(float64(policy.DomainLimit)/policy.Window.Seconds()), policy.DomainLimit)
+		t.byDomain.CleanupLimiters()
+	}
+	if policy.IPBlockLimit > 0 {
+		t.byBlock = httpserver.NewRateLimiter(rate.Limit(float64(policy.IPBlockLimit)/policy.Window.Seconds()), policy.IPBlockLimit)
+		t.byBlock.CleanupLimiters()
+	}
+	for _, domain := range policy.ExemptDomains {
+		t.exempt[strings.ToLower(domain)] = true
+	}
+	return t
+}
+
+// Allow counts a registration attempt for email from ip and returns
+// ErrRegistrationThrottled when either limit is exhausted
+func (t *RegistrationThrottle) Allow(email, ip string) error {
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if t.byDomain != nil && !t.exempt[domain] && !t.byDomain.GetLimiter(domain).Allow() {
+		registrationMetrics.Add("throttled_domain", 1)
+		log.Printf("registration throttled for domain %s from %s", domain, ip)
+		return errors.ErrRegistrationThrottled
+	}
+
+	if block := ipBlock(ip); t.byBlock != nil && block != "" && !t.byBlock.GetLimiter(block).Allow() {
+		registrationMetrics.Add("throttled_ip_block", 1)
+		log.Printf("registration throttled for IP block %s (%s)", block, domain)
+		return errors.ErrRegistrationThrottled
+	}
+
+	registrationMetrics.Add("allowed", 1)
+	return nil
+}
+
+// ipBlock returns the network an address belongs to for throttling, empty
+// when the address cannot be parsed
+func ipBlock(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	bits := 48
+	if addr.Unmap().Is4() {
+		addr, bits = addr.Unmap(), 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/jobs"
+	"user-management-api/pkg/pdf"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:embed templates/reports/*.html
+var reportTemplateFS embed.FS
+
+var reportTemplates = template.Must(template.ParseFS(reportTemplateFS, "templates/reports/*.html"))
+
+// reportTimeout bounds how long a single report may take to build and render
+const reportTimeout = 2 * time.Minute
+
+// ReportBranding is printed on every generated report
+type ReportBranding struct {
+	Name  string
+	Color string
+}
+
+type reportPage struct {
+	Title       string
+	Brand       ReportBranding
+	GeneratedAt time.Time
+	User        *models.User
+	Month       string
+	Activity    *monthlyActivity
+}
+
+type monthlyActivity struct {
+	Registrations int64
+	Updates       int64
+	Deletions     int64
+	ByRole        []models.AggregateBucket
+	ByStatus      []models.AggregateBucket
+}
+
+// ReportService records report requests and renders them to PDF on the
+// background job queue
+type ReportService struct {
+	reportRepo    interfaces.ReportRepository
+	userRepo      interfaces.UserRepository
+	tombstoneRepo interfaces.TombstoneRepository
+	renderer      pdf.Renderer
+	queue         *jobs.Queue
+	outputPath    string
+	brand         ReportBranding
+}
+
+func NewReportService(reportRepo interfaces.ReportRepository, userRepo interfaces.UserRepository, tombstoneRepo interfaces.TombstoneRepository, renderer pdf.Renderer, queue *jobs.Queue, outputPath string, brand ReportBranding) *ReportService {
+	return &ReportService{
+		reportRepo:    reportRepo,
+		userRepo:      userRepo,
+		tombstoneRepo: tombstoneRepo,
+		renderer:      renderer,
+		queue:         queue,
+		outputPath:    outputPath,
+		brand:         brand,
+	}
+}
+
+// RecoverInterrupted fails reports left unfinished by a previous process
+func (s *ReportService) RecoverInterrupted(ctx context.Context) error {
+	count, err := s.reportRepo.FailUnfinished(ctx, "interrupted by server restart")
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("marked %d unfinished reports as failed", count)
+	}
+	return nil
+}
+
+// Request records a pending report and schedules its generation
+func (s *ReportService) Request(ctx context.Context, requesterID primitive.ObjectID, req *models.CreateReportRequest) (*models.Report, error) {
+	report := &models.Report{
+		Type:        req.Type,
+		RequestedBy: requesterID,
+		Status:      models.ReportStatusPending,
+	}
+
+	switch req.Type {
+	case models.ReportTypeUserProfile:
+		subjectID, err := primitive.ObjectIDFromHex(req.UserID)
+		if err != nil {
+			return nil, errors.ErrInvalidInput
+		}
+		if _, err := s.userRepo.GetByID(ctx, subjectID); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errors.ErrUserNotFound
+			}
+			return nil, errors.ErrInternalServer
+		}
+		report.SubjectID = &subjectID
+	case models.ReportTypeMonthlyActivity:
+		if _, err := time.Parse("2006-01", req.Month); err != nil {
+			return nil, errors.ErrInvalidInput
+		}
+		report.Month = req.Month
+	default:
+		return nil, errors.ErrInvalidInput
+	}
+
+	if err := s.reportRepo.Create(ctx, report); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	reportID := report.ID
+	if err := s.queue.Enqueue("report "+reportID.Hex(), func(ctx context.Context) error {
+		return s.generate(ctx, reportID)
+	}); err != nil {
+		_ = s.reportRepo.UpdateStatus(ctx, reportID, models.ReportStatusFailed, "not scheduled: "+err.Error())
+		return nil, errors.ErrQueueFull
+	}
+
+	return report, nil
+}
+
+func (s *ReportService) List(ctx context.Context, requesterID primitive.ObjectID) ([]*models.Report, error) {
+	reports, err := s.reportRepo.ListByRequester(ctx, requesterID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return reports, nil
+}
+
+// Get returns a report; admins only see the reports they requested
+func (s *ReportService) Get(ctx context.Context, requesterID, id primitive.ObjectID) (*models.Report, error) {
+	report, err := s.reportRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrReportNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if report.RequestedBy != requesterID {
+		return nil, errors.ErrReportNotFound
+	}
+	return report, nil
+}
+
+// GetFile returns a completed report, whose FilePath can be served
+func (s *ReportService) GetFile(ctx context.Context, requesterID, id primitive.ObjectID) (*models.Report, error) {
+	report, err := s.Get(ctx, requesterID, id)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != models.ReportStatusCompleted {
+		return nil, errors.ErrReportNotReady
+	}
+	return report, nil
+}
+
+// generate runs on a queue worker
+func (s *ReportService) generate(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+
+	if err := s.reportRepo.UpdateStatus(ctx, id, models.ReportStatusRunning, ""); err != nil {
+		return err
+	}
+
+	path, size, err := s.build(ctx, id)
+	if err != nil {
+		// record the failure even if the job context is already done
+		failCtx, failCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer failCancel()
+		_ = s.reportRepo.UpdateStatus(failCtx, id, models.ReportStatusFailed, err.Error())
+		return err
+	}
+
+	return s.reportRepo.Complete(ctx, id, path, size)
+}
+
+func (s *ReportService) build(ctx context.Context, id primitive.ObjectID) (string, int64, error) {
+	report, err := s.reportRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", 0, err
+	}
+
+	page := reportPage{
+		Brand:       s.brand,
+		GeneratedAt: time.Now().UTC(),
+	}
+	switch report.Type {
+	case models.ReportTypeUserProfile:
+		user, err := s.userRepo.GetByID(ctx, *report.SubjectID)
+		if err != nil {
+			return "", 0, fmt.Errorf("load user: %w", err)
+		}
+		page.Title = "User Profile: " + user.Username
+		page.User = user
+	case models.ReportTypeMonthlyActivity:
+		activity, err := s.monthlyActivity(ctx, report.Month)
+		if err != nil {
+			return "", 0, fmt.Errorf("load activity: %w", err)
+		}
+		page.Title = "Monthly Activity: " + report.Month
+		page.Month = report.Month
+		page.Activity = activity
+	default:
+		return "", 0, fmt.Errorf("unknown report type %q", report.Type)
+	}
+
+	var html bytes.Buffer
+	if err := reportTemplates.ExecuteTemplate(&html, report.Type+".html", page); err != nil {
+		return "", 0, fmt.Errorf("render html: %w", err)
+	}
+	document, err := s.renderer.Render(ctx, html.Bytes())
+	if err != nil {
+		return "", 0, fmt.Errorf("render pdf: %w", err)
+	}
+
+	if err := os.MkdirAll(s.outputPath, 0755); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(s.outputPath, report.ID.Hex()+".pdf")
+	if err := os.WriteFile(path, document, 0640); err != nil {
+		return "", 0, err
+	}
+	return path, int64(len(document)), nil
+}
+
+func (s *ReportService) monthlyActivity(ctx context.Context, month string) (*monthlyActivity, error) {
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, err
+	}
+	to := from.AddDate(0, 1, 0)
+
+	activity := &monthlyActivity{}
+	if activity.Registrations, err = s.userRepo.CountBetween(ctx, "created_at", from, to); err != nil {
+		return nil, err
+	}
+	if activity.Updates, err = s.userRepo.CountBetween(ctx, "updated_at", from, to); err != nil {
+		return nil, err
+	}
+	if activity.Deletions, err = s.tombstoneRepo.CountBetween(ctx, "users", from, to); err != nil {
+		return nil, err
+	}
+	if activity.ByRole, err = s.userRepo.CountBy(ctx, "role"); err != nil {
+		return nil, err
+	}
+	if activity.ByStatus, err = s.userRepo.CountBy(ctx, "status"); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
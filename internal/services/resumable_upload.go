@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"user-management-api/internal/config"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FinishUpload checks and saves a fully received resumable upload, read
+// from staged, and returns the record it was saved as
+type FinishUpload func(upload *models.ResumableUpload, staged *os.File) (*models.File, error)
+
+// ResumableUploadService keeps track of files sent in chunks with the tus
+// protocol. Chunks are appended to a file in the "resumable" directory of
+// the upload staging directory, so every request for an upload must reach
+// a server that shares it. A chunk cut short by a dropped connection keeps
+// what arrived, and the client resumes from there. The progress of each
+// upload is published to those following it.
+type ResumableUploadService struct {
+	uploadRepo interfaces.ResumableUploadRepository
+	uploads    config.UploadConfig
+	dir        string
+	progress   *UploadProgress
+
+	// mu guards writing, the uploads a request is appending to
+	mu      sync.Mutex
+	writing map[primitive.ObjectID]bool
+}
+
+func NewResumableUploadService(uploadRepo interfaces.ResumableUploadRepository, uploads config.UploadConfig) *ResumableUploadService {
+	return &ResumableUploadService{
+		uploadRepo: uploadRepo,
+		uploads:    uploads,
+		dir:        filepath.Join(uploads.TempDir, "resumable"),
+		progress:   NewUploadProgress(),
+		writing:    make(map[primitive.ObjectID]bool),
+	}
+}
+
+// Create starts an upload of length bytes checked against the named
+// profile, any when empty. The file's type can only be checked once it
+// has arrived, but its name and length are checked up front. checksum,
+// when given, is the hex SHA-256 the whole file must have; the file is
+// public unless visibility says otherwise.
+func (s *ResumableUploadService) Create(ctx context.Context, userID primitive.ObjectID, profileName, filename, checksum, visibility string, length int64) (*models.ResumableUpload, error) {
+	if profileName == "" {
+		profileName = config.UploadProfileAny
+	}
+	profile, ok := s.uploads.Profiles[profileName]
+	// avatars are resized on upload and cannot be sent in chunks
+	if !ok || profileName == config.UploadProfileAvatar {
+		return nil, errors.ErrUnknownUploadProfile
+	}
+
+	filename = path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	ext := strings.ToLower(path.Ext(filename))
+	switch {
+	case filename == "." || filename == "/":
+		return nil, fileRejected("filename is invalid")
+	case !slices.Contains(profile.AllowedExts, ext):
+		return nil, fileRejected("file extension '%s' not allowed. Allowed extensions: %v", ext, profile.AllowedExts)
+	case length > profile.MaxFileSize:
+		tooLarge := *errors.ErrFileTooLarge
+		tooLarge.Message = fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", profile.MaxFileSize)
+		return nil, &tooLarge
+	case checksum != "" && !validChecksum(checksum):
+		return nil, errors.ErrInvalidChecksum
+	case visibility != "" && visibility != models.FileVisibilityPublic && visibility != models.FileVisibilityPrivate:
+		return nil, errors.ErrInvalidVisibility
+	}
+	if visibility == "" {
+		visibility = models.FileVisibilityPublic
+	}
+
+	upload := &models.ResumableUpload{
+		OwnerID:    userID,
+		Profile:    profileName,
+		Filename:   filename,
+		Length:     length,
+		Checksum:   strings.ToLower(checksum),
+		Visibility: visibility,
+		ExpiresAt:  time.Now().Add(s.uploads.ResumableTTL),
+	}
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if err := s.createStaged(upload.ID); err != nil {
+		log.Printf("Failed to stage resumable upload %s: %v", upload.ID.Hex(), err)
+		s.uploadRepo.Delete(context.WithoutCancel(ctx), upload.ID)
+		return nil, errors.ErrInternalServer
+	}
+	return upload, nil
+}
+
+func (s *ResumableUploadService) Get(ctx context.Context, userID, id primitive.ObjectID) (*models.ResumableUpload, error) {
+	return s.getOwned(ctx, userID, id)
+}
+
+// Follow returns the progress events of one of the caller's uploads,
+// starting with where it stands, and a function to stop following it
+func (s *ResumableUploadService) Follow(ctx context.Context, userID, id primitive.ObjectID) (<-chan models.UploadProgressEvent, func(), error) {
+	upload, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	stage := models.UploadStageReceiving
+	if upload.FileID != nil {
+		stage = models.UploadStageCompleted
+	}
+	events, stop := s.progress.Follow(id, progressEvent(upload, stage, ""))
+	return events, stop, nil
+}
+
+// Report publishes that upload reached stage, with message when it failed
+func (s *ResumableUploadService) Report(upload *models.ResumableUpload, stage, message string) {
+	s.progress.Publish(upload.ID, progressEvent(upload, stage, message))
+}
+
+// Append writes chunk at offset, which must be where the upload stands.
+// Once every byte has arrived, finish saves the file, and is tried again
+// by a later empty chunk at the end when it fails. A chunk that breaks off
+// is kept up to where it did, and the upload is returned with the error.
+// Its arrival and completion are reported to the upload's followers;
+// finish reports the stages in between.
+func (s *ResumableUploadService) Append(ctx context.Context, userID, id primitive.ObjectID, offset int64, chunk io.Reader, finish FinishUpload) (*models.ResumableUpload, *models.File, error) {
+	if !s.lock(id) {
+		return nil, nil, errors.ErrUploadLocked
+	}
+	defer s.unlock(id)
+
+	upload, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != upload.Offset {
+		return upload, nil, errors.ErrUploadOffsetMismatch
+	}
+	if upload.FileID != nil {
+		return upload, nil, nil
+	}
+
+	written, err := s.write(upload, chunk)
+	if written > 0 {
+		upload.Offset += written
+		if err := s.uploadRepo.SetOffset(context.WithoutCancel(ctx), upload.ID, upload.Offset); err != nil {
+			return upload, nil, errors.ErrInternalServer
+		}
+		s.Report(upload, models.UploadStageReceiving, "")
+	}
+	if err != nil {
+		return upload, nil, err
+	}
+	if upload.Offset < upload.Length {
+		return upload, nil, nil
+	}
+
+	staged, err := os.Open(s.stagedPath(upload.ID))
+	if err != nil {
+		return upload, nil, errors.ErrInternalServer
+	}
+	defer staged.Close()
+	file, err := finish(upload, staged)
+	if err != nil {
+		return upload, nil, err
+	}
+	if err := s.uploadRepo.Complete(ctx, upload.ID, file.ID); err != nil {
+		s.Report(upload, models.UploadStageFailed, errors.ErrInternalServer.Message)
+		return upload, nil, errors.ErrInternalServer
+	}
+	upload.FileID = &file.ID
+	s.Report(upload, models.UploadStageCompleted, "")
+	if err := os.Remove(staged.Name()); err != nil {
+		log.Printf("Failed to remove resumable upload %s: %v", upload.ID.Hex(), err)
+	}
+	return upload, file, nil
+}
+
+// Delete abandons an upload, removing what arrived of it, and tells its
+// followers it failed. The file of a finished upload is kept.
+func (s *ResumableUploadService) Delete(ctx context.Context, userID, id primitive.ObjectID) error {
+	if !s.lock(id) {
+		return errors.ErrUploadLocked
+	}
+	defer s.unlock(id)
+
+	upload, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if upload.FileID == nil {
+		s.Report(upload, models.UploadStageFailed, "Upload cancelled")
+	}
+	if err := os.Remove(s.stagedPath(id)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove resumable upload %s: %v", id.Hex(), err)
+	}
+	if err := s.uploadRepo.Delete(ctx, id); err != nil && err != mongo.ErrNoDocuments {
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// getOwned loads one of the caller's uploads that has not expired. Other
+// users' uploads are reported as not found.
+func (s *ResumableUploadService) getOwned(ctx context.Context, userID, id primitive.ObjectID) (*models.ResumableUpload, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrResumableUploadNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if upload.OwnerID != userID || time.Now().After(upload.ExpiresAt) {
+		return nil, errors.ErrResumableUploadNotFound
+	}
+	return upload, nil
+}
+
+func (s *ResumableUploadService) stagedPath(id primitive.ObjectID) string {
+	return filepath.Join(s.dir, id.Hex())
+}
+
+func (s *ResumableUploadService) createStaged(id primitive.ObjectID) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	staged, err := os.OpenFile(s.stagedPath(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	return staged.Close()
+}
+
+// write appends chunk to the staged file, up to the upload's length, and
+// returns how much it wrote. Bytes past the recorded offset, left by a
+// write that failed to be recorded, are overwritten.
+func (s *ResumableUploadService) write(upload *models.ResumableUpload, chunk io.Reader) (int64, error) {
+	staged, err := os.OpenFile(s.stagedPath(upload.ID), os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// the cleanup command removed it
+			return 0, errors.ErrResumableUploadNotFound
+		}
+		return 0, errors.ErrInternalServer
+	}
+	if err := staged.Truncate(upload.Offset); err != nil {
+		staged.Close()
+		return 0, errors.ErrInternalServer
+	}
+	if _, err := staged.Seek(upload.Offset, io.SeekStart); err != nil {
+		staged.Close()
+		return 0, errors.ErrInternalServer
+	}
+	progress := &progressReader{r: io.LimitReader(chunk, upload.Length-upload.Offset), last: time.Now(), report: func(read int64) {
+		event := progressEvent(upload, models.UploadStageReceiving, "")
+		event.Offset += read
+		s.progress.Publish(upload.ID, event)
+	}}
+	written, err := io.Copy(staged, progress)
+	if closeErr := staged.Close(); err == nil && closeErr != nil {
+		return 0, errors.ErrInternalServer
+	}
+	return written, err
+}
+
+func progressEvent(upload *models.ResumableUpload, stage, message string) models.UploadProgressEvent {
+	return models.UploadProgressEvent{
+		Stage:  stage,
+		Offset: upload.Offset,
+		Length: upload.Length,
+		FileID: upload.FileID,
+		Error:  message,
+	}
+}
+
+// lock claims an upload for one request, reporting false when another
+// holds it
+func (s *ResumableUploadService) lock(id primitive.ObjectID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writing[id] {
+		return false
+	}
+	s.writing[id] = true
+	return true
+}
+
+func (s *ResumableUploadService) unlock(id primitive.ObjectID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.writing, id)
+}
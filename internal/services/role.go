@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RoleService manages the roles collection and keeps the role table used
+// by token issuance and middleware.RequirePermission in step with it
+type RoleService struct {
+	roleRepo interfaces.RoleRepository
+	userRepo interfaces.UserRepository
+}
+
+func NewRoleService(roleRepo interfaces.RoleRepository, userRepo interfaces.UserRepository) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+// Load seeds the built-in roles when missing and publishes every role
+func (s *RoleService) Load(ctx context.Context) error {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for name, permissions := range models.DefaultRoles {
+		if slices.ContainsFunc(roles, func(r *models.Role) bool { return r.Name == name }) {
+			continue
+		}
+		role := &models.Role{Name: name, Permissions: permissions, Inherits: models.DefaultRoleInherits[name], System: true}
+		if err := s.roleRepo.Create(ctx, role); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		roles = append(roles, role)
+	}
+	s.publish(roles)
+	return nil
+}
+
+// RefreshEvery reloads roles until ctx ends, so changes made through
+// other instances are picked up
+func (s *RoleService) RefreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.reload(ctx); err != nil {
+				log.Printf("failed to reload roles: %v", err)
+			}
+		}
+	}
+}
+
+func (s *RoleService) List(ctx context.Context) ([]*models.Role, error) {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return roles, nil
+}
+
+func (s *RoleService) Get(ctx context.Context, name string) (*models.Role, error) {
+	role, err := s.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrRoleNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	return role, nil
+}
+
+func (s *RoleService) Create(ctx context.Context, req *models.CreateRoleRequest) (*models.Role, error) {
+	if err := checkPermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+	if err := s.checkInherits(ctx, req.Name, req.Inherits); err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		Inherits:    req.Inherits,
+	}
+	if !req.SessionLimit.IsZero() {
+		role.SessionLimit = req.SessionLimit
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrRoleExists
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.refresh(ctx)
+	return role, nil
+}
+
+func (s *RoleService) Update(ctx context.Context, name string, req *models.UpdateRoleRequest) (*models.Role, error) {
+	role, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	// admins must keep every permission, or nobody could manage roles; only
+	// their session limit may change
+	if slices.Contains(models.CatalogueRoles, role.Name) && (req.Description != nil || req.Permissions != nil || req.Inherits != nil) {
+		return nil, errors.ErrRoleProtected
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		if err := checkPermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+		role.Permissions = req.Permissions
+	}
+	if req.Inherits != nil {
+		if err := s.checkInherits(ctx, role.Name, req.Inherits); err != nil {
+			return nil, err
+		}
+		role.Inherits = req.Inherits
+	}
+	if req.SessionLimit != nil {
+		role.SessionLimit = req.SessionLimit
+		if role.SessionLimit.IsZero() {
+			role.SessionLimit = nil
+		}
+	}
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrRoleNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.refresh(ctx)
+	return role, nil
+}
+
+// Delete removes a role no user holds any longer
+func (s *RoleService) Delete(ctx context.Context, name string) error {
+	role, err := s.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.System {
+		return errors.ErrRoleProtected
+	}
+
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	for _, other := range roles {
+		if slices.Contains(other.Inherits, name) {
+			return errors.ErrRoleInherited
+		}
+	}
+
+	buckets, err := s.userRepo.CountBy(ctx, "role")
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+	for _, bucket := range buckets {
+		if bucket.Key == name && bucket.Count > 0 {
+			return errors.ErrRoleInUse
+		}
+	}
+
+	if err := s.roleRepo.Delete(ctx, name); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrRoleNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	s.refresh(ctx)
+	return nil
+}
+
+// refresh republishes roles after a write; a failure only delays the
+// change until the next periodic reload
+func (s *RoleService) refresh(ctx context.Context) {
+	if err := s.reload(ctx); err != nil {
+		log.Printf("failed to reload roles: %v", err)
+	}
+}
+
+func (s *RoleService) reload(ctx context.Context) error {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.publish(roles)
+	return nil
+}
+
+// publish resolves inheritance and makes the roles effective
+func (s *RoleService) publish(roles []*models.Role) {
+	permissions := make(map[string][]string, len(roles))
+	inherits := make(map[string][]string, len(roles))
+	sessionLimits := make(map[string]models.SessionLimit)
+	for _, role := range roles {
+		permissions[role.Name] = role.Permissions
+		inherits[role.Name] = role.Inherits
+		if role.SessionLimit != nil {
+			sessionLimits[role.Name] = *role.SessionLimit
+		}
+	}
+	// admins were seeded with the permissions of their release and cannot
+	// be changed, so they follow the catalogue instead
+	for _, name := range models.CatalogueRoles {
+		permissions[name] = models.Permissions
+		inherits[name] = models.DefaultRoleInherits[name]
+	}
+
+	scopes := make(map[string][]string, len(roles))
+	includes := make(map[string][]string, len(roles))
+	for name := range permissions {
+		included := includedRoles(name, inherits)
+		var granted []string
+		for _, role := range included {
+			for _, permission := range permissions[role] {
+				if !slices.Contains(granted, permission) {
+					granted = append(granted, permission)
+				}
+			}
+		}
+		scopes[name] = granted
+		includes[name] = included
+	}
+	models.SetRoles(scopes, includes)
+	models.SetSessionLimits(sessionLimits)
+}
+
+// checkInherits rejects parents that do not exist or that would make name
+// inherit itself
+func (s *RoleService) checkInherits(ctx context.Context, name string, parents []string) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return errors.ErrInternalServer
+	}
+
+	inherits := make(map[string][]string, len(roles))
+	for _, role := range roles {
+		inherits[role.Name] = role.Inherits
+	}
+	for _, parent := range parents {
+		if _, ok := inherits[parent]; !ok {
+			return errors.ErrUnknownRole
+		}
+	}
+	inherits[name] = parents
+	for _, parent := range parents {
+		if slices.Contains(includedRoles(parent, inherits), name) {
+			return errors.ErrRoleCycle
+		}
+	}
+	return nil
+}
+
+// includedRoles returns name and every role it inherits, directly or
+// through other roles
+func includedRoles(name string, inherits map[string][]string) []string {
+	included := []string{name}
+	for i := 0; i < len(included); i++ {
+		for _, parent := range inherits[included[i]] {
+			if !slices.Contains(included, parent) {
+				included = append(included, parent)
+			}
+		}
+	}
+	return included
+}
+
+func checkPermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !slices.Contains(models.Permissions, permission) {
+			return errors.ErrUnknownPermission
+		}
+	}
+	return nil
+}
+
+// checkRole rejects role names that are not defined
+func checkRole(role string) error {
+	if !models.RoleExists(role) {
+		return errors.ErrUnknownRole
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/saml"
+	"user-management-api/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// samlRequestTTL is how long the IdP has to answer an AuthnRequest
+const samlRequestTTL = 10 * time.Minute
+
+const tokenPurposeSAMLRequest = "saml_request"
+
+// SAMLService signs users in through an external SAML identity provider and
+// provisions a local account the first time a user arrives
+type SAMLService struct {
+	sp          *saml.ServiceProvider
+	userRepo    interfaces.UserRepository
+	assertions  interfaces.SAMLAssertionRepository
+	tokens      *utils.OneTimeTokens
+	sessions    *SessionService
+	history     *HistoryService
+	events      *AuthEventService
+	jwtSecret   string
+	defaultRole string
+	accessTTL   time.Duration
+}
+
+func NewSAMLService(sp *saml.ServiceProvider, userRepo interfaces.UserRepository, assertions interfaces.SAMLAssertionRepository, tokens *utils.OneTimeTokens, sessions *SessionService, history *HistoryService, events *AuthEventService, jwtSecret, defaultRole string, accessTTL time.Duration) *SAMLService {
+	return &SAMLService{
+		sp:          sp,
+		userRepo:    userRepo,
+		assertions:  assertions,
+		tokens:      tokens,
+		sessions:    sessions,
+		history:     history,
+		events:      events,
+		jwtSecret:   jwtSecret,
+		defaultRole: defaultRole,
+		accessTTL:   accessTTL,
+	}
+}
+
+func (s *SAMLService) Metadata() ([]byte, error) {
+	return s.sp.Metadata()
+}
+
+// LoginURL returns the IdP redirect that starts SSO. The AuthnRequest ID is
+// a one-time token, so Login accepts only one response to it.
+func (s *SAMLService) LoginURL(ctx context.Context, relayState string) (string, error) {
+	subject, err := utils.RandomToken(16)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+	token, err := s.tokens.Issue(ctx, tokenPurposeSAMLRequest, subject, samlRequestTTL)
+	if err != nil {
+		return "", errors.ErrInternalServer
+	}
+	// an xs:ID cannot start with a digit
+	return s.sp.AuthnRequestURL("_"+token, relayState, time.Now())
+}
+
+// Login verifies the posted SAMLResponse and returns a local token. Only
+// responses to an AuthnRequest from LoginURL are accepted, and each
+// assertion only once.
+func (s *SAMLService) Login(ctx context.Context, samlResponse string, client models.LoginContext) (*models.AuthResponse, error) {
+	assertion, err := s.accept(ctx, samlResponse)
+	if err != nil {
+		log.Printf("saml: rejected response: %v", err)
+		s.events.Record(ctx, &models.AuthEvent{
+			Type:      models.AuthEventLoginFailed,
+			Method:    "saml",
+			Reason:    errors.ErrSSOFailed.Type,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+		})
+		return nil, errors.ErrSSOFailed
+	}
+
+	email := strings.ToLower(assertion.Email())
+	if email == "" {
+		log.Printf("saml: assertion for %q carries no e-mail", assertion.NameID)
+		return nil, errors.ErrSSOFailed
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, errors.ErrInternalServer
+		}
+		if user, err = s.provision(ctx, email, assertion); err != nil {
+			return nil, err
+		}
+	}
+	if !user.IsActive() {
+		s.events.Record(ctx, &models.AuthEvent{
+			Type:      models.AuthEventLoginFailed,
+			UserID:    &user.ID,
+			Email:     user.Email,
+			Method:    "saml",
+			Reason:    errors.ErrUnAuthorized.Type,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+		})
+		return nil, errors.ErrUnAuthorized
+	}
+	s.events.RecordFor(ctx, models.AuthEventLogin, user, client, "saml")
+	session, err := s.sessions.Start(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sessions.issueTokens(ctx, user, session, s.jwtSecret, s.accessTTL)
+}
+
+// accept verifies a SAMLResponse, consumes the AuthnRequest it answers and
+// claims its assertion ID
+func (s *SAMLService) accept(ctx context.Context, samlResponse string) (*saml.Assertion, error) {
+	assertion, err := s.sp.ParseResponse(samlResponse, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(assertion.InResponseTo, "_") {
+		return nil, fmt.Errorf("unsolicited response %q", assertion.ID)
+	}
+	if _, err := s.tokens.Consume(ctx, tokenPurposeSAMLRequest, strings.TrimPrefix(assertion.InResponseTo, "_")); err != nil {
+		return nil, fmt.Errorf("response %q answers an unknown request: %w", assertion.ID, err)
+	}
+	claimed, err := s.assertions.Claim(ctx, assertion.ID, assertion.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("assertion %q replayed", assertion.ID)
+	}
+	return assertion, nil
+}
+
+// Logout ends every session of the user the IdP logged out and
+// acknowledges the LogoutRequest. rawQuery is the query string of the
+// redirect, whose signature is checked. Users unknown here have nothing to
+// end and are acknowledged too.
+func (s *SAMLService) Logout(ctx context.Context, rawQuery string, client models.LoginContext) (string, error) {
+	request, err := s.sp.ParseLogoutRequest(rawQuery, time.Now())
+	if err != nil {
+		log.Printf("saml: rejected logout request: %v", err)
+		return "", errors.ErrSSOFailed
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(request.NameID))
+	switch {
+	case err == nil:
+		s.sessions.EndAll(ctx, user.ID)
+		s.events.RecordFor(ctx, models.AuthEventLogout, user, client, "saml")
+	case err != mongo.ErrNoDocuments:
+		return "", errors.ErrInternalServer
+	}
+	return s.sp.LogoutResponseURL(request.ID, request.RelayState, time.Now())
+}
+
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// provision creates a local user for a first-time SSO login. The random
+// password is never disclosed, so the account can only sign in via SSO
+// until a password is set.
+func (s *SAMLService) provision(ctx context.Context, email string, assertion *saml.Assertion) (*models.User, error) {
+	password, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	username, err := s.availableUsername(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:  username,
+		Email:     email,
+		Password:  hashedPassword,
+		FirstName: assertion.Attribute("givenName", "firstName", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname"),
+		LastName:  assertion.Attribute("sn", "surname", "lastName", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname"),
+		Role:      s.defaultRole,
+		Status:    models.UserStatusActive,
+		// the IdP vouches for the address
+		EmailVerified: true,
+	}
+	if err := beforeUserWrite(ctx, BeforeCreate, user, nil); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// a concurrent first login for the same address won the insert
+			if existing, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+				return existing, nil
+			}
+			return nil, errors.ErrUserExists
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, user.ID, nil, user)
+	afterUserWrite(ctx, AfterCreate, user, nil)
+
+	log.Printf("saml: provisioned user %s for %s", user.ID.Hex(), email)
+	return user, nil
+}
+
+// availableUsername derives a unique username from the e-mail local part
+func (s *SAMLService) availableUsername(ctx context.Context, email string) (string, error) {
+	base := usernameUnsafe.ReplaceAllString(strings.SplitN(email, "@", 2)[0], "_")
+	if len(base) < 3 {
+		base = "user_" + base
+	}
+	if len(base) > 14 {
+		base = base[:14]
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		if _, err := s.userRepo.GetByUsername(ctx, candidate); err == mongo.ErrNoDocuments {
+			return candidate, nil
+		} else if err != nil {
+			return "", errors.ErrInternalServer
+		}
+		suffix, err := utils.RandomToken(2)
+		if err != nil {
+			return "", errors.ErrInternalServer
+		}
+		candidate = base + "_" + suffix
+	}
+	return "", errors.ErrUserExists
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Session limit policies, applied when a user already has the maximum
+// number of active sessions
+const (
+	SessionLimitReject      = "reject"
+	SessionLimitEvictOldest = "evict_oldest"
+)
+
+// tokenPurposeRefresh marks refresh tokens, issued one per session
+const tokenPurposeRefresh = "refresh"
+
+// SessionService records sign-ins, enforces the per-user session limit and
+// warns users by email when a sign-in comes from an IP address or device
+// they have not used before and have not marked as trusted
+type SessionService struct {
+	sessionRepo interfaces.SessionRepository
+	notifier    *NotificationService
+	devices     *TrustedDeviceService
+	activity    *ActivityService
+	tokens      *utils.OneTimeTokens
+	ttl         time.Duration
+	refreshTTL  time.Duration
+	maxActive   int
+	limitPolicy string
+}
+
+// NewSessionService records sessions that last ttl; no token issued for a
+// session outlives it. Refresh tokens last refreshTTL, zero disables them.
+// A maxActive of zero means no limit.
+func NewSessionService(sessionRepo interfaces.SessionRepository, notifier *NotificationService, devices *TrustedDeviceService, activity *ActivityService, tokens *utils.OneTimeTokens, ttl, refreshTTL time.Duration, maxActive int, limitPolicy string) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		notifier:    notifier,
+		devices:     devices,
+		activity:    activity,
+		tokens:      tokens,
+		ttl:         ttl,
+		refreshTTL:  refreshTTL,
+		maxActive:   maxActive,
+		limitPolicy: limitPolicy,
+	}
+}
+
+// TTL is how long sessions last
+func (s *SessionService) TTL() time.Duration {
+	return s.ttl
+}
+
+// issueTokens signs an access token for session that lasts accessTTL but
+// never past the end of the session and, when refresh tokens are enabled,
+// a refresh token replacing any earlier one of the session
+func (s *SessionService) issueTokens(ctx context.Context, user *models.User, session *models.Session, jwtSecret string, accessTTL time.Duration) (*models.AuthResponse, error) {
+	now := time.Now()
+	ttl := min(accessTTL, session.ExpiresAt.Sub(now))
+	token, err := auth.GenerateSessionJWT(user.ID, user.Email, user.Role, models.ScopesForRole(user.Role), session.ID, jwtSecret, ttl)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	response := &models.AuthResponse{
+		Token:     token,
+		TokenType: models.TokenTypeBearer,
+		ExpiresIn: int(ttl.Seconds()),
+		ExpiresAt: now.Add(ttl),
+		User:      *user.ToResponse(),
+	}
+	if s.refreshTTL > 0 {
+		refreshTTL := min(s.refreshTTL, session.ExpiresAt.Sub(now))
+		response.RefreshToken, err = s.tokens.Issue(ctx, tokenPurposeRefresh, session.ID.Hex(), refreshTTL)
+		if err != nil {
+			return nil, errors.ErrInternalServer
+		}
+	}
+	return response, nil
+}
+
+// consumeRefreshToken uses up a refresh token and returns its session,
+// which must still be active
+func (s *SessionService) consumeRefreshToken(ctx context.Context, token string) (*models.Session, error) {
+	subject, err := s.tokens.Consume(ctx, tokenPurposeRefresh, token)
+	if err != nil {
+		if err == utils.ErrInvalidOneTimeToken {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	id, err := primitive.ObjectIDFromHex(subject)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+	session, err := s.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if session.RevokedAt != nil || !time.Now().Before(session.ExpiresAt) {
+		return nil, errors.ErrInvalidToken
+	}
+	return session, nil
+}
+
+// Start opens a session for user, making room for it or refusing it as the
+// session limit requires. The new IP/device check is best effort and never
+// blocks the sign-in.
+func (s *SessionService) Start(ctx context.Context, user *models.User, client models.LoginContext) (*models.Session, error) {
+	newDevice := s.isNewDevice(ctx, user.ID, client)
+
+	session := &models.Session{
+		UserID:    user.ID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	if err := s.enforceLimit(ctx, user, session); err != nil {
+		return nil, err
+	}
+	s.activity.RecordLogin(ctx, user.ID)
+
+	if newDevice && !user.Preferences.LoginAlertsOptOut && !s.devices.IsTrusted(ctx, user.ID, client) {
+		if err := s.notifier.SendLoginAlert(user, session); err != nil {
+			log.Printf("login alert for %s: %v", user.ID.Hex(), err)
+		}
+	}
+	return session, nil
+}
+
+// End revokes a session so its tokens stop working
+func (s *SessionService) End(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.sessionRepo.Revoke(ctx, id); err != nil {
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// EndAll revokes every active session of a user. Failures are logged; the
+// sessions still run out on their own.
+func (s *SessionService) EndAll(ctx context.Context, userID primitive.ObjectID) {
+	sessions, err := s.sessionRepo.ListActive(ctx, userID)
+	if err == nil && len(sessions) > 0 {
+		ids := make([]primitive.ObjectID, len(sessions))
+		for i, session := range sessions {
+			ids[i] = session.ID
+		}
+		err = s.sessionRepo.Revoke(ctx, ids...)
+	}
+	if err != nil {
+		log.Printf("failed to end sessions of user %s: %v", userID.Hex(), err)
+	}
+}
+
+// IsSessionActive reports whether a session can still authorize requests
+func (s *SessionService) IsSessionActive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	session, err := s.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	return session.RevokedAt == nil && time.Now().Before(session.ExpiresAt), nil
+}
+
+// limitFor returns the session limit and policy for holders of role: the
+// role's own where it sets them, the global ones otherwise
+func (s *SessionService) limitFor(role string) (int, string) {
+	maxActive, policy := s.maxActive, s.limitPolicy
+	if limit, ok := models.SessionLimitForRole(role); ok {
+		if limit.MaxActive != nil {
+			maxActive = *limit.MaxActive
+		}
+		if limit.Policy != "" {
+			policy = limit.Policy
+		}
+	}
+	return maxActive, policy
+}
+
+// enforceLimit brings the user back within their session limit once
+// session, the new one, is stored. Checking after the insert rather than
+// before keeps concurrent sign-ins from all passing the check: whichever
+// lists the sessions last sees every one of them. Under the reject policy
+// session is deleted again and the sign-in refused when the user has too
+// many, so concurrent sign-ins may all be refused but never all succeed.
+// Otherwise the oldest sessions are revoked, and if a newer concurrent
+// sign-in evicted session itself the sign-in is refused.
+func (s *SessionService) enforceLimit(ctx context.Context, user *models.User, session *models.Session) error {
+	maxActive, policy := s.limitFor(user.Role)
+	if maxActive <= 0 {
+		return nil
+	}
+	active, err := s.sessionRepo.ListActive(ctx, user.ID)
+	if err != nil {
+		s.discard(ctx, session)
+		return errors.ErrInternalServer
+	}
+	excess := len(active) - maxActive
+	if excess <= 0 {
+		return nil
+	}
+	if policy == SessionLimitReject {
+		s.discard(ctx, session)
+		return errors.ErrSessionLimit
+	}
+
+	evicted := make([]primitive.ObjectID, 0, excess)
+	for _, old := range active[:excess] {
+		evicted = append(evicted, old.ID)
+	}
+	if err := s.sessionRepo.Revoke(ctx, evicted...); err != nil {
+		s.discard(ctx, session)
+		return errors.ErrInternalServer
+	}
+	log.Printf("evicted %d session(s) for %s over the limit of %d", len(evicted), user.ID.Hex(), maxActive)
+	if slices.Contains(evicted, session.ID) {
+		return errors.ErrSessionLimit
+	}
+	return nil
+}
+
+// discard deletes a session the sign-in that opened it did not get to use
+func (s *SessionService) discard(ctx context.Context, session *models.Session) {
+	if err := s.sessionRepo.Delete(ctx, session.ID); err != nil {
+		log.Printf("failed to delete unused session %s: %v", session.ID.Hex(), err)
+	}
+}
+
+// isNewDevice reports whether the sign-in comes from an IP or user agent
+// the user has not signed in from before. The very first sign-in has
+// nothing to compare against and is not considered new.
+func (s *SessionService) isNewDevice(ctx context.Context, userID primitive.ObjectID, client models.LoginContext) bool {
+	previous, err := s.sessionRepo.CountByUser(ctx, userID)
+	if err != nil || previous == 0 {
+		if err != nil {
+			log.Printf("session lookup for %s: %v", userID.Hex(), err)
+		}
+		return false
+	}
+	ipSeen, deviceSeen, err := s.sessionRepo.Seen(ctx, userID, client.IP, client.UserAgent)
+	if err != nil {
+		log.Printf("session lookup for %s: %v", userID.Hex(), err)
+		return false
+	}
+	return !ipSeen || !deviceSeen
+}
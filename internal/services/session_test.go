@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// startConcurrently opens attempts sessions for user at once and returns
+// how many sign-ins succeeded
+func startConcurrently(t *testing.T, sessions *SessionService, user *models.User, attempts int) int {
+	t.Helper()
+	var wg sync.WaitGroup
+	results := make([]error, attempts)
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = sessions.Start(context.Background(), user, models.LoginContext{IP: "203.0.113.1", UserAgent: "test"})
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range results {
+		switch err {
+		case nil:
+			succeeded++
+		case errors.ErrSessionLimit:
+		default:
+			t.Errorf("Start returned %v, want nil or ErrSessionLimit", err)
+		}
+	}
+	return succeeded
+}
+
+func TestSessionLimitConcurrentSignIns(t *testing.T) {
+	const maxActive, attempts = 2, 10
+	for _, policy := range []string{SessionLimitReject, SessionLimitEvictOldest} {
+		t.Run(policy, func(t *testing.T) {
+			repo := &fakeSessionRepo{}
+			userRepo := &fakeUserRepo{}
+			sessions := NewSessionService(repo, nil, nil, NewActivityService(userRepo, time.Minute), nil, time.Hour, 0, maxActive, policy)
+			user := &models.User{ID: primitive.NewObjectID(), Role: models.RoleUser}
+
+			succeeded := startConcurrently(t, sessions, user, attempts)
+			active, _ := repo.ListActive(context.Background(), user.ID)
+			if len(active) > maxActive {
+				t.Errorf("%d active sessions after concurrent sign-ins, want at most %d", len(active), maxActive)
+			}
+			if succeeded < len(active) {
+				t.Errorf("%d sign-ins succeeded but %d sessions are active", succeeded, len(active))
+			}
+
+			// once the rush is over, sign-ins behave as the policy says
+			startConcurrently(t, sessions, user, 1)
+			active, _ = repo.ListActive(context.Background(), user.ID)
+			if len(active) > maxActive {
+				t.Errorf("%d active sessions after a later sign-in, want at most %d", len(active), maxActive)
+			}
+		})
+	}
+}
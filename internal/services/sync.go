@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+)
+
+const (
+	syncTokenVersion = "v1"
+	syncDefaultLimit = 100
+	syncMaxLimit     = 500
+)
+
+// SyncService serves delta synchronization of users for offline-capable
+// clients. A sync token encodes the time of the last change a client has
+// seen; deletions are reported from tombstones, which are only kept for
+// the retention period, so older tokens require a full resync.
+type SyncService struct {
+	userRepo      interfaces.UserRepository
+	tombstoneRepo interfaces.TombstoneRepository
+	retention     time.Duration
+}
+
+func NewSyncService(userRepo interfaces.UserRepository, tombstoneRepo interfaces.TombstoneRepository, retention time.Duration) *SyncService {
+	return &SyncService{
+		userRepo:      userRepo,
+		tombstoneRepo: tombstoneRepo,
+		retention:     retention,
+	}
+}
+
+// change is an update or a deletion, ordered by when it happened
+type change struct {
+	at      time.Time
+	user    *models.User
+	deleted string
+}
+
+// Sync returns the changes after token, oldest first. An empty token starts
+// a full sync from the beginning.
+func (s *SyncService) Sync(ctx context.Context, token string, limit int) (*models.SyncResponse, error) {
+	if limit < 1 || limit > syncMaxLimit {
+		limit = syncDefaultLimit
+	}
+
+	since, err := decodeSyncToken(token)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	if !since.IsZero() && time.Since(since) > s.retention {
+		return nil, errors.ErrSyncTokenExpired
+	}
+
+	// Fetch one extra from each source to know whether more remain
+	users, err := s.userRepo.ListUpdatedSince(ctx, since, limit+1)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	tombstones, err := s.tombstoneRepo.ListSince(ctx, "users", since, limit+1)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	changes := make([]change, 0, len(users)+len(tombstones))
+	for _, user := range users {
+		changes = append(changes, change{at: user.UpdatedAt, user: user})
+	}
+	for _, tombstone := range tombstones {
+		changes = append(changes, change{at: tombstone.DeletedAt, deleted: tombstone.DocumentID.Hex()})
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].at.Before(changes[j].at) })
+
+	result := &models.SyncResponse{
+		Created: []*models.UserResponse{},
+		Updated: []*models.UserResponse{},
+		Deleted: []string{},
+	}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		result.HasMore = true
+	}
+
+	cursor := since
+	for _, c := range changes {
+		switch {
+		case c.deleted != "":
+			result.Deleted = append(result.Deleted, c.deleted)
+		case c.user.CreatedAt.After(since):
+			result.Created = append(result.Created, c.user.ToResponse())
+		default:
+			result.Updated = append(result.Updated, c.user.ToResponse())
+		}
+		cursor = c.at
+	}
+	if len(changes) == 0 && since.IsZero() {
+		cursor = time.Now()
+	}
+
+	result.NextToken = encodeSyncToken(cursor)
+	return result, nil
+}
+
+func encodeSyncToken(at time.Time) string {
+	raw := syncTokenVersion + ":" + strconv.FormatInt(at.UnixMilli(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSyncToken(token string) (time.Time, error) {
+	if token == "" {
+		return time.Time{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	version, millis, found := strings.Cut(string(raw), ":")
+	if !found || version != syncTokenVersion {
+		return time.Time{}, errors.ErrInvalidInput
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+	"user-management-api/pkg/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// trustedDeviceTTL is how long a device stays trusted before the user has
+// to vouch for it again
+const trustedDeviceTTL = 30 * 24 * time.Hour
+
+// TrustedDeviceService lets users mark the devices they sign in from as
+// trusted. Sign-ins from a trusted device do not trigger new device alerts,
+// and it is the hook point for skipping a second factor.
+type TrustedDeviceService struct {
+	deviceRepo interfaces.TrustedDeviceRepository
+}
+
+func NewTrustedDeviceService(deviceRepo interfaces.TrustedDeviceRepository) *TrustedDeviceService {
+	return &TrustedDeviceService{
+		deviceRepo: deviceRepo,
+	}
+}
+
+// deviceFingerprint identifies the device a request comes from. Without an
+// X-Device-ID header, devices sharing a user agent are indistinguishable.
+func deviceFingerprint(client models.LoginContext) string {
+	return utils.HashToken(client.UserAgent + "\x00" + client.DeviceID)
+}
+
+// Trust marks the device the request comes from as trusted for 30 days,
+// renewing it if it already is
+func (s *TrustedDeviceService) Trust(ctx context.Context, userID primitive.ObjectID, client models.LoginContext, name string) (*models.TrustedDevice, error) {
+	device, err := s.deviceRepo.Upsert(ctx, &models.TrustedDevice{
+		UserID:      userID,
+		Fingerprint: deviceFingerprint(client),
+		Name:        name,
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+		ExpiresAt:   time.Now().Add(trustedDeviceTTL),
+	})
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return device, nil
+}
+
+func (s *TrustedDeviceService) List(ctx context.Context, userID primitive.ObjectID) ([]*models.TrustedDevice, error) {
+	devices, err := s.deviceRepo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+	return devices, nil
+}
+
+// Revoke stops trusting one of the user's devices
+func (s *TrustedDeviceService) Revoke(ctx context.Context, userID, id primitive.ObjectID) error {
+	if err := s.deviceRepo.Delete(ctx, userID, id); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.ErrDeviceNotFound
+		}
+		return errors.ErrInternalServer
+	}
+	return nil
+}
+
+// IsTrusted reports whether the request comes from one of the user's
+// trusted devices. Lookup failures count as untrusted.
+func (s *TrustedDeviceService) IsTrusted(ctx context.Context, userID primitive.ObjectID, client models.LoginContext) bool {
+	trusted, err := s.deviceRepo.IsTrusted(ctx, userID, deviceFingerprint(client))
+	if err != nil {
+		log.Printf("trusted device lookup for %s: %v", userID.Hex(), err)
+		return false
+	}
+	return trusted
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"io"
+	"sync"
+	"time"
+	"user-management-api/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// progressBuffer is how many events a follower can fall behind before
+	// the oldest it has not read are dropped
+	progressBuffer = 16
+	// progressInterval spaces the events reporting a chunk's arrival
+	progressInterval = 250 * time.Millisecond
+)
+
+// UploadProgress passes the progress events of resumable uploads to those
+// following them. Events are not stored: a follower only sees those
+// published after it started following, on the same server.
+type UploadProgress struct {
+	mu        sync.Mutex
+	followers map[primitive.ObjectID]map[chan models.UploadProgressEvent]struct{}
+}
+
+func NewUploadProgress() *UploadProgress {
+	return &UploadProgress{followers: make(map[primitive.ObjectID]map[chan models.UploadProgressEvent]struct{})}
+}
+
+// Follow returns the events of upload id, starting with first, and a
+// function to stop following it, which closes the channel
+func (p *UploadProgress) Follow(id primitive.ObjectID, first models.UploadProgressEvent) (<-chan models.UploadProgressEvent, func()) {
+	events := make(chan models.UploadProgressEvent, progressBuffer)
+	events <- first
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.followers[id] == nil {
+		p.followers[id] = make(map[chan models.UploadProgressEvent]struct{})
+	}
+	p.followers[id][events] = struct{}{}
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			delete(p.followers[id], events)
+			if len(p.followers[id]) == 0 {
+				delete(p.followers, id)
+			}
+			close(events)
+		})
+	}
+}
+
+// Publish sends event to the followers of upload id without waiting for
+// them. A follower that fell behind loses its oldest event, so the latest,
+// and how the upload ended, still reach it.
+func (p *UploadProgress) Publish(id primitive.ObjectID, event models.UploadProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for events := range p.followers[id] {
+		select {
+		case events <- event:
+			continue
+		default:
+		}
+		select {
+		case <-events:
+		default:
+		}
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// progressReader calls report with how much has been read, at most once
+// per progressInterval
+type progressReader struct {
+	r      io.Reader
+	read   int64
+	last   time.Time
+	report func(read int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 && time.Since(p.last) >= progressInterval {
+		p.last = time.Now()
+		p.report(p.read)
+	}
+	return n, err
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"log"
 	"math"
+	"strings"
+	"sync"
+	"time"
 	"user-management-api/internal/models"
 	"user-management-api/internal/repository/interfaces"
 	"user-management-api/pkg/errors"
@@ -13,12 +17,29 @@ import (
 )
 
 type UserService struct {
-	userRepo interfaces.UserRepository
+	userRepo       interfaces.UserRepository
+	tombstoneRepo  interfaces.TombstoneRepository
+	membershipRepo interfaces.MembershipRepository
+	bulkRepo       interfaces.BulkOperationRepository
+	tx             interfaces.Transactor
+	history        *HistoryService
+	checklist      *models.ProfileChecklist
+
+	aggregateMu    sync.Mutex
+	aggregateCache map[string]*models.UserAggregateResponse
+	statsCache     *models.UserStats
 }
 
-func NewUserService(userRepo interfaces.UserRepository) *UserService {
+func NewUserService(userRepo interfaces.UserRepository, tombstoneRepo interfaces.TombstoneRepository, membershipRepo interfaces.MembershipRepository, bulkRepo interfaces.BulkOperationRepository, tx interfaces.Transactor, history *HistoryService, checklist *models.ProfileChecklist) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		tombstoneRepo:  tombstoneRepo,
+		membershipRepo: membershipRepo,
+		bulkRepo:       bulkRepo,
+		tx:             tx,
+		history:        history,
+		checklist:      checklist,
+		aggregateCache: make(map[string]*models.UserAggregateResponse),
 	}
 }
 
@@ -33,12 +54,28 @@ func (s *UserService) GetByID(ctx context.Context, id primitive.ObjectID) (*mode
 	return user.ToResponse(), nil
 }
 
-func (s *UserService) Create(ctx context.Context, req *models.CreateUserRequest) (*models.UserResponse, error) {
-	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
-		return nil, errors.ErrUserExists
+// GetProfile returns a user's own profile, scored against the onboarding
+// checklist
+func (s *UserService) GetProfile(ctx context.Context, id primitive.ObjectID) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
 	}
-	if _, err := s.userRepo.GetByUsername(ctx, req.Username); err == nil {
-		return nil, errors.ErrUserExists
+	response := user.ToResponse()
+	response.Completeness = s.checklist.Evaluate(user)
+	return response, nil
+}
+
+// Create adds a user; actorRole must be able to manage req.Role
+func (s *UserService) Create(ctx context.Context, actorID primitive.ObjectID, actorRole string, req *models.CreateUserRequest) (*models.UserResponse, error) {
+	if err := checkRole(req.Role); err != nil {
+		return nil, err
+	}
+	if !models.CanManageRole(actorRole, req.Role) {
+		return nil, errors.ErrRoleNotManageable
 	}
 	// hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
@@ -54,17 +91,35 @@ func (s *UserService) Create(ctx context.Context, req *models.CreateUserRequest)
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Role:      req.Role,
-		IsActive:  true,
+		Locale:    req.Locale,
+		Status:    models.UserStatusActive,
+	}
+	if err := beforeUserWrite(ctx, BeforeCreate, user, nil); err != nil {
+		return nil, err
 	}
 
+	// duplicates are caught by the unique indexes, as in AuthService.Register
 	if err = s.userRepo.Create(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrUserExists
+		}
 		return nil, errors.ErrInternalServer
 	}
+	s.history.Record(ctx, actorID, nil, user)
+	afterUserWrite(ctx, AfterCreate, user, nil)
 
 	return user.ToResponse(), nil
 }
 
-func (s *UserService) Update(ctx context.Context, id primitive.ObjectID, req *models.UpdateUserRequest) (*models.UserResponse, error) {
+// Update changes a user; actorRole must be able to manage both the user's
+// current role and the new one
+func (s *UserService) Update(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, req *models.UpdateUserRequest) (*models.UserResponse, error) {
+	return s.Patch(ctx, actorID, actorRole, id, req.AsPatch())
+}
+
+// Patch changes the fields present in req on a user whose role actorRole
+// can manage
+func (s *UserService) Patch(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, req *models.PatchUserRequest) (*models.UserResponse, error) {
 	// Get existing user
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
@@ -73,50 +128,175 @@ func (s *UserService) Update(ctx context.Context, id primitive.ObjectID, req *mo
 		}
 		return nil, errors.ErrInternalServer
 	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+	before := *user
 
 	// Update fields if provided
-	if req.Username != "" {
-		user.Username = req.Username
+	if req.Username != nil {
+		user.Username = *req.Username
 	}
-	if req.Email != "" {
-		user.Email = req.Email
+	if req.Email != nil {
+		user.Email = *req.Email
 	}
-	if req.FirstName != "" {
-		user.FirstName = req.FirstName
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
 	}
-	if req.LastName != "" {
-		user.LastName = req.LastName
+	if req.LastName != nil {
+		user.LastName = *req.LastName
 	}
-	if req.Role != "" {
-		user.Role = req.Role
+	if req.Role != nil {
+		if err := checkRole(*req.Role); err != nil {
+			return nil, err
+		}
+		if !models.CanManageRole(actorRole, *req.Role) {
+			return nil, errors.ErrRoleNotManageable
+		}
+		user.Role = *req.Role
 	}
-	if req.Avatar != "" {
-		user.Avatar = req.Avatar
+	if req.Avatar != nil {
+		user.Avatar = *req.Avatar
 	}
-	if req.IsActive != nil {
-		user.IsActive = *req.IsActive
+	if req.Locale != nil {
+		user.Locale = *req.Locale
+	}
+	if err := beforeUserWrite(ctx, BeforeUpdate, user, &before); err != nil {
+		return nil, err
 	}
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, errors.ErrInternalServer
 	}
+	s.history.Record(ctx, actorID, &before, user)
+	afterUserWrite(ctx, AfterUpdate, user, &before)
 
 	return user.ToResponse(), nil
 }
 
-func (s *UserService) Delete(ctx context.Context, id primitive.ObjectID) error {
+// UpdatePreferences replaces the user's own preferences
+func (s *UserService) UpdatePreferences(ctx context.Context, id primitive.ObjectID, req *models.UpdatePreferencesRequest) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	before := *user
+
+	user.Preferences = models.UserPreferences{
+		LoginAlertsOptOut: *req.LoginAlertsOptOut,
+	}
+	if err := beforeUserWrite(ctx, BeforeUpdate, user, &before); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdatePreferences(ctx, id, user.Preferences); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, id, &before, user)
+	afterUserWrite(ctx, AfterUpdate, user, &before)
+	return user.ToResponse(), nil
+}
+
+// UpdateMetadata applies patch to the user's metadata as a JSON merge
+// patch: null removes a key and objects are merged
+func (s *UserService) UpdateMetadata(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, patch map[string]any) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+	before := *user
+
+	user.Metadata = models.MergeMetadata(user.Metadata, patch)
+	if err := models.ValidateMetadata(user.Metadata); err != nil {
+		return nil, errors.ErrInvalidMetadata
+	}
+	if err := beforeUserWrite(ctx, BeforeUpdate, user, &before); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateMetadata(ctx, id, user.Metadata); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, actorID, &before, user)
+	afterUserWrite(ctx, AfterUpdate, user, &before)
+	return user.ToResponse(), nil
+}
+
+// Delete removes a user whose role actorRole can manage
+func (s *UserService) Delete(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID) error {
 	// Check if user exists
-	if _, err := s.userRepo.GetByID(ctx, id); err != nil {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return errors.ErrUserNotFound
 		}
 		return errors.ErrInternalServer
 	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return errors.ErrRoleNotManageable
+	}
+	if err := beforeUserWrite(ctx, BeforeDelete, user, nil); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return errors.ErrInternalServer
+	}
 
-	return s.userRepo.Delete(ctx, id)
+	// Leave a tombstone so sync clients learn about the deletion
+	if err := s.tombstoneRepo.Create(ctx, "users", id); err != nil {
+		log.Printf("failed to record tombstone for user %s: %v", id.Hex(), err)
+	}
+	s.history.RecordDelete(ctx, actorID, user)
+	afterUserWrite(ctx, AfterDelete, user, nil)
+	return nil
 }
 
-func (s *UserService) List(ctx context.Context, page, limit int) (*models.PaginatedResponse, error) {
+// List returns a page of users, newest first, limited to the active
+// members of orgID when it is set and to holders of every one of tags
+func (s *UserService) List(ctx context.Context, orgID *primitive.ObjectID, tags []string, page, limit int) (*models.PaginatedResponse, error) {
+	var filter interfaces.UserFilter
+	if len(tags) > 0 {
+		normalized, err := models.NormalizeTags(tags)
+		if err != nil {
+			return nil, errors.ErrInvalidTags
+		}
+		filter.Tags = normalized
+	}
+	if orgID != nil {
+		ids, err := s.membershipRepo.MemberIDs(ctx, *orgID)
+		if err != nil {
+			return nil, errors.ErrInternalServer
+		}
+		filter.IDs = ids
+	}
+	return s.list(ctx, filter, page, limit)
+}
+
+// Search finds users whose username, email or names match query, most
+// relevant first
+func (s *UserService) Search(ctx context.Context, query string, page, limit int) (*models.PaginatedResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.ErrInvalidInput
+	}
+	return s.list(ctx, interfaces.UserFilter{Search: query}, page, limit)
+}
+
+func (s *UserService) list(ctx context.Context, filter interfaces.UserFilter, page, limit int) (*models.PaginatedResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -124,7 +304,7 @@ func (s *UserService) List(ctx context.Context, page, limit int) (*models.Pagina
 		limit = 10
 	}
 
-	users, total, err := s.userRepo.List(ctx, page, limit)
+	users, total, err := s.userRepo.List(ctx, filter, page, limit)
 	if err != nil {
 		return nil, errors.ErrInternalServer
 	}
@@ -149,3 +329,177 @@ func (s *UserService) List(ctx context.Context, page, limit int) (*models.Pagina
 		},
 	}, nil
 }
+
+// GetBatch fetches many users with a single query and reports which of the
+// requested IDs were not found
+func (s *UserService) GetBatch(ctx context.Context, hexIDs []string) (*models.BatchUserResponse, error) {
+	ids, err := parseUserIDs(hexIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	found := make(map[primitive.ObjectID]*models.User, len(users))
+	for _, user := range users {
+		found[user.ID] = user
+	}
+
+	result := &models.BatchUserResponse{
+		Users:   make([]*models.UserResponse, 0, len(users)),
+		Missing: []string{},
+	}
+	for _, id := range ids {
+		if user, ok := found[id]; ok {
+			result.Users = append(result.Users, user.ToResponse())
+		} else {
+			result.Missing = append(result.Missing, id.Hex())
+		}
+	}
+	return result, nil
+}
+
+// parseUserIDs parses hexIDs, dropping duplicates but keeping their order
+func parseUserIDs(hexIDs []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(hexIDs))
+	seen := make(map[primitive.ObjectID]bool, len(hexIDs))
+	for _, hexID := range hexIDs {
+		id, err := primitive.ObjectIDFromHex(hexID)
+		if err != nil {
+			return nil, errors.ErrInvalidInput
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// aggregateCacheTTL keeps dashboard refreshes from re-running the pipeline
+const aggregateCacheTTL = time.Minute
+
+// Aggregate counts users grouped by role, status or created_month.
+// Results are cached per dimension for aggregateCacheTTL.
+func (s *UserService) Aggregate(ctx context.Context, groupBy string) (*models.UserAggregateResponse, error) {
+	switch groupBy {
+	case "role", "status", "created_month":
+	default:
+		return nil, errors.ErrInvalidInput
+	}
+
+	s.aggregateMu.Lock()
+	cached, ok := s.aggregateCache[groupBy]
+	s.aggregateMu.Unlock()
+	if ok && time.Since(cached.GeneratedAt) < aggregateCacheTTL {
+		return cached, nil
+	}
+
+	buckets, err := s.userRepo.CountBy(ctx, groupBy)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	result := &models.UserAggregateResponse{
+		GroupBy:     groupBy,
+		Buckets:     buckets,
+		GeneratedAt: time.Now(),
+	}
+
+	s.aggregateMu.Lock()
+	s.aggregateCache[groupBy] = result
+	s.aggregateMu.Unlock()
+
+	return result, nil
+}
+
+// statsDays is how many days of signups Stats reports, today included
+const statsDays = 30
+
+// Stats returns the dashboard figures: total and active users, users per
+// role and signups per UTC day over the last statsDays days. Like
+// Aggregate, results are cached for aggregateCacheTTL.
+func (s *UserService) Stats(ctx context.Context) (*models.UserStats, error) {
+	s.aggregateMu.Lock()
+	cached := s.statsCache
+	s.aggregateMu.Unlock()
+	if cached != nil && time.Since(cached.GeneratedAt) < aggregateCacheTTL {
+		return cached, nil
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first := today.AddDate(0, 0, 1-statsDays)
+	stats, err := s.userRepo.Stats(ctx, first)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	// fill in the days without signups so charts get an unbroken series
+	counts := make(map[string]int64, len(stats.SignupsPerDay))
+	for _, day := range stats.SignupsPerDay {
+		counts[day.Date] = day.Count
+	}
+	stats.SignupsPerDay = make([]models.DailyCount, statsDays)
+	for i := range stats.SignupsPerDay {
+		date := first.AddDate(0, 0, i).Format("2006-01-02")
+		stats.SignupsPerDay[i] = models.DailyCount{Date: date, Count: counts[date]}
+	}
+	stats.GeneratedAt = time.Now()
+
+	s.aggregateMu.Lock()
+	s.statsCache = stats
+	s.aggregateMu.Unlock()
+
+	return stats, nil
+}
+
+const (
+	changesPollInterval = time.Second
+	changesMaxWait      = 30 * time.Second
+	changesBatchSize    = 500
+)
+
+// WaitForChanges returns the IDs of users updated after since, blocking for
+// up to wait until at least one change appears or the caller goes away
+func (s *UserService) WaitForChanges(ctx context.Context, since time.Time, wait time.Duration) (*models.UserChangesResponse, error) {
+	if wait <= 0 || wait > changesMaxWait {
+		wait = changesMaxWait
+	}
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(changesPollInterval)
+	defer ticker.Stop()
+
+	for {
+		users, err := s.userRepo.ChangedSince(ctx, since, changesBatchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, errors.ErrInternalServer
+		}
+		if len(users) > 0 {
+			result := &models.UserChangesResponse{
+				ChangedIDs: make([]string, len(users)),
+				Cursor:     users[len(users)-1].UpdatedAt,
+				HasMore:    len(users) == changesBatchSize,
+			}
+			for i, user := range users {
+				result.ChangedIDs[i] = user.ID.Hex()
+			}
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return &models.UserChangesResponse{ChangedIDs: []string{}, Cursor: since}, nil
+		case <-ticker.C:
+		}
+	}
+}
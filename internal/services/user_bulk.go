@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/auth"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BulkDelete deletes the users among hexIDs. Every before_delete hook must
+// pass before anything is deleted; the users, their tombstones and the
+// audit record are then written in one transaction where the database
+// supports it.
+func (s *UserService) BulkDelete(ctx context.Context, actorID primitive.ObjectID, actorRole string, hexIDs []string) (*models.BulkOperation, error) {
+	users, missing, err := s.bulkTargets(ctx, actorRole, hexIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if err := beforeUserWrite(ctx, BeforeDelete, user, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	operation := newBulkOperation(ctx, actorID, models.BulkActionDelete, users, missing)
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.DeleteMany(ctx, operation.UserIDs); err != nil {
+			return err
+		}
+		for _, id := range operation.UserIDs {
+			if err := s.tombstoneRepo.Create(ctx, "users", id); err != nil {
+				return err
+			}
+		}
+		return s.bulkRepo.Create(ctx, operation)
+	})
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	for _, user := range users {
+		s.history.RecordDelete(ctx, actorID, user)
+		afterUserWrite(ctx, AfterDelete, user, nil)
+	}
+	return operation, nil
+}
+
+// BulkDeactivate deactivates the users among hexIDs; users whose status
+// cannot become deactivated, or whose deletion is pending, are left out. Every before_update hook must pass first; changes
+// hooks make to the users are not saved.
+func (s *UserService) BulkDeactivate(ctx context.Context, actorID primitive.ObjectID, actorRole string, hexIDs []string) (*models.BulkOperation, error) {
+	targets, missing, err := s.bulkTargets(ctx, actorRole, hexIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var before, after []*models.User
+	for _, user := range targets {
+		if !user.Status.CanBecome(models.UserStatusDeactivated) || user.Deletion != nil {
+			continue
+		}
+		updated := *user
+		updated.Status = models.UserStatusDeactivated
+		updated.StatusReason = ""
+		updated.StatusChangedAt = &now
+		if err := beforeUserWrite(ctx, BeforeUpdate, &updated, user); err != nil {
+			return nil, err
+		}
+		before = append(before, user)
+		after = append(after, &updated)
+	}
+
+	operation := newBulkOperation(ctx, actorID, models.BulkActionDeactivate, after, missing)
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.Deactivate(ctx, operation.UserIDs); err != nil {
+			return err
+		}
+		return s.bulkRepo.Create(ctx, operation)
+	})
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	for i := range after {
+		s.history.Record(ctx, actorID, before[i], after[i])
+		afterUserWrite(ctx, AfterUpdate, after[i], before[i])
+	}
+	return operation, nil
+}
+
+// ListBulkOperations returns a page of bulk operations, newest first
+func (s *UserService) ListBulkOperations(ctx context.Context, page, limit int) (*models.PaginatedResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	operations, total, err := s.bulkRepo.List(ctx, page, limit)
+	if err != nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	return &models.PaginatedResponse{
+		Success: true,
+		Message: "Bulk operations retrieved successfully",
+		Data:    operations,
+		Pagination: models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      int(total),
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// bulkTargets loads the users of a bulk operation and lists the requested
+// IDs that do not exist. The whole operation is refused when actorRole
+// cannot manage one of the users.
+func (s *UserService) bulkTargets(ctx context.Context, actorRole string, hexIDs []string) ([]*models.User, []string, error) {
+	ids, err := parseUserIDs(hexIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, errors.ErrInternalServer
+	}
+
+	found := make(map[primitive.ObjectID]bool, len(users))
+	for _, user := range users {
+		if !models.CanManageRole(actorRole, user.Role) {
+			return nil, nil, errors.ErrRoleNotManageable
+		}
+		found[user.ID] = true
+	}
+	missing := []string{}
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id.Hex())
+		}
+	}
+	return users, missing, nil
+}
+
+// newBulkOperation builds the audit record of a bulk operation changing
+// users, attributed like the change history to the request's principal
+func newBulkOperation(ctx context.Context, actorID primitive.ObjectID, action string, users []*models.User, missing []string) *models.BulkOperation {
+	principal := auth.Principal{EffectiveID: actorID, ActorID: actorID, ActorType: auth.ActorUser}
+	if p, ok := auth.PrincipalFrom(ctx); ok && p.EffectiveID == actorID {
+		principal = p
+	}
+	operation := &models.BulkOperation{
+		Action:      action,
+		ActorID:     principal.ActorID,
+		EffectiveID: principal.EffectiveID,
+		ActorType:   principal.ActorType,
+		ClientID:    principal.ClientID,
+		UserIDs:     make([]primitive.ObjectID, 0, len(users)),
+		Missing:     missing,
+	}
+	for _, user := range users {
+		operation.UserIDs = append(operation.UserIDs, user.ID)
+	}
+	return operation
+}
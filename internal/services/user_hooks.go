@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/errors"
+)
+
+// UserHookEvent names the point in a user write where hooks run
+type UserHookEvent string
+
+const (
+	BeforeCreate UserHookEvent = "before_create"
+	AfterCreate  UserHookEvent = "after_create"
+	BeforeUpdate UserHookEvent = "before_update"
+	AfterUpdate  UserHookEvent = "after_update"
+	BeforeDelete UserHookEvent = "before_delete"
+	AfterDelete  UserHookEvent = "after_delete"
+	// AfterAnonymize runs once an erased user's personal data is scrubbed;
+	// Previous holds the user as it was
+	AfterAnonymize UserHookEvent = "after_anonymize"
+)
+
+// UserHookInput describes the write a hook is called for
+type UserHookInput struct {
+	Event UserHookEvent
+	// User is the user being written; Before* hooks may still change it
+	User *models.User
+	// Previous is the stored user before an update, nil otherwise
+	Previous *models.User
+}
+
+// UserHook attaches a side effect (CRM sync, provisioning, ...) to user
+// writes. An error from a Before* hook cancels the write: *errors.AppError
+// values reach the client as they are, anything else as an internal error.
+// Errors from After* hooks are logged, since the write has already happened.
+type UserHook func(ctx context.Context, input *UserHookInput) error
+
+var (
+	userHooksMu sync.RWMutex
+	userHooks   = map[UserHookEvent][]UserHook{}
+)
+
+// RegisterUserHook runs hook on every user write of the given event, for
+// admin and self-service writes alike. It is meant to be called at startup;
+// hooks run in registration order. Changes made through emailed links
+// (email verification, password resets) do not run hooks.
+func RegisterUserHook(event UserHookEvent, hook UserHook) {
+	userHooksMu.Lock()
+	defer userHooksMu.Unlock()
+	userHooks[event] = append(userHooks[event], hook)
+}
+
+// runUserHooks runs the hooks for event, stopping at the first error
+func runUserHooks(ctx context.Context, event UserHookEvent, user, previous *models.User) error {
+	userHooksMu.RLock()
+	hooks := userHooks[event]
+	userHooksMu.RUnlock()
+
+	input := &UserHookInput{Event: event, User: user, Previous: previous}
+	for _, hook := range hooks {
+		if err := hook(ctx, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// beforeUserWrite runs the Before* hooks and maps their error to the one
+// returned to the caller
+func beforeUserWrite(ctx context.Context, event UserHookEvent, user, previous *models.User) error {
+	err := runUserHooks(ctx, event, user, previous)
+	if err == nil {
+		return nil
+	}
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appErr
+	}
+	log.Printf("%s hook for user %s: %v", event, user.ID.Hex(), err)
+	return errors.ErrInternalServer
+}
+
+// afterUserWrite runs the After* hooks, logging their failure
+func afterUserWrite(ctx context.Context, event UserHookEvent, user, previous *models.User) {
+	if err := runUserHooks(ctx, event, user, previous); err != nil {
+		log.Printf("%s hook for user %s: %v", event, user.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"user-management-api/internal/models"
+	"user-management-api/internal/repository/interfaces"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UserStatusService moves users through their lifecycle (see
+// models.UserStatus) and signs out users who stop being active
+type UserStatusService struct {
+	userRepo    interfaces.UserRepository
+	sessionRepo interfaces.SessionRepository
+	tokenRepo   interfaces.PersonalAccessTokenRepository
+	history     *HistoryService
+}
+
+func NewUserStatusService(userRepo interfaces.UserRepository, sessionRepo interfaces.SessionRepository, tokenRepo interfaces.PersonalAccessTokenRepository, history *HistoryService) *UserStatusService {
+	return &UserStatusService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		tokenRepo:   tokenRepo,
+		history:     history,
+	}
+}
+
+// Change moves a user to req.Status, keeping req.Reason with it. The change
+// must be an allowed transition, actorRole must be able to manage the user,
+// and users pending deletion go through ErasureService.Cancel instead.
+func (s *UserStatusService) Change(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, req *models.ChangeUserStatusRequest) (*models.UserResponse, error) {
+	if actorID == id {
+		return nil, errors.ErrOwnStatus
+	}
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if user.AnonymizedAt != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+	if user.Deletion != nil {
+		return nil, errors.ErrDeletionPending
+	}
+	if !user.Status.CanBecome(req.Status) {
+		return nil, errors.ErrInvalidStatusTransition
+	}
+
+	now := time.Now()
+	updated := *user
+	updated.Status = req.Status
+	updated.StatusReason = req.Reason
+	updated.StatusChangedAt = &now
+	if err := beforeUserWrite(ctx, BeforeUpdate, &updated, user); err != nil {
+		return nil, err
+	}
+
+	changed, err := s.userRepo.SetStatus(ctx, id, user.Status, req.Status, req.Reason, now)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// the status changed or a deletion was requested meanwhile
+			return nil, errors.ErrInvalidStatusTransition
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, actorID, user, changed)
+	afterUserWrite(ctx, AfterUpdate, changed, user)
+	return changed.ToResponse(), nil
+}
+
+// SignOutDeactivated is an AfterUpdate hook that ends the sessions and
+// revokes the access tokens of users who just stopped being active,
+// whether through Change or a bulk deactivation
+func (s *UserStatusService) SignOutDeactivated(ctx context.Context, input *UserHookInput) error {
+	if input.Previous == nil || !input.Previous.IsActive() || input.User.IsActive() {
+		return nil
+	}
+	signOutEverywhere(ctx, s.sessionRepo, s.tokenRepo, input.User.ID, "now "+string(input.User.Status))
+	return nil
+}
+
+// signOutEverywhere ends a user's sessions and revokes its personal access
+// tokens. The user is already locked out, so failures are logged rather
+// than returned; why completes the log message.
+func signOutEverywhere(ctx context.Context, sessionRepo interfaces.SessionRepository, tokenRepo interfaces.PersonalAccessTokenRepository, userID primitive.ObjectID, why string) {
+	sessions, err := sessionRepo.ListActive(ctx, userID)
+	if err == nil && len(sessions) > 0 {
+		ids := make([]primitive.ObjectID, len(sessions))
+		for i, session := range sessions {
+			ids[i] = session.ID
+		}
+		err = sessionRepo.Revoke(ctx, ids...)
+	}
+	if err != nil {
+		log.Printf("failed to end sessions of user %s %s: %v", userID.Hex(), why, err)
+	}
+	if err := tokenRepo.DeleteByUser(ctx, userID); err != nil {
+		log.Printf("failed to revoke access tokens of user %s %s: %v", userID.Hex(), why, err)
+	}
+}
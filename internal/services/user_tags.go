@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"user-management-api/internal/models"
+	"user-management-api/pkg/errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AddTags attaches tags to a user whose role actorRole can manage. Tags the
+// user already has are left as they are.
+func (s *UserService) AddTags(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, tags []string) (*models.UserResponse, error) {
+	tags, err := models.NormalizeTags(tags)
+	if err != nil {
+		return nil, errors.ErrInvalidTags
+	}
+	user, err := s.taggableUser(ctx, actorRole, id)
+	if err != nil {
+		return nil, err
+	}
+
+	held := make(map[string]bool, len(user.Tags))
+	for _, tag := range user.Tags {
+		held[tag] = true
+	}
+	updated := *user
+	updated.Tags = append([]string(nil), user.Tags...)
+	for _, tag := range tags {
+		if !held[tag] {
+			updated.Tags = append(updated.Tags, tag)
+		}
+	}
+	if len(updated.Tags) > models.MaxUserTags {
+		return nil, errors.ErrTooManyTags
+	}
+	if len(updated.Tags) == len(user.Tags) {
+		return user.ToResponse(), nil
+	}
+	if err := beforeUserWrite(ctx, BeforeUpdate, &updated, user); err != nil {
+		return nil, err
+	}
+
+	changed, err := s.userRepo.AddTags(ctx, id, tags)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, actorID, user, changed)
+	afterUserWrite(ctx, AfterUpdate, changed, user)
+	return changed.ToResponse(), nil
+}
+
+// RemoveTag detaches a tag from a user whose role actorRole can manage;
+// removing a tag the user does not have changes nothing
+func (s *UserService) RemoveTag(ctx context.Context, actorID primitive.ObjectID, actorRole string, id primitive.ObjectID, tag string) (*models.UserResponse, error) {
+	tags, err := models.NormalizeTags([]string{tag})
+	if err != nil {
+		return nil, errors.ErrInvalidTags
+	}
+	user, err := s.taggableUser(ctx, actorRole, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *user
+	updated.Tags = nil
+	for _, held := range user.Tags {
+		if held != tags[0] {
+			updated.Tags = append(updated.Tags, held)
+		}
+	}
+	if len(updated.Tags) == len(user.Tags) {
+		return user.ToResponse(), nil
+	}
+	if err := beforeUserWrite(ctx, BeforeUpdate, &updated, user); err != nil {
+		return nil, err
+	}
+
+	changed, err := s.userRepo.RemoveTags(ctx, id, tags)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	s.history.Record(ctx, actorID, user, changed)
+	afterUserWrite(ctx, AfterUpdate, changed, user)
+	return changed.ToResponse(), nil
+}
+
+// taggableUser loads the user behind id if actorRole can manage it
+func (s *UserService) taggableUser(ctx context.Context, actorRole string, id primitive.ObjectID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, errors.ErrInternalServer
+	}
+	if user.AnonymizedAt != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !models.CanManageRole(actorRole, user.Role) {
+		return nil, errors.ErrRoleNotManageable
+	}
+	return user, nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ClaimsSubject identifies the user a token is being issued for
+type ClaimsSubject struct {
+	UserID primitive.ObjectID
+	Email  string
+	Role   string
+}
+
+// ClaimsHook returns extra claims (tenant, plan, permissions, ...) to add to
+// a user token. Values must marshal to JSON; an error aborts issuing the
+// token.
+type ClaimsHook func(subject ClaimsSubject) (map[string]any, error)
+
+var (
+	claimsHooksMu sync.RWMutex
+	claimsHooks   []ClaimsHook
+)
+
+// RegisterClaimsHook adds hook to every user token issued from now on. It
+// is meant to be called at startup; hooks run in registration order and a
+// later hook wins when two set the same claim. Custom claims are carried
+// in the "ext" claim so they can never clash with the built-in ones.
+func RegisterClaimsHook(hook ClaimsHook) {
+	claimsHooksMu.Lock()
+	defer claimsHooksMu.Unlock()
+	claimsHooks = append(claimsHooks, hook)
+}
+
+// applyClaimsHooks fills claims.Custom from the registered hooks
+func applyClaimsHooks(claims *JWTClaims) error {
+	claimsHooksMu.RLock()
+	hooks := claimsHooks
+	claimsHooksMu.RUnlock()
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	subject := ClaimsSubject{UserID: claims.UserID, Email: claims.Email, Role: claims.Role}
+	custom := make(map[string]json.RawMessage)
+	for _, hook := range hooks {
+		values, err := hook(subject)
+		if err != nil {
+			return fmt.Errorf("jwt: claims hook: %w", err)
+		}
+		for name, value := range values {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("jwt: custom claim %q: %w", name, err)
+			}
+			custom[name] = raw
+		}
+	}
+	if len(custom) > 0 {
+		claims.Custom = custom
+	}
+	return nil
+}
+
+// CustomClaim decodes the custom claim name into T. It reports false when
+// the token has no such claim or it does not decode as T.
+func CustomClaim[T any](claims *JWTClaims, name string) (T, bool) {
+	var value T
+	raw, ok := claims.Custom[name]
+	if !ok {
+		return value, false
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		var zero T
+		return zero, false
+	}
+	return value, true
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is a single entry of the key ring, identified by its kid
+type signingKey struct {
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+var (
+	ErrUnknownKeyID = errors.New("jwt: unknown key id")
+
+	keyRingMu sync.RWMutex
+	keyRing   = map[string]signingKey{}
+	activeKID string
+)
+
+// SetHMACSigningKeys replaces the key ring with the given HMAC secrets.
+// New tokens are signed with activeKID and carry it in the kid header,
+// while tokens signed by any other key in the ring still validate. This
+// lets a key be rotated by adding the new one, making it active, and
+// dropping the old one once its tokens have expired.
+func SetHMACSigningKeys(keys map[string]string, active string) error {
+	if _, ok := keys[active]; !ok {
+		return fmt.Errorf("jwt: active key id %q is not in the key set", active)
+	}
+
+	ring := make(map[string]signingKey, len(keys))
+	for kid, secret := range keys {
+		if secret == "" {
+			return fmt.Errorf("jwt: key %q has an empty secret", kid)
+		}
+		ring[kid] = signingKey{
+			method:    jwt.SigningMethodHS256,
+			signKey:   []byte(secret),
+			verifyKey: []byte(secret),
+		}
+	}
+
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing = ring
+	activeKID = active
+	return nil
+}
+
+// activeSigningKey returns the key new tokens are signed with, if any
+func activeSigningKey() (string, signingKey, bool) {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+	if activeKID == "" {
+		return "", signingKey{}, false
+	}
+	key, ok := keyRing[activeKID]
+	return activeKID, key, ok
+}
+
+// lookupVerifyKey resolves the verification key for a parsed token from its
+// kid header, falling back to the legacy shared secret for kid-less tokens
+func lookupVerifyKey(token *jwt.Token, secret string) (any, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); !isHMAC {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return []byte(secret), nil
+	}
+
+	keyRingMu.RLock()
+	key, found := keyRing[kid]
+	keyRingMu.RUnlock()
+	if !found {
+		return nil, ErrUnknownKeyID
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.verifyKey, nil
+}
+
+// SetAsymmetricSigningKey adds a PEM encoded RSA (RS256) or Ed25519 (EdDSA)
+// private key to the key ring under kid and makes it the active key. Keys
+// already in the ring stay valid for verification, so tokens issued with
+// the previous HMAC secrets keep working until they expire. Other services
+// only need the public half to verify tokens.
+func SetAsymmetricSigningKey(kid, alg string, privatePEM []byte) error {
+	if kid == "" {
+		return errors.New("jwt: asymmetric key requires a key id")
+	}
+
+	var key signingKey
+	switch alg {
+	case "RS256":
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return fmt.Errorf("jwt: parse RSA private key: %w", err)
+		}
+		key = signingKey{method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}
+	case "EdDSA":
+		private, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return fmt.Errorf("jwt: parse Ed25519 private key: %w", err)
+		}
+		edKey, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return errors.New("jwt: key is not an Ed25519 private key")
+		}
+		key = signingKey{method: jwt.SigningMethodEdDSA, signKey: edKey, verifyKey: edKey.Public()}
+	default:
+		return fmt.Errorf("jwt: unsupported asymmetric algorithm %q", alg)
+	}
+
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing[kid] = key
+	activeKID = kid
+	return nil
+}
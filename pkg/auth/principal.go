@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actor types of a Principal
+const (
+	// ActorUser is a user acting as themselves
+	ActorUser = "user"
+	// ActorImpersonator is an admin holding an impersonation token
+	ActorImpersonator = "impersonator"
+	// ActorDelegate is a user acting under another user's access grant
+	ActorDelegate = "delegate"
+	// ActorClient is an OAuth client, on its own or with a user's token
+	ActorClient = "client"
+)
+
+// Principal tells audit records who is behind a request. EffectiveID is
+// the user the request acts as; the actor is who actually made it.
+// ActorID is zero when the actor is a client, which is named by ClientID.
+type Principal struct {
+	EffectiveID primitive.ObjectID
+	ActorID     primitive.ObjectID
+	ActorType   string
+	ClientID    string
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal stored in ctx by WithPrincipal
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// PrincipalFromClaims derives the principal of a request made with a user
+// token
+func PrincipalFromClaims(claims *JWTClaims) Principal {
+	p := Principal{EffectiveID: claims.UserID, ActorID: claims.UserID, ActorType: ActorUser}
+	switch {
+	case claims.ImpersonatedBy != nil:
+		p.ActorID, p.ActorType = *claims.ImpersonatedBy, ActorImpersonator
+	case claims.ClientID != "":
+		p.ActorID, p.ActorType, p.ClientID = primitive.NilObjectID, ActorClient, claims.ClientID
+	}
+	return p
+}
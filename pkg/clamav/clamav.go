@@ -0,0 +1,136 @@
+// Package clamav scans files for viruses with a clamd daemon, reached over
+// TCP or a unix socket. Files are streamed to clamd with its INSTREAM
+// command, so they never have to be readable by the daemon itself.
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrSizeLimit is returned when a file is larger than clamd's
+// StreamMaxLength setting
+var ErrSizeLimit = errors.New("clamav: file exceeds clamd's StreamMaxLength")
+
+// chunkSize is how much of the file is sent to clamd at a time
+const chunkSize = 64 << 10
+
+// Result is the verdict on a scanned file. Signature names the virus found
+// in an infected file.
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Client talks to one clamd daemon, opening a connection per scan
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// New returns a client for the clamd listening at address, either
+// tcp://host:port or unix:///path/to/clamd.ctl; a bare path is taken as a
+// unix socket. clamd must take each part of a file, and answer once it
+// has the whole file, within timeout.
+func New(address string, timeout time.Duration) (*Client, error) {
+	if strings.HasPrefix(address, "/") {
+		return &Client{network: "unix", address: address, timeout: timeout}, nil
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("clamav: invalid address %q", address)
+	}
+	switch {
+	case u.Scheme == "tcp" && u.Host != "":
+		return &Client{network: "tcp", address: u.Host, timeout: timeout}, nil
+	case u.Scheme == "unix" && u.Path != "":
+		return &Client{network: "unix", address: u.Path, timeout: timeout}, nil
+	}
+	return nil, fmt.Errorf("clamav: address %q must be tcp://host:port or unix:///path", address)
+}
+
+// Address is where clamd is reached, for logs
+func (c *Client) Address() string {
+	return c.network + "://" + c.address
+}
+
+// Scan streams r to clamd and returns its verdict. An error means the file
+// could not be scanned, not that it is infected. Only clamd is held to the
+// timeout: r may be an upload still arriving, which takes as long as the
+// client needs.
+func (c *Client) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamav: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if _, err := c.write(conn, []byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("clamav: %w", err)
+	}
+	// every chunk is preceded by its length; an empty chunk ends the stream
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := c.write(conn, buf[:4+n]); err != nil {
+				// clamd hangs up once the stream passes its limit
+				return c.reply(conn, err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+	if _, err := c.write(conn, []byte{0, 0, 0, 0}); err != nil {
+		return c.reply(conn, err)
+	}
+	return c.reply(conn, nil)
+}
+
+// write sends b to clamd, which must take it within the timeout
+func (c *Client) write(conn net.Conn, b []byte) (int, error) {
+	conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	return conn.Write(b)
+}
+
+// reply reads clamd's answer, such as "stream: OK" or "stream: Eicar-Test
+// FOUND". writeErr is the error that cut the stream short, if any; it is
+// returned when clamd did not say why.
+func (c *Client) reply(conn net.Conn, writeErr error) (*Result, error) {
+	conn.SetReadDeadline(time.Now().Add(c.timeout))
+	line, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && line == "" {
+		if writeErr != nil {
+			return nil, fmt.Errorf("clamav: %w", writeErr)
+		}
+		return nil, fmt.Errorf("clamav: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\x00")
+	status := strings.TrimPrefix(line, "stream: ")
+	switch {
+	case status == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(status, " FOUND")}, nil
+	case strings.Contains(status, "size limit exceeded"):
+		return nil, ErrSizeLimit
+	}
+	return nil, fmt.Errorf("clamav: unexpected reply %q", line)
+}
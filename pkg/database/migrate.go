@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrate brings documents written by earlier releases up to date. Each
+// step only matches documents still in the old shape, so running it on
+// every start is cheap once they have been converted.
+func migrate(ctx context.Context, db *mongo.Database) error {
+	return migrateUserStatus(ctx, db.Collection("users"))
+}
+
+// migrateUserStatus replaces the is_active flag of users with a status:
+// invited users become pending, active ones active and the rest
+// deactivated. Pending deletions remember the status to restore.
+func migrateUserStatus(ctx context.Context, users *mongo.Collection) error {
+	statusOf := func(active string) bson.M {
+		return bson.M{"$switch": bson.M{
+			"branches": bson.A{
+				bson.M{"case": bson.M{"$eq": bson.A{active, true}}, "then": "active"},
+				bson.M{"case": bson.M{"$gt": bson.A{"$invitation", nil}}, "then": "pending"},
+			},
+			"default": "deactivated",
+		}}
+	}
+
+	// deletions first: the user's own is_active was already cleared when
+	// the deletion was requested
+	_, err := users.UpdateMany(ctx,
+		bson.M{"deletion": bson.M{"$exists": true}, "deletion.previous_status": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"deletion.previous_status": statusOf("$deletion.was_active")}}},
+			{{Key: "$unset", Value: "deletion.was_active"}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = users.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"status": statusOf("$is_active")}}},
+			{{Key: "$unset", Value: "is_active"}},
+		},
+	)
+	return err
+}
@@ -3,8 +3,10 @@ package database
 import (
 	"context"
 	"time"
+	"user-management-api/pkg/timing"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -14,23 +16,29 @@ type MongoDB struct {
 	Database *mongo.Database
 }
 
-func NewMongoDB(uri, dbName string, timeout time.Duration) (*MongoDB, error) {
+func NewMongoDB(uri, dbName string, timeout, tombstoneRetention time.Duration) (*MongoDB, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetMonitor(timingMonitor()))
 
 	if err != nil {
 		return nil, err
 	}
 
-	// ping db
+	// ping db; a failed attempt must not leave its connection pool behind
 	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
 		return nil, err
 	}
 
 	db := client.Database(dbName)
 
-	if err := createIndexes(ctx, db); err != nil {
+	if err := createIndexes(ctx, db, tombstoneRetention); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	if err := migrate(ctx, db); err != nil {
+		client.Disconnect(context.Background())
 		return nil, err
 	}
 	return &MongoDB{
@@ -39,7 +47,44 @@ func NewMongoDB(uri, dbName string, timeout time.Duration) (*MongoDB, error) {
 	}, nil
 }
 
-func createIndexes(ctx context.Context, db *mongo.Database) error {
+// ConnectWithRetry calls NewMongoDB until it succeeds, waiting a second
+// after the first failure and twice as long after each further one, up to
+// maxInterval. onRetry is told about every failure. It gives up only when
+// ctx is done.
+func ConnectWithRetry(ctx context.Context, uri, dbName string, timeout, tombstoneRetention, maxInterval time.Duration, onRetry func(attempt int, err error, wait time.Duration)) (*MongoDB, error) {
+	wait := time.Second
+	for attempt := 1; ; attempt++ {
+		db, err := NewMongoDB(uri, dbName, timeout, tombstoneRetention)
+		if err == nil {
+			return db, nil
+		}
+		if wait > maxInterval {
+			wait = maxInterval
+		}
+		onRetry(attempt, err, wait)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// timingMonitor charges the time of every database command to the
+// repository layer of the request it was issued for
+func timingMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			timing.Add(ctx, timing.LayerRepository, evt.Duration)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			timing.Add(ctx, timing.LayerRepository, evt.Duration)
+		},
+	}
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database, tombstoneRetention time.Duration) error {
 	userCollection := db.Collection("users")
 
 	// Create unique index on email
@@ -59,10 +104,228 @@ func createIndexes(ctx context.Context, db *mongo.Database) error {
 		Keys: bson.D{{Key: "created_at", Value: -1}},
 	}
 
+	// Create index on updated_at for change polling
+	updatedAtIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "updated_at", Value: 1}},
+	}
+
+	// Text index for user search, weighted towards the account identifiers
+	searchIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "username", Value: "text"},
+			{Key: "email", Value: "text"},
+			{Key: "first_name", Value: "text"},
+			{Key: "last_name", Value: "text"},
+		},
+		Options: options.Index().SetName("user_search").SetWeights(bson.M{"username": 3, "email": 3}),
+	}
+
+	// Sparse index on pending deletions, polled for the ones that are due
+	deletionIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "deletion.due_at", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	// Users are listed, exported and counted by status
+	statusIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}},
+	}
+
+	// Multikey index for listing users by tag
+	tagsIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "tags", Value: 1}},
+	}
+
 	_, err := userCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		emailIndex,
 		usernameIndex,
 		createdAtIndex,
+		updatedAtIndex,
+		searchIndex,
+		deletionIndex,
+		statusIndex,
+		tagsIndex,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Grants are looked up by owner and grantee on every delegated request
+	_, err = db.Collection("grants").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "grantee_id", Value: 1}}},
+		{Keys: bson.D{{Key: "grantee_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Authorization codes are looked up by hash and expire on their own
+	_, err = db.Collection("oauth_codes").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "code_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Client IDs are presented on every token request
+	_, err = db.Collection("oauth_clients").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "client_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sessions double as the sign-in history checked for new IPs and devices
+	_, err = db.Collection("sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "ip", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "user_agent", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("user_history").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "changed_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Audit logs are read per record, newest first, and purged by age
+	_, err = db.Collection("audit_logs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "resource", Value: 1}, {Key: "resource_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// IP ban records drop out once an address has been quiet long enough
+	_, err = db.Collection("ip_bans").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Auth events are queried by user, actor or type within a date range
+	_, err = db.Collection("auth_events").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Roles are referenced by name from users
+	_, err = db.Collection("roles").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Policies are unique per subject, path pattern and method
+	_, err = db.Collection("policies").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "subject", Value: 1}, {Key: "object", Value: 1}, {Key: "action", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Memberships are unique per organization and user, and listed for
+	// either side
+	_, err = db.Collection("memberships").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Bulk operations are listed newest first
+	_, err = db.Collection("bulk_operations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Projects are listed per owner, newest first
+	_, err = db.Collection("projects").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Identities are found by provider account at sign-in; each provider
+	// account belongs to one user and each user has one per provider
+	_, err = db.Collection("identities").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "provider", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Trusted devices are checked at every sign-in and expire on their own
+	_, err = db.Collection("trusted_devices").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "fingerprint", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Personal access tokens are looked up by hash on every request they
+	// authenticate, listed per user, and expire on their own
+	_, err = db.Collection("personal_access_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// One-time tokens are looked up by hash, kept one per purpose and
+	// subject, and expire on their own
+	_, err = db.Collection("one_time_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "purpose", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Accepted SAML assertion IDs only matter while the assertion is valid
+	_, err = db.Collection("saml_assertions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Tombstones are read in deletion order and expire after the retention
+	_, err = db.Collection("tombstones").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "collection", Value: 1}, {Key: "deleted_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(tombstoneRetention.Seconds())),
+		},
 	})
 
 	return err
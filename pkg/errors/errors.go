@@ -9,6 +9,9 @@ type AppError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Type    string `json:"type"`
+	// Details carries request-specific context, such as the failing fields
+	// of a validation error
+	Details any `json:"details,omitempty"`
 }
 
 func (e *AppError) Error() string {
@@ -23,13 +26,98 @@ func NewAppError(code int, message, errorType string) *AppError {
 	}
 }
 
+// WithDetails returns a copy of e carrying details, leaving the shared
+// error value untouched
+func (e *AppError) WithDetails(details any) *AppError {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
 // common error types
 
 var (
-	ErrInvalidCredentials = NewAppError(http.StatusUnauthorized, "Invalid credentials", "INVALID_CREDENTIALS")
-	ErrUnAuthorized       = NewAppError(http.StatusUnauthorized, "Unauthorized access", "UNAUTHORIZED")
-	ErrUserNotFound       = NewAppError(http.StatusNotFound, "User not found", "USER_NOT_FOUND")
-	ErrUserExists         = NewAppError(http.StatusConflict, "User already exists", "USER_EXISTS")
-	ErrInvalidInput       = NewAppError(http.StatusBadRequest, "Invalid input", "INVALID_INPUT")
-	ErrInternalServer     = NewAppError(http.StatusInternalServerError, "Internal server error", "INTERNAL")
+	ErrInvalidCredentials      = NewAppError(http.StatusUnauthorized, "Invalid credentials", "INVALID_CREDENTIALS")
+	ErrUnAuthorized            = NewAppError(http.StatusUnauthorized, "Unauthorized access", "UNAUTHORIZED")
+	ErrUserNotFound            = NewAppError(http.StatusNotFound, "User not found", "USER_NOT_FOUND")
+	ErrUserExists              = NewAppError(http.StatusConflict, "User already exists", "USER_EXISTS")
+	ErrInvalidInput            = NewAppError(http.StatusBadRequest, "Invalid input", "INVALID_INPUT")
+	ErrInternalServer          = NewAppError(http.StatusInternalServerError, "Internal server error", "INTERNAL")
+	ErrForbidden               = NewAppError(http.StatusForbidden, "Insufficient permissions", "FORBIDDEN")
+	ErrGrantNotFound           = NewAppError(http.StatusNotFound, "Grant not found", "GRANT_NOT_FOUND")
+	ErrCannotImpersonate       = NewAppError(http.StatusForbidden, "This user cannot be impersonated", "CANNOT_IMPERSONATE")
+	ErrSyncTokenExpired        = NewAppError(http.StatusGone, "Sync token expired, a full resync is required", "SYNC_TOKEN_EXPIRED")
+	ErrClientNotFound          = NewAppError(http.StatusNotFound, "Client not found", "CLIENT_NOT_FOUND")
+	ErrInvalidClient           = NewAppError(http.StatusUnauthorized, "Client authentication failed", "invalid_client")
+	ErrUnsupportedGrant        = NewAppError(http.StatusBadRequest, "Unsupported grant type", "unsupported_grant_type")
+	ErrInvalidScope            = NewAppError(http.StatusBadRequest, "Requested scope is not allowed", "invalid_scope")
+	ErrTemplateNotFound        = NewAppError(http.StatusNotFound, "Export template not found", "TEMPLATE_NOT_FOUND")
+	ErrInvalidExport           = NewAppError(http.StatusBadRequest, "Unknown export column or date format", "INVALID_EXPORT")
+	ErrReportNotFound          = NewAppError(http.StatusNotFound, "Report not found", "REPORT_NOT_FOUND")
+	ErrReportNotReady          = NewAppError(http.StatusConflict, "Report is not ready yet", "REPORT_NOT_READY")
+	ErrQueueFull               = NewAppError(http.StatusServiceUnavailable, "Too many background jobs, try again later", "QUEUE_FULL")
+	ErrInvalidToken            = NewAppError(http.StatusBadRequest, "Invalid or expired token", "INVALID_TOKEN")
+	ErrInvalidGrant            = NewAppError(http.StatusBadRequest, "Authorization code is invalid or expired", "invalid_grant")
+	ErrInvalidRedirectURI      = NewAppError(http.StatusBadRequest, "Redirect URI is not registered for this client", "invalid_request")
+	ErrUnsupportedResponseType = NewAppError(http.StatusBadRequest, "Only the code response type with S256 PKCE is supported", "unsupported_response_type")
+	ErrSessionLimit            = NewAppError(http.StatusConflict, "Maximum number of active sessions reached", "SESSION_LIMIT")
+	ErrPasswordExpired         = NewAppError(http.StatusForbidden, "Password has expired and must be changed", "PASSWORD_EXPIRED")
+	ErrPasswordChangeRequired  = NewAppError(http.StatusForbidden, "Password was reset by an administrator and must be changed", "PASSWORD_CHANGE_REQUIRED")
+	ErrInvalidTags             = NewAppError(http.StatusBadRequest, "Tags must be at most 40 characters of lowercase letters, digits and _ : . -, starting with a letter or digit", "INVALID_TAGS")
+	ErrTooManyTags             = NewAppError(http.StatusConflict, "A user can have at most 32 tags", "TOO_MANY_TAGS")
+	ErrInvalidAvatar           = NewAppError(http.StatusBadRequest, "Avatar must be a JPEG, PNG, GIF or WebP image of at most 40 megapixels", "INVALID_AVATAR")
+	ErrDirectUploadUnsupported = NewAppError(http.StatusNotImplemented, "Direct uploads need S3 or GCS storage", "DIRECT_UPLOAD_UNSUPPORTED")
+	ErrUnknownUploadProfile    = NewAppError(http.StatusBadRequest, "Unknown upload profile, or one that does not take direct uploads", "UNKNOWN_UPLOAD_PROFILE")
+	ErrFileNotAllowed          = NewAppError(http.StatusBadRequest, "The upload profile does not accept this file", "FILE_VALIDATION_FAILED")
+	ErrUploadNotFound          = NewAppError(http.StatusNotFound, "No file was uploaded to the presigned URL", "UPLOAD_NOT_FOUND")
+	ErrFileNotFound            = NewAppError(http.StatusNotFound, "File not found", "FILE_NOT_FOUND")
+	ErrFileInfected            = NewAppError(http.StatusUnprocessableEntity, "The file is infected", "FILE_INFECTED")
+	ErrScanFailed              = NewAppError(http.StatusServiceUnavailable, "File could not be scanned for viruses, try again later", "SCAN_FAILED")
+	ErrInvalidChecksum         = NewAppError(http.StatusBadRequest, "Checksum must be a hex-encoded SHA-256", "INVALID_CHECKSUM")
+	ErrChecksumMismatch        = NewAppError(http.StatusBadRequest, "The file does not match its checksum", "CHECKSUM_MISMATCH")
+	ErrInvalidVisibility       = NewAppError(http.StatusBadRequest, "Visibility must be public or private", "INVALID_VISIBILITY")
+	ErrFileTooLarge            = NewAppError(http.StatusRequestEntityTooLarge, "The file is larger than the upload profile allows", "FILE_TOO_LARGE")
+	ErrResumableUploadNotFound = NewAppError(http.StatusNotFound, "Upload not found or expired", "RESUMABLE_UPLOAD_NOT_FOUND")
+	ErrUploadOffsetMismatch    = NewAppError(http.StatusConflict, "Upload-Offset does not match the bytes received so far", "UPLOAD_OFFSET_MISMATCH")
+	ErrUploadLocked            = NewAppError(http.StatusLocked, "Another request is writing to this upload", "UPLOAD_LOCKED")
+	ErrInvalidMetadata         = NewAppError(http.StatusBadRequest, "Metadata is limited to 16 KiB, 64 top-level keys and 5 levels of nesting; keys must not start with $ or contain dots", "INVALID_METADATA")
+	ErrTokenNotFound           = NewAppError(http.StatusNotFound, "Access token not found", "TOKEN_NOT_FOUND")
+	ErrTokenLimit              = NewAppError(http.StatusConflict, "Maximum number of access tokens reached", "TOKEN_LIMIT")
+	ErrDeviceNotFound          = NewAppError(http.StatusNotFound, "Device not found", "DEVICE_NOT_FOUND")
+	ErrProjectNotFound         = NewAppError(http.StatusNotFound, "Project not found", "PROJECT_NOT_FOUND")
+	ErrProviderNotFound        = NewAppError(http.StatusNotFound, "Identity provider not found", "PROVIDER_NOT_FOUND")
+	ErrIdentityNotFound        = NewAppError(http.StatusNotFound, "Identity not found", "IDENTITY_NOT_FOUND")
+	ErrIdentityLinked          = NewAppError(http.StatusConflict, "This account is already linked to another user", "IDENTITY_ALREADY_LINKED")
+	ErrProviderAlreadyLinked   = NewAppError(http.StatusConflict, "Another account of this provider is already linked", "PROVIDER_ALREADY_LINKED")
+	ErrIdentityEmailConflict   = NewAppError(http.StatusConflict, "The email of this account belongs to another user", "IDENTITY_EMAIL_CONFLICT")
+	ErrIdentityNotLinked       = NewAppError(http.StatusUnauthorized, "No user is linked to this account", "IDENTITY_NOT_LINKED")
+	ErrLastSignInMethod        = NewAppError(http.StatusConflict, "Cannot unlink the only way to sign in to this account", "LAST_SIGN_IN_METHOD")
+	ErrInvalidExpand           = NewAppError(http.StatusBadRequest, "Unknown relation in expand", "INVALID_EXPAND")
+	ErrRegistrationThrottled   = NewAppError(http.StatusTooManyRequests, "Too many registrations. Please try again later.", "REGISTRATION_THROTTLED")
+	ErrRoleNotFound            = NewAppError(http.StatusNotFound, "Role not found", "ROLE_NOT_FOUND")
+	ErrRoleExists              = NewAppError(http.StatusConflict, "Role already exists", "ROLE_EXISTS")
+	ErrRoleInUse               = NewAppError(http.StatusConflict, "Role is still assigned to users", "ROLE_IN_USE")
+	ErrRoleProtected           = NewAppError(http.StatusForbidden, "Built-in roles cannot be deleted and admin roles cannot be changed", "ROLE_PROTECTED")
+	ErrRoleInherited           = NewAppError(http.StatusConflict, "Role is still inherited by other roles", "ROLE_INHERITED")
+	ErrRoleCycle               = NewAppError(http.StatusBadRequest, "A role cannot inherit itself", "ROLE_CYCLE")
+	ErrRoleNotManageable       = NewAppError(http.StatusForbidden, "Users with this role can only be managed by a higher role", "ROLE_NOT_MANAGEABLE")
+	ErrUnknownRole             = NewAppError(http.StatusBadRequest, "Unknown role", "UNKNOWN_ROLE")
+	ErrUnknownPermission       = NewAppError(http.StatusBadRequest, "Unknown permission", "UNKNOWN_PERMISSION")
+	ErrPolicyNotFound          = NewAppError(http.StatusNotFound, "Policy not found", "POLICY_NOT_FOUND")
+	ErrPolicyExists            = NewAppError(http.StatusConflict, "A policy for this subject, object and action already exists", "POLICY_EXISTS")
+	ErrOrganizationNotFound    = NewAppError(http.StatusNotFound, "Organization not found", "ORGANIZATION_NOT_FOUND")
+	ErrMembershipNotFound      = NewAppError(http.StatusNotFound, "Member not found", "MEMBERSHIP_NOT_FOUND")
+	ErrInvitationNotFound      = NewAppError(http.StatusNotFound, "No pending invitation to this organization", "INVITATION_NOT_FOUND")
+	ErrAlreadyMember           = NewAppError(http.StatusConflict, "User is already a member or invited", "ALREADY_MEMBER")
+	ErrLastOwner               = NewAppError(http.StatusConflict, "An organization must keep at least one owner", "LAST_OWNER")
+	ErrOrgForbidden            = NewAppError(http.StatusForbidden, "Your role in this organization does not allow this", "ORG_FORBIDDEN")
+	ErrSSOFailed               = NewAppError(http.StatusUnauthorized, "Single sign-on failed", "SSO_FAILED")
+	ErrDeletionNotPending      = NewAppError(http.StatusConflict, "The user has no deletion pending within its grace period", "DELETION_NOT_PENDING")
+	ErrDeletionPending         = NewAppError(http.StatusConflict, "The user's deletion is pending; cancel it first", "DELETION_PENDING")
+	ErrInvalidStatusTransition = NewAppError(http.StatusConflict, "The user cannot change from its current status to the requested one", "INVALID_STATUS_TRANSITION")
+	ErrOwnStatus               = NewAppError(http.StatusForbidden, "You cannot change your own status", "OWN_STATUS")
+	ErrUserNotActive           = NewAppError(http.StatusConflict, "Only active users can have their password reset", "USER_NOT_ACTIVE")
+	ErrInvalidRequest          = NewAppError(http.StatusBadRequest, "Invalid request body", "INVALID_REQUEST")
+	ErrInvalidQuery            = NewAppError(http.StatusBadRequest, "Invalid query parameters", "INVALID_QUERY")
+	ErrValidationFailed        = NewAppError(http.StatusBadRequest, "Validation failed", "VALIDATION_FAILED")
 )
@@ -0,0 +1,307 @@
+// Package export renders rows of any record type into tabular formats. A
+// Table describes the columns a record type can be exported with; callers
+// pick a subset and order of those columns and a date format per export.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrUnknownColumn = errors.New("export: unknown column")
+	ErrUnknownFormat = errors.New("export: unknown format")
+)
+
+// Output formats accepted by Table.NewWriter
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Named date formats accepted in Options.DateFormat
+var DateFormats = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04:05",
+	"unix":     "unix",
+}
+
+const DefaultDateFormat = "rfc3339"
+
+// Column extracts one exported field from a record
+type Column[T any] struct {
+	Key    string
+	Header string
+	Value  func(T) any
+}
+
+// Table is the set of columns available for a record type, in their default
+// order
+type Table[T any] struct {
+	columns []Column[T]
+	byKey   map[string]Column[T]
+}
+
+func NewTable[T any](columns ...Column[T]) *Table[T] {
+	byKey := make(map[string]Column[T], len(columns))
+	for _, col := range columns {
+		byKey[col.Key] = col
+	}
+	return &Table[T]{columns: columns, byKey: byKey}
+}
+
+// Keys returns every column key in default order
+func (t *Table[T]) Keys() []string {
+	keys := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		keys[i] = col.Key
+	}
+	return keys
+}
+
+// Select returns the named columns in the given order, or every column when
+// keys is empty
+func (t *Table[T]) Select(keys []string) ([]Column[T], error) {
+	if len(keys) == 0 {
+		return t.columns, nil
+	}
+	selected := make([]Column[T], 0, len(keys))
+	for _, key := range keys {
+		col, ok := t.byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownColumn, key)
+		}
+		selected = append(selected, col)
+	}
+	return selected, nil
+}
+
+// Options controls a single export
+type Options struct {
+	Columns    []string
+	DateFormat string
+}
+
+// Validate checks the options against the table without writing anything, so
+// errors can still be reported before a response is started
+func (t *Table[T]) Validate(opts Options) error {
+	if _, err := t.Select(opts.Columns); err != nil {
+		return err
+	}
+	if _, err := dateLayout(opts.DateFormat); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Writer writes records one at a time in some output format
+type Writer[T any] interface {
+	Write(row T) error
+	// Flush finishes the output and reports the first write error
+	Flush() error
+}
+
+// NewWriter returns a writer for format, FormatCSV when empty
+func (t *Table[T]) NewWriter(w io.Writer, format string, opts Options) (Writer[T], error) {
+	switch format {
+	case "", FormatCSV:
+		return t.NewCSVWriter(w, opts)
+	case FormatJSON:
+		return t.NewJSONWriter(w, opts)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
+
+// CSVWriter writes records one at a time so large exports never have to be
+// held in memory
+type CSVWriter[T any] struct {
+	w       *csv.Writer
+	columns []Column[T]
+	layout  string
+	record  []string
+}
+
+// NewCSVWriter writes the header row for the selected columns and returns a
+// writer for the records
+func (t *Table[T]) NewCSVWriter(w io.Writer, opts Options) (*CSVWriter[T], error) {
+	columns, err := t.Select(opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := dateLayout(opts.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	cw := &CSVWriter[T]{
+		w:       csv.NewWriter(w),
+		columns: columns,
+		layout:  layout,
+		record:  make([]string, len(columns)),
+	}
+	for i, col := range columns {
+		cw.record[i] = col.Header
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *CSVWriter[T]) Write(row T) error {
+	for i, col := range cw.columns {
+		cw.record[i] = formatValue(col.Value(row), cw.layout)
+	}
+	return cw.w.Write(cw.record)
+}
+
+// Flush writes any buffered data and reports the first write error
+func (cw *CSVWriter[T]) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// JSONWriter writes records as a JSON array of objects keyed by column,
+// one record at a time. Dates are formatted like in CSV; other values keep
+// their JSON types.
+type JSONWriter[T any] struct {
+	w       *bufio.Writer
+	columns []Column[T]
+	keys    [][]byte
+	layout  string
+	rows    int
+	err     error
+}
+
+// NewJSONWriter starts the array and returns a writer for its elements
+func (t *Table[T]) NewJSONWriter(w io.Writer, opts Options) (*JSONWriter[T], error) {
+	columns, err := t.Select(opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := dateLayout(opts.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	jw := &JSONWriter[T]{
+		w:       bufio.NewWriter(w),
+		columns: columns,
+		keys:    make([][]byte, len(columns)),
+		layout:  layout,
+	}
+	for i, col := range columns {
+		if jw.keys[i], err = json.Marshal(col.Key); err != nil {
+			return nil, err
+		}
+	}
+	jw.w.WriteByte('[')
+	return jw, nil
+}
+
+func (jw *JSONWriter[T]) Write(row T) error {
+	if jw.err != nil {
+		return jw.err
+	}
+	if jw.rows > 0 {
+		jw.w.WriteByte(',')
+	}
+	jw.rows++
+	jw.w.WriteByte('{')
+	for i, col := range jw.columns {
+		if i > 0 {
+			jw.w.WriteByte(',')
+		}
+		value, err := json.Marshal(jsonValue(col.Value(row), jw.layout))
+		if err != nil {
+			jw.err = err
+			return err
+		}
+		jw.w.Write(jw.keys[i])
+		jw.w.WriteByte(':')
+		jw.w.Write(value)
+	}
+	// bufio.Writer keeps its first error and reports it from here on
+	if err := jw.w.WriteByte('}'); err != nil {
+		jw.err = err
+	}
+	return jw.err
+}
+
+// Flush closes the array and writes any buffered data
+func (jw *JSONWriter[T]) Flush() error {
+	if jw.err != nil {
+		return jw.err
+	}
+	jw.w.WriteByte(']')
+	return jw.w.Flush()
+}
+
+func dateLayout(name string) (string, error) {
+	if name == "" {
+		name = DefaultDateFormat
+	}
+	layout, ok := DateFormats[name]
+	if !ok {
+		return "", fmt.Errorf("export: unknown date format %q", name)
+	}
+	return layout, nil
+}
+
+// jsonValue formats dates like formatValue and leaves other values to
+// encoding/json
+func jsonValue(v any, layout string) any {
+	switch val := v.(type) {
+	case time.Time:
+		if val.IsZero() {
+			return nil
+		}
+		if layout == "unix" {
+			return val.Unix()
+		}
+		return val.UTC().Format(layout)
+	case *time.Time:
+		if val == nil {
+			return nil
+		}
+		return jsonValue(*val, layout)
+	default:
+		return v
+	}
+}
+
+func formatValue(v any, layout string) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		if layout == "unix" {
+			return strconv.FormatInt(val.Unix(), 10)
+		}
+		return val.UTC().Format(layout)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return formatValue(*val, layout)
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return fmt.Sprint(val)
+	}
+}
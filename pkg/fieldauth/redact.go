@@ -0,0 +1,102 @@
+// Package fieldauth hides response fields from requesters lacking the
+// permission declared on them, so one response struct can serve every
+// audience.
+//
+// A field is guarded with an authz tag naming the permission:
+//
+//	Email string `json:"email" authz:"users:pii"`
+//
+// Redact clears guarded fields in place. When the struct has a
+// `Redacted []string` field, the JSON names of the cleared fields are
+// appended to it so clients can tell a withheld value from an empty one.
+package fieldauth
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Viewer is the requester a response is redacted for
+type Viewer struct {
+	ID          primitive.ObjectID
+	Permissions []string
+}
+
+// Owned is implemented by records describing a user, who always sees
+// every field of their own record
+type Owned interface {
+	OwnerID() primitive.ObjectID
+}
+
+// Redact clears the guarded fields viewer may not see in v, which is
+// walked through pointers, interfaces, slices, maps and nested structs.
+// Only values reachable through a pointer can be changed; v itself should
+// be a pointer or hold pointers.
+func Redact(v any, viewer Viewer) {
+	redact(reflect.ValueOf(v), viewer)
+}
+
+func redact(v reflect.Value, viewer Viewer) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			redact(v.Elem(), viewer)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i), viewer)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			redact(iter.Value(), viewer)
+		}
+	case reflect.Struct:
+		redactStruct(v, viewer)
+	}
+}
+
+func redactStruct(v reflect.Value, viewer Viewer) {
+	owner := false
+	if v.CanAddr() {
+		if owned, ok := v.Addr().Interface().(Owned); ok {
+			owner = owned.OwnerID() == viewer.ID
+		}
+	}
+
+	t := v.Type()
+	var redacted []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		permission := field.Tag.Get("authz")
+		if permission == "" || owner || slices.Contains(viewer.Permissions, permission) {
+			redact(v.Field(i), viewer)
+			continue
+		}
+		if !v.Field(i).CanSet() {
+			continue
+		}
+		v.Field(i).SetZero()
+		redacted = append(redacted, jsonName(field))
+	}
+
+	if len(redacted) > 0 {
+		if list := v.FieldByName("Redacted"); list.IsValid() && list.CanSet() && list.Type() == reflect.TypeOf([]string(nil)) {
+			list.Set(reflect.AppendSlice(list, reflect.ValueOf(redacted)))
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,91 @@
+package filesafe
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+var (
+	ErrInvalidArchive   = errors.New("filesafe: archive is corrupt")
+	ErrTooManyEntries   = errors.New("filesafe: archive has too many entries")
+	ErrArchiveTooLarge  = errors.New("filesafe: archive expands beyond the size limit")
+	ErrCompressionRatio = errors.New("filesafe: archive compression ratio is too high")
+)
+
+// ArchiveLimits bound what an archive may expand to. MaxRatio compares the
+// expanded size with the size of the archive itself.
+type ArchiveLimits struct {
+	MaxEntries int
+	MaxBytes   int64
+	MaxRatio   int64
+}
+
+// budget returns how many bytes an archive of size bytes may expand to and
+// the error to report past it
+func (l ArchiveLimits) budget(size int64) (int64, error) {
+	if byRatio := size * l.MaxRatio; byRatio < l.MaxBytes {
+		return byRatio, ErrCompressionRatio
+	}
+	return l.MaxBytes, ErrArchiveTooLarge
+}
+
+// CheckZip checks the ZIP archive in r against limits. The sizes entries
+// declare are checked first, then every entry is decompressed, since the
+// declared sizes can lie.
+func CheckZip(r io.ReaderAt, size int64, limits ArchiveLimits) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return ErrInvalidArchive
+	}
+	if len(archive.File) > limits.MaxEntries {
+		return ErrTooManyEntries
+	}
+	budget, errOverBudget := limits.budget(size)
+
+	var declared uint64
+	for _, file := range archive.File {
+		declared += file.UncompressedSize64
+		if declared > uint64(budget) {
+			return errOverBudget
+		}
+	}
+
+	remaining := budget
+	for _, file := range archive.File {
+		entry, err := file.Open()
+		if err != nil {
+			return ErrInvalidArchive
+		}
+		n, err := io.Copy(io.Discard, io.LimitReader(entry, remaining+1))
+		entry.Close()
+		if err != nil {
+			return ErrInvalidArchive
+		}
+		if n > remaining {
+			return errOverBudget
+		}
+		remaining -= n
+	}
+	return nil
+}
+
+// CheckGzip checks the gzip stream in r, of size bytes, against limits
+func CheckGzip(r io.Reader, size int64, limits ArchiveLimits) error {
+	stream, err := gzip.NewReader(r)
+	if err != nil {
+		return ErrInvalidArchive
+	}
+	defer stream.Close()
+
+	budget, errOverBudget := limits.budget(size)
+	n, err := io.Copy(io.Discard, io.LimitReader(stream, budget+1))
+	if err != nil {
+		return ErrInvalidArchive
+	}
+	if n > budget {
+		return errOverBudget
+	}
+	return nil
+}
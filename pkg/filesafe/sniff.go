@@ -0,0 +1,164 @@
+package filesafe
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// SniffLen is how many of a file's first bytes DetectType looks at
+const SniffLen = 3072
+
+var ErrPolyglot = errors.New("filesafe: file also holds content of another type")
+
+// ooxmlTypes are the Office Open XML formats, by the directory of their
+// main part
+var ooxmlTypes = map[string]string{
+	"word": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"xl":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ppt":  "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// legacyTypes map types DetectType tells apart to the type
+// http.DetectContentType reported for them
+var legacyTypes = map[string]string{
+	"image/svg+xml":             "text/xml",
+	"text/csv":                  "text/plain",
+	"text/tab-separated-values": "text/plain",
+	"application/json":          "text/plain",
+}
+
+// markupSignatures are the markup browsers may render, or servers run,
+// when found in the first bytes of a file that is not text
+var markupSignatures = [][]byte{
+	[]byte("<!doctype html"),
+	[]byte("<html"),
+	[]byte("<head"),
+	[]byte("<body"),
+	[]byte("<script"),
+	[]byte("<iframe"),
+	[]byte("<?php"),
+}
+
+// DetectType returns the type of a file from head, its first SniffLen
+// bytes, without parameters such as charset. Unlike
+// http.DetectContentType it recognizes Office documents, SVG images and
+// many other formats. ZIP-based formats are only recognized when their
+// first entries tell them apart: DetectZipType looks at the whole archive.
+func DetectType(head []byte) string {
+	contentType, _, _ := strings.Cut(mimetype.Detect(head).String(), ";")
+	return contentType
+}
+
+// DetectZipType returns the type of the ZIP archive in r, of size bytes,
+// from its entries: Office Open XML documents hold [Content_Types].xml and
+// a word, xl or ppt directory, OpenDocument and EPUB files a "mimetype"
+// entry naming their type. Other archives, corrupt ones included, are
+// application/zip.
+func DetectZipType(r io.ReaderAt, size int64) string {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return "application/zip"
+	}
+	var contentTypes bool
+	var ooxml string
+	for _, file := range archive.File {
+		switch dir, _, _ := strings.Cut(file.Name, "/"); {
+		case file.Name == "mimetype":
+			if contentType := zipMimetype(file); contentType != "" {
+				return contentType
+			}
+		case file.Name == "[Content_Types].xml":
+			contentTypes = true
+		case ooxml == "":
+			ooxml = ooxmlTypes[dir]
+		}
+	}
+	if contentTypes && ooxml != "" {
+		return ooxml
+	}
+	return "application/zip"
+}
+
+// zipMimetype returns the ZIP-based type named by an archive's "mimetype"
+// entry, or "" when it names none
+func zipMimetype(file *zip.File) string {
+	entry, err := file.Open()
+	if err != nil {
+		return ""
+	}
+	defer entry.Close()
+	named, err := io.ReadAll(io.LimitReader(entry, 128))
+	if err != nil {
+		return ""
+	}
+	contentType := strings.TrimSpace(string(named))
+	if contentType == "application/zip" || !IsZip(contentType) {
+		return ""
+	}
+	return contentType
+}
+
+// IsZip reports whether contentType is a ZIP archive or a format built on
+// one, such as Office documents
+func IsZip(contentType string) bool {
+	for m := mimetype.Lookup(contentType); m != nil; m = m.Parent() {
+		if m.Is("application/zip") {
+			return true
+		}
+	}
+	return false
+}
+
+// isText reports whether contentType is plain text or a format written as
+// text, such as HTML, XML, JSON and SVG
+func isText(contentType string) bool {
+	for m := mimetype.Lookup(contentType); m != nil; m = m.Parent() {
+		if m.Is("text/plain") {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeAllowed reports whether contentType, as returned by DetectType, is
+// in allowed under its name or one of its aliases, such as
+// application/x-gzip for application/gzip. Lists written for
+// http.DetectContentType keep working: text/plain allows CSV and JSON
+// files and text/xml SVG images.
+func TypeAllowed(allowed []string, contentType string) bool {
+	m := mimetype.Lookup(contentType)
+	for _, t := range allowed {
+		if t == contentType || (m != nil && m.Is(t)) || legacyTypes[contentType] == t {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPolyglot refuses files that are valid as more than one format,
+// which are used to slip content past type checks: a file that is not text
+// must not start with HTML, script or PHP, whose first bytes are in head,
+// and a file that is not a ZIP archive must not hold one, as a GIF image
+// with a Java archive appended does. r holds the file, of size bytes, and
+// contentType is its type from DetectType.
+func CheckPolyglot(r io.ReaderAt, size int64, head []byte, contentType string) error {
+	if !isText(contentType) {
+		lower := bytes.ToLower(head)
+		for _, signature := range markupSignatures {
+			if bytes.Contains(lower, signature) {
+				return ErrPolyglot
+			}
+		}
+	}
+	if !IsZip(contentType) {
+		if _, err := zip.NewReader(r, size); err == nil {
+			return ErrPolyglot
+		}
+	}
+	return nil
+}
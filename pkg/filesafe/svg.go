@@ -0,0 +1,140 @@
+// Package filesafe makes uploaded files safe to store and serve: types are
+// detected from the contents, files that pass for two types are refused,
+// SVG images are stripped of anything that can run script, and archives
+// are checked against decompression bombs before anyone extracts them.
+package filesafe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrInvalidSVG = errors.New("filesafe: not a well-formed SVG document")
+
+// svgDropElements are removed together with everything inside them
+var svgDropElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"handler":       true,
+	"listener":      true,
+}
+
+// svgSafeDataURIs are the data: URIs links may keep; anything else, SVG
+// included, can carry script
+var svgSafeDataURIs = []string{"data:image/png", "data:image/jpeg", "data:image/gif", "data:image/webp"}
+
+// IsSVG reports whether head, the first bytes of a file, look like an SVG
+// document
+func IsSVG(head []byte) bool {
+	return bytes.Contains(bytes.ToLower(head), []byte("<svg"))
+}
+
+// SanitizeSVG copies the SVG document in r to w without script elements,
+// foreign objects, event handler attributes, javascript: and non-image
+// data: links, comments, processing instructions and DOCTYPE declarations.
+// The root element must be <svg>.
+func SanitizeSVG(w io.Writer, r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+
+	depth, skipDepth, sawRoot := 0, 0, false
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ErrInvalidSVG
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if !sawRoot {
+				if !strings.EqualFold(t.Name.Local, "svg") {
+					return ErrInvalidSVG
+				}
+				sawRoot = true
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			if svgDropElements[strings.ToLower(t.Name.Local)] {
+				skipDepth = depth
+				continue
+			}
+			out.WriteByte('<')
+			out.WriteString(qualifiedName(t.Name))
+			for _, attr := range t.Attr {
+				if !safeSVGAttr(attr) {
+					continue
+				}
+				out.WriteByte(' ')
+				out.WriteString(qualifiedName(attr.Name))
+				out.WriteString(`="`)
+				xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteByte('"')
+			}
+			out.WriteByte('>')
+		case xml.EndElement:
+			depth--
+			if skipDepth > 0 {
+				if depth < skipDepth {
+					skipDepth = 0
+				}
+				continue
+			}
+			out.WriteString("</")
+			out.WriteString(qualifiedName(t.Name))
+			out.WriteByte('>')
+		case xml.CharData:
+			if skipDepth == 0 && sawRoot {
+				xml.EscapeText(&out, t)
+			}
+		}
+	}
+	if !sawRoot || depth != 0 {
+		return ErrInvalidSVG
+	}
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// safeSVGAttr reports whether attr can neither run script nor load it
+func safeSVGAttr(attr xml.Attr) bool {
+	if strings.HasPrefix(strings.ToLower(attr.Name.Local), "on") {
+		return false
+	}
+	// Browsers ignore whitespace and control characters inside URL schemes
+	value := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(attr.Value))
+	if strings.Contains(value, "javascript:") || strings.Contains(value, "vbscript:") {
+		return false
+	}
+	if strings.HasPrefix(value, "data:") {
+		for _, prefix := range svgSafeDataURIs {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -1,10 +1,10 @@
-package middleware
+package httpserver
 
 import (
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
-	"user-management-api/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
@@ -73,10 +73,10 @@ func RateLimitMiddleware(rps rate.Limit, burst int) gin.HandlerFunc {
 		
 		// Check if request is allowed
 		if !clientLimiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, models.APIResponse{
-				Success: false,
-				Message: "Rate limit exceeded. Please try again later.",
-				Error:   "RATE_LIMIT_EXCEEDED",
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"message": "Rate limit exceeded. Please try again later.",
+				"error":   "RATE_LIMIT_EXCEEDED",
 			})
 			c.Abort()
 			return
@@ -86,17 +86,34 @@ func RateLimitMiddleware(rps rate.Limit, burst int) gin.HandlerFunc {
 	}
 }
 
+// RateLimitProfile is a per-client request rate and burst size
+type RateLimitProfile struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+func (p RateLimitProfile) String() string {
+	return fmt.Sprintf("%g req/s, burst %d", float64(p.Rate), p.Burst)
+}
+
+// Rate limit profiles routes choose from
+var (
+	StrictProfile   = RateLimitProfile{Rate: 1, Burst: 2}
+	ModerateProfile = RateLimitProfile{Rate: 10, Burst: 20}
+	LenientProfile  = RateLimitProfile{Rate: 100, Burst: 200}
+)
+
 // StrictRateLimit - Very restrictive (1 req/sec, burst 2)
 func StrictRateLimit() gin.HandlerFunc {
-	return RateLimitMiddleware(1, 2)
+	return RateLimitMiddleware(StrictProfile.Rate, StrictProfile.Burst)
 }
 
 // ModerateRateLimit - Moderate (10 req/sec, burst 20)
 func ModerateRateLimit() gin.HandlerFunc {
-	return RateLimitMiddleware(10, 20)
+	return RateLimitMiddleware(ModerateProfile.Rate, ModerateProfile.Burst)
 }
 
 // LenientRateLimit - Lenient (100 req/sec, burst 200)
 func LenientRateLimit() gin.HandlerFunc {
-	return RateLimitMiddleware(100, 200)
+	return RateLimitMiddleware(LenientProfile.Rate, LenientProfile.Burst)
 }
\ No newline at end of file
@@ -0,0 +1,30 @@
+package httpserver
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Switch is an http.Handler whose handler can be replaced while serving,
+// e.g. to go from a degraded mode to the full application once the
+// services it depends on are up. Requests in flight finish on the handler
+// they started with.
+type Switch struct {
+	current atomic.Pointer[http.Handler]
+}
+
+// NewSwitch returns a Switch serving handler
+func NewSwitch(handler http.Handler) *Switch {
+	s := &Switch{}
+	s.Set(handler)
+	return s
+}
+
+// Set makes the Switch serve handler from now on
+func (s *Switch) Set(handler http.Handler) {
+	s.current.Store(&handler)
+}
+
+func (s *Switch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*s.current.Load()).ServeHTTP(w, r)
+}
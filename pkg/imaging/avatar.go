@@ -0,0 +1,86 @@
+// Package imaging turns uploaded images into the derived images the API
+// stores, such as square avatars and WebP or AVIF copies, and strips the
+// metadata of uploaded images. Images are decoded from JPEG, PNG, GIF and
+// WebP; their dimensions are checked before decoding so a small file
+// cannot claim a huge canvas.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("imaging: not a JPEG, PNG, GIF or WebP image")
+	ErrImageTooLarge    = errors.New("imaging: image dimensions are too large")
+)
+
+// MaxPixels is the largest canvas, in pixels, an image may decode to
+const MaxPixels = 40_000_000
+
+// Avatar decodes the image in src, crops it to a centered square and scales
+// it to size by size pixels, returning it encoded as PNG. The first frame
+// of animated images is used.
+func Avatar(src io.ReadSeeker, size int) ([]byte, error) {
+	img, err := decode(src)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, centeredSquare(img.Bounds()), draw.Src, nil)
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, dst); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// centeredSquare returns the largest square centered in bounds
+func centeredSquare(bounds image.Rectangle) image.Rectangle {
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// decode decodes the image in src after checking that its canvas is at
+// most MaxPixels
+func decode(src io.ReadSeeker) (image.Image, error) {
+	if err := checkDimensions(src); err != nil {
+		return nil, err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	return img, nil
+}
+
+// checkDimensions reads the image header in src and refuses canvases
+// larger than MaxPixels
+func checkDimensions(src io.Reader) error {
+	cfg, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return ErrUnsupportedImage
+	}
+	if cfg.Width < 1 || cfg.Height < 1 {
+		return ErrUnsupportedImage
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return ErrImageTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io"
+)
+
+// StripsMetadata reports whether StripMetadata handles images of
+// contentType
+func StripsMetadata(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp":
+		return true
+	}
+	return false
+}
+
+// StripMetadata copies the JPEG, PNG or WebP image in src to w without its
+// EXIF, XMP, IPTC and text metadata, which can carry GPS positions, camera
+// serial numbers and the like. Pixels are copied untouched, except for
+// JPEG images rotated by their EXIF orientation: dropping the tag would
+// show them sideways, so they are decoded, turned upright and re-encoded.
+// Color profiles are kept. Other types are copied unchanged.
+func StripMetadata(w io.Writer, src io.ReadSeeker, contentType string) error {
+	switch contentType {
+	case "image/jpeg":
+		orientation, err := jpegOrientation(src)
+		if err != nil {
+			return err
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if orientation > 1 && orientation <= 8 {
+			img, err := decode(src)
+			if err != nil {
+				return err
+			}
+			return jpeg.Encode(w, orient(img, orientation), &jpeg.Options{Quality: 90})
+		}
+		return stripJPEG(w, src)
+	case "image/png":
+		return stripPNG(w, src)
+	case "image/webp":
+		return stripWebP(w, src)
+	}
+	_, err := io.Copy(w, src)
+	return err
+}
+
+// jpegSegments calls fn with each marker segment of the JPEG in r up to the
+// start of scan, which it hands over with the reader positioned at the
+// scan data. Standalone markers have no payload.
+func jpegSegments(r *bufio.Reader, fn func(marker byte, payload []byte) error) error {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return ErrUnsupportedImage
+	}
+	for {
+		b, err := r.ReadByte()
+		if err != nil || b != 0xFF {
+			return ErrUnsupportedImage
+		}
+		marker := byte(0xFF)
+		for marker == 0xFF { // fill bytes
+			if marker, err = r.ReadByte(); err != nil {
+				return ErrUnsupportedImage
+			}
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD9) {
+			if err := fn(marker, nil); err != nil || marker == 0xD9 {
+				return err
+			}
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return ErrUnsupportedImage
+		}
+		n := int(binary.BigEndian.Uint16(length[:])) - 2
+		if n < 0 {
+			return ErrUnsupportedImage
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return ErrUnsupportedImage
+		}
+		if err := fn(marker, payload); err != nil || marker == 0xDA {
+			return err
+		}
+	}
+}
+
+// keepJPEGSegment keeps everything but application and comment segments,
+// except the JFIF header, ICC color profiles and the Adobe segment, which
+// decoders need to render colors right
+func keepJPEGSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xFE:
+		return false
+	case marker == 0xE0:
+		return bytes.HasPrefix(payload, []byte("JFIF\x00")) || bytes.HasPrefix(payload, []byte("JFXX\x00"))
+	case marker == 0xE2:
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE:
+		return bytes.HasPrefix(payload, []byte("Adobe"))
+	case marker >= 0xE0 && marker <= 0xEF:
+		return false
+	}
+	return true
+}
+
+func stripJPEG(w io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	if _, err := w.Write([]byte{0xFF, 0xD8}); err != nil {
+		return err
+	}
+	err := jpegSegments(r, func(marker byte, payload []byte) error {
+		if !keepJPEGSegment(marker, payload) {
+			return nil
+		}
+		if payload == nil && marker != 0xDA {
+			_, err := w.Write([]byte{0xFF, marker})
+			return err
+		}
+		header := []byte{0xFF, marker, 0, 0}
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)+2))
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		_, err := w.Write(payload)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// the entropy-coded data and everything after it is copied as is
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// jpegOrientation returns the EXIF orientation of the JPEG in src, or 1
+// when it has none
+func jpegOrientation(src io.Reader) (int, error) {
+	orientation := 1
+	err := jpegSegments(bufio.NewReader(src), func(marker byte, payload []byte) error {
+		if tiff, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00")); ok && marker == 0xE1 {
+			orientation = exifOrientation(tiff)
+		}
+		return nil
+	})
+	return orientation, err
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF
+// structure, returning 1 when it is missing or unreadable
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		// tag 0x0112 holds a single SHORT
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
+
+// orient turns img upright according to its EXIF orientation
+func orient(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// pngMetadata are the PNG chunks that carry metadata rather than pixels
+var pngMetadata = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(w io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	var signature [8]byte
+	if _, err := io.ReadFull(r, signature[:]); err != nil || string(signature[:]) != "\x89PNG\r\n\x1a\n" {
+		return ErrUnsupportedImage
+	}
+	if _, err := w.Write(signature[:]); err != nil {
+		return err
+	}
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return ErrUnsupportedImage
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:])
+
+		// the chunk data is followed by its CRC
+		out := w
+		if pngMetadata[kind] {
+			out = io.Discard
+		} else if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(out, r, length+4); err != nil {
+			if err == io.EOF {
+				return ErrUnsupportedImage
+			}
+			return err
+		}
+		if kind == "IEND" {
+			return nil
+		}
+	}
+}
+
+// webpChunk is a chunk of a WebP file; Size excludes the padding byte
+type webpChunk struct {
+	FourCC string
+	Size   int64
+	Offset int64
+}
+
+// VP8X flags announcing EXIF and XMP chunks
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags. The
+// RIFF header holds the file size, so the chunks are listed first.
+func stripWebP(w io.Writer, src io.ReadSeeker) error {
+	var header [12]byte
+	if _, err := io.ReadFull(src, header[:]); err != nil || string(header[:4]) != "RIFF" || string(header[8:]) != "WEBP" {
+		return ErrUnsupportedImage
+	}
+	riffEnd := 8 + int64(binary.LittleEndian.Uint32(header[4:8]))
+
+	var chunks []webpChunk
+	size := int64(4) // "WEBP"
+	for offset := int64(12); offset < riffEnd; {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(src, chunkHeader[:]); err != nil {
+			return ErrUnsupportedImage
+		}
+		chunk := webpChunk{
+			FourCC: string(chunkHeader[:4]),
+			Size:   int64(binary.LittleEndian.Uint32(chunkHeader[4:])),
+			Offset: offset + 8,
+		}
+		padded := chunk.Size + chunk.Size&1
+		if _, err := src.Seek(padded, io.SeekCurrent); err != nil {
+			return err
+		}
+		offset += 8 + padded
+		if chunk.FourCC == "EXIF" || chunk.FourCC == "XMP " {
+			continue
+		}
+		chunks = append(chunks, chunk)
+		size += 8 + padded
+	}
+
+	binary.LittleEndian.PutUint32(header[4:8], uint32(size))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := src.Seek(chunk.Offset, io.SeekStart); err != nil {
+			return err
+		}
+		var chunkHeader [8]byte
+		copy(chunkHeader[:4], chunk.FourCC)
+		binary.LittleEndian.PutUint32(chunkHeader[4:], uint32(chunk.Size))
+		if _, err := w.Write(chunkHeader[:]); err != nil {
+			return err
+		}
+		data := io.Reader(io.LimitReader(src, chunk.Size+chunk.Size&1))
+		if chunk.FourCC == "VP8X" && chunk.Size > 0 {
+			flags := make([]byte, 1)
+			if _, err := io.ReadFull(src, flags); err != nil {
+				return ErrUnsupportedImage
+			}
+			flags[0] &^= webpFlagEXIF | webpFlagXMP
+			data = io.MultiReader(bytes.NewReader(flags), io.LimitReader(src, chunk.Size+chunk.Size&1-1))
+		}
+		if n, err := io.Copy(w, data); err != nil {
+			return err
+		} else if n != chunk.Size+chunk.Size&1 {
+			return ErrUnsupportedImage
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Formats images can be transcoded to
+const (
+	FormatWebP = "webp"
+	FormatAVIF = "avif"
+)
+
+var ErrEncoderUnavailable = errors.New("imaging: encoder is not available")
+
+// FormatContentType is the MIME type of images in format
+func FormatContentType(format string) string {
+	return "image/" + format
+}
+
+// Transcoder converts JPEG and PNG images to WebP with cwebp and to AVIF
+// with avifenc, the reference encoders, run as separate processes. Only
+// the formats whose encoder was found are available.
+type Transcoder struct {
+	encoders map[string]string
+}
+
+// NewTranscoder looks up the cwebp and avifenc binaries on PATH (or uses
+// them as paths). An encoder that is missing leaves its format
+// unavailable; an empty name skips the lookup.
+func NewTranscoder(cwebp, avifenc string) *Transcoder {
+	t := &Transcoder{encoders: make(map[string]string)}
+	for format, binary := range map[string]string{FormatWebP: cwebp, FormatAVIF: avifenc} {
+		if binary == "" {
+			continue
+		}
+		if path, err := exec.LookPath(binary); err == nil {
+			t.encoders[format] = path
+		}
+	}
+	return t
+}
+
+// Available reports whether images can be transcoded to format
+func (t *Transcoder) Available(format string) bool {
+	_, ok := t.encoders[format]
+	return ok
+}
+
+// Transcodes reports whether images of contentType can be transcoded
+func (t *Transcoder) Transcodes(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png"
+}
+
+// Transcode writes the JPEG or PNG image in the file src to the file dst
+// in format, leaving metadata behind. The image's dimensions are checked
+// first so the encoder is never handed a decompression bomb.
+func (t *Transcoder) Transcode(ctx context.Context, src, dst, format string) error {
+	binary, ok := t.encoders[format]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrEncoderUnavailable, format)
+	}
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	err = checkDimensions(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	switch format {
+	case FormatWebP:
+		args = []string{"-quiet", "-q", "80", "-metadata", "none", src, "-o", dst}
+	case FormatAVIF:
+		args = []string{"--speed", "6", "--ignore-exif", "--ignore-xmp", src, dst}
+	}
+	cmd := exec.CommandContext(ctx, binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", format, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
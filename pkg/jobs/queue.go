@@ -0,0 +1,114 @@
+// Package jobs runs background work on a fixed pool of in-process workers.
+// Jobs are not persisted; callers that need durable state record it
+// themselves before enqueueing.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+var (
+	ErrQueueFull   = errors.New("jobs: queue is full")
+	ErrQueueClosed = errors.New("jobs: queue is shut down")
+)
+
+// Job is a unit of background work. The context is cancelled when the queue
+// shuts down.
+type Job func(ctx context.Context) error
+
+type task struct {
+	name string
+	run  Job
+}
+
+type Queue struct {
+	tasks  chan task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueue starts workers goroutines that take jobs from a buffer of size
+// entries
+func NewQueue(workers, size int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		tasks:  make(chan task, size),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue schedules job without blocking; it fails when the buffer is full
+func (q *Queue) Enqueue(name string, job Job) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.tasks <- task{name: name, run: job}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting jobs and waits for queued ones to finish. If ctx
+// expires first, running jobs are cancelled.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.tasks)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for t := range q.tasks {
+		q.run(t)
+	}
+}
+
+func (q *Queue) run(t task) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("job %s panicked: %v", t.name, r)
+		}
+	}()
+	if err := t.run(q.ctx); err != nil {
+		log.Printf("job %s failed: %v", t.name, err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package oauth is a minimal OAuth2 authorization code client used to
+// sign users in with, and link their accounts to, external identity
+// providers such as Google and GitHub.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Endpoints are the URLs of a provider's authorization code flow
+type Endpoints struct {
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	Scopes      []string
+}
+
+// Presets holds the endpoints of well-known providers, so only client
+// credentials need to be configured for them
+var Presets = map[string]Endpoints{
+	"google": {
+		AuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:    "https://oauth2.googleapis.com/token",
+		UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:      []string{"openid", "email", "profile"},
+	},
+	"github": {
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+}
+
+// UserInfo is the identity a provider vouches for
+type UserInfo struct {
+	// Subject is the provider's stable, unique id for the account
+	Subject string
+	Email   string
+	Name    string
+}
+
+// Provider runs the authorization code flow against one provider
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	Endpoints    Endpoints
+	HTTPClient   *http.Client
+}
+
+// NewProvider returns a provider, filling endpoints that are left empty
+// from the preset of the same name
+func NewProvider(name, clientID, clientSecret string, endpoints Endpoints) (*Provider, error) {
+	preset := Presets[name]
+	if endpoints.AuthURL == "" {
+		endpoints.AuthURL = preset.AuthURL
+	}
+	if endpoints.TokenURL == "" {
+		endpoints.TokenURL = preset.TokenURL
+	}
+	if endpoints.UserInfoURL == "" {
+		endpoints.UserInfoURL = preset.UserInfoURL
+	}
+	if len(endpoints.Scopes) == 0 {
+		endpoints.Scopes = preset.Scopes
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("oauth provider %s requires a client id and secret", name)
+	}
+	if endpoints.AuthURL == "" || endpoints.TokenURL == "" || endpoints.UserInfoURL == "" {
+		return nil, fmt.Errorf("oauth provider %s requires auth, token and userinfo URLs", name)
+	}
+	return &Provider{
+		Name:         name,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoints:    endpoints,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// AuthCodeURL returns the provider URL the browser is sent to. The provider
+// redirects back to redirectURL with a code and the given state.
+func (p *Provider) AuthCodeURL(state, redirectURL string) string {
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {redirectURL},
+		"scope":         {strings.Join(p.Endpoints.Scopes, " ")},
+		"state":         {state},
+	}
+	separator := "?"
+	if strings.Contains(p.Endpoints.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.Endpoints.AuthURL + separator + query.Encode()
+}
+
+// Exchange trades an authorization code for an access token. redirectURL
+// must be the one the code was requested with.
+func (p *Provider) Exchange(ctx context.Context, code, redirectURL string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Endpoints.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := p.do(req, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("oauth %s: token exchange failed: %s", p.Name, token.Error)
+	}
+	return token.AccessToken, nil
+}
+
+// FetchUser returns the identity behind an access token. Both OpenID
+// Connect ("sub") and GitHub style ("id") userinfo responses are accepted.
+func (p *Provider) FetchUser(ctx context.Context, accessToken string) (*UserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Endpoints.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var claims struct {
+		Sub   string      `json:"sub"`
+		ID    json.Number `json:"id"`
+		Email string      `json:"email"`
+		Name  string      `json:"name"`
+	}
+	if err := p.do(req, &claims); err != nil {
+		return nil, err
+	}
+
+	info := &UserInfo{Subject: claims.Sub, Email: strings.ToLower(claims.Email), Name: claims.Name}
+	if info.Subject == "" {
+		info.Subject = claims.ID.String()
+	}
+	if info.Subject == "" {
+		return nil, errors.New("oauth " + p.Name + ": userinfo carries no subject")
+	}
+	return info, nil
+}
+
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth %s: %s returned %d", p.Name, req.URL.Host, resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
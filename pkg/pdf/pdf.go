@@ -0,0 +1,52 @@
+// Package pdf converts rendered HTML documents to PDF. The Renderer
+// interface keeps callers independent of the conversion backend.
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+var ErrRendererUnavailable = errors.New("pdf: renderer is not available")
+
+type Renderer interface {
+	// Render converts a complete HTML document to PDF bytes
+	Render(ctx context.Context, html []byte) ([]byte, error)
+}
+
+// WkhtmltopdfRenderer shells out to the wkhtmltopdf binary, streaming HTML on
+// stdin and reading the PDF from stdout
+type WkhtmltopdfRenderer struct {
+	Binary string
+	Args   []string
+}
+
+// NewWkhtmltopdfRenderer looks up binary on PATH (or uses it as a path)
+func NewWkhtmltopdfRenderer(binary string) (*WkhtmltopdfRenderer, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRendererUnavailable, err)
+	}
+	return &WkhtmltopdfRenderer{
+		Binary: path,
+		// never let report HTML reach the network or the local disk
+		Args: []string{"--quiet", "--disable-external-links", "--disable-local-file-access", "--encoding", "utf-8"},
+	}, nil
+}
+
+func (r *WkhtmltopdfRenderer) Render(ctx context.Context, html []byte) ([]byte, error) {
+	args := append(append([]string{}, r.Args...), "-", "-")
+	cmd := exec.CommandContext(ctx, r.Binary, args...)
+	cmd.Stdin = bytes.NewReader(html)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("wkhtmltopdf: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+// Package policy decides route access from subject, object, action rules
+// in the manner of Casbin's RESTful RBAC model: subjects are role names,
+// objects are URL path patterns and actions are HTTP methods.
+package policy
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Effects of a Rule
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+// Wildcard matches any subject or action
+const Wildcard = "*"
+
+// Rule grants or refuses Subject the Action on Object. Object is a path
+// pattern where a ":name" segment matches any one segment and a final "*"
+// segment matches the rest of the path.
+type Rule struct {
+	Subject string
+	Object  string
+	Action  string
+	Effect  string
+}
+
+// Decision is the outcome of Enforce
+type Decision int
+
+const (
+	// NotApplicable means no rule covers the object and action, so access
+	// is left to the route's own checks
+	NotApplicable Decision = iota
+	// Allowed means a rule allows the subject and none denies it
+	Allowed
+	// Denied means a rule denies the subject, or rules cover the object
+	// and action but none allows the subject
+	Denied
+)
+
+// Enforcer evaluates a rule set that can be replaced at any time
+type Enforcer struct {
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+func NewEnforcer() *Enforcer {
+	return &Enforcer{}
+}
+
+// SetRules replaces every rule
+func (e *Enforcer) SetRules(rules []Rule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = rules
+}
+
+// Enforce decides whether a caller holding subjects, a role and the roles
+// it inherits, may perform action on object. Deny rules take precedence
+// over allow rules.
+func (e *Enforcer) Enforce(subjects []string, object, action string) Decision {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	decision := NotApplicable
+	for _, rule := range e.rules {
+		if !matchAction(rule.Action, action) || !KeyMatch(object, rule.Object) {
+			continue
+		}
+		if decision == NotApplicable {
+			decision = Denied
+		}
+		if rule.Subject != Wildcard && !slices.Contains(subjects, rule.Subject) {
+			continue
+		}
+		if rule.Effect == Deny {
+			return Denied
+		}
+		decision = Allowed
+	}
+	return decision
+}
+
+func matchAction(pattern, action string) bool {
+	return pattern == Wildcard || strings.EqualFold(pattern, action)
+}
+
+// KeyMatch reports whether path matches pattern, like Casbin's keyMatch2:
+// "/users/:id" matches "/users/42", and "/users/*" matches "/users" and
+// everything below it
+func KeyMatch(path, pattern string) bool {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i, part := range patternParts {
+		if part == Wildcard && i == len(patternParts)-1 {
+			return len(pathParts) >= i
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") && pathParts[i] != "" {
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return len(pathParts) == len(patternParts)
+}
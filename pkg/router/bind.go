@@ -0,0 +1,267 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// maxFormMemory is how much of a multipart body is held in memory, the
+// rest of its files going to temporary files
+const maxFormMemory = 32 << 20
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+// Decode decodes r into v the way its request suggests: GET requests from
+// the query string, JSON bodies, or when no content type is given, with
+// DecodeJSON, and other bodies as forms
+func Decode(r *http.Request, v any) error {
+	if r.Method == http.MethodGet {
+		return DecodeQuery(r, v)
+	}
+	switch ContentType(r) {
+	case "", "application/json":
+		return DecodeJSON(r, v)
+	default:
+		return DecodeForm(r, v)
+	}
+}
+
+// DecodeJSON decodes the JSON body of r into v and validates the binding
+// tags of v. An empty body is io.EOF.
+func DecodeJSON(r *http.Request, v any) error {
+	if r.Body == nil {
+		return errors.New("invalid request")
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return err
+	}
+	return validateBinding(v)
+}
+
+// DecodeQuery sets the fields of the struct v points to from the query
+// string of r by their form tags and validates their binding tags
+func DecodeQuery(r *http.Request, v any) error {
+	if err := decodeValues(v, r.URL.Query(), nil); err != nil {
+		return err
+	}
+	return validateBinding(v)
+}
+
+// DecodeForm sets the fields of the struct v points to from the
+// url-encoded or multipart body of r, and its query string, by their form
+// tags and validates their binding tags. Multipart files bind to
+// *multipart.FileHeader and []*multipart.FileHeader fields.
+func DecodeForm(r *http.Request, v any) error {
+	if err := r.ParseMultipartForm(maxFormMemory); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+	var files map[string][]*multipart.FileHeader
+	if r.MultipartForm != nil {
+		files = r.MultipartForm.File
+	}
+	if err := decodeValues(v, r.Form, files); err != nil {
+		return err
+	}
+	return validateBinding(v)
+}
+
+// ContentType returns the media type of the request body without
+// parameters, empty when none is given
+func ContentType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// validateBinding checks the binding tags of v, a struct or a slice of
+// them; other values are not validated
+func validateBinding(v any) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		validateOnce.Do(func() {
+			validate = validator.New()
+			validate.SetTagName("binding")
+		})
+		return validate.Struct(value.Interface())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := validateBinding(value.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// decodeValues sets the fields of the struct v points to from values and
+// files. Fields are named by their form tag, "-" skipping them, or else by
+// their Go name; untagged struct fields are filled in turn.
+func decodeValues(v any, values url.Values, files map[string][]*multipart.FileHeader) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("router: cannot decode a form into %T", v)
+	}
+	return decodeStruct(target.Elem(), values, files)
+}
+
+func decodeStruct(target reflect.Value, values url.Values, files map[string][]*multipart.FileHeader) error {
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, hasTag := field.Tag.Lookup("form")
+		if tag == "-" {
+			continue
+		}
+		value := target.Field(i)
+		if !hasTag && field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			if err := decodeStruct(value, values, files); err != nil {
+				return err
+			}
+			continue
+		}
+		name := field.Name
+		if tag != "" {
+			name, _, _ = strings.Cut(tag, ",")
+		}
+
+		switch field.Type {
+		case fileHeaderType:
+			if found := files[name]; len(found) > 0 {
+				value.Set(reflect.ValueOf(found[0]))
+			}
+			continue
+		case fileHeadersType:
+			if found := files[name]; len(found) > 0 {
+				value.Set(reflect.ValueOf(found))
+			}
+			continue
+		}
+		found, ok := values[name]
+		if !ok || len(found) == 0 {
+			continue
+		}
+		if err := setField(value, field, found); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField sets value, of field, from the strings given for it
+func setField(value reflect.Value, field reflect.StructField, found []string) error {
+	switch value.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(value.Type().Elem())
+		if err := setField(elem.Elem(), field, found); err != nil {
+			return err
+		}
+		value.Set(elem)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(value.Type(), len(found), len(found))
+		for i, s := range found {
+			if err := setScalar(slice.Index(i), field, s); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	default:
+		return setScalar(value, field, found[0])
+	}
+}
+
+func setScalar(value reflect.Value, field reflect.StructField, s string) error {
+	if value.Type() == timeType {
+		if s == "" {
+			return nil
+		}
+		layout := field.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			s = "false"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			s = "0"
+		}
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
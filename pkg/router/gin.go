@@ -0,0 +1,29 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin adapts h to gin, the default backend
+func Gin(h HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h(ginContext{c})
+	}
+}
+
+type ginContext struct {
+	c *gin.Context
+}
+
+func (g ginContext) Request() *http.Request               { return g.c.Request }
+func (g ginContext) Param(name string) string             { return g.c.Param(name) }
+func (g ginContext) Query(name string) string             { return g.c.Query(name) }
+func (g ginContext) ClientIP() string                     { return g.c.ClientIP() }
+func (g ginContext) BindJSON(v any) error                 { return DecodeJSON(g.c.Request, v) }
+func (g ginContext) Header(key, value string)             { g.c.Header(key, value) }
+func (g ginContext) Status(code int)                      { g.c.Status(code) }
+func (g ginContext) JSON(status int, v any)               { g.c.JSON(status, v) }
+func (g ginContext) Redirect(status int, location string) { g.c.Redirect(status, location) }
+func (g ginContext) Writer() http.ResponseWriter          { return g.c.Writer }
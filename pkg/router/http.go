@@ -0,0 +1,73 @@
+package router
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+)
+
+// ParamFunc reads a path parameter the way a net/http router stores it;
+// with chi, pass chi.URLParam
+type ParamFunc func(r *http.Request, name string) string
+
+// PathValue reads path parameters set by http.ServeMux patterns
+func PathValue(r *http.Request, name string) string {
+	return r.PathValue(name)
+}
+
+// HTTP adapts h to net/http, reading path parameters with param
+func HTTP(h HandlerFunc, param ParamFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h(&httpContext{w: w, r: r, param: param})
+	})
+}
+
+type httpContext struct {
+	w     http.ResponseWriter
+	r     *http.Request
+	param ParamFunc
+}
+
+func (h *httpContext) Request() *http.Request   { return h.r }
+func (h *httpContext) Param(name string) string { return h.param(h.r, name) }
+func (h *httpContext) Query(name string) string { return h.r.URL.Query().Get(name) }
+
+// ClientIP returns the peer address: proxies are not trusted, so put
+// middleware such as chi's RealIP in front when running behind one
+func (h *httpContext) ClientIP() string {
+	host, _, err := net.SplitHostPort(h.r.RemoteAddr)
+	if err != nil {
+		return h.r.RemoteAddr
+	}
+	return host
+}
+
+func (h *httpContext) BindJSON(v any) error {
+	return DecodeJSON(h.r, v)
+}
+
+func (h *httpContext) Header(key, value string) {
+	if value == "" {
+		h.w.Header().Del(key)
+		return
+	}
+	h.w.Header().Set(key, value)
+}
+
+func (h *httpContext) Status(code int) {
+	h.w.WriteHeader(code)
+}
+
+func (h *httpContext) JSON(status int, v any) {
+	h.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	h.w.WriteHeader(status)
+	json.NewEncoder(h.w).Encode(v)
+}
+
+func (h *httpContext) Redirect(status int, location string) {
+	http.Redirect(h.w, h.r, location, status)
+}
+
+func (h *httpContext) Writer() http.ResponseWriter {
+	return h.w
+}
@@ -0,0 +1,46 @@
+// Package router decouples handlers from the HTTP framework. Handlers
+// written against Context run under gin through Gin, or under any
+// net/http router such as chi or http.ServeMux through HTTP.
+package router
+
+import "net/http"
+
+// Context is what a handler needs from a request and its response
+type Context interface {
+	// Request returns the request; values set by authentication, such as
+	// the auth.Principal, are on its context
+	Request() *http.Request
+	// Param returns the path parameter name, empty when missing
+	Param(name string) string
+	// Query returns the query parameter name, empty when missing
+	Query(name string) string
+	// ClientIP returns the address of the client, taken from proxy headers
+	// where the backend is set up to trust them
+	ClientIP() string
+	// BindJSON decodes the request body into v and validates its binding
+	// tags
+	BindJSON(v any) error
+	// Header sets the response header key, or removes it when value is
+	// empty; headers must be set before the status is written
+	Header(key, value string)
+	// Status writes the response status, for responses without a body or
+	// whose body is written to Writer
+	Status(code int)
+	// JSON writes v as the response body with status
+	JSON(status int, v any)
+	// Redirect sends the client to location with status
+	Redirect(status int, location string)
+	// Writer returns the response, for bodies that are not JSON or are
+	// streamed
+	Writer() http.ResponseWriter
+}
+
+// HandlerFunc handles a request independently of the HTTP framework
+type HandlerFunc func(Context)
+
+// Wrap adapts a net/http handler, such as expvar.Handler, to a HandlerFunc
+func Wrap(h http.Handler) HandlerFunc {
+	return func(c Context) {
+		h.ServeHTTP(c.Writer(), c.Request())
+	}
+}
@@ -0,0 +1,554 @@
+// Package saml implements the parts of a SAML 2.0 service provider needed
+// for browser SSO: SP metadata, AuthnRequests over the HTTP-Redirect
+// binding, signed responses over the HTTP-POST binding and IdP initiated
+// single logout. XML signatures are verified with goxmldsig. The package
+// keeps no state: callers check that responses answer a request they made
+// and that assertions are not replayed.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	bindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	bindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	statusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	nameIDEmail     = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	methodBearer    = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	algRSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRSASHA1   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+
+	// clockSkew tolerates small clock differences with the IdP
+	clockSkew = 2 * time.Minute
+	// maxLogoutAge is how long after it was issued a LogoutRequest is
+	// honoured
+	maxLogoutAge = 5 * time.Minute
+)
+
+var (
+	ErrInvalidResponse  = errors.New("saml: invalid response")
+	ErrExpired          = errors.New("saml: assertion is expired or not yet valid")
+	ErrWrongAudience    = errors.New("saml: assertion is not intended for this service provider")
+	ErrWrongIssuer      = errors.New("saml: assertion was not issued by the configured IdP")
+	ErrMissingSignature = errors.New("saml: message is not signed")
+	ErrInvalidSignature = errors.New("saml: signature verification failed")
+	ErrUnsupportedAlg   = errors.New("saml: unsupported signature algorithm")
+)
+
+// ServiceProvider holds the SP identity and the trusted IdP settings
+type ServiceProvider struct {
+	EntityID    string
+	ACSURL      string
+	SLOURL      string
+	IDPEntityID string
+	IDPSSOURL   string
+	IDPSLOURL   string
+	IDPCert     *x509.Certificate
+}
+
+// Assertion is the verified identity extracted from a SAML response. ID
+// is unique to the assertion, which must not be accepted again before
+// ExpiresAt. InResponseTo is the ID of the AuthnRequest it answers, empty
+// when the IdP sent it unprompted.
+type Assertion struct {
+	ID           string
+	InResponseTo string
+	ExpiresAt    time.Time
+	NameID       string
+	SessionIndex string
+	Attributes   map[string][]string
+}
+
+// LogoutRequest is an IdP initiated logout whose signature was verified
+type LogoutRequest struct {
+	ID             string
+	NameID         string
+	SessionIndexes []string
+	RelayState     string
+}
+
+// emailAttributes are the attribute names IdPs commonly use for e-mail
+var emailAttributes = []string{
+	"email",
+	"mail",
+	"urn:oid:0.9.2342.19200300.100.1.3",
+	"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+}
+
+// Email returns the user's e-mail from the attributes or the NameID
+func (a *Assertion) Email() string {
+	for _, name := range emailAttributes {
+		if values := a.Attributes[name]; len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	if strings.Contains(a.NameID, "@") {
+		return a.NameID
+	}
+	return ""
+}
+
+// Attribute returns the first value of the first attribute name present
+func (a *Assertion) Attribute(names ...string) string {
+	for _, name := range names {
+		if values := a.Attributes[name]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// ParseCertificate reads a PEM (or bare base64 DER) X.509 certificate
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		return x509.ParseCertificate(block.Bytes)
+	}
+	der, err := decodeBase64(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("saml: certificate is neither PEM nor base64 DER")
+	}
+	return x509.ParseCertificate(der)
+}
+
+// Metadata returns the SP metadata document to register with the IdP
+func (sp *ServiceProvider) Metadata() ([]byte, error) {
+	type endpoint struct {
+		Binding  string `xml:"Binding,attr"`
+		Location string `xml:"Location,attr"`
+		Index    *int   `xml:"index,attr,omitempty"`
+	}
+	type spDescriptor struct {
+		AuthnRequestsSigned        bool       `xml:"AuthnRequestsSigned,attr"`
+		WantAssertionsSigned       bool       `xml:"WantAssertionsSigned,attr"`
+		ProtocolSupportEnumeration string     `xml:"protocolSupportEnumeration,attr"`
+		SingleLogoutService        []endpoint `xml:"SingleLogoutService"`
+		NameIDFormat               string     `xml:"NameIDFormat"`
+		AssertionConsumerService   []endpoint `xml:"AssertionConsumerService"`
+	}
+	type entityDescriptor struct {
+		XMLName         xml.Name     `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+		EntityID        string       `xml:"entityID,attr"`
+		SPSSODescriptor spDescriptor `xml:"SPSSODescriptor"`
+	}
+
+	index := 0
+	descriptor := entityDescriptor{
+		EntityID: sp.EntityID,
+		SPSSODescriptor: spDescriptor{
+			WantAssertionsSigned:       true,
+			ProtocolSupportEnumeration: nsProtocol,
+			NameIDFormat:               nameIDEmail,
+			AssertionConsumerService: []endpoint{
+				{Binding: bindingPOST, Location: sp.ACSURL, Index: &index},
+			},
+		},
+	}
+	if sp.SLOURL != "" {
+		descriptor.SPSSODescriptor.SingleLogoutService = []endpoint{
+			{Binding: bindingRedirect, Location: sp.SLOURL},
+		}
+	}
+
+	out, err := xml.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// AuthnRequestURL builds the IdP redirect that starts a login with the
+// AuthnRequest id, which the response must answer
+func (sp *ServiceProvider) AuthnRequestURL(id, relayState string, now time.Time) (string, error) {
+	request := fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s"><saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy Format="%s" AllowCreate="true"/></samlp:AuthnRequest>`,
+		nsProtocol, nsAssertion, escapeAttr(id), now.UTC().Format(time.RFC3339),
+		escapeAttr(sp.IDPSSOURL), escapeAttr(sp.ACSURL), bindingPOST, escapeText(sp.EntityID), nameIDEmail)
+	return redirectURL(sp.IDPSSOURL, "SAMLRequest", request, relayState)
+}
+
+// ParseResponse verifies a base64 SAMLResponse posted to the ACS and
+// returns the asserted identity. Either the response or the assertion must
+// carry a valid signature from the IdP certificate, and only the element
+// the signature covers, as returned by the verifier, is read.
+func (sp *ServiceProvider) ParseResponse(encoded string, now time.Time) (*Assertion, error) {
+	raw, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	response, err := parseDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !is(response, nsProtocol, "Response") {
+		return nil, ErrInvalidResponse
+	}
+
+	responseSigned := false
+	switch verified, err := sp.verify(response, now); err {
+	case nil:
+		response, responseSigned = verified, true
+	case ErrMissingSignature:
+	default:
+		return nil, err
+	}
+
+	if destination := attr(response, "Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, ErrInvalidResponse
+	}
+	status := single(response, nsProtocol, "Status")
+	if status == nil {
+		return nil, ErrInvalidResponse
+	}
+	statusCode := single(status, nsProtocol, "StatusCode")
+	if statusCode == nil || attr(statusCode, "Value") != statusSuccess {
+		return nil, ErrInvalidResponse
+	}
+
+	assertion := single(response, nsAssertion, "Assertion")
+	if assertion == nil {
+		return nil, ErrInvalidResponse
+	}
+	if !responseSigned {
+		if assertion, err = sp.verify(assertion, now); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := sp.readAssertion(assertion, now)
+	if err != nil {
+		return nil, err
+	}
+	// the response's own InResponseTo is only trusted when it is signed
+	if responseSigned {
+		switch inResponseTo := attr(response, "InResponseTo"); {
+		case result.InResponseTo == "":
+			result.InResponseTo = inResponseTo
+		case inResponseTo != "" && inResponseTo != result.InResponseTo:
+			return nil, ErrInvalidResponse
+		}
+	}
+	return result, nil
+}
+
+// verify checks the enveloped signature of el against the IdP certificate
+// and returns the signed element, detached from the document with the
+// namespaces it inherits
+func (sp *ServiceProvider) verify(el *etree.Element, now time.Time) (*etree.Element, error) {
+	nsContext, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	detached, err := etreeutils.NSDetatch(nsContext, el)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	validator := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+		Roots: []*x509.Certificate{sp.IDPCert},
+	})
+	validator.Clock = dsig.NewFakeClockAt(now)
+	verified, err := validator.Validate(detached)
+	if err == dsig.ErrMissingSignature {
+		return nil, ErrMissingSignature
+	}
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return verified, nil
+}
+
+func (sp *ServiceProvider) readAssertion(assertion *etree.Element, now time.Time) (*Assertion, error) {
+	id := attr(assertion, "ID")
+	if id == "" {
+		return nil, ErrInvalidResponse
+	}
+	issuer := single(assertion, nsAssertion, "Issuer")
+	if issuer == nil || text(issuer) != sp.IDPEntityID {
+		return nil, ErrWrongIssuer
+	}
+
+	conditions := single(assertion, nsAssertion, "Conditions")
+	if conditions == nil {
+		return nil, ErrInvalidResponse
+	}
+	if err := checkWindow(attr(conditions, "NotBefore"), attr(conditions, "NotOnOrAfter"), now); err != nil {
+		return nil, err
+	}
+	audienceOK := false
+	for _, restriction := range children(conditions, nsAssertion, "AudienceRestriction") {
+		for _, audience := range children(restriction, nsAssertion, "Audience") {
+			if text(audience) == sp.EntityID {
+				audienceOK = true
+			}
+		}
+	}
+	if !audienceOK {
+		return nil, ErrWrongAudience
+	}
+
+	subject := single(assertion, nsAssertion, "Subject")
+	if subject == nil {
+		return nil, ErrInvalidResponse
+	}
+	nameID := single(subject, nsAssertion, "NameID")
+	if nameID == nil {
+		return nil, ErrInvalidResponse
+	}
+	result := &Assertion{
+		ID:         id,
+		NameID:     text(nameID),
+		Attributes: map[string][]string{},
+	}
+	// bearer confirmations must say until when they hold, which bounds how
+	// long the assertion's ID has to be remembered
+	confirmed := false
+	for _, confirmation := range children(subject, nsAssertion, "SubjectConfirmation") {
+		data := single(confirmation, nsAssertion, "SubjectConfirmationData")
+		if attr(confirmation, "Method") != methodBearer || data == nil {
+			continue
+		}
+		if recipient := attr(data, "Recipient"); recipient != "" && recipient != sp.ACSURL {
+			continue
+		}
+		notOnOrAfter, err := time.Parse(time.RFC3339, attr(data, "NotOnOrAfter"))
+		if err != nil || checkWindow(attr(data, "NotBefore"), attr(data, "NotOnOrAfter"), now) != nil {
+			continue
+		}
+		confirmed = true
+		result.InResponseTo = attr(data, "InResponseTo")
+		result.ExpiresAt = notOnOrAfter.Add(clockSkew)
+		break
+	}
+	if !confirmed {
+		return nil, ErrExpired
+	}
+
+	if authn := single(assertion, nsAssertion, "AuthnStatement"); authn != nil {
+		result.SessionIndex = attr(authn, "SessionIndex")
+	}
+	for _, statement := range children(assertion, nsAssertion, "AttributeStatement") {
+		for _, attribute := range children(statement, nsAssertion, "Attribute") {
+			name := attr(attribute, "Name")
+			for _, value := range children(attribute, nsAssertion, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], text(value))
+			}
+		}
+	}
+	return result, nil
+}
+
+// ParseLogoutRequest verifies an IdP initiated LogoutRequest received over
+// the HTTP-Redirect binding, given the raw query string of the request.
+// The query must be signed with the IdP certificate, as the binding
+// specifies, and the request must be recent.
+func (sp *ServiceProvider) ParseLogoutRequest(rawQuery string, now time.Time) (*LogoutRequest, error) {
+	params, err := rawParams(rawQuery)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	if err := sp.verifyRedirect(params, "SAMLRequest"); err != nil {
+		return nil, err
+	}
+	encoded, err := url.QueryUnescape(params["SAMLRequest"])
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	relayState, err := url.QueryUnescape(params["RelayState"])
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	raw, err := inflate(encoded)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	request, err := parseDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !is(request, nsProtocol, "LogoutRequest") {
+		return nil, ErrInvalidResponse
+	}
+	if destination := attr(request, "Destination"); destination != "" && destination != sp.SLOURL {
+		return nil, ErrInvalidResponse
+	}
+	issuer := single(request, nsAssertion, "Issuer")
+	if issuer == nil || text(issuer) != sp.IDPEntityID {
+		return nil, ErrWrongIssuer
+	}
+	issued, err := time.Parse(time.RFC3339, attr(request, "IssueInstant"))
+	if err != nil || now.Add(clockSkew).Before(issued) || now.Sub(issued) > maxLogoutAge+clockSkew {
+		return nil, ErrExpired
+	}
+	if err := checkWindow("", attr(request, "NotOnOrAfter"), now); err != nil {
+		return nil, err
+	}
+
+	result := &LogoutRequest{ID: attr(request, "ID"), RelayState: relayState}
+	if result.ID == "" {
+		return nil, ErrInvalidResponse
+	}
+	if nameID := single(request, nsAssertion, "NameID"); nameID != nil {
+		result.NameID = text(nameID)
+	}
+	for _, index := range children(request, nsProtocol, "SessionIndex") {
+		result.SessionIndexes = append(result.SessionIndexes, text(index))
+	}
+	return result, nil
+}
+
+// verifyRedirect checks the signature of a message sent over the
+// HTTP-Redirect binding, which signs the message, RelayState and SigAlg
+// parameters exactly as they were encoded in the query
+func (sp *ServiceProvider) verifyRedirect(params map[string]string, param string) error {
+	if params[param] == "" || params["Signature"] == "" {
+		return ErrMissingSignature
+	}
+	signed := param + "=" + params[param]
+	if relayState, ok := params["RelayState"]; ok {
+		signed += "&RelayState=" + relayState
+	}
+	signed += "&SigAlg=" + params["SigAlg"]
+
+	sigAlg, err := url.QueryUnescape(params["SigAlg"])
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	var digest hash.Hash
+	var hashAlg crypto.Hash
+	switch sigAlg {
+	case algRSASHA256:
+		digest, hashAlg = sha256.New(), crypto.SHA256
+	case algRSASHA1:
+		digest, hashAlg = sha1.New(), crypto.SHA1
+	default:
+		return ErrUnsupportedAlg
+	}
+	encodedSignature, err := url.QueryUnescape(params["Signature"])
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	signature, err := decodeBase64(encodedSignature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	publicKey, ok := sp.IDPCert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return ErrUnsupportedAlg
+	}
+	digest.Write([]byte(signed))
+	if rsa.VerifyPKCS1v15(publicKey, hashAlg, digest.Sum(nil), signature) != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// rawParams splits a query string into its parameters, still URL-encoded.
+// A parameter given twice makes the query ambiguous and is refused.
+func rawParams(rawQuery string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		if _, seen := params[name]; seen {
+			return nil, ErrInvalidResponse
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// LogoutResponseURL builds the redirect acknowledging an IdP LogoutRequest
+func (sp *ServiceProvider) LogoutResponseURL(inResponseTo, relayState string, now time.Time) (string, error) {
+	response := fmt.Sprintf(`<samlp:LogoutResponse xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" InResponseTo="%s"><saml:Issuer>%s</saml:Issuer><samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status></samlp:LogoutResponse>`,
+		nsProtocol, nsAssertion, newID(), now.UTC().Format(time.RFC3339),
+		escapeAttr(sp.IDPSLOURL), escapeAttr(inResponseTo), escapeText(sp.EntityID), statusSuccess)
+	return redirectURL(sp.IDPSLOURL, "SAMLResponse", response, relayState)
+}
+
+// checkWindow validates optional NotBefore/NotOnOrAfter timestamps
+func checkWindow(notBefore, notOnOrAfter string, now time.Time) error {
+	if notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil || now.Add(clockSkew).Before(t) {
+			return ErrExpired
+		}
+	}
+	if notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339, notOnOrAfter)
+		if err != nil || !now.Add(-clockSkew).Before(t) {
+			return ErrExpired
+		}
+	}
+	return nil
+}
+
+// redirectURL deflates and encodes a message for the HTTP-Redirect binding
+func redirectURL(target, param, message, relayState string) (string, error) {
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write([]byte(message)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(param, base64.StdEncoding.EncodeToString(compressed.Bytes()))
+	if relayState != "" {
+		query.Set("RelayState", relayState)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// inflate reverses the HTTP-Redirect encoding, capping the output size
+func inflate(encoded string) ([]byte, error) {
+	compressed, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), 1<<20))
+}
+
+// newID returns a random identifier that is a valid xs:ID
+func newID() string {
+	buf := make([]byte, 20)
+	_, _ = rand.Read(buf)
+	return "_" + hex.EncodeToString(buf)
+}
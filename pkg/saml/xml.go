@@ -0,0 +1,79 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+
+	"github.com/beevik/etree"
+	xrv "github.com/mattermost/xml-roundtrip-validator"
+)
+
+// parseDocument reads an XML message. Documents that would not survive
+// being parsed and written back unchanged are refused, since signature
+// checks rely on the two agreeing.
+func parseDocument(data []byte) (*etree.Element, error) {
+	if err := xrv.Validate(bytes.NewReader(data)); err != nil {
+		return nil, ErrInvalidResponse
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, ErrInvalidResponse
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, ErrInvalidResponse
+	}
+	return root, nil
+}
+
+// children returns the child elements of el named tag in namespace ns
+func children(el *etree.Element, ns, tag string) []*etree.Element {
+	var found []*etree.Element
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == ns {
+			found = append(found, child)
+		}
+	}
+	return found
+}
+
+// single returns the only child element of el named tag in namespace ns,
+// or nil when there is none or more than one
+func single(el *etree.Element, ns, tag string) *etree.Element {
+	found := children(el, ns, tag)
+	if len(found) != 1 {
+		return nil
+	}
+	return found[0]
+}
+
+func is(el *etree.Element, ns, tag string) bool {
+	return el.Tag == tag && el.NamespaceURI() == ns
+}
+
+func attr(el *etree.Element, name string) string {
+	return el.SelectAttrValue(name, "")
+}
+
+func text(el *etree.Element) string {
+	return strings.TrimSpace(el.Text())
+}
+
+var (
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+)
+
+func escapeAttr(value string) string {
+	return attrEscaper.Replace(value)
+}
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// decodeBase64 decodes base64 that may be wrapped over several lines
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
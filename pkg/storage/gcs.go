@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	gcsScope  = "https://www.googleapis.com/auth/devstorage.read_write"
+	gcsAPI    = "https://storage.googleapis.com/storage/v1"
+	gcsUpload = "https://storage.googleapis.com/upload/storage/v1"
+)
+
+// GCSConfig says which Google Cloud Storage bucket to use. CredentialsFile
+// is a service account's JSON key; without one the application default
+// credentials are used, such as GOOGLE_APPLICATION_CREDENTIALS or the
+// service account of the instance. Prefix is put in front of every key.
+// PublicURL, when set, is where the bucket's objects are served from, such
+// as a CDN; otherwise URLs point at storage.googleapis.com.
+type GCSConfig struct {
+	Bucket          string
+	CredentialsFile string
+	Prefix          string
+	PublicURL       string
+}
+
+// GCS stores files as objects in a Google Cloud Storage bucket, through
+// the JSON API
+type GCS struct {
+	client    *http.Client
+	bucket    string
+	prefix    string
+	publicURL string
+}
+
+func NewGCS(ctx context.Context, cfg GCSConfig) (*GCS, error) {
+	var tokens oauth2.TokenSource
+	if cfg.CredentialsFile != "" {
+		key, err := os.ReadFile(cfg.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("storage: gcs credentials: %w", err)
+		}
+		jwt, err := google.JWTConfigFromJSON(key, gcsScope)
+		if err != nil {
+			return nil, fmt.Errorf("storage: gcs credentials: %w", err)
+		}
+		tokens = jwt.TokenSource(ctx)
+	} else {
+		creds, err := google.FindDefaultCredentials(ctx, gcsScope)
+		if err != nil {
+			return nil, fmt.Errorf("storage: gcs credentials: %w", err)
+		}
+		tokens = creds.TokenSource
+	}
+
+	publicURL := strings.TrimRight(cfg.PublicURL, "/")
+	if publicURL == "" {
+		publicURL = "https://storage.googleapis.com/" + cfg.Bucket
+	}
+	return &GCS{
+		client:    oauth2.NewClient(ctx, tokens),
+		bucket:    cfg.Bucket,
+		prefix:    strings.Trim(cfg.Prefix, "/"),
+		publicURL: publicURL,
+	}, nil
+}
+
+// object returns the object name of key
+func (g *GCS) object(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if g.prefix == "" {
+		return key, nil
+	}
+	return g.prefix + "/" + key, nil
+}
+
+// objectURL is the JSON API address of an object's metadata
+func (g *GCS) objectURL(object string) string {
+	return gcsAPI + "/b/" + url.PathEscape(g.bucket) + "/o/" + url.PathEscape(object)
+}
+
+// Save streams r to the bucket in a single media upload
+func (g *GCS) Save(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	object, err := g.object(key)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"uploadType": {"media"}, "name": {object}}
+	endpoint := gcsUpload + "/b/" + url.PathEscape(g.bucket) + "/o?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return gcsError(resp)
+}
+
+// Open streams the object's contents. The reader fetches other byte ranges
+// with separate requests for ReadAt and after a Seek.
+func (g *GCS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := g.object(key)
+	if err != nil {
+		return nil, err
+	}
+	body, size, err := g.get(ctx, object, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		info, err := g.Stat(ctx, key)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+		size = info.Size
+	}
+	return &gcsReader{ctx: ctx, g: g, object: object, size: size, body: body}, nil
+}
+
+// get fetches length bytes of the object from offset, the rest of it when
+// length is negative, and returns them with their number if known
+func (g *GCS) get(ctx context.Context, object string, offset, length int64) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.objectURL(object)+"?alt=media", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case length >= 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	case offset > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := gcsError(resp); err != nil {
+		resp.Body.Close()
+		return nil, 0, err
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// gcsReader reads an object as one stream until it is seeked, and serves
+// ReadAt with range requests, so it can be checked in place and serve
+// range requests like files of the other backends
+type gcsReader struct {
+	ctx    context.Context
+	g      *GCS
+	object string
+	size   int64
+	offset int64
+	// body streams the object from offset; nil after a Seek until the
+	// next Read
+	body io.ReadCloser
+}
+
+func (r *gcsReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, _, err := r.g.get(r.ctx, r.object, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *gcsReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	body, _, err := r.g.get(r.ctx, r.object, off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *gcsReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("storage: gcs: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("storage: gcs: negative position")
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *gcsReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
+
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	object, err := g.object(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, g.objectURL(object), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return gcsError(resp)
+}
+
+// PresignPut starts a resumable upload session for the object and returns
+// its URL, which takes the file in a single PUT. GCS keeps sessions open
+// for a week whatever expires says; the caller enforces shorter lifetimes.
+func (g *GCS) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	object, err := g.object(key)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{"uploadType": {"resumable"}, "name": {object}}
+	endpoint := gcsUpload + "/b/" + url.PathEscape(g.bucket) + "/o?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Upload-Content-Type", contentType)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := gcsError(resp); err != nil {
+		return "", err
+	}
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return "", fmt.Errorf("storage: gcs: no upload session in response")
+	}
+	return session, nil
+}
+
+func (g *GCS) Stat(ctx context.Context, key string) (*Object, error) {
+	object, err := g.object(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.objectURL(object), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := gcsError(resp); err != nil {
+		return nil, err
+	}
+
+	var meta gcsObject
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return nil, err
+	}
+	return meta.object()
+}
+
+// List pages through the objects under the prefix
+func (g *GCS) List(ctx context.Context, fn func(key string, obj *Object) error) error {
+	prefix := ""
+	if g.prefix != "" {
+		prefix = g.prefix + "/"
+	}
+	query := url.Values{"prefix": {prefix}, "fields": {"items(name,size,updated),nextPageToken"}}
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcsAPI+"/b/"+url.PathEscape(g.bucket)+"/o?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return err
+		}
+		var page struct {
+			Items         []gcsObject `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		err = gcsError(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&page)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page.Items {
+			obj, err := item.object()
+			if err != nil {
+				return err
+			}
+			if err := fn(strings.TrimPrefix(item.Name, prefix), obj); err != nil {
+				return err
+			}
+		}
+		if page.NextPageToken == "" {
+			return nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+func (g *GCS) URL(key string) string {
+	if g.prefix == "" {
+		return g.publicURL + "/" + key
+	}
+	return g.publicURL + "/" + g.prefix + "/" + key
+}
+
+// gcsObject is an object's metadata as the JSON API sends it, with the
+// size as a string
+type gcsObject struct {
+	Name        string    `json:"name"`
+	Size        string    `json:"size"`
+	ContentType string    `json:"contentType"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (o gcsObject) object() (*Object, error) {
+	size, err := strconv.ParseInt(o.Size, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("storage: gcs: invalid object size %q", o.Size)
+	}
+	return &Object{Size: size, ContentType: o.ContentType, Modified: o.Updated}, nil
+}
+
+// gcsError maps a missing object to ErrNotFound and other failures to an
+// error carrying the start of the API's message
+func gcsError(resp *http.Response) error {
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("storage: gcs: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config says where an S3-compatible bucket is. Without AccessKey the
+// credentials come from the AWS environment variables, the shared
+// credentials file or the instance's IAM role. PathStyle addresses the
+// bucket in the path rather than the host name, as MinIO expects. Prefix is
+// put in front of every key. PublicURL, when set, is where the bucket's
+// objects are served from, such as a CDN; otherwise URLs point at Endpoint.
+// Files larger than PartSize are sent as a multipart upload.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool
+	Prefix    string
+	PublicURL string
+	PartSize  uint64
+}
+
+// S3 stores files as objects in an S3-compatible bucket
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	prefix    string
+	publicURL string
+	partSize  uint64
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	creds := credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, "")
+	if cfg.AccessKey == "" {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 client: %w", err)
+	}
+
+	publicURL := strings.TrimRight(cfg.PublicURL, "/")
+	if publicURL == "" {
+		endpoint := client.EndpointURL()
+		if cfg.PathStyle {
+			publicURL = endpoint.Scheme + "://" + endpoint.Host + "/" + cfg.Bucket
+		} else {
+			publicURL = endpoint.Scheme + "://" + cfg.Bucket + "." + endpoint.Host
+		}
+	}
+	return &S3{
+		client:    client,
+		bucket:    cfg.Bucket,
+		prefix:    strings.Trim(cfg.Prefix, "/"),
+		publicURL: publicURL,
+		partSize:  cfg.PartSize,
+	}, nil
+}
+
+// object returns the object name of key
+func (s *S3) object(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.prefix == "" {
+		return key, nil
+	}
+	return s.prefix + "/" + key, nil
+}
+
+// Save uploads r in one request, or as a multipart upload of PartSize parts
+// when it is larger than PartSize or its size is unknown
+func (s *S3) Save(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	object, err := s.object(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, object, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    s.partSize,
+	})
+	return err
+}
+
+// Open returns the object as a reader that can seek, fetching byte ranges
+// as it is read
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.object(key)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, object, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	// GetObject does not contact the bucket until the object is used
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, s3Error(err)
+	}
+	return obj, nil
+}
+
+// Delete removes the object; S3 does not report deleting a missing object,
+// so it is looked up first
+func (s *S3) Delete(ctx context.Context, key string) error {
+	object, err := s.object(key)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.StatObject(ctx, s.bucket, object, minio.StatObjectOptions{}); err != nil {
+		return s3Error(err)
+	}
+	return s.client.RemoveObject(ctx, s.bucket, object, minio.RemoveObjectOptions{})
+}
+
+// PresignPut signs a PUT of the object that only succeeds with the given
+// Content-Type. S3 refuses lifetimes beyond seven days.
+func (s *S3) PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	object, err := s.object(key)
+	if err != nil {
+		return "", err
+	}
+	u, err := s.client.PresignHeader(ctx, http.MethodPut, s.bucket, object, expires, nil, http.Header{"Content-Type": {contentType}})
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	object, err := s.object(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, object, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return &Object{Size: info.Size, ContentType: info.ContentType, Modified: info.LastModified}, nil
+}
+
+// List walks the objects under the prefix, a page of up to a thousand at a
+// time
+func (s *S3) List(ctx context.Context, fn func(key string, obj *Object) error) error {
+	opts := minio.ListObjectsOptions{Recursive: true}
+	if s.prefix != "" {
+		opts.Prefix = s.prefix + "/"
+	}
+	for info := range s.client.ListObjectsIter(ctx, s.bucket, opts) {
+		if info.Err != nil {
+			return info.Err
+		}
+		key := strings.TrimPrefix(info.Key, opts.Prefix)
+		if err := fn(key, &Object{Size: info.Size, Modified: info.LastModified}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3) URL(key string) string {
+	if s.prefix == "" {
+		return s.publicURL + "/" + key
+	}
+	return s.publicURL + "/" + s.prefix + "/" + key
+}
+
+// s3Error maps a missing object to ErrNotFound
+func s3Error(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+// Package storage keeps uploaded files behind one interface so they can
+// live on local disk or in an object store. Files are addressed by keys:
+// slash-separated relative paths such as "images/photo_1700000000_<id>.png".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: file not found")
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage saves, reads and deletes files by key
+type Storage interface {
+	// Save stores the size bytes read from r under key, replacing any file
+	// already there. A size of -1 means unknown.
+	Save(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the file under key, or ErrNotFound. The readers of
+	// every backend are also io.Seekers and io.ReaderAts, so stored files
+	// can serve range requests and be checked in place.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file under key, or returns ErrNotFound
+	Delete(ctx context.Context, key string) error
+	// URL is where clients can fetch the file under key
+	URL(key string) string
+	// List calls fn with the key and description of every stored file, in
+	// no particular order, stopping at the first error fn returns
+	List(ctx context.Context, fn func(key string, obj *Object) error) error
+}
+
+// CleanKey returns key as a canonical relative path, or ErrInvalidKey when
+// it is empty, absolute or climbs out of the store with ".."
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// Object describes a stored file. Listings leave ContentType empty.
+type Object struct {
+	Size        int64
+	ContentType string
+	Modified    time.Time
+}
+
+// DirectUploader is implemented by backends that clients can upload to
+// without sending the file through the API
+type DirectUploader interface {
+	// PresignPut returns a URL that accepts a PUT of the file for key,
+	// sent with contentType as its Content-Type, without credentials
+	// until expires has passed
+	PresignPut(ctx context.Context, key, contentType string, expires time.Duration) (string, error)
+	// Stat describes the file under key, or returns ErrNotFound
+	Stat(ctx context.Context, key string) (*Object, error)
+}
+
+// NewKey returns a key under prefix for a new file called filename, made
+// unique with a timestamp and an ID
+func NewKey(prefix, filename string) string {
+	ext := path.Ext(filename)
+	name := fmt.Sprintf("%s_%d_%s%s", strings.TrimSuffix(filename, ext), time.Now().Unix(), primitive.NewObjectID().Hex(), ext)
+	return path.Join(prefix, name)
+}
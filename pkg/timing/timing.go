@@ -0,0 +1,124 @@
+// Package timing splits the latency of a request between the layers it
+// passes through. A Budget travels in the request context; each layer
+// brackets its work with Track, and time spent in a nested layer is
+// charged to that layer instead of the one that called it.
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Layers a request passes through, outermost first
+const (
+	LayerMiddleware = "middleware"
+	LayerHandler    = "handler"
+	LayerService    = "service"
+	LayerRepository = "repository"
+)
+
+// Layers lists the layers in the order they are reported
+var Layers = []string{LayerMiddleware, LayerHandler, LayerService, LayerRepository}
+
+type budgetKey struct{}
+
+// frame is one layer in progress; children is the time already charged to
+// layers nested inside it
+type frame struct {
+	layer    string
+	start    time.Time
+	children time.Duration
+}
+
+// Budget accumulates the time one request spends in each layer. It assumes
+// the layers of a request run one after another; work a request hands to
+// other goroutines is charged to whichever layer is innermost at the time.
+type Budget struct {
+	mu    sync.Mutex
+	start time.Time
+	spent map[string]time.Duration
+	stack []*frame
+	done  bool
+}
+
+// WithBudget returns ctx carrying a new Budget, started now
+func WithBudget(ctx context.Context) (context.Context, *Budget) {
+	b := &Budget{start: time.Now(), spent: make(map[string]time.Duration)}
+	return context.WithValue(ctx, budgetKey{}, b), b
+}
+
+// FromContext returns the Budget in ctx, or nil
+func FromContext(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetKey{}).(*Budget)
+	return b
+}
+
+// Track starts charging time to layer and returns the function that stops
+// it, meant to be deferred. Calls within the same layer, such as one service
+// calling another, are charged once. Without a Budget in ctx it does nothing.
+func Track(ctx context.Context, layer string) func() {
+	b := FromContext(ctx)
+	if b == nil {
+		return func() {}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done || (len(b.stack) > 0 && b.stack[len(b.stack)-1].layer == layer) {
+		return func() {}
+	}
+	f := &frame{layer: layer, start: time.Now()}
+	b.stack = append(b.stack, f)
+	return func() { b.pop(f) }
+}
+
+// Add charges d, measured elsewhere, to layer; the layer it ran inside is
+// not charged for it
+func Add(ctx context.Context, layer string, d time.Duration) {
+	b := FromContext(ctx)
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	b.spent[layer] += d
+	if len(b.stack) > 0 {
+		b.stack[len(b.stack)-1].children += d
+	}
+}
+
+func (b *Budget) pop(f *frame) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.stack) - 1; i >= 0; i-- {
+		if b.stack[i] != f {
+			continue
+		}
+		elapsed := time.Since(f.start)
+		// Work charged from other goroutines can outlast this layer
+		if own := elapsed - f.children; own > 0 {
+			b.spent[f.layer] += own
+		}
+		b.stack = append(b.stack[:i], b.stack[i+1:]...)
+		if i > 0 {
+			b.stack[i-1].children += elapsed
+		}
+		return
+	}
+}
+
+// Finish stops the budget and returns the request's total latency and the
+// share of it spent in each layer. Later Track and Add calls are ignored.
+func (b *Budget) Finish() (time.Duration, map[string]time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = true
+	spent := make(map[string]time.Duration, len(b.spent))
+	for layer, d := range b.spent {
+		spent[layer] = d
+	}
+	return time.Since(b.start), spent
+}
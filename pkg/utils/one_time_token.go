@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"time"
+)
+
+// OneTimeTokenLength is the length of tokens issued by OneTimeTokens: 32
+// random bytes and a 16 byte signature, hex encoded
+const OneTimeTokenLength = 96
+
+// ErrInvalidOneTimeToken is returned for tokens that are malformed, were
+// issued for another purpose, have already been used or have expired
+var ErrInvalidOneTimeToken = errors.New("invalid or expired one-time token")
+
+// oneTimeTokenMetrics counts tokens by purpose and outcome ("password_reset.
+// issued", ".used", ".expired", ".invalid", ".revoked"), published at
+// /debug/vars so bursts of resets or guessing show up in monitoring
+var oneTimeTokenMetrics = expvar.NewMap("one_time_tokens")
+
+// OneTimeTokenStore keeps the hashes of outstanding one-time tokens
+type OneTimeTokenStore interface {
+	// Save stores a token for purpose and subject, replacing any unused
+	// token issued earlier for the same pair
+	Save(ctx context.Context, purpose, subject, tokenHash string, expiresAt time.Time) error
+	// Consume atomically deletes a token and returns its subject and
+	// expiry; found is false when there is no such token
+	Consume(ctx context.Context, purpose, tokenHash string) (subject string, expiresAt time.Time, found bool, err error)
+	// Revoke deletes the unused token for purpose and subject, if any
+	Revoke(ctx context.Context, purpose, subject string) (found bool, err error)
+}
+
+// OneTimeTokens issues single-use tokens bound to a purpose (email
+// verification, password reset, invitation, ...) and a subject, usually a
+// user ID. Tokens are signed with the purpose, so one issued for a
+// verification link can never reset a password, and only their SHA-256 is
+// stored.
+type OneTimeTokens struct {
+	store  OneTimeTokenStore
+	secret []byte
+}
+
+func NewOneTimeTokens(store OneTimeTokenStore, secret []byte) *OneTimeTokens {
+	return &OneTimeTokens{
+		store:  store,
+		secret: secret,
+	}
+}
+
+// Issue returns a new token for purpose and subject that is valid for ttl.
+// Any earlier token for the same purpose and subject stops working.
+func (t *OneTimeTokens) Issue(ctx context.Context, purpose, subject string, ttl time.Duration) (string, error) {
+	nonce, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	token := nonce + t.sign(purpose, nonce)
+	if err := t.store.Save(ctx, purpose, subject, HashToken(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	oneTimeTokenMetrics.Add(purpose+".issued", 1)
+	return token, nil
+}
+
+// Consume checks token against purpose and returns its subject. A token can
+// be consumed only once.
+func (t *OneTimeTokens) Consume(ctx context.Context, purpose, token string) (string, error) {
+	if len(token) != OneTimeTokenLength {
+		oneTimeTokenMetrics.Add(purpose+".invalid", 1)
+		return "", ErrInvalidOneTimeToken
+	}
+	nonce, signature := token[:64], token[64:]
+	if !hmac.Equal([]byte(signature), []byte(t.sign(purpose, nonce))) {
+		oneTimeTokenMetrics.Add(purpose+".invalid", 1)
+		return "", ErrInvalidOneTimeToken
+	}
+
+	subject, expiresAt, found, err := t.store.Consume(ctx, purpose, HashToken(token))
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		// already used, replaced by a newer token or revoked
+		oneTimeTokenMetrics.Add(purpose+".invalid", 1)
+		return "", ErrInvalidOneTimeToken
+	}
+	if !time.Now().Before(expiresAt) {
+		oneTimeTokenMetrics.Add(purpose+".expired", 1)
+		return "", ErrInvalidOneTimeToken
+	}
+	oneTimeTokenMetrics.Add(purpose+".used", 1)
+	return subject, nil
+}
+
+// Revoke invalidates the outstanding token for purpose and subject, such as
+// a reset link once the password has been changed another way
+func (t *OneTimeTokens) Revoke(ctx context.Context, purpose, subject string) error {
+	found, err := t.store.Revoke(ctx, purpose, subject)
+	if err != nil {
+		return err
+	}
+	if found {
+		oneTimeTokenMetrics.Add(purpose+".revoked", 1)
+	}
+	return nil
+}
+
+// sign returns the truncated HMAC binding nonce to purpose
+func (t *OneTimeTokens) sign(purpose, nonce string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
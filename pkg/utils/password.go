@@ -1,13 +1,175 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// PasswordHashParams selects how new password hashes are made. Existing
+// hashes keep verifying after a change, whichever algorithm made them.
+type PasswordHashParams struct {
+	Algorithm  string
+	BcryptCost int
+	// Argon2id memory in KiB, passes over it, and lanes
+	Argon2Memory  uint32
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+func (p PasswordHashParams) String() string {
+	if p.Algorithm == HashArgon2id {
+		return fmt.Sprintf("argon2id m=%d t=%d p=%d", p.Argon2Memory, p.Argon2Time, p.Argon2Threads)
+	}
+	return fmt.Sprintf("bcrypt cost=%d", p.BcryptCost)
+}
+
+// Validate checks that p can hash passwords
+func (p PasswordHashParams) Validate() error {
+	switch p.Algorithm {
+	case HashBcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case HashArgon2id:
+		if p.Argon2Memory < 8*uint32(p.Argon2Threads) || p.Argon2Time < 1 || p.Argon2Threads < 1 {
+			return fmt.Errorf("argon2id needs at least 1 pass, 1 thread and 8 KiB of memory per thread")
+		}
+	default:
+		return fmt.Errorf("unsupported password hash algorithm %q", p.Algorithm)
+	}
+	return nil
+}
+
+const argon2KeyLen, argon2SaltLen = 32, 16
+
+var (
+	hashParamsMu sync.RWMutex
+	hashParams   = PasswordHashParams{Algorithm: HashBcrypt, BcryptCost: bcrypt.DefaultCost}
+)
+
+// SetPasswordHashParams makes HashPassword use p
+func SetPasswordHashParams(p PasswordHashParams) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	hashParamsMu.Lock()
+	hashParams = p
+	hashParamsMu.Unlock()
+	return nil
+}
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashParamsMu.RLock()
+	p := hashParams
+	hashParamsMu.RUnlock()
+	return hashWith(p, password)
+}
+
+func hashWith(p PasswordHashParams, password string) (string, error) {
+	if p.Algorithm == HashArgon2id {
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, p.Argon2Time, p.Argon2Memory, p.Argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+			p.Argon2Memory, p.Argon2Time, p.Argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
 	return string(bytes), err
 }
 
 func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return checkArgon2id(password, hash)
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// checkArgon2id verifies password against a hash in the PHC string format
+// "$argon2id$v=19$m=65536,t=3,p=2$salt$key"
+func checkArgon2id(password, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	var version int
+	var memory, passes uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &passes, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+	actual := argon2.IDKey([]byte(password), salt, passes, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1
+}
+
+// CalibratePasswordHashParams raises the work factor of base until hashing
+// a password on this host takes about target, and returns the highest
+// setting that stays within it. bcrypt raises the cost, each step doubling
+// the time; argon2id raises the passes, keeping base's memory and threads.
+// base is never weakened.
+func CalibratePasswordHashParams(base PasswordHashParams, target time.Duration) (PasswordHashParams, error) {
+	if err := base.Validate(); err != nil {
+		return base, err
+	}
+	best := base
+	for candidate := base; ; {
+		elapsed, err := timeHash(candidate)
+		if err != nil {
+			return base, err
+		}
+		if elapsed > target {
+			return best, nil
+		}
+		best = candidate
+		if candidate.Algorithm == HashArgon2id {
+			// Passes scale linearly, so jump close to the target at once
+			next := uint32(float64(candidate.Argon2Time) * float64(target) / float64(elapsed))
+			if next <= candidate.Argon2Time {
+				next = candidate.Argon2Time + 1
+			}
+			candidate.Argon2Time = next
+		} else {
+			if candidate.BcryptCost == bcrypt.MaxCost {
+				return best, nil
+			}
+			candidate.BcryptCost++
+		}
+	}
+}
+
+// timeHash returns how long hashing a password with p takes
+func timeHash(p PasswordHashParams) (time.Duration, error) {
+	start := time.Now()
+	if _, err := hashWith(p, "calibration password"); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
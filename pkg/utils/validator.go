@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"fmt"
 	"reflect"
 
 	"github.com/go-playground/validator/v10"
@@ -20,12 +19,8 @@ func ValidateStruct(s any) error {
 func FormatValidationError(err error, obj any) map[string]string {
 	errors := make(map[string]string)
 
-	objType := reflect.TypeOf(obj)
-	fmt.Println(objType, "|", objType.Kind(), "|", reflect.Ptr, "|", objType.Elem())
-
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
 		objType := reflect.TypeOf(obj)
-		fmt.Println(objType, "|", objType.Kind(), "|", reflect.Ptr, "|", objType.Elem())
 		if objType.Kind() == reflect.Ptr {
 			objType = objType.Elem()
 		}